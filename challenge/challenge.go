@@ -0,0 +1,159 @@
+// Package challenge implements a self-hosted proof-of-work challenge. The
+// server issues a signed token, and the client must find a solution such that
+// the SHA-256 hash of the token and solution begins with a given number of
+// zero bits. This makes automated submissions expensive without relying on a
+// third-party CAPTCHA service.
+package challenge
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"math/bits"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mtlynch/picoshare/random"
+)
+
+var (
+	ErrInvalidToken     = errors.New("challenge token is invalid")
+	ErrExpiredToken     = errors.New("challenge token has expired")
+	ErrWrongScope       = errors.New("challenge token was issued for a different resource")
+	ErrInsufficientWork = errors.New("challenge solution is incorrect")
+	ErrAlreadyUsed      = errors.New("challenge token has already been used")
+)
+
+type (
+	// Challenge is a puzzle for the client to solve.
+	Challenge struct {
+		Token      string
+		Difficulty int
+	}
+
+	// Issuer creates challenges and verifies their solutions. Each token can
+	// only be redeemed once.
+	Issuer struct {
+		key        []byte
+		difficulty int
+		lifetime   time.Duration
+		mu         sync.Mutex
+		// used maps each redeemed token to its expiration time.
+		used map[string]time.Time
+	}
+)
+
+// NewIssuer creates an Issuer that signs tokens with key and requires
+// solutions to have difficulty leading zero bits. Tokens expire after
+// lifetime.
+func NewIssuer(key []byte, difficulty int, lifetime time.Duration) *Issuer {
+	return &Issuer{
+		key:        key,
+		difficulty: difficulty,
+		lifetime:   lifetime,
+		used:       map[string]time.Time{},
+	}
+}
+
+// Issue creates a new challenge that's only valid for the given scope.
+func (i *Issuer) Issue(scope string, now time.Time) Challenge {
+	payload := strings.Join([]string{
+		scope,
+		base64.RawURLEncoding.EncodeToString(random.Bytes(16)),
+		strconv.FormatInt(now.Add(i.lifetime).Unix(), 10),
+		strconv.Itoa(i.difficulty),
+	}, "|")
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	return Challenge{
+		Token:      encoded + "." + i.sign(encoded),
+		Difficulty: i.difficulty,
+	}
+}
+
+// Verify checks that token is a valid, unexpired, unused token for scope and
+// that solution solves its puzzle. On success, the token can't be used again.
+func (i *Issuer) Verify(scope, token, solution string, now time.Time) error {
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(i.sign(encoded))) {
+		return ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return ErrInvalidToken
+	}
+	parts := strings.Split(string(payload), "|")
+	if len(parts) != 4 {
+		return ErrInvalidToken
+	}
+	expiresUnix, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return ErrInvalidToken
+	}
+	difficulty, err := strconv.Atoi(parts[3])
+	if err != nil {
+		return ErrInvalidToken
+	}
+
+	if parts[0] != scope {
+		return ErrWrongScope
+	}
+	expires := time.Unix(expiresUnix, 0)
+	if now.After(expires) {
+		return ErrExpiredToken
+	}
+	if leadingZeroBits(sha256.Sum256([]byte(token+":"+solution))) < difficulty {
+		return ErrInsufficientWork
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.prune(now)
+	if _, ok := i.used[token]; ok {
+		return ErrAlreadyUsed
+	}
+	i.used[token] = expires
+
+	return nil
+}
+
+// Solve finds a solution to the challenge by brute force. It's meant for
+// clients written in Go, such as tests.
+func Solve(c Challenge) string {
+	for n := 0; ; n++ {
+		solution := strconv.Itoa(n)
+		if leadingZeroBits(sha256.Sum256([]byte(c.Token+":"+solution))) >= c.Difficulty {
+			return solution
+		}
+	}
+}
+
+func (i *Issuer) sign(encoded string) string {
+	mac := hmac.New(sha256.New, i.key)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// prune forgets redeemed tokens that have expired, as they'd fail
+// verification anyway.
+func (i *Issuer) prune(now time.Time) {
+	for token, expires := range i.used {
+		if now.After(expires) {
+			delete(i.used, token)
+		}
+	}
+}
+
+func leadingZeroBits(hash [sha256.Size]byte) int {
+	n := 0
+	for _, b := range hash {
+		if b != 0 {
+			return n + bits.LeadingZeros8(b)
+		}
+		n += 8
+	}
+	return n
+}
@@ -0,0 +1,95 @@
+package challenge_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mtlynch/picoshare/challenge"
+)
+
+func TestVerify(t *testing.T) {
+	issued := mustParseTime("2024-01-01T00:00:00Z")
+	otherIssuer := challenge.NewIssuer([]byte("other-key"), 8, 5*time.Minute)
+
+	for _, tt := range []struct {
+		description string
+		modify      func(c challenge.Challenge, solution string) (scope, token, answer string, now time.Time)
+		err         error
+	}{
+		{
+			description: "accepts valid solution",
+			modify: func(c challenge.Challenge, solution string) (string, string, string, time.Time) {
+				return "link-a", c.Token, solution, issued.Add(time.Minute)
+			},
+			err: nil,
+		},
+		{
+			description: "rejects wrong solution",
+			modify: func(c challenge.Challenge, solution string) (string, string, string, time.Time) {
+				return "link-a", c.Token, solution + "x", issued.Add(time.Minute)
+			},
+			err: challenge.ErrInsufficientWork,
+		},
+		{
+			description: "rejects token for a different scope",
+			modify: func(c challenge.Challenge, solution string) (string, string, string, time.Time) {
+				return "link-b", c.Token, solution, issued.Add(time.Minute)
+			},
+			err: challenge.ErrWrongScope,
+		},
+		{
+			description: "rejects expired token",
+			modify: func(c challenge.Challenge, solution string) (string, string, string, time.Time) {
+				return "link-a", c.Token, solution, issued.Add(6 * time.Minute)
+			},
+			err: challenge.ErrExpiredToken,
+		},
+		{
+			description: "rejects tampered token",
+			modify: func(c challenge.Challenge, solution string) (string, string, string, time.Time) {
+				return "link-a", "x" + c.Token, solution, issued.Add(time.Minute)
+			},
+			err: challenge.ErrInvalidToken,
+		},
+		{
+			description: "rejects token from a different issuer",
+			modify: func(_ challenge.Challenge, _ string) (string, string, string, time.Time) {
+				c := otherIssuer.Issue("link-a", issued)
+				return "link-a", c.Token, challenge.Solve(c), issued.Add(time.Minute)
+			},
+			err: challenge.ErrInvalidToken,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			issuer := challenge.NewIssuer([]byte("dummy-key"), 8, 5*time.Minute)
+			c := issuer.Issue("link-a", issued)
+			scope, token, solution, now := tt.modify(c, challenge.Solve(c))
+
+			if got, want := issuer.Verify(scope, token, solution, now), tt.err; got != want {
+				t.Errorf("err=%v, want=%v", got, want)
+			}
+		})
+	}
+}
+
+func TestVerifyRejectsReusedToken(t *testing.T) {
+	now := mustParseTime("2024-01-01T00:00:00Z")
+	issuer := challenge.NewIssuer([]byte("dummy-key"), 8, 5*time.Minute)
+	c := issuer.Issue("link-a", now)
+	solution := challenge.Solve(c)
+
+	if err := issuer.Verify("link-a", c.Token, solution, now); err != nil {
+		t.Fatalf("first verification failed: %v", err)
+	}
+	if got, want := issuer.Verify("link-a", c.Token, solution, now), challenge.ErrAlreadyUsed; got != want {
+		t.Errorf("err=%v, want=%v", got, want)
+	}
+}
+
+func mustParseTime(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
@@ -0,0 +1,130 @@
+// Package clamd scans files for malware using the INSTREAM command of the
+// ClamAV daemon's protocol.
+package clamd
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+const (
+	// chunkSize is the size of each chunk we stream to clamd. It must be
+	// smaller than clamd's StreamMaxLength.
+	chunkSize   = 64 * 1024
+	dialTimeout = 10 * time.Second
+	// scanTimeout bounds how long we wait for clamd to finish a scan.
+	scanTimeout = 5 * time.Minute
+)
+
+// Client sends files to a clamd server for scanning.
+type Client struct {
+	network string
+	address string
+}
+
+// New creates a client for a clamd server listening on the given network
+// ("tcp" or "unix") and address.
+func New(network, address string) Client {
+	return Client{
+		network: network,
+		address: address,
+	}
+}
+
+// ParseAddress splits a clamd address of the form unix:///path/to/clamd.sock,
+// tcp://host:port, or host:port into a network and address.
+func ParseAddress(raw string) (string, string, error) {
+	if path, ok := strings.CutPrefix(raw, "unix://"); ok {
+		if path == "" {
+			return "", "", errors.New("clamd socket path is empty")
+		}
+		return "unix", path, nil
+	}
+
+	address := strings.TrimPrefix(raw, "tcp://")
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return "", "", fmt.Errorf("invalid clamd address %q: %w", raw, err)
+	}
+	return "tcp", address, nil
+}
+
+// Scan streams the contents of r to clamd and reports whether clamd found
+// malware. It returns an error if clamd couldn't complete the scan.
+func (c Client) Scan(r io.Reader) (picoshare.ScanResult, error) {
+	conn, err := net.DialTimeout(c.network, c.address, dialTimeout)
+	if err != nil {
+		return picoshare.ScanResult{}, fmt.Errorf("connecting to clamd: %w", err)
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(scanTimeout)); err != nil {
+		return picoshare.ScanResult{}, err
+	}
+
+	if _, err := io.WriteString(conn, "zINSTREAM\x00"); err != nil {
+		return picoshare.ScanResult{}, fmt.Errorf("sending INSTREAM command to clamd: %w", err)
+	}
+
+	if err := writeChunks(conn, r); err != nil {
+		return picoshare.ScanResult{}, fmt.Errorf("streaming data to clamd: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString('\x00')
+	if err != nil && !(errors.Is(err, io.EOF) && reply != "") {
+		return picoshare.ScanResult{}, fmt.Errorf("reading clamd reply: %w", err)
+	}
+
+	return parseReply(strings.TrimRight(reply, "\x00\n"))
+}
+
+// writeChunks writes r to w in the INSTREAM format: each chunk is prefixed
+// with its length as a 4-byte big-endian integer, and a zero-length chunk
+// marks the end of the stream.
+func writeChunks(w io.Writer, r io.Reader) error {
+	buf := make([]byte, chunkSize)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if err := binary.Write(w, binary.BigEndian, uint32(n)); err != nil {
+				return err
+			}
+			if _, err := w.Write(buf[:n]); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+
+	return binary.Write(w, binary.BigEndian, uint32(0))
+}
+
+// parseReply interprets clamd's reply, which looks like "stream: OK",
+// "stream: <signature> FOUND", or "<message> ERROR".
+func parseReply(reply string) (picoshare.ScanResult, error) {
+	result := strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case result == "OK":
+		return picoshare.ScanResult{Status: picoshare.ScanStatusClean}, nil
+	case strings.HasSuffix(result, " FOUND"):
+		return picoshare.ScanResult{
+			Status: picoshare.ScanStatusInfected,
+			Detail: strings.TrimSuffix(result, " FOUND"),
+		}, nil
+	case strings.HasSuffix(result, " ERROR"):
+		return picoshare.ScanResult{}, fmt.Errorf("clamd failed to scan file: %s", strings.TrimSuffix(result, " ERROR"))
+	default:
+		return picoshare.ScanResult{}, fmt.Errorf("unexpected reply from clamd: %q", reply)
+	}
+}
@@ -0,0 +1,151 @@
+package clamd_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/mtlynch/picoshare/clamd"
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+// fakeClamd accepts a single INSTREAM request, records the data it receives,
+// and sends the given reply.
+func fakeClamd(t *testing.T, reply string) (string, <-chan []byte) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start fake clamd: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		command, err := r.ReadString('\x00')
+		if err != nil || command != "zINSTREAM\x00" {
+			received <- nil
+			return
+		}
+
+		var data bytes.Buffer
+		for {
+			var size uint32
+			if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+				received <- nil
+				return
+			}
+			if size == 0 {
+				break
+			}
+			if _, err := io.CopyN(&data, r, int64(size)); err != nil {
+				received <- nil
+				return
+			}
+		}
+		received <- data.Bytes()
+
+		io.WriteString(conn, reply+"\x00")
+	}()
+
+	return listener.Addr().String(), received
+}
+
+func TestScan(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		reply       string
+		result      picoshare.ScanResult
+		expectErr   bool
+	}{
+		{
+			description: "reports clean file",
+			reply:       "stream: OK",
+			result:      picoshare.ScanResult{Status: picoshare.ScanStatusClean},
+		},
+		{
+			description: "reports infected file with signature name",
+			reply:       "stream: Eicar-Test-Signature FOUND",
+			result: picoshare.ScanResult{
+				Status: picoshare.ScanStatusInfected,
+				Detail: "Eicar-Test-Signature",
+			},
+		},
+		{
+			description: "returns error when clamd fails",
+			reply:       "INSTREAM size limit exceeded. ERROR",
+			expectErr:   true,
+		},
+		{
+			description: "returns error on unexpected reply",
+			reply:       "banana",
+			expectErr:   true,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			address, received := fakeClamd(t, tt.reply)
+			input := strings.Repeat("dummy data ", 10000)
+
+			result, err := clamd.New("tcp", address).Scan(strings.NewReader(input))
+			if got, want := err != nil, tt.expectErr; got != want {
+				t.Fatalf("err=%v, expectErr=%v", err, want)
+			}
+			if got, want := result, tt.result; got != want {
+				t.Errorf("result=%+v, want=%+v", got, want)
+			}
+			if got, want := string(<-received), input; got != want {
+				t.Errorf("clamd received %d bytes, want %d", len(got), len(want))
+			}
+		})
+	}
+}
+
+func TestScanReturnsErrorWhenClamdIsUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	if _, err := clamd.New("tcp", address).Scan(strings.NewReader("dummy")); err == nil {
+		t.Errorf("expected error when clamd is unreachable")
+	}
+}
+
+func TestParseAddress(t *testing.T) {
+	for _, tt := range []struct {
+		raw       string
+		network   string
+		address   string
+		expectErr bool
+	}{
+		{"unix:///var/run/clamav/clamd.ctl", "unix", "/var/run/clamav/clamd.ctl", false},
+		{"tcp://127.0.0.1:3310", "tcp", "127.0.0.1:3310", false},
+		{"clamav:3310", "tcp", "clamav:3310", false},
+		{"clamav", "", "", true},
+		{"unix://", "", "", true},
+	} {
+		t.Run(tt.raw, func(t *testing.T) {
+			network, address, err := clamd.ParseAddress(tt.raw)
+			if got, want := err != nil, tt.expectErr; got != want {
+				t.Fatalf("err=%v, expectErr=%v", err, want)
+			}
+			if got, want := network, tt.network; got != want {
+				t.Errorf("network=%s, want=%s", got, want)
+			}
+			if got, want := address, tt.address; got != want {
+				t.Errorf("address=%s, want=%s", got, want)
+			}
+		})
+	}
+}
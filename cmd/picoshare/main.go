@@ -2,24 +2,37 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"net/smtp"
+	"net/url"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
 	gorilla "github.com/mtlynch/gorilla-handlers"
 
+	"github.com/mtlynch/picoshare/clamd"
 	"github.com/mtlynch/picoshare/garbagecollect"
 	"github.com/mtlynch/picoshare/handlers"
 	"github.com/mtlynch/picoshare/handlers/auth/shared_secret"
+	"github.com/mtlynch/picoshare/handlers/parse"
+	"github.com/mtlynch/picoshare/integrity"
+	"github.com/mtlynch/picoshare/notify"
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/reminders"
+	"github.com/mtlynch/picoshare/scan"
 	"github.com/mtlynch/picoshare/space"
 	"github.com/mtlynch/picoshare/store/sqlite"
+	"github.com/mtlynch/picoshare/thumbnail"
 )
 
 func main() {
@@ -52,7 +65,81 @@ func main() {
 
 	clock := handlers.NewClock()
 
-	server := handlers.New(authenticator, &store, spaceChecker, &collector, &clock)
+	verifier := integrity.NewVerifier(store, &clock)
+	integrityChecks := integrity.NewScheduler(&verifier, 24*time.Hour)
+	integrityChecks.StartAsync()
+
+	notifiers, email, err := notifiersFromEnv(store)
+	if err != nil {
+		log.Fatalf("invalid notification configuration: %v", err)
+	}
+	if email != nil {
+		emailRetries := notify.NewRetryScheduler(email, 10*time.Minute)
+		emailRetries.StartAsync()
+	}
+	if len(notifiers) > 0 {
+		lead, err := expirationReminderLeadFromEnv()
+		if err != nil {
+			log.Fatalf("invalid expiration reminder configuration: %v", err)
+		}
+		reminder := reminders.NewReminder(store, notifiers, lead, strings.TrimRight(os.Getenv("PS_BASE_URL"), "/"))
+		reminderChecks := reminders.NewScheduler(&reminder, time.Hour)
+		reminderChecks.StartAsync()
+	}
+
+	downloadLimits, err := downloadLimitsFromEnv()
+	if err != nil {
+		log.Fatalf("invalid download limits: %v", err)
+	}
+
+	scanner, err := scannerFromEnv()
+	if err != nil {
+		log.Fatalf("invalid malware scanner configuration: %v", err)
+	}
+
+	compressUploads := os.Getenv("PS_COMPRESS_UPLOADS") != ""
+
+	thumbnails := thumbnail.NewWorker(100)
+	thumbnails.StartAsync()
+
+	var scans *scan.Worker
+	if scanner != nil {
+		scans = scan.NewWorker(scanner, 100)
+		scans.StartAsync()
+
+		scanRetries := scan.NewScheduler(scans, &store, func(id picoshare.EntryID, result picoshare.ScanResult) {
+			// Entries don't get thumbnails until their scan succeeds.
+			entry, err := store.GetEntryMetadata(id)
+			if err != nil {
+				log.Printf("failed to retrieve entry %v after malware scan: %v", id, err)
+				return
+			}
+			if result.IsServable() && thumbnail.Supports(entry.ContentType) {
+				thumbnails.Enqueue(&store, id)
+			}
+		}, 10*time.Minute)
+		scanRetries.StartAsync()
+	}
+
+	// Pass nil interfaces rather than an empty list or a nil pointer so that the
+	// server can tell that notifications and email are off.
+	var notifier notify.Notifier
+	if len(notifiers) > 0 {
+		notifier = notifiers
+	}
+	var mailer handlers.Mailer
+	if email != nil {
+		mailer = email
+	}
+
+	server := handlers.New(authenticator, &store, spaceChecker, &collector, &clock, handlers.Options{
+		DownloadLimitOverrides: downloadLimits,
+		Scans:                  scans,
+		CompressUploads:        compressUploads,
+		Thumbnails:             thumbnails,
+		Notifier:               notifier,
+		Mailer:                 mailer,
+	})
 
 	h := gorilla.LoggingHandler(os.Stdout, server.Router())
 	if os.Getenv("PS_BEHIND_PROXY") != "" {
@@ -91,6 +178,104 @@ func sharedSecretFromEnv() (string, error) {
 	return secret, nil
 }
 
+// downloadLimitsFromEnv reads download limits that override the limits in the
+// settings page. An unset environment variable leaves that limit to settings.
+func downloadLimitsFromEnv() (picoshare.DownloadLimits, error) {
+	var bytesPerSecond *uint64
+	if raw := os.Getenv("PS_MAX_DOWNLOAD_BYTES_PER_SECOND"); raw != "" {
+		v, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return picoshare.DownloadLimits{}, fmt.Errorf("parsing PS_MAX_DOWNLOAD_BYTES_PER_SECOND: %w", err)
+		}
+		bytesPerSecond = &v
+	}
+
+	var concurrentPerIP *int
+	if raw := os.Getenv("PS_MAX_CONCURRENT_DOWNLOADS_PER_IP"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil {
+			return picoshare.DownloadLimits{}, fmt.Errorf("parsing PS_MAX_CONCURRENT_DOWNLOADS_PER_IP: %w", err)
+		}
+		concurrentPerIP = &v
+	}
+
+	return parse.DownloadLimits(bytesPerSecond, concurrentPerIP)
+}
+
+// scannerFromEnv returns a malware scanner if the environment specifies a
+// clamd server, or nil if scanning is disabled.
+func scannerFromEnv() (scan.Scanner, error) {
+	raw := os.Getenv("PS_CLAMD_ADDRESS")
+	if raw == "" {
+		return nil, nil
+	}
+	network, address, err := clamd.ParseAddress(raw)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("scanning uploads for malware with clamd at %s", raw)
+	return clamd.New(network, address), nil
+}
+
+// notifiersFromEnv returns the notifiers that the environment configures and
+// the email notifier, if the environment configures one. If the environment
+// configures none, PicoShare doesn't send notifications.
+func notifiersFromEnv(emailLog notify.EmailLog) (notify.Notifiers, *notify.Email, error) {
+	var notifiers notify.Notifiers
+	var email *notify.Email
+
+	if webhookURL := os.Getenv("PS_WEBHOOK_URL"); webhookURL != "" {
+		if _, err := url.ParseRequestURI(webhookURL); err != nil {
+			return nil, nil, fmt.Errorf("parsing PS_WEBHOOK_URL: %w", err)
+		}
+		log.Printf("sending notifications to webhook at %s", webhookURL)
+		notifiers = append(notifiers, notify.NewWebhook(webhookURL))
+	}
+
+	if address := os.Getenv("PS_SMTP_ADDRESS"); address != "" {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parsing PS_SMTP_ADDRESS: %w", err)
+		}
+		from := os.Getenv("PS_SMTP_FROM")
+		if from == "" {
+			return nil, nil, errors.New("PS_SMTP_FROM must be set when PS_SMTP_ADDRESS is set")
+		}
+		var to []string
+		for addr := range strings.SplitSeq(os.Getenv("PS_SMTP_TO"), ",") {
+			if addr = strings.TrimSpace(addr); addr != "" {
+				to = append(to, addr)
+			}
+		}
+		if len(to) == 0 {
+			return nil, nil, errors.New("PS_SMTP_TO must be set when PS_SMTP_ADDRESS is set")
+		}
+		var auth smtp.Auth
+		if username := os.Getenv("PS_SMTP_USERNAME"); username != "" {
+			auth = smtp.PlainAuth("", username, os.Getenv("PS_SMTP_PASSWORD"), host)
+		}
+		log.Printf("sending notification emails through %s", address)
+		email = notify.NewEmail(address, auth, from, to, emailLog)
+		notifiers = append(notifiers, email)
+	}
+
+	return notifiers, email, nil
+}
+
+// expirationReminderLeadFromEnv returns how long before an entry expires
+// PicoShare sends a reminder.
+func expirationReminderLeadFromEnv() (time.Duration, error) {
+	raw := os.Getenv("PS_EXPIRATION_REMINDER_DAYS")
+	if raw == "" {
+		return 3 * 24 * time.Hour, nil
+	}
+	days, err := strconv.ParseUint(raw, 10, 16)
+	if err != nil || days == 0 {
+		return 0, fmt.Errorf("PS_EXPIRATION_REMINDER_DAYS must be a positive number of days: %q", raw)
+	}
+	return time.Duration(days) * 24 * time.Hour, nil
+}
+
 func ensureDirExists(dir string) {
 	if _, err := os.Stat(dir); os.IsNotExist(err) {
 		if err := os.Mkdir(dir, os.ModePerm); err != nil {
@@ -141,12 +141,65 @@ test("uploads a file and deletes it", async ({ page }) => {
   await page.getByRole("button", { name: "Delete" }).click();
 
   await expect(page).toHaveURL(/\/files\/.+\/confirm-delete$/);
-  await page.getByRole("button", { name: "Delete" }).click();
+  await page.getByRole("button", { name: "Move to Trash" }).click();
 
   await expect(page).toHaveURL("/files");
   await expect(
     await page.getByRole("row").filter({ hasText: "upload-for-deletion.txt" })
   ).toHaveCount(0);
+
+  // Delete the file permanently from the trash.
+  await page.getByRole("menuitem", { name: "Trash" }).click();
+  await expect(page).toHaveURL("/trash");
+
+  page.on("dialog", (dialog) => dialog.accept());
+  const trashRow = page
+    .getByRole("row")
+    .filter({ hasText: "upload-for-deletion.txt" });
+  await trashRow.getByRole("button", { name: "Delete permanently" }).click();
+  await expect(trashRow).toHaveClass(/deleted-entry/);
+
+  await page.reload();
+  await expect(
+    await page.getByRole("row").filter({ hasText: "upload-for-deletion.txt" })
+  ).toHaveCount(0);
+});
+
+test("restores a file from the trash", async ({ page }) => {
+  await login(page);
+
+  await page.locator(".file-input").setInputFiles([
+    {
+      name: "upload-for-restore.txt",
+      mimeType: "text/plain",
+      buffer: Buffer.from("I'm an upload that will come back"),
+    },
+  ]);
+  await expect(page.locator("#upload-result .message-body")).toHaveText(
+    "Upload complete!"
+  );
+
+  await page.getByRole("menuitem", { name: "Files" }).click();
+  await page
+    .getByRole("row")
+    .filter({ hasText: "upload-for-restore.txt" })
+    .getByRole("button", { name: "Edit" })
+    .click();
+  await page.getByRole("button", { name: "Delete" }).click();
+  await page.getByRole("button", { name: "Move to Trash" }).click();
+  await expect(page).toHaveURL("/files");
+
+  await page.getByRole("menuitem", { name: "Trash" }).click();
+  const trashRow = page
+    .getByRole("row")
+    .filter({ hasText: "upload-for-restore.txt" });
+  await trashRow.getByRole("button", { name: "Restore" }).click();
+  await expect(trashRow).toHaveClass(/deleted-entry/);
+
+  await page.getByRole("menuitem", { name: "Files" }).click();
+  await expect(
+    await page.getByRole("row").filter({ hasText: "upload-for-restore.txt" })
+  ).toHaveCount(1);
 });
 
 // Prevent a regression of a bug affecting Firefox:
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+func parseEntryUnavailablePage() *template.Template {
+	fns := template.FuncMap{
+		"formatTimestamp": func(t time.Time) string {
+			return t.UTC().Format(time.RFC3339)
+		},
+		"formatAvailableFrom": func(t time.Time) string {
+			return t.UTC().Format("January 2, 2006 at 15:04 MST")
+		},
+	}
+	return parseTemplatesWithFuncs(fns, "templates/pages/entry-unavailable.html")
+}
+
+// checkEntryAvailable checks that the entry's embargo, if any, has ended. If
+// not, checkEntryAvailable responds with the time when the entry becomes
+// available and returns false. The response is the "not yet available" page
+// if t is non-nil and plain text otherwise.
+//
+// Authenticated users can access entries before they become available so that
+// they can check their uploads ahead of time.
+func (s Server) checkEntryAvailable(w http.ResponseWriter, r *http.Request, t *template.Template, entry picoshare.UploadMetadata) bool {
+	if entry.IsAvailable(s.clock.Now()) {
+		return true
+	}
+
+	// Shared caches must not serve the entry to anyone else before it becomes
+	// available, nor serve this response after it becomes available.
+	if isAuthenticated(r.Context()) {
+		w.Header().Set("Cache-Control", "private")
+		return true
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Retry-After", entry.AvailableFrom.UTC().Format(http.TimeFormat))
+
+	if t == nil {
+		http.Error(w, "This file is not available yet", http.StatusForbidden)
+		return false
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusForbidden)
+	if err := t.Execute(w, struct {
+		commonProps
+		AvailableFrom time.Time
+	}{
+		commonProps:   makeCommonProps("PicoShare - Not Yet Available", r.Context()),
+		AvailableFrom: entry.AvailableFrom,
+	}); err != nil {
+		log.Printf("failed to render not yet available page for entry %v: %v", entry.ID, err)
+	}
+	return false
+}
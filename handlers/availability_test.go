@@ -0,0 +1,317 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mtlynch/picoshare/handlers"
+	"github.com/mtlynch/picoshare/handlers/auth/shared_secret"
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/store/test_sqlite"
+)
+
+func TestEntryGetAvailability(t *testing.T) {
+	unauthenticated, err := shared_secret.New("dummypass")
+	if err != nil {
+		t.Fatalf("failed to create shared secret: %v", err)
+	}
+
+	availableFrom := mustParseTime("2024-06-01T09:00:00Z")
+
+	for _, tt := range []struct {
+		description       string
+		availableFrom     time.Time
+		now               time.Time
+		authenticated     bool
+		route             string
+		status            int
+		retryAfter        string
+		bodyContains      string
+		cacheControl      string
+		contentsAvailable bool
+	}{
+		{
+			description:       "serves entry with no embargo",
+			now:               mustParseTime("2024-01-01T00:00:00Z"),
+			route:             "/-AAAAAAAAAA",
+			status:            http.StatusOK,
+			contentsAvailable: true,
+		},
+		{
+			description:   "refuses entry before it's available",
+			availableFrom: availableFrom,
+			now:           mustParseTime("2024-05-31T09:00:00Z"),
+			route:         "/-AAAAAAAAAA",
+			status:        http.StatusForbidden,
+			retryAfter:    "Sat, 01 Jun 2024 09:00:00 GMT",
+			bodyContains:  "Not Yet Available",
+			cacheControl:  "no-store",
+		},
+		{
+			description:   "refuses preview of entry before it's available",
+			availableFrom: availableFrom,
+			now:           mustParseTime("2024-05-31T09:00:00Z"),
+			route:         "/-AAAAAAAAAA/_/view",
+			status:        http.StatusForbidden,
+			retryAfter:    "Sat, 01 Jun 2024 09:00:00 GMT",
+			cacheControl:  "no-store",
+		},
+		{
+			description:   "hides entry from oEmbed before it's available",
+			availableFrom: availableFrom,
+			now:           mustParseTime("2024-05-31T09:00:00Z"),
+			route:         "/oembed?url=https%3A%2F%2Fexample.com%2F-AAAAAAAAAA",
+			status:        http.StatusNotFound,
+		},
+		{
+			description:       "serves entry once it's available",
+			availableFrom:     availableFrom,
+			now:               availableFrom,
+			route:             "/-AAAAAAAAAA",
+			status:            http.StatusOK,
+			contentsAvailable: true,
+		},
+		{
+			description:       "serves entry to admin before it's available",
+			availableFrom:     availableFrom,
+			now:               mustParseTime("2024-05-31T09:00:00Z"),
+			authenticated:     true,
+			route:             "/-AAAAAAAAAA",
+			status:            http.StatusOK,
+			cacheControl:      "private",
+			contentsAvailable: true,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			dataStore := test_sqlite.New()
+			contents := "dummy data"
+			if err := dataStore.InsertEntry(strings.NewReader(contents), picoshare.UploadMetadata{
+				ID:            "AAAAAAAAAA",
+				Filename:      "release.zip",
+				ContentType:   "application/zip",
+				Uploaded:      mustParseTime("2024-01-01T00:00:00Z"),
+				Expires:       picoshare.NeverExpire,
+				Size:          mustParseFileSize(len(contents)),
+				AvailableFrom: tt.availableFrom,
+			}); err != nil {
+				t.Fatalf("failed to insert dummy entry: %v", err)
+			}
+
+			c := mockClock{tt.now}
+			s := handlers.New(unauthenticated, &dataStore, nilSpaceChecker, nilGarbageCollector, c, handlers.Options{})
+			if tt.authenticated {
+				s = handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, c, handlers.Options{})
+			}
+
+			req, err := http.NewRequest("GET", tt.route, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+			res := rec.Result()
+
+			if got, want := res.StatusCode, tt.status; got != want {
+				t.Fatalf("status=%d, want=%d", got, want)
+			}
+			if got, want := res.Header.Get("Retry-After"), tt.retryAfter; got != want {
+				t.Errorf("Retry-After=%q, want=%q", got, want)
+			}
+			if got, want := res.Header.Get("Cache-Control"), tt.cacheControl; got != want {
+				t.Errorf("Cache-Control=%q, want=%q", got, want)
+			}
+
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("failed to read response body: %v", err)
+			}
+			if !strings.Contains(string(body), tt.bodyContains) {
+				t.Errorf("body doesn't contain %q: %s", tt.bodyContains, body)
+			}
+			if got, want := string(body) == contents, tt.contentsAvailable; got != want {
+				t.Errorf("served contents=%v, want=%v", got, want)
+			}
+		})
+	}
+}
+
+func TestEntryGetAvailabilitySingleUseLink(t *testing.T) {
+	unauthenticated, err := shared_secret.New("dummypass")
+	if err != nil {
+		t.Fatalf("failed to create shared secret: %v", err)
+	}
+
+	availableFrom := mustParseTime("2024-06-01T09:00:00Z")
+
+	dataStore := test_sqlite.New()
+	contents := "dummy data"
+	if err := dataStore.InsertEntry(strings.NewReader(contents), picoshare.UploadMetadata{
+		ID:            "AAAAAAAAAA",
+		Filename:      "release.zip",
+		ContentType:   "application/zip",
+		Uploaded:      mustParseTime("2024-01-01T00:00:00Z"),
+		Expires:       picoshare.NeverExpire,
+		Size:          mustParseFileSize(len(contents)),
+		Visibility:    picoshare.VisibilitySignedLinkOnly,
+		AvailableFrom: availableFrom,
+	}); err != nil {
+		t.Fatalf("failed to insert dummy entry: %v", err)
+	}
+
+	admin := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, mockClock{mustParseTime("2024-05-31T09:00:00Z")}, handlers.Options{})
+	req, err := http.NewRequest("POST", "/api/entry/AAAAAAAAAA/signed-urls", strings.NewReader(`{"expiresInMinutes": 2880, "singleUse": true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	admin.Router().ServeHTTP(rec, req)
+	var response handlers.SignedURLPostResponse
+	if err := json.NewDecoder(rec.Result().Body).Decode(&response); err != nil {
+		t.Fatalf("response is not valid JSON: %v", err)
+	}
+	signedURL, err := url.Parse(response.URL)
+	if err != nil {
+		t.Fatalf("response URL is invalid: %v", err)
+	}
+
+	for i, rr := range []struct {
+		now        time.Time
+		status     int
+		retryAfter string
+	}{
+		{
+			now:        mustParseTime("2024-05-31T10:00:00Z"),
+			status:     http.StatusForbidden,
+			retryAfter: "Sat, 01 Jun 2024 09:00:00 GMT",
+		},
+		{
+			now:    availableFrom,
+			status: http.StatusOK,
+		},
+		{
+			now:    availableFrom.Add(time.Minute),
+			status: http.StatusGone,
+		},
+	} {
+		s := handlers.New(unauthenticated, &dataStore, nilSpaceChecker, nilGarbageCollector, mockClock{rr.now}, handlers.Options{})
+		req, err := http.NewRequest("GET", signedURL.RequestURI(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		s.Router().ServeHTTP(rec, req)
+		res := rec.Result()
+
+		if got, want := res.StatusCode, rr.status; got != want {
+			t.Fatalf("request %d: status=%d, want=%d", i, got, want)
+		}
+		if got, want := res.Header.Get("Retry-After"), rr.retryAfter; got != want {
+			t.Errorf("request %d: Retry-After=%q, want=%q", i, got, want)
+		}
+	}
+}
+
+func TestEntryPostAvailableFrom(t *testing.T) {
+	for _, tt := range []struct {
+		description           string
+		availableFrom         string
+		guestLinkID           string
+		status                int
+		availableFromExpected time.Time
+	}{
+		{
+			description:           "schedules availability",
+			availableFrom:         "2039-06-01T09:00:00Z",
+			status:                http.StatusOK,
+			availableFromExpected: mustParseTime("2039-06-01T09:00:00Z"),
+		},
+		{
+			description:   "rejects availability after expiration",
+			availableFrom: "2041-01-01T00:00:00Z",
+			status:        http.StatusBadRequest,
+		},
+		{
+			description:   "rejects malformed availability",
+			availableFrom: "tomorrow",
+			status:        http.StatusBadRequest,
+		},
+		{
+			description:   "rejects availability from guest",
+			availableFrom: "2039-06-01T09:00:00Z",
+			guestLinkID:   "abcdefgh23456789",
+			status:        http.StatusBadRequest,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			dataStore := test_sqlite.New()
+			if err := dataStore.InsertGuestLink(picoshare.GuestLink{
+				ID:              picoshare.GuestLinkID("abcdefgh23456789"),
+				Created:         mustParseTime("2024-01-01T00:00:00Z"),
+				UrlExpires:      picoshare.NeverExpire,
+				MaxFileLifetime: picoshare.FileLifetimeInfinite,
+			}); err != nil {
+				t.Fatalf("failed to insert dummy guest link: %v", err)
+			}
+
+			s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock(), handlers.Options{})
+
+			var b bytes.Buffer
+			mw := multipart.NewWriter(&b)
+			f, err := mw.CreateFormFile("file", "release.zip")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := f.Write([]byte("dummy bytes")); err != nil {
+				t.Fatal(err)
+			}
+			if err := mw.WriteField("availableFrom", tt.availableFrom); err != nil {
+				t.Fatal(err)
+			}
+			if err := mw.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			route := "/api/entry?expiration=2040-01-01T00:00:00Z"
+			if tt.guestLinkID != "" {
+				route = "/api/guest/" + tt.guestLinkID
+			}
+			req, err := http.NewRequest("POST", route, &b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Add("Content-Type", mw.FormDataContentType())
+			req.Header.Add("Accept", "application/json")
+
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+			res := rec.Result()
+
+			if got, want := res.StatusCode, tt.status; got != want {
+				t.Fatalf("status=%d, want=%d", got, want)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var response handlers.EntryPostResponse
+			if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+				t.Fatalf("response is not valid JSON: %v", err)
+			}
+			entry, err := dataStore.GetEntryMetadata(picoshare.EntryID(response.ID))
+			if err != nil {
+				t.Fatalf("failed to get entry: %v", err)
+			}
+			if got, want := entry.AvailableFrom, tt.availableFromExpected; !got.Equal(want) {
+				t.Errorf("availableFrom=%v, want=%v", got, want)
+			}
+		})
+	}
+}
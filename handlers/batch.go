@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/mtlynch/picoshare/handlers/parse"
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/store"
+)
+
+// maxBatchEntries is the maximum number of entries in a single batch request.
+const maxBatchEntries = 1000
+
+type (
+	batchAction string
+
+	entriesBatchRequest struct {
+		Action     batchAction
+		IDs        []picoshare.EntryID
+		Expiration picoshare.ExpirationTime
+		Tags       []picoshare.Tag
+	}
+)
+
+const (
+	batchActionDelete          = batchAction("delete")
+	batchActionExpire          = batchAction("expire")
+	batchActionTag             = batchAction("tag")
+	batchActionExportDownloads = batchAction("exportDownloads")
+)
+
+func (s Server) entriesBatchPost() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req, err := s.entriesBatchFromRequest(r)
+		if err != nil {
+			log.Printf("invalid batch request: %v", err)
+			http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		db := s.getDB(r)
+
+		switch req.Action {
+		case batchActionDelete:
+			err = db.TrashEntries(req.IDs)
+		case batchActionExpire:
+			err = db.UpdateEntriesExpiration(req.IDs, req.Expiration)
+		case batchActionTag:
+			err = db.AddEntriesTags(req.IDs, req.Tags, parse.MaxTagsPerEntry)
+		case batchActionExportDownloads:
+			s.exportDownloads(w, r, req.IDs)
+			return
+		}
+		if _, ok := errors.AsType[store.EntryNotFoundError](err); ok {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		} else if _, ok := errors.AsType[store.EntryTooManyTagsError](err); ok {
+			http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+			return
+		} else if err != nil {
+			log.Printf("failed to %s %d entries: %v", req.Action, len(req.IDs), err)
+			http.Error(w, fmt.Sprintf("Failed to %s entries", req.Action), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// exportDownloads writes the download history of the given entries as a CSV
+// file.
+func (s Server) exportDownloads(w http.ResponseWriter, r *http.Request, ids []picoshare.EntryID) {
+	db := s.getDB(r)
+
+	// Read everything before writing the response so that a failure partway
+	// through doesn't produce a truncated file that looks complete.
+	rows := [][]string{{"entry_id", "filename", "time", "client_ip", "user_agent"}}
+	for _, id := range ids {
+		metadata, err := db.GetEntryMetadata(id)
+		if _, ok := errors.AsType[store.EntryNotFoundError](err); ok {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("error retrieving entry with id %v: %v", id, err)
+			http.Error(w, "failed to retrieve entry", http.StatusInternalServerError)
+			return
+		}
+
+		downloads, err := db.GetEntryDownloads(id)
+		if err != nil {
+			log.Printf("error retrieving downloads for id %v: %v", id, err)
+			http.Error(w, "failed to retrieve downloads", http.StatusInternalServerError)
+			return
+		}
+
+		for _, d := range downloads {
+			rows = append(rows, []string{id.String(), metadata.Filename.String(), d.Time.UTC().Format(time.RFC3339), d.ClientIP, d.UserAgent})
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="downloads.csv"`)
+	if err := csv.NewWriter(w).WriteAll(rows); err != nil {
+		log.Printf("failed to write download history: %v", err)
+	}
+}
+
+func (s Server) entriesBatchFromRequest(r *http.Request) (entriesBatchRequest, error) {
+	var payload struct {
+		Action     string   `json:"action"`
+		IDs        []string `json:"ids"`
+		Expiration string   `json:"expiration"`
+		Tags       []string `json:"tags"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		log.Printf("failed to decode JSON request: %v", err)
+		return entriesBatchRequest{}, err
+	}
+
+	if len(payload.IDs) == 0 {
+		return entriesBatchRequest{}, errors.New("no entries selected")
+	}
+	if len(payload.IDs) > maxBatchEntries {
+		return entriesBatchRequest{}, fmt.Errorf("too many entries - limit %d per request", maxBatchEntries)
+	}
+
+	req := entriesBatchRequest{
+		Action: batchAction(payload.Action),
+		IDs:    make([]picoshare.EntryID, len(payload.IDs)),
+	}
+	for i, raw := range payload.IDs {
+		id, err := parseEntryID(raw)
+		if err != nil {
+			return entriesBatchRequest{}, err
+		}
+		req.IDs[i] = id
+	}
+
+	switch req.Action {
+	case batchActionDelete, batchActionExportDownloads:
+	case batchActionExpire:
+		// Treat an empty expiration string as NeverExpire, just like edits to a
+		// single entry. Otherwise, accept either a preset such as "7d" or a
+		// timestamp.
+		req.Expiration = picoshare.NeverExpire
+		if payload.Expiration != "" {
+			expiration, err := parse.ExpirationFromPreset(payload.Expiration, s.clock.Now())
+			if err == parse.ErrExpirationPresetUnrecognized {
+				expiration, err = parse.Expiration(payload.Expiration, s.clock.Now())
+			}
+			if err != nil {
+				return entriesBatchRequest{}, err
+			}
+			req.Expiration = expiration
+		}
+	case batchActionTag:
+		tags, err := parse.Tags(payload.Tags)
+		if err != nil {
+			return entriesBatchRequest{}, err
+		}
+		if len(tags) == 0 {
+			return entriesBatchRequest{}, errors.New("no tags specified")
+		}
+		req.Tags = tags
+	default:
+		return entriesBatchRequest{}, fmt.Errorf("unrecognized action: %s", payload.Action)
+	}
+
+	return req, nil
+}
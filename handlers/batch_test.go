@@ -0,0 +1,229 @@
+package handlers_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mtlynch/picoshare/handlers"
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/store"
+	"github.com/mtlynch/picoshare/store/test_sqlite"
+)
+
+func TestEntriesBatchPost(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		payload     string
+		status      int
+		// remaining lists the IDs of the entries that should exist after the
+		// request.
+		remaining       []picoshare.EntryID
+		expiresExpected picoshare.ExpirationTime
+		tagsExpected    []picoshare.Tag
+	}{
+		{
+			description:     "deletes selected entries",
+			payload:         `{"action": "delete", "ids": ["AAAAAAAAAA", "BBBBBBBBBB"]}`,
+			status:          http.StatusNoContent,
+			remaining:       []picoshare.EntryID{"CCCCCCCCCC"},
+			expiresExpected: mustParseExpirationTime("2030-01-01T00:00:00Z"),
+		},
+		{
+			description:     "changes expiration to a timestamp",
+			payload:         `{"action": "expire", "ids": ["AAAAAAAAAA", "CCCCCCCCCC"], "expiration": "2031-02-03T00:00:00Z"}`,
+			status:          http.StatusNoContent,
+			remaining:       []picoshare.EntryID{"AAAAAAAAAA", "BBBBBBBBBB", "CCCCCCCCCC"},
+			expiresExpected: mustParseExpirationTime("2031-02-03T00:00:00Z"),
+		},
+		{
+			description:     "changes expiration to a preset",
+			payload:         `{"action": "expire", "ids": ["AAAAAAAAAA", "CCCCCCCCCC"], "expiration": "7d"}`,
+			status:          http.StatusNoContent,
+			remaining:       []picoshare.EntryID{"AAAAAAAAAA", "BBBBBBBBBB", "CCCCCCCCCC"},
+			expiresExpected: mustParseExpirationTime("2024-01-08T00:00:00Z"),
+		},
+		{
+			description:     "treats missing expiration as never expire",
+			payload:         `{"action": "expire", "ids": ["AAAAAAAAAA", "CCCCCCCCCC"]}`,
+			status:          http.StatusNoContent,
+			remaining:       []picoshare.EntryID{"AAAAAAAAAA", "BBBBBBBBBB", "CCCCCCCCCC"},
+			expiresExpected: picoshare.NeverExpire,
+		},
+		{
+			description:     "adds tag to selected entries",
+			payload:         `{"action": "tag", "ids": ["AAAAAAAAAA", "CCCCCCCCCC"], "tags": ["Archive"]}`,
+			status:          http.StatusNoContent,
+			remaining:       []picoshare.EntryID{"AAAAAAAAAA", "BBBBBBBBBB", "CCCCCCCCCC"},
+			expiresExpected: mustParseExpirationTime("2030-01-01T00:00:00Z"),
+			tagsExpected:    []picoshare.Tag{"archive", "existing"},
+		},
+		{
+			description:     "changes nothing when any entry would exceed the tag limit",
+			payload:         `{"action": "tag", "ids": ["AAAAAAAAAA", "CCCCCCCCCC"], "tags": ["tag01", "tag02", "tag03", "tag04", "tag05", "tag06", "tag07", "tag08", "tag09", "tag10", "tag11", "tag12", "tag13", "tag14", "tag15", "tag16", "tag17", "tag18", "tag19", "tag20"]}`,
+			status:          http.StatusBadRequest,
+			remaining:       []picoshare.EntryID{"AAAAAAAAAA", "BBBBBBBBBB", "CCCCCCCCCC"},
+			expiresExpected: mustParseExpirationTime("2030-01-01T00:00:00Z"),
+			tagsExpected:    []picoshare.Tag{"existing"},
+		},
+		{
+			description:     "changes nothing when any entry doesn't exist",
+			payload:         `{"action": "expire", "ids": ["AAAAAAAAAA", "DDDDDDDDDD"], "expiration": "2031-02-03T00:00:00Z"}`,
+			status:          http.StatusNotFound,
+			remaining:       []picoshare.EntryID{"AAAAAAAAAA", "BBBBBBBBBB", "CCCCCCCCCC"},
+			expiresExpected: mustParseExpirationTime("2030-01-01T00:00:00Z"),
+		},
+		{
+			description:     "deletes nothing when any entry doesn't exist",
+			payload:         `{"action": "delete", "ids": ["AAAAAAAAAA", "DDDDDDDDDD"]}`,
+			status:          http.StatusNotFound,
+			remaining:       []picoshare.EntryID{"AAAAAAAAAA", "BBBBBBBBBB", "CCCCCCCCCC"},
+			expiresExpected: mustParseExpirationTime("2030-01-01T00:00:00Z"),
+		},
+		{
+			description:     "rejects unrecognized action",
+			payload:         `{"action": "rename", "ids": ["AAAAAAAAAA"]}`,
+			status:          http.StatusBadRequest,
+			remaining:       []picoshare.EntryID{"AAAAAAAAAA", "BBBBBBBBBB", "CCCCCCCCCC"},
+			expiresExpected: mustParseExpirationTime("2030-01-01T00:00:00Z"),
+		},
+		{
+			description:     "rejects request without entries",
+			payload:         `{"action": "delete", "ids": []}`,
+			status:          http.StatusBadRequest,
+			remaining:       []picoshare.EntryID{"AAAAAAAAAA", "BBBBBBBBBB", "CCCCCCCCCC"},
+			expiresExpected: mustParseExpirationTime("2030-01-01T00:00:00Z"),
+		},
+		{
+			description:     "rejects invalid entry ID",
+			payload:         `{"action": "delete", "ids": ["AAAAAAAAAA", "not-an-id"]}`,
+			status:          http.StatusBadRequest,
+			remaining:       []picoshare.EntryID{"AAAAAAAAAA", "BBBBBBBBBB", "CCCCCCCCCC"},
+			expiresExpected: mustParseExpirationTime("2030-01-01T00:00:00Z"),
+		},
+		{
+			description:     "rejects invalid tag",
+			payload:         `{"action": "tag", "ids": ["AAAAAAAAAA"], "tags": ["a,b"]}`,
+			status:          http.StatusBadRequest,
+			remaining:       []picoshare.EntryID{"AAAAAAAAAA", "BBBBBBBBBB", "CCCCCCCCCC"},
+			expiresExpected: mustParseExpirationTime("2030-01-01T00:00:00Z"),
+			tagsExpected:    []picoshare.Tag{"existing"},
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			dataStore := test_sqlite.New()
+			for _, id := range []picoshare.EntryID{"AAAAAAAAAA", "BBBBBBBBBB", "CCCCCCCCCC"} {
+				contents := "dummy data"
+				if err := dataStore.InsertEntry(strings.NewReader(contents), picoshare.UploadMetadata{
+					ID:       id,
+					Filename: picoshare.Filename(id.String() + ".txt"),
+					Uploaded: mustParseTime("2023-01-01T00:00:00Z"),
+					Expires:  mustParseExpirationTime("2030-01-01T00:00:00Z"),
+					Size:     mustParseFileSize(len(contents)),
+					Tags:     []picoshare.Tag{"existing"},
+				}); err != nil {
+					t.Fatalf("failed to insert dummy entry: %v", err)
+				}
+			}
+
+			c := mockClock{mustParseTime("2024-01-01T00:00:00Z")}
+			s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, c, handlers.Options{})
+
+			req, err := http.NewRequest("POST", "/api/entries/batch", strings.NewReader(tt.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+			res := rec.Result()
+
+			if got, want := res.StatusCode, tt.status; got != want {
+				t.Fatalf("status=%d, want=%d", got, want)
+			}
+
+			for _, id := range []picoshare.EntryID{"AAAAAAAAAA", "BBBBBBBBBB", "CCCCCCCCCC"} {
+				entry, err := dataStore.GetEntryMetadata(id)
+				if _, ok := err.(store.EntryNotFoundError); ok {
+					if slices.Contains(tt.remaining, id) {
+						t.Errorf("entry %v was deleted, want it to remain", id)
+					}
+					continue
+				} else if err != nil {
+					t.Fatalf("failed to get entry %v: %v", id, err)
+				}
+				if !slices.Contains(tt.remaining, id) {
+					t.Errorf("entry %v remains, want it deleted", id)
+				}
+
+				// Only entries A and C are ever the targets of edits.
+				if id == "BBBBBBBBBB" {
+					continue
+				}
+				if got, want := entry.Expires, tt.expiresExpected; got != want {
+					t.Errorf("%v: expiration=%v, want=%v", id, got, want)
+				}
+				tagsExpected := tt.tagsExpected
+				if tagsExpected == nil {
+					tagsExpected = []picoshare.Tag{"existing"}
+				}
+				if got, want := entry.Tags, tagsExpected; !slices.Equal(got, want) {
+					t.Errorf("%v: tags=%v, want=%v", id, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestEntriesBatchExportDownloads(t *testing.T) {
+	dataStore := test_sqlite.New()
+	for _, id := range []picoshare.EntryID{"AAAAAAAAAA", "BBBBBBBBBB"} {
+		contents := "dummy data"
+		if err := dataStore.InsertEntry(strings.NewReader(contents), picoshare.UploadMetadata{
+			ID:       id,
+			Filename: picoshare.Filename(id.String() + ".txt"),
+			Uploaded: mustParseTime("2023-01-01T00:00:00Z"),
+			Expires:  picoshare.NeverExpire,
+			Size:     mustParseFileSize(len(contents)),
+		}); err != nil {
+			t.Fatalf("failed to insert dummy entry: %v", err)
+		}
+	}
+	if err := dataStore.InsertEntryDownload("AAAAAAAAAA", picoshare.DownloadRecord{
+		Time:      mustParseTime("2024-01-02T03:04:05Z"),
+		ClientIP:  "192.0.2.1",
+		UserAgent: "curl/8.0, probably",
+	}); err != nil {
+		t.Fatalf("failed to insert download record: %v", err)
+	}
+
+	s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock(), handlers.Options{})
+
+	req, err := http.NewRequest("POST", "/api/entries/batch", strings.NewReader(`{"action": "exportDownloads", "ids": ["AAAAAAAAAA", "BBBBBBBBBB"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, req)
+	res := rec.Result()
+
+	if got, want := res.StatusCode, http.StatusOK; got != want {
+		t.Fatalf("status=%d, want=%d", got, want)
+	}
+	if got, want := res.Header.Get("Content-Type"), "text/csv; charset=utf-8"; got != want {
+		t.Errorf("Content-Type=%s, want=%s", got, want)
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("failed to read response body: %v", err)
+	}
+	if got, want := string(body), "entry_id,filename,time,client_ip,user_agent\n"+
+		`AAAAAAAAAA,AAAAAAAAAA.txt,2024-01-02T03:04:05Z,192.0.2.1,"curl/8.0, probably"`+"\n"; got != want {
+		t.Errorf("body=%q, want=%q", got, want)
+	}
+}
@@ -1,11 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/store"
 )
 
 func (s Server) entryDelete() http.HandlerFunc {
@@ -17,9 +20,15 @@ func (s Server) entryDelete() http.HandlerFunc {
 			return
 		}
 
-		err = s.getDB(r).DeleteEntry(id)
-		if err != nil {
-			log.Printf("failed to delete entry %v: %v", id, err)
+		// Deleting an entry moves it to the trash, so the user can still restore
+		// it until the trash retention period elapses.
+		err = s.getDB(r).TrashEntries([]picoshare.EntryID{id})
+		if _, ok := errors.AsType[store.EntryNotFoundError](err); ok {
+			// There's no error for deleting an entry that doesn't exist or is
+			// already in the trash.
+			return
+		} else if err != nil {
+			log.Printf("failed to move entry %v to trash: %v", id, err)
 			http.Error(w, "failed to delete entry", http.StatusInternalServerError)
 			return
 		}
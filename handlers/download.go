@@ -1,22 +1,37 @@
 package handlers
 
 import (
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"mime"
 	"net"
 	"net/http"
 	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/gorilla/mux"
 
+	"github.com/mtlynch/picoshare/handlers/parse"
 	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/ratelimit"
+	"github.com/mtlynch/picoshare/signedurl"
 	"github.com/mtlynch/picoshare/store"
 )
 
+// entryBandwidthIdleTimeout is how long we keep an entry's bandwidth limit
+// state after its last download activity. It's long enough that a client that
+// briefly stalls mid-download still shares the entry's limit with new
+// downloads.
+const entryBandwidthIdleTimeout = 10 * time.Minute
+
 func (s Server) entryGet() http.HandlerFunc {
+	serveEntry := s.entryServer()
+
 	return func(w http.ResponseWriter, r *http.Request) {
 		id, err := parseEntryID(mux.Vars(r)["id"])
 		if err != nil {
@@ -25,6 +40,43 @@ func (s Server) entryGet() http.HandlerFunc {
 			return
 		}
 
+		serveEntry(w, r, id, isBareEntryPath(r, id))
+	}
+}
+
+func (s Server) entrySlugGet() http.HandlerFunc {
+	serveEntry := s.entryServer()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		slug, err := parse.Slug(mux.Vars(r)["slug"])
+		if err != nil || slug == "" {
+			http.Error(w, "entry not found", http.StatusNotFound)
+			return
+		}
+
+		id, err := s.getDB(r).GetEntryIDBySlug(slug)
+		if _, ok := errors.AsType[store.EntrySlugNotFoundError](err); ok {
+			http.Error(w, "entry not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("error retrieving entry with slug %v: %v", slug, err)
+			http.Error(w, "failed to retrieve entry", http.StatusInternalServerError)
+			return
+		}
+
+		serveEntry(w, r, id, isBareSlugPath(r, slug))
+	}
+}
+
+// entryServer returns a function that serves the entry with the given ID.
+// isBarePath indicates whether the request is for the entry's short link
+// rather than a link that includes the filename.
+func (s Server) entryServer() func(w http.ResponseWriter, r *http.Request, id picoshare.EntryID, isBarePath bool) {
+	snippetPage := parseSnippetPage()
+	landingPage := parseEntryLandingPage()
+	unavailablePage := parseEntryUnavailablePage()
+
+	return func(w http.ResponseWriter, r *http.Request, id picoshare.EntryID, isBarePath bool) {
 		entry, err := s.getDB(r).GetEntryMetadata(id)
 		if _, ok := errors.AsType[store.EntryNotFoundError](err); ok {
 			http.Error(w, "entry not found", http.StatusNotFound)
@@ -35,8 +87,53 @@ func (s Server) entryGet() http.HandlerFunc {
 			return
 		}
 
-		if entry.Filename != "" {
-			w.Header().Set("Content-Disposition", fmt.Sprintf(`filename="%s"`, entry.Filename))
+		// Authorize the client before revealing anything else about the entry,
+		// such as whether it's quarantined or when it becomes available.
+		if !s.authorizeEntryDownload(w, r, entry) {
+			return
+		}
+
+		if rawVersion := r.URL.Query().Get("v"); rawVersion != "" && !entry.IsSnippet {
+			version, err := parseEntryVersion(rawVersion)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Invalid version: %v", err), http.StatusBadRequest)
+				return
+			}
+			if version != entry.Version {
+				entry, err = s.entryAtVersion(r, entry, version)
+				if _, ok := errors.AsType[store.EntryVersionNotFoundError](err); ok {
+					http.Error(w, "version not found", http.StatusNotFound)
+					return
+				} else if err != nil {
+					log.Printf("error retrieving version %d of entry %v: %v", version, id, err)
+					http.Error(w, "failed to retrieve entry", http.StatusInternalServerError)
+					return
+				}
+			}
+		}
+
+		if !checkEntryScan(w, entry) {
+			return
+		}
+
+		if !s.checkEntryAvailable(w, r, unavailablePage, entry) {
+			return
+		}
+
+		// Redeem the signed link only once the entry is ready to serve, so that
+		// clicking a single-use link too early doesn't use it up.
+		if signedurl.IsSigned(r.URL.Query()) && !s.redeemSignedURL(w, r, id) {
+			return
+		}
+
+		if entry.IsSnippet {
+			s.serveSnippetPage(w, r, snippetPage, entry)
+			return
+		}
+
+		if isLinkPreviewCrawler(r.Header.Get("User-Agent")) && isBarePath {
+			s.serveEntryLandingPage(w, r, landingPage, entry)
+			return
 		}
 
 		contentType := entry.ContentType
@@ -45,20 +142,114 @@ func (s Server) entryGet() http.HandlerFunc {
 				contentType = inferred
 			}
 		}
-		w.Header().Set("Content-Type", contentType.String())
 
-		entryFile, err := s.getDB(r).ReadEntryFile(id)
-		if err != nil {
-			log.Printf("error retrieving entry data with id %v: %v", id, err)
-			http.Error(w, "failed to retrieve entry", http.StatusInternalServerError)
-			return
+		s.serveEntryFile(w, r, entry, contentType)
+	}
+}
+
+// entryAtVersion returns the entry's metadata with the attributes of its
+// contents replaced by those of a previous version.
+func (s Server) entryAtVersion(r *http.Request, entry picoshare.UploadMetadata, version int) (picoshare.UploadMetadata, error) {
+	versions, err := s.getDB(r).GetEntryVersions(entry.ID)
+	if err != nil {
+		return picoshare.UploadMetadata{}, err
+	}
+
+	for _, v := range versions {
+		if v.Number != version {
+			continue
 		}
+		entry.Version = v.Number
+		entry.Filename = v.Filename
+		entry.ContentType = v.ContentType
+		entry.Uploaded = v.Uploaded
+		entry.Size = v.Size
+		entry.SHA256 = v.SHA256
+		entry.Scan = v.Scan
+		return entry, nil
+	}
+
+	return picoshare.UploadMetadata{}, store.EntryVersionNotFoundError{ID: entry.ID, Version: version}
+}
+
+func parseEntryVersion(s string) (int, error) {
+	version, err := strconv.Atoi(s)
+	if err != nil || version < 1 {
+		return 0, errors.New("version must be a positive integer")
+	}
+	return version, nil
+}
+
+// serveEntryFile writes an entry's data to the client as the given content
+// type, subject to PicoShare's download limits.
+func (s Server) serveEntryFile(w http.ResponseWriter, r *http.Request, entry picoshare.UploadMetadata, contentType picoshare.ContentType) {
+	if entry.Filename != "" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`filename="%s"`, entry.Filename))
+	}
+	w.Header().Set("Content-Type", contentType.String())
 
-		http.ServeContent(w, r, entry.Filename.String(), entry.Uploaded, entryFile)
+	if entry.SHA256 != "" {
+		setDigestHeaders(w, entry.SHA256)
+	}
 
-		if err := recordDownload(s.getDB(r), entry.ID, s.clock.Now(), r.RemoteAddr, r.Header.Get("User-Agent")); err != nil {
-			log.Printf("failed to record download of file %s: %v", id.String(), err)
+	settings, err := s.getDB(r).ReadSettings()
+	if err != nil {
+		log.Printf("failed to read settings from database: %v", err)
+		http.Error(w, "Failed to read download limits", http.StatusInternalServerError)
+		return
+	}
+	limits := s.downloadLimitOverrides.WithDefaults(settings.DownloadLimits)
+
+	if limits.MaxConcurrentPerIP != nil {
+		clientIP := clientIPFromRemoteAddr(r.RemoteAddr)
+		if !s.activeDownloads.Acquire(clientIP, *limits.MaxConcurrentPerIP) {
+			log.Printf("rejecting download from %s: too many concurrent downloads", clientIP)
+			http.Error(w, "Too many concurrent downloads", http.StatusTooManyRequests)
+			return
 		}
+		defer s.activeDownloads.Release(clientIP)
+	}
+
+	entryFile, err := s.getDB(r).ReadEntryVersionFile(entry.ID, entry.Version)
+	if err != nil {
+		log.Printf("error retrieving entry data with id %v: %v", entry.ID, err)
+		http.Error(w, "failed to retrieve entry", http.StatusInternalServerError)
+		return
+	}
+
+	http.ServeContent(w, r, entry.Filename.String(), entry.Uploaded, s.throttleDownload(entryFile, entry, limits))
+
+	download, err := recordDownload(s.getDB(r), entry, s.clock.Now(), r.RemoteAddr, r.Header.Get("User-Agent"))
+	if err != nil {
+		log.Printf("failed to record download of file %s: %v", entry.ID.String(), err)
+	}
+
+	s.notifySensitiveDownload(r, entry, download)
+}
+
+// throttleDownload limits the rate at which a client can read an entry's data
+// to both the server-wide download bandwidth and the entry's own bandwidth.
+func (s Server) throttleDownload(entryFile io.ReadSeeker, entry picoshare.UploadMetadata, limits picoshare.DownloadLimits) io.ReadSeeker {
+	buckets := []*ratelimit.Bucket{}
+	if limits.MaxBytesPerSecond != nil {
+		s.downloadBandwidth.SetBytesPerSecond(*limits.MaxBytesPerSecond)
+		buckets = append(buckets, s.downloadBandwidth)
+	}
+	if entry.MaxDownloadBytesPerSecond != nil {
+		buckets = append(buckets, s.entryBandwidth.Get(entry.ID.String(), *entry.MaxDownloadBytesPerSecond))
+	}
+	if len(buckets) == 0 {
+		return entryFile
+	}
+	return ratelimit.NewReadSeeker(entryFile, buckets...)
+}
+
+// setDigestHeaders advertises the hash of an entry's data so that clients can
+// verify their download and cache it by content.
+func setDigestHeaders(w http.ResponseWriter, sha256Hex string) {
+	w.Header().Set("ETag", fmt.Sprintf(`"%s"`, sha256Hex))
+	if digest, err := hex.DecodeString(sha256Hex); err == nil {
+		w.Header().Set("Digest", "sha-256="+base64.StdEncoding.EncodeToString(digest))
 	}
 }
 
@@ -71,15 +262,20 @@ func inferContentTypeFromFilename(f picoshare.Filename) (picoshare.ContentType,
 	return picoshare.ContentType(""), errors.New("could not infer content type from filename")
 }
 
-func recordDownload(db Store, id picoshare.EntryID, t time.Time, remoteAddr, userAgent string) error {
+func recordDownload(db Store, entry picoshare.UploadMetadata, t time.Time, remoteAddr, userAgent string) (picoshare.DownloadRecord, error) {
+	download := picoshare.DownloadRecord{
+		Time:      t,
+		ClientIP:  clientIPFromRemoteAddr(remoteAddr),
+		UserAgent: userAgent,
+		Version:   entry.Version,
+	}
+	return download, db.InsertEntryDownload(entry.ID, download)
+}
+
+func clientIPFromRemoteAddr(remoteAddr string) string {
 	ip, _, err := net.SplitHostPort(remoteAddr)
 	if err != nil {
-		ip = remoteAddr
+		return remoteAddr
 	}
-
-	return db.InsertEntryDownload(id, picoshare.DownloadRecord{
-		Time:      t,
-		ClientIP:  ip,
-		UserAgent: userAgent,
-	})
+	return ip
 }
@@ -1,6 +1,7 @@
 package handlers_test
 
 import (
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -120,7 +121,7 @@ func TestEntryGet(t *testing.T) {
 				}
 			}
 
-			s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock())
+			s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock(), handlers.Options{})
 
 			req, err := http.NewRequest("GET", tt.requestRoute, nil)
 			if err != nil {
@@ -150,3 +151,225 @@ func TestEntryGet(t *testing.T) {
 		})
 	}
 }
+
+func TestEntryGetWithDownloadLimits(t *testing.T) {
+	for _, tt := range []struct {
+		description               string
+		maxDownloadBytesPerSecond *uint64
+		overrides                 picoshare.DownloadLimits
+		settings                  picoshare.DownloadLimits
+	}{
+		{
+			description:               "serves complete file with per-entry bandwidth limit",
+			maxDownloadBytesPerSecond: makeUint64Pointer(1024),
+		},
+		{
+			description: "serves complete file with server-wide bandwidth limit",
+			settings: picoshare.DownloadLimits{
+				MaxBytesPerSecond: makeUint64Pointer(1024),
+			},
+		},
+		{
+			description: "releases concurrent download slot after each download",
+			overrides: picoshare.DownloadLimits{
+				MaxConcurrentPerIP: makeIntPointer(1),
+			},
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			dataStore := test_sqlite.New()
+
+			data := "dummy data"
+			if err := dataStore.InsertEntry(strings.NewReader(data), picoshare.UploadMetadata{
+				ID:                        dummyTextEntry.ID,
+				Filename:                  dummyTextEntry.Filename,
+				ContentType:               dummyTextEntry.ContentType,
+				Uploaded:                  mustParseTime("2023-01-01T00:00:00Z"),
+				Expires:                   picoshare.NeverExpire,
+				Size:                      mustParseFileSize(len(data)),
+				MaxDownloadBytesPerSecond: tt.maxDownloadBytesPerSecond,
+			}); err != nil {
+				panic(err)
+			}
+			if err := dataStore.UpdateSettings(picoshare.Settings{
+				DefaultFileLifetime: picoshare.NewFileLifetimeInDays(30),
+				DownloadLimits:      tt.settings,
+			}); err != nil {
+				panic(err)
+			}
+
+			s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock(), handlers.Options{DownloadLimitOverrides: tt.overrides})
+
+			// Download twice to verify that limits don't block later requests.
+			for i := 0; i < 2; i++ {
+				req, err := http.NewRequest("GET", "/-TTTTTTTTTT", nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				req.RemoteAddr = "203.0.113.1:1234"
+
+				rec := httptest.NewRecorder()
+				s.Router().ServeHTTP(rec, req)
+				res := rec.Result()
+
+				if got, want := res.StatusCode, http.StatusOK; got != want {
+					t.Fatalf("download %d returned wrong status code: got %v want %v", i, got, want)
+				}
+
+				body, err := io.ReadAll(res.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got, want := string(body), data; got != want {
+					t.Errorf("body=%s, want=%s", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestEntryGetDigestHeaders(t *testing.T) {
+	dataStore := test_sqlite.New()
+	data := "dummy data"
+	if err := dataStore.InsertEntry(strings.NewReader(data), picoshare.UploadMetadata{
+		ID:          dummyTextEntry.ID,
+		Filename:    dummyTextEntry.Filename,
+		ContentType: dummyTextEntry.ContentType,
+		Uploaded:    mustParseTime("2023-01-01T00:00:00Z"),
+		Expires:     picoshare.NeverExpire,
+		Size:        mustParseFileSize(len(data)),
+	}); err != nil {
+		panic(err)
+	}
+
+	s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock(), handlers.Options{})
+
+	for _, tt := range []struct {
+		description string
+		ifNoneMatch string
+		status      int
+	}{
+		{
+			description: "sends digest headers with file",
+			status:      http.StatusOK,
+		},
+		{
+			description: "returns 304 when client already has the file",
+			ifNoneMatch: `"797bb0abff798d7200af7685dca7901edffc52bf26500d5bd97282658ee24152"`,
+			status:      http.StatusNotModified,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/-TTTTTTTTTT", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+			res := rec.Result()
+
+			if got, want := res.StatusCode, tt.status; got != want {
+				t.Fatalf("status=%d, want=%d", got, want)
+			}
+			if got, want := res.Header.Get("ETag"), `"797bb0abff798d7200af7685dca7901edffc52bf26500d5bd97282658ee24152"`; got != want {
+				t.Errorf("ETag=%s, want=%s", got, want)
+			}
+			if got, want := res.Header.Get("Digest"), "sha-256=eXuwq/95jXIAr3aF3KeQHt/8Ur8mUA1b2XKCZY7iQVI="; got != want {
+				t.Errorf("Digest=%s, want=%s", got, want)
+			}
+		})
+	}
+}
+
+func TestEntrySlugGet(t *testing.T) {
+	dataStore := test_sqlite.New()
+	for _, e := range []struct {
+		id       picoshare.EntryID
+		filename picoshare.Filename
+		slug     picoshare.EntrySlug
+	}{
+		{"AAAAAAAAAA", "q3-report.pdf", "q3-report"},
+		{"BBBBBBBBBB", "q4-report.pdf", ""},
+	} {
+		contents := "contents of " + e.filename.String()
+		if err := dataStore.InsertEntry(strings.NewReader(contents), picoshare.UploadMetadata{
+			ID:          e.id,
+			Filename:    e.filename,
+			ContentType: "application/pdf",
+			Uploaded:    mustParseTime("2024-01-01T00:00:00Z"),
+			Expires:     picoshare.NeverExpire,
+			Size:        mustParseFileSize(len(contents)),
+			Slug:        e.slug,
+		}); err != nil {
+			t.Fatalf("failed to insert dummy entry: %v", err)
+		}
+	}
+
+	s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock(), handlers.Options{})
+
+	for _, tt := range []struct {
+		description      string
+		route            string
+		status           int
+		expectedContents string
+	}{
+		{
+			description:      "serves entry by slug",
+			route:            "/s/q3-report",
+			status:           http.StatusOK,
+			expectedContents: "contents of q3-report.pdf",
+		},
+		{
+			description:      "serves entry by slug regardless of case",
+			route:            "/s/Q3-Report",
+			status:           http.StatusOK,
+			expectedContents: "contents of q3-report.pdf",
+		},
+		{
+			description:      "serves entry by slug with filename",
+			route:            "/s/q3-report/q3-report.pdf",
+			status:           http.StatusOK,
+			expectedContents: "contents of q3-report.pdf",
+		},
+		{
+			description: "returns 404 for slug that belongs to no entry",
+			route:       "/s/q4-report",
+			status:      http.StatusNotFound,
+		},
+		{
+			description: "returns 404 for invalid slug",
+			route:       "/s/q3_report",
+			status:      http.StatusNotFound,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tt.route, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+			res := rec.Result()
+
+			if got, want := res.StatusCode, tt.status; got != want {
+				t.Fatalf("status=%d, want=%d", got, want)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("failed to read response body: %v", err)
+			}
+			if got, want := string(body), tt.expectedContents; got != want {
+				t.Errorf("body=%s, want=%s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mtlynch/picoshare/handlers/parse"
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/store"
+)
+
+// defaultExpiringSoonDays is how many days ahead the expiring files page and
+// API look when the request doesn't specify.
+const defaultExpiringSoonDays = "7"
+
+type (
+	ExpiringEntry struct {
+		ID       string    `json:"id"`
+		Filename string    `json:"filename"`
+		Size     uint64    `json:"size"`
+		Expires  time.Time `json:"expires"`
+	}
+
+	EntryExtendPostResponse struct {
+		Expires time.Time `json:"expires"`
+	}
+)
+
+func (s Server) expiringEntriesGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		window, err := expiringSoonWindowFromRequest(r)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		entries, err := s.getExpiringEntries(r, window)
+		if err != nil {
+			log.Printf("failed to retrieve expiring entries: %v", err)
+			http.Error(w, "Failed to retrieve expiring files", http.StatusInternalServerError)
+			return
+		}
+
+		response := make([]ExpiringEntry, len(entries))
+		for i, entry := range entries {
+			response[i] = ExpiringEntry{
+				ID:       entry.ID.String(),
+				Filename: entry.Filename.String(),
+				Size:     entry.Size.UInt64(),
+				Expires:  entry.Expires.Time().UTC(),
+			}
+		}
+		respondJSON(w, response)
+	}
+}
+
+// expiringSoonWindowFromRequest parses how far ahead to look for expiring
+// entries from the "days" URL parameter.
+func expiringSoonWindowFromRequest(r *http.Request) (time.Duration, error) {
+	daysRaw := r.URL.Query().Get("days")
+	if daysRaw == "" {
+		daysRaw = defaultExpiringSoonDays
+	}
+	return parse.ExpiringSoonWindow(daysRaw)
+}
+
+// getExpiringEntries returns the entries that expire within the window,
+// soonest expiration first.
+func (s Server) getExpiringEntries(r *http.Request, window time.Duration) ([]picoshare.UploadMetadata, error) {
+	now := s.clock.Now()
+	page, err := s.getDB(r).QueryEntries(store.EntryQuery{
+		ExpiresFrom:   new(now),
+		ExpiresBefore: new(now.Add(window)),
+		SortBy:        store.SortByExpires,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return page.Entries, nil
+}
+
+// entryExtendPost pushes back an entry's expiration by the default file
+// lifetime from the settings.
+func (s Server) entryExtendPost() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseEntryID(mux.Vars(r)["id"])
+		if err != nil {
+			log.Printf("error parsing ID: %v", err)
+			http.Error(w, fmt.Sprintf("bad entry ID: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		db := s.getDB(r)
+
+		entry, err := db.GetEntryMetadata(id)
+		if _, ok := errors.AsType[store.EntryNotFoundError](err); ok {
+			http.Error(w, "entry not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("error retrieving entry with id %v: %v", id, err)
+			http.Error(w, "failed to retrieve entry", http.StatusInternalServerError)
+			return
+		}
+
+		if entry.Expires == picoshare.NeverExpire {
+			http.Error(w, "File never expires", http.StatusBadRequest)
+			return
+		}
+
+		settings, err := db.ReadSettings()
+		if err != nil {
+			log.Printf("failed to read settings from database: %v", err)
+			http.Error(w, "Failed to read settings", http.StatusInternalServerError)
+			return
+		}
+
+		// Extend from the current expiration so that extending an entry early
+		// doesn't shorten its lifetime.
+		start := s.clock.Now()
+		if entry.Expires.Time().After(start) {
+			start = entry.Expires.Time()
+		}
+		expiration := settings.DefaultFileLifetime.ExpirationFromTime(start)
+
+		if err := db.UpdateEntriesExpiration([]picoshare.EntryID{id}, expiration); err != nil {
+			log.Printf("failed to extend entry %v: %v", id, err)
+			http.Error(w, "Failed to extend file", http.StatusInternalServerError)
+			return
+		}
+
+		respondJSON(w, EntryExtendPostResponse{
+			Expires: expiration.Time().UTC(),
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mtlynch/picoshare/handlers"
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/store/test_sqlite"
+)
+
+func TestExpiringEntriesGet(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		route       string
+		status      int
+		expected    []string
+	}{
+		{
+			description: "lists entries expiring within a week by default",
+			route:       "/api/entries/expiring",
+			status:      http.StatusOK,
+			expected:    []string{"tomorrow.txt", "in-five-days.txt"},
+		},
+		{
+			description: "lists entries expiring within requested number of days",
+			route:       "/api/entries/expiring?days=30",
+			status:      http.StatusOK,
+			expected:    []string{"tomorrow.txt", "in-five-days.txt", "in-twenty-days.txt"},
+		},
+		{
+			description: "rejects zero days",
+			route:       "/api/entries/expiring?days=0",
+			status:      http.StatusBadRequest,
+		},
+		{
+			description: "rejects non-numeric days",
+			route:       "/api/entries/expiring?days=soon",
+			status:      http.StatusBadRequest,
+		},
+		{
+			description: "rejects window longer than a year",
+			route:       "/api/entries/expiring?days=366",
+			status:      http.StatusBadRequest,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			dataStore := test_sqlite.New()
+			for _, entry := range []struct {
+				id       picoshare.EntryID
+				filename picoshare.Filename
+				expires  picoshare.ExpirationTime
+			}{
+				{"AAAAAAAAAA", "in-twenty-days.txt", mustParseExpirationTime("2024-01-21T00:00:00Z")},
+				{"BBBBBBBBBB", "tomorrow.txt", mustParseExpirationTime("2024-01-02T00:00:00Z")},
+				{"CCCCCCCCCC", "yesterday.txt", mustParseExpirationTime("2023-12-31T00:00:00Z")},
+				{"DDDDDDDDDD", "in-five-days.txt", mustParseExpirationTime("2024-01-06T00:00:00Z")},
+				{"EEEEEEEEEE", "never.txt", picoshare.NeverExpire},
+			} {
+				contents := "dummy data"
+				if err := dataStore.InsertEntry(strings.NewReader(contents), picoshare.UploadMetadata{
+					ID:       entry.id,
+					Filename: entry.filename,
+					Uploaded: mustParseTime("2023-12-01T00:00:00Z"),
+					Expires:  entry.expires,
+					Size:     mustParseFileSize(len(contents)),
+				}); err != nil {
+					t.Fatalf("failed to insert dummy entry: %v", err)
+				}
+			}
+
+			c := mockClock{mustParseTime("2024-01-01T00:00:00Z")}
+			s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, c, handlers.Options{})
+
+			req, err := http.NewRequest("GET", tt.route, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+			res := rec.Result()
+
+			if got, want := res.StatusCode, tt.status; got != want {
+				t.Fatalf("status=%d, want=%d", got, want)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var response []handlers.ExpiringEntry
+			if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+				t.Fatalf("response is not valid JSON: %v", err)
+			}
+			filenames := []string{}
+			for _, entry := range response {
+				filenames = append(filenames, entry.Filename)
+			}
+			if got, want := filenames, tt.expected; !reflect.DeepEqual(got, want) {
+				t.Errorf("filenames=%v, want=%v", got, want)
+			}
+		})
+	}
+}
+
+func TestEntryExtendPost(t *testing.T) {
+	for _, tt := range []struct {
+		description     string
+		route           string
+		expires         picoshare.ExpirationTime
+		status          int
+		expiresExpected picoshare.ExpirationTime
+	}{
+		{
+			description:     "extends entry from its current expiration",
+			route:           "/api/entry/AAAAAAAAAA/extend",
+			expires:         mustParseExpirationTime("2024-01-03T00:00:00Z"),
+			status:          http.StatusOK,
+			expiresExpected: mustParseExpirationTime("2024-02-02T00:00:00Z"),
+		},
+		{
+			description:     "extends expired entry from the current time",
+			route:           "/api/entry/AAAAAAAAAA/extend",
+			expires:         mustParseExpirationTime("2023-12-31T00:00:00Z"),
+			status:          http.StatusOK,
+			expiresExpected: mustParseExpirationTime("2024-01-31T00:00:00Z"),
+		},
+		{
+			description:     "rejects extension of entry that never expires",
+			route:           "/api/entry/AAAAAAAAAA/extend",
+			expires:         picoshare.NeverExpire,
+			status:          http.StatusBadRequest,
+			expiresExpected: picoshare.NeverExpire,
+		},
+		{
+			description:     "rejects extension of entry that doesn't exist",
+			route:           "/api/entry/BBBBBBBBBB/extend",
+			expires:         mustParseExpirationTime("2024-01-03T00:00:00Z"),
+			status:          http.StatusNotFound,
+			expiresExpected: mustParseExpirationTime("2024-01-03T00:00:00Z"),
+		},
+		{
+			description:     "rejects invalid entry ID",
+			route:           "/api/entry/A/extend",
+			expires:         mustParseExpirationTime("2024-01-03T00:00:00Z"),
+			status:          http.StatusBadRequest,
+			expiresExpected: mustParseExpirationTime("2024-01-03T00:00:00Z"),
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			dataStore := test_sqlite.New()
+			if err := dataStore.UpdateSettings(picoshare.Settings{
+				DefaultFileLifetime: picoshare.NewFileLifetimeInDays(30),
+			}); err != nil {
+				t.Fatalf("failed to update settings: %v", err)
+			}
+			contents := "dummy data"
+			if err := dataStore.InsertEntry(strings.NewReader(contents), picoshare.UploadMetadata{
+				ID:       "AAAAAAAAAA",
+				Filename: "report.txt",
+				Uploaded: mustParseTime("2023-12-01T00:00:00Z"),
+				Expires:  tt.expires,
+				Size:     mustParseFileSize(len(contents)),
+			}); err != nil {
+				t.Fatalf("failed to insert dummy entry: %v", err)
+			}
+
+			c := mockClock{mustParseTime("2024-01-01T00:00:00Z")}
+			s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, c, handlers.Options{})
+
+			req, err := http.NewRequest("POST", tt.route, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+			res := rec.Result()
+
+			if got, want := res.StatusCode, tt.status; got != want {
+				t.Fatalf("status=%d, want=%d", got, want)
+			}
+
+			if tt.status == http.StatusOK {
+				var response handlers.EntryExtendPostResponse
+				if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+					t.Fatalf("response is not valid JSON: %v", err)
+				}
+				if got, want := response.Expires, time.Time(tt.expiresExpected); !got.Equal(want) {
+					t.Errorf("response expires=%v, want=%v", got, want)
+				}
+			}
+
+			entry, err := dataStore.GetEntryMetadata("AAAAAAAAAA")
+			if err != nil {
+				t.Fatalf("failed to get entry: %v", err)
+			}
+			if got, want := entry.Expires, tt.expiresExpected; !time.Time(got).Equal(time.Time(want)) {
+				t.Errorf("expires=%v, want=%v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/mtlynch/picoshare/handlers/parse"
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/store"
+)
+
+// fileIndexPageSize is the number of entries on each page of the file index.
+const fileIndexPageSize = 50
+
+type (
+	fileTypeFilter struct {
+		Name              string
+		Label             string
+		ContentTypePrefix string
+	}
+
+	// fileIndexParams are the search, filter, sort, and page parameters of a
+	// request for the file index.
+	fileIndexParams struct {
+		values url.Values
+		Page   int
+	}
+)
+
+var fileTypeFilters = []fileTypeFilter{
+	{Name: "image", Label: "Images", ContentTypePrefix: "image/"},
+	{Name: "video", Label: "Videos", ContentTypePrefix: "video/"},
+	{Name: "audio", Label: "Audio", ContentTypePrefix: "audio/"},
+	{Name: "text", Label: "Text", ContentTypePrefix: "text/"},
+	{Name: "application", Label: "Documents and other files", ContentTypePrefix: "application/"},
+}
+
+var fileIndexSortFields = map[string]store.EntrySortField{
+	"filename": store.SortByFilename,
+	"note":     store.SortByNote,
+	"size":     store.SortBySize,
+	"uploaded": store.SortByUploaded,
+	"expires":  store.SortByExpires,
+}
+
+// entryQueryFromRequest parses the file index's URL parameters into a query
+// for the data store.
+func entryQueryFromRequest(r *http.Request, now time.Time) (store.EntryQuery, fileIndexParams, error) {
+	values := r.URL.Query()
+	params := fileIndexParams{values: values, Page: 1}
+
+	q := store.EntryQuery{
+		Search:      values.Get("q"),
+		GuestLinkID: picoshare.GuestLinkID(values.Get("guestLink")),
+		SortBy:      store.SortByUploaded,
+		Descending:  true,
+		Limit:       fileIndexPageSize,
+	}
+
+	if name := values.Get("type"); name != "" {
+		found := false
+		for _, filter := range fileTypeFilters {
+			if filter.Name == name {
+				q.ContentTypePrefix = filter.ContentTypePrefix
+				found = true
+			}
+		}
+		if !found {
+			return store.EntryQuery{}, fileIndexParams{}, fmt.Errorf("unrecognized file type: %s", name)
+		}
+	}
+
+	var err error
+	if tag := values.Get("tag"); tag != "" {
+		if q.Tag, err = parse.Tag(tag); err != nil {
+			return store.EntryQuery{}, fileIndexParams{}, fmt.Errorf("invalid tag: %w", err)
+		}
+	}
+	if q.Folder, err = parse.Folder(values.Get("folder")); err != nil {
+		return store.EntryQuery{}, fileIndexParams{}, fmt.Errorf("invalid folder: %w", err)
+	}
+
+	if q.MinSize, err = parseMegabytes(values.Get("minSizeMB")); err != nil {
+		return store.EntryQuery{}, fileIndexParams{}, fmt.Errorf("invalid minimum size: %w", err)
+	}
+	if q.MaxSize, err = parseMegabytes(values.Get("maxSizeMB")); err != nil {
+		return store.EntryQuery{}, fileIndexParams{}, fmt.Errorf("invalid maximum size: %w", err)
+	}
+
+	if preset := values.Get("expires"); preset != "" {
+		expiration, err := parse.ExpirationFromPreset(preset, now)
+		if err != nil {
+			return store.EntryQuery{}, fileIndexParams{}, err
+		}
+		if expiration == picoshare.NeverExpire {
+			q.ExpiresFrom = new(expiration.Time())
+		} else {
+			q.ExpiresFrom = new(now)
+			q.ExpiresBefore = new(expiration.Time())
+		}
+	}
+
+	if sortRaw := values.Get("sort"); sortRaw != "" {
+		sortBy, ok := fileIndexSortFields[sortRaw]
+		if !ok {
+			return store.EntryQuery{}, fileIndexParams{}, fmt.Errorf("unrecognized sort field: %s", sortRaw)
+		}
+		q.SortBy = sortBy
+	}
+	switch values.Get("order") {
+	case "":
+	case "asc":
+		q.Descending = false
+	case "desc":
+		q.Descending = true
+	default:
+		return store.EntryQuery{}, fileIndexParams{}, errors.New("order must be asc or desc")
+	}
+
+	if pageRaw := values.Get("page"); pageRaw != "" {
+		page, err := strconv.Atoi(pageRaw)
+		if err != nil || page < 1 {
+			return store.EntryQuery{}, fileIndexParams{}, errors.New("page must be a positive integer")
+		}
+		params.Page = page
+	}
+	q.Offset = (params.Page - 1) * fileIndexPageSize
+
+	return q, params, nil
+}
+
+func parseMegabytes(raw string) (*uint64, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	mb, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return nil, err
+	}
+	if mb < 0 {
+		return nil, errors.New("must be non-negative")
+	}
+	return new(uint64(mb * 1024 * 1024)), nil
+}
+
+// Get returns the value of a URL parameter, so that the file index can
+// pre-fill its search form.
+func (p fileIndexParams) Get(key string) string {
+	return p.values.Get(key)
+}
+
+// SortURL returns the link that sorts the file index by the given field. If the
+// index is already sorted by that field, the link reverses the order.
+func (p fileIndexParams) SortURL(field string) string {
+	values := p.copyValues()
+	order := "asc"
+	if p.currentSort() == field && p.currentOrder() == "asc" {
+		order = "desc"
+	}
+	values.Set("sort", field)
+	values.Set("order", order)
+	values.Del("page")
+	return "/files?" + values.Encode()
+}
+
+// SortIcon returns the Font Awesome icon that indicates whether the file index
+// is sorted by the given field.
+func (p fileIndexParams) SortIcon(field string) string {
+	if p.currentSort() != field {
+		return "fa-sort"
+	}
+	if p.currentOrder() == "asc" {
+		return "fa-sort-up"
+	}
+	return "fa-sort-down"
+}
+
+// PageURL returns the link to the given page of the current results.
+func (p fileIndexParams) PageURL(page int) string {
+	values := p.copyValues()
+	values.Set("page", strconv.Itoa(page))
+	return "/files?" + values.Encode()
+}
+
+func (p fileIndexParams) currentSort() string {
+	if sort := p.values.Get("sort"); sort != "" {
+		return sort
+	}
+	return "uploaded"
+}
+
+func (p fileIndexParams) currentOrder() string {
+	if order := p.values.Get("order"); order != "" {
+		return order
+	}
+	return "desc"
+}
+
+func (p fileIndexParams) copyValues() url.Values {
+	values := url.Values{}
+	for key := range p.values {
+		// Omit parameters that the search form left blank.
+		if v := p.values.Get(key); v != "" {
+			values.Set(key, v)
+		}
+	}
+	return values
+}
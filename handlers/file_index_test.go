@@ -0,0 +1,141 @@
+package handlers_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mtlynch/picoshare/handlers"
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/store/test_sqlite"
+)
+
+func TestFileIndexGet(t *testing.T) {
+	dataStore := test_sqlite.New()
+	for i := range 55 {
+		contents := fmt.Sprintf("contents of file %d", i)
+		filename := fmt.Sprintf("file-%02d.txt", i)
+		contentType := picoshare.ContentType("text/plain")
+		if i == 0 {
+			filename = "holiday-photo.jpg"
+			contentType = "image/jpeg"
+		}
+		if err := dataStore.InsertEntry(strings.NewReader(contents), picoshare.UploadMetadata{
+			ID:          picoshare.EntryID(fmt.Sprintf("AAAAAAAA%02d", i)),
+			Filename:    picoshare.Filename(filename),
+			ContentType: contentType,
+			Uploaded:    mustParseTime(fmt.Sprintf("2024-01-01T00:%02d:00Z", i)),
+			Expires:     picoshare.NeverExpire,
+			Size:        mustParseFileSize(len(contents)),
+		}); err != nil {
+			panic(err)
+		}
+	}
+
+	s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock(), handlers.Options{})
+
+	for _, tt := range []struct {
+		description string
+		query       string
+		status      int
+		contains    []string
+		omits       []string
+	}{
+		{
+			description: "shows first page of newest files",
+			query:       "",
+			status:      http.StatusOK,
+			contains:    []string{"file-54.txt", "file-05.txt", "of 55", "Page 1 of 2"},
+			omits:       []string{"file-04.txt"},
+		},
+		{
+			description: "shows second page",
+			query:       "page=2",
+			status:      http.StatusOK,
+			contains:    []string{"file-04.txt", "holiday-photo.jpg"},
+			omits:       []string{"file-05.txt"},
+		},
+		{
+			description: "searches by filename",
+			query:       "q=holiday",
+			status:      http.StatusOK,
+			contains:    []string{"holiday-photo.jpg"},
+			omits:       []string{"file-54.txt"},
+		},
+		{
+			description: "filters by file type",
+			query:       "type=image",
+			status:      http.StatusOK,
+			contains:    []string{"holiday-photo.jpg"},
+			omits:       []string{"file-54.txt"},
+		},
+		{
+			description: "sorts by filename",
+			query:       "sort=filename&order=asc",
+			status:      http.StatusOK,
+			contains:    []string{"file-01.txt"},
+			omits:       []string{"holiday-photo.jpg", "file-54.txt"},
+		},
+		{
+			description: "rejects unrecognized sort field",
+			query:       "sort=color",
+			status:      http.StatusBadRequest,
+		},
+		{
+			description: "rejects unrecognized file type",
+			query:       "type=spreadsheet",
+			status:      http.StatusBadRequest,
+		},
+		{
+			description: "rejects negative size",
+			query:       "minSizeMB=-1",
+			status:      http.StatusBadRequest,
+		},
+		{
+			description: "rejects invalid folder",
+			query:       "folder=a/../b",
+			status:      http.StatusBadRequest,
+		},
+		{
+			description: "rejects invalid page",
+			query:       "page=0",
+			status:      http.StatusBadRequest,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/files?"+tt.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+			res := rec.Result()
+
+			if got, want := res.StatusCode, tt.status; got != want {
+				t.Fatalf("status=%d, want=%d", got, want)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("failed to read response body: %v", err)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(string(body), want) {
+					t.Errorf("body doesn't contain %q", want)
+				}
+			}
+			for _, unwanted := range tt.omits {
+				if strings.Contains(string(body), unwanted) {
+					t.Errorf("body contains %q, want it omitted", unwanted)
+				}
+			}
+		})
+	}
+}
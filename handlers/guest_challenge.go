@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/mtlynch/picoshare/store"
+)
+
+const (
+	// guestChallengeDifficulty is the number of leading zero bits a challenge
+	// solution must produce. Each additional bit doubles the expected work, so
+	// 16 bits takes a typical browser around a second.
+	guestChallengeDifficulty = 16
+	guestChallengeLifetime   = 10 * time.Minute
+
+	guestChallengeTokenHeader    = "PicoShare-Challenge-Token"
+	guestChallengeSolutionHeader = "PicoShare-Challenge-Solution"
+)
+
+type GuestChallengeResponse struct {
+	Token      string `json:"token"`
+	Difficulty int    `json:"difficulty"`
+}
+
+func (s Server) guestChallengeGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		guestLinkID, err := parseGuestLinkID(mux.Vars(r)["guestLinkID"])
+		if err != nil {
+			log.Printf("error parsing guest link ID: %v", err)
+			http.Error(w, fmt.Sprintf("Invalid guest link ID: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		if _, err := s.getDB(r).GetGuestLink(guestLinkID); err != nil {
+			if _, ok := errors.AsType[store.GuestLinkNotFoundError](err); ok {
+				http.Error(w, "Invalid guest link ID", http.StatusNotFound)
+				return
+			}
+			log.Printf("error retrieving guest link with ID %v: %v", guestLinkID, err)
+			http.Error(w, "Failed to retrieve guest link", http.StatusInternalServerError)
+			return
+		}
+
+		c := s.guestChallenges.Issue(guestLinkID.String(), s.clock.Now())
+
+		w.Header().Set("Cache-Control", "no-store")
+		respondJSON(w, GuestChallengeResponse{
+			Token:      c.Token,
+			Difficulty: c.Difficulty,
+		})
+	}
+}
@@ -21,6 +21,17 @@ const (
 
 type GuestLinkPostResponse struct {
 	ID string `json:"id"`
+	// Emailed is true if the mail server accepted the email that invites the
+	// recipients to upload. If the request had recipients and Emailed is false,
+	// PicoShare retries sending the email in the background.
+	Emailed bool `json:"emailed"`
+}
+
+// guestLinkInvitation is an optional email that invites guests to upload
+// through a new guest link.
+type guestLinkInvitation struct {
+	recipients []string
+	message    string
 }
 
 // Omit visually similar characters (I,l,1), (0,O)
@@ -28,12 +39,22 @@ var guestLinkIDCharacters = []rune("abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTU
 
 func (s Server) guestLinksPost() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		gl, err := s.guestLinkFromRequest(r)
+		gl, invitation, err := s.guestLinkFromRequest(r)
 		if err != nil {
 			http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
 			return
 		}
 
+		if len(invitation.recipients) > 0 && s.mailer == nil {
+			http.Error(w, "PicoShare can't send email because no SMTP server is configured", http.StatusServiceUnavailable)
+			return
+		}
+
+		if gl.NotifyOnUpload && s.notifier == nil {
+			http.Error(w, "Invalid request: PicoShare can't notify you of uploads because no notifiers are configured", http.StatusBadRequest)
+			return
+		}
+
 		gl.ID = generateGuestLinkID()
 		gl.Created = s.clock.Now()
 
@@ -43,7 +64,17 @@ func (s Server) guestLinksPost() http.HandlerFunc {
 			return
 		}
 
-		respondJSON(w, GuestLinkPostResponse{ID: gl.ID.String()})
+		var emailed bool
+		if len(invitation.recipients) > 0 {
+			emailed, err = s.mailer.SendGuestLink(gl, fmt.Sprintf("%s/g/%s", baseURLFromRequest(r), gl.ID), invitation.message, invitation.recipients)
+			if err != nil {
+				log.Printf("failed to email guest link %v: %v", gl.ID, err)
+				http.Error(w, "Created guest link but failed to send email", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		respondJSON(w, GuestLinkPostResponse{ID: gl.ID.String(), Emailed: emailed})
 	}
 }
 
@@ -97,52 +128,96 @@ func (s *Server) guestLinksEnableDisable() http.HandlerFunc {
 	}
 }
 
-func (s Server) guestLinkFromRequest(r *http.Request) (picoshare.GuestLink, error) {
+func (s Server) guestLinkFromRequest(r *http.Request) (picoshare.GuestLink, guestLinkInvitation, error) {
 	var payload struct {
 		Label          string  `json:"label"`
 		UrlExpiration  string  `json:"urlExpirationTime"`
 		FileExpiration string  `json:"fileLifetime"`
 		MaxFileBytes   *uint64 `json:"maxFileBytes"`
 		MaxFileUploads *int    `json:"maxFileUploads"`
+
+		MaxUploadsPerIPPerHour  *int    `json:"maxUploadsPerIpPerHour"`
+		MaxBytesPerIPPerDay     *uint64 `json:"maxBytesPerIpPerDay"`
+		MaxUploadBytesPerSecond *uint64 `json:"maxUploadBytesPerSecond"`
+
+		RequireChallenge bool `json:"requireChallenge"`
+
+		AutoTag string `json:"autoTag"`
+
+		NotifyOnUpload bool `json:"notifyOnUpload"`
+
+		Recipients []string `json:"recipients"`
+		Message    string   `json:"message"`
 	}
 	err := json.NewDecoder(r.Body).Decode(&payload)
 	if err != nil {
 		log.Printf("failed to decode JSON request: %v", err)
-		return picoshare.GuestLink{}, err
+		return picoshare.GuestLink{}, guestLinkInvitation{}, err
 	}
 
 	label, err := parse.GuestLinkLabel(payload.Label)
 	if err != nil {
-		return picoshare.GuestLink{}, err
+		return picoshare.GuestLink{}, guestLinkInvitation{}, err
 	}
 
 	urlExpiration, err := parse.Expiration(payload.UrlExpiration, s.clock.Now())
 	if err != nil {
-		return picoshare.GuestLink{}, err
+		return picoshare.GuestLink{}, guestLinkInvitation{}, err
 	}
 
 	fileExpiration, err := parse.FileLifetimeFromString(payload.FileExpiration)
 	if err != nil {
-		return picoshare.GuestLink{}, err
+		return picoshare.GuestLink{}, guestLinkInvitation{}, err
 	}
 
 	maxFileBytes, err := parseMaxFileBytes(payload.MaxFileBytes)
 	if err != nil {
-		return picoshare.GuestLink{}, err
+		return picoshare.GuestLink{}, guestLinkInvitation{}, err
 	}
 
 	maxFileUploads, err := parseUploadCountLimit(payload.MaxFileUploads)
 	if err != nil {
-		return picoshare.GuestLink{}, err
+		return picoshare.GuestLink{}, guestLinkInvitation{}, err
+	}
+
+	rateLimit, err := parse.GuestUploadRateLimit(payload.MaxUploadsPerIPPerHour, payload.MaxBytesPerIPPerDay, payload.MaxUploadBytesPerSecond)
+	if err != nil {
+		return picoshare.GuestLink{}, guestLinkInvitation{}, err
+	}
+
+	var autoTag picoshare.Tag
+	if payload.AutoTag != "" {
+		autoTag, err = parse.Tag(payload.AutoTag)
+		if err != nil {
+			return picoshare.GuestLink{}, guestLinkInvitation{}, err
+		}
+	}
+
+	var invitation guestLinkInvitation
+	if len(payload.Recipients) > 0 {
+		invitation.recipients, err = parse.EmailRecipients(payload.Recipients)
+		if err != nil {
+			return picoshare.GuestLink{}, guestLinkInvitation{}, err
+		}
+		invitation.message, err = parse.EmailMessage(payload.Message)
+		if err != nil {
+			return picoshare.GuestLink{}, guestLinkInvitation{}, err
+		}
+	} else if payload.Message != "" {
+		return picoshare.GuestLink{}, guestLinkInvitation{}, errors.New("message requires at least one recipient")
 	}
 
 	return picoshare.GuestLink{
-		Label:           label,
-		UrlExpires:      urlExpiration,
-		MaxFileLifetime: fileExpiration,
-		MaxFileBytes:    maxFileBytes,
-		MaxFileUploads:  maxFileUploads,
-	}, nil
+		Label:            label,
+		UrlExpires:       urlExpiration,
+		MaxFileLifetime:  fileExpiration,
+		MaxFileBytes:     maxFileBytes,
+		MaxFileUploads:   maxFileUploads,
+		RateLimit:        rateLimit,
+		RequireChallenge: payload.RequireChallenge,
+		AutoTag:          autoTag,
+		NotifyOnUpload:   payload.NotifyOnUpload,
+	}, invitation, nil
 }
 
 func parseMaxFileBytes(limitRaw *uint64) (picoshare.GuestUploadMaxFileBytes, error) {
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/mtlynch/picoshare/handlers"
+	"github.com/mtlynch/picoshare/notify"
 	"github.com/mtlynch/picoshare/picoshare"
 	"github.com/mtlynch/picoshare/store"
 	"github.com/mtlynch/picoshare/store/test_sqlite"
@@ -65,6 +66,99 @@ func TestGuestLinksPost(t *testing.T) {
 			},
 			status: http.StatusOK,
 		},
+		{
+			description: "request with a tag for uploads",
+			payload: `{
+					"label": null,
+					"urlExpirationTime":"2030-01-02T03:04:25Z",
+					"fileLifetime":"876000h0m0s",
+					"maxFileBytes": null,
+					"maxFileUploads": null,
+					"autoTag": " ACME "
+				}`,
+			currentTime: mustParseTime("2024-01-01T00:00:00Z"),
+			expected: picoshare.GuestLink{
+				Created:         mustParseTime("2024-01-01T00:00:00Z"),
+				UrlExpires:      mustParseExpirationTime("2030-01-02T03:04:25Z"),
+				MaxFileLifetime: picoshare.FileLifetimeInfinite,
+				MaxFileBytes:    picoshare.GuestUploadUnlimitedFileSize,
+				MaxFileUploads:  picoshare.GuestUploadUnlimitedFileUploads,
+				AutoTag:         picoshare.Tag("acme"),
+			},
+			status: http.StatusOK,
+		},
+		{
+			description: "invalid tag for uploads",
+			payload: `{
+					"label": null,
+					"urlExpirationTime":"2030-01-02T03:04:25Z",
+					"fileLifetime":"876000h0m0s",
+					"maxFileBytes": null,
+					"maxFileUploads": null,
+					"autoTag": "one, two"
+				}`,
+			currentTime: mustParseTime("2024-01-01T00:00:00Z"),
+			status:      http.StatusBadRequest,
+		},
+		{
+			description: "request with per-IP rate limits",
+			payload: `{
+					"label": null,
+					"urlExpirationTime":"2030-01-02T03:04:25Z",
+					"fileLifetime":"876000h0m0s",
+					"maxFileBytes": null,
+					"maxFileUploads": null,
+					"maxUploadsPerIpPerHour": 5,
+					"maxBytesPerIpPerDay": 10485760,
+					"maxUploadBytesPerSecond": 65536
+				}`,
+			currentTime: mustParseTime("2024-01-01T00:00:00Z"),
+			expected: picoshare.GuestLink{
+				Created:         mustParseTime("2024-01-01T00:00:00Z"),
+				Label:           picoshare.GuestLinkLabel(""),
+				UrlExpires:      mustParseExpirationTime("2030-01-02T03:04:25Z"),
+				MaxFileLifetime: picoshare.FileLifetimeInfinite,
+				MaxFileBytes:    picoshare.GuestUploadUnlimitedFileSize,
+				MaxFileUploads:  picoshare.GuestUploadUnlimitedFileUploads,
+				RateLimit: picoshare.GuestUploadRateLimit{
+					MaxUploadsPerIPPerHour: makeIntPointer(5),
+					MaxBytesPerIPPerDay:    makeUint64Pointer(10485760),
+					MaxBytesPerSecond:      makeUint64Pointer(65536),
+				},
+			},
+			status: http.StatusOK,
+		},
+		{
+			description: "request that requires a challenge",
+			payload: `{
+					"label": null,
+					"urlExpirationTime":"2030-01-02T03:04:25Z",
+					"fileLifetime":"876000h0m0s",
+					"requireChallenge": true
+				}`,
+			currentTime: mustParseTime("2024-01-01T00:00:00Z"),
+			expected: picoshare.GuestLink{
+				Created:          mustParseTime("2024-01-01T00:00:00Z"),
+				Label:            picoshare.GuestLinkLabel(""),
+				UrlExpires:       mustParseExpirationTime("2030-01-02T03:04:25Z"),
+				MaxFileLifetime:  picoshare.FileLifetimeInfinite,
+				MaxFileBytes:     picoshare.GuestUploadUnlimitedFileSize,
+				MaxFileUploads:   picoshare.GuestUploadUnlimitedFileUploads,
+				RequireChallenge: true,
+			},
+			status: http.StatusOK,
+		},
+		{
+			description: "reject request with zero uploads per hour",
+			payload: `{
+					"label": null,
+					"urlExpirationTime":"2030-01-02T03:04:25Z",
+					"fileLifetime":"876000h0m0s",
+					"maxUploadsPerIpPerHour": 0
+				}`,
+			currentTime: mustParseTime("2024-01-01T00:00:00Z"),
+			status:      http.StatusBadRequest,
+		},
 		{
 			description: "guest file expires in 1 day",
 			payload: `{
@@ -228,7 +322,7 @@ func TestGuestLinksPost(t *testing.T) {
 		t.Run(tt.description, func(t *testing.T) {
 			dataStore := test_sqlite.New()
 			c := mockClock{tt.currentTime}
-			s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, c)
+			s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, c, handlers.Options{})
 
 			req, err := http.NewRequest("POST", "/api/guest-links", strings.NewReader(tt.payload))
 			if err != nil {
@@ -274,6 +368,170 @@ func TestGuestLinksPost(t *testing.T) {
 	}
 }
 
+func TestGuestLinksPostInvitation(t *testing.T) {
+	for _, tt := range []struct {
+		description    string
+		payload        string
+		noMailer       bool
+		noNotifier     bool
+		mailServerDown bool
+		status         int
+		wantEmailed    bool
+		wantTo         []string
+		wantMessage    string
+		wantNotify     bool
+	}{
+		{
+			description: "emails guest link to recipients",
+			payload: `{
+					"urlExpirationTime":"2030-01-02T03:04:25Z",
+					"fileLifetime":"876000h0m0s",
+					"recipients": ["alice@example.com", "Bob <bob@example.com>"],
+					"message": "Please send me your tax forms."
+				}`,
+			status:      http.StatusOK,
+			wantEmailed: true,
+			wantTo:      []string{"alice@example.com", "bob@example.com"},
+			wantMessage: "Please send me your tax forms.",
+		},
+		{
+			description: "reports when the mail server hasn't accepted the email yet",
+			payload: `{
+					"urlExpirationTime":"2030-01-02T03:04:25Z",
+					"fileLifetime":"876000h0m0s",
+					"recipients": ["alice@example.com"]
+				}`,
+			mailServerDown: true,
+			status:         http.StatusOK,
+			wantEmailed:    false,
+			wantTo:         []string{"alice@example.com"},
+		},
+		{
+			description: "stores request to notify owner of uploads",
+			payload: `{
+					"urlExpirationTime":"2030-01-02T03:04:25Z",
+					"fileLifetime":"876000h0m0s",
+					"notifyOnUpload": true
+				}`,
+			status:     http.StatusOK,
+			wantNotify: true,
+		},
+		{
+			description: "rejects recipients when no SMTP server is configured",
+			payload: `{
+					"urlExpirationTime":"2030-01-02T03:04:25Z",
+					"fileLifetime":"876000h0m0s",
+					"recipients": ["alice@example.com"]
+				}`,
+			noMailer: true,
+			status:   http.StatusServiceUnavailable,
+		},
+		{
+			description: "rejects upload notifications when no notifiers are configured",
+			payload: `{
+					"urlExpirationTime":"2030-01-02T03:04:25Z",
+					"fileLifetime":"876000h0m0s",
+					"notifyOnUpload": true
+				}`,
+			noNotifier: true,
+			status:     http.StatusBadRequest,
+		},
+		{
+			description: "rejects invalid recipient",
+			payload: `{
+					"urlExpirationTime":"2030-01-02T03:04:25Z",
+					"fileLifetime":"876000h0m0s",
+					"recipients": ["not an email"]
+				}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			description: "rejects message without recipients",
+			payload: `{
+					"urlExpirationTime":"2030-01-02T03:04:25Z",
+					"fileLifetime":"876000h0m0s",
+					"message": "Please send me your tax forms."
+				}`,
+			status: http.StatusBadRequest,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			dataStore := test_sqlite.New()
+
+			mailer := &mockMailer{sent: !tt.mailServerDown}
+			var m handlers.Mailer = mailer
+			if tt.noMailer {
+				m = nil
+			}
+			var n notify.Notifier = newMockNotifier()
+			if tt.noNotifier {
+				n = nil
+			}
+
+			c := mockClock{mustParseTime("2024-01-01T00:00:00Z")}
+			s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, c, handlers.Options{Notifier: n, Mailer: m})
+
+			req, err := http.NewRequest("POST", "/api/guest-links", strings.NewReader(tt.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Host = "example.com"
+			req.Header.Add("Content-Type", "text/json")
+
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+
+			if got, want := rec.Code, tt.status; got != want {
+				t.Fatalf("status=%d, want=%d", got, want)
+			}
+
+			if tt.status != http.StatusOK {
+				if got, want := mailer.sendings, 0; got != want {
+					t.Errorf("sent %d emails, want %d", got, want)
+				}
+				links, err := dataStore.GetGuestLinks()
+				if err != nil {
+					t.Fatalf("failed to retrieve guest links: %v", err)
+				}
+				if got, want := len(links), 0; got != want {
+					t.Errorf("stored %d guest links, want %d", got, want)
+				}
+				return
+			}
+
+			var response handlers.GuestLinkPostResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("response is not valid JSON: %v", rec.Body.String())
+			}
+			if got, want := response.Emailed, tt.wantEmailed; got != want {
+				t.Errorf("emailed=%v, want=%v", got, want)
+			}
+
+			if len(tt.wantTo) > 0 {
+				if got, want := mailer.url, "http://example.com/g/"+response.ID; got != want {
+					t.Errorf("url=%v, want=%v", got, want)
+				}
+				if got, want := mailer.to, tt.wantTo; !reflect.DeepEqual(got, want) {
+					t.Errorf("recipients=%v, want=%v", got, want)
+				}
+				if got, want := mailer.message, tt.wantMessage; got != want {
+					t.Errorf("message=%q, want=%q", got, want)
+				}
+			} else if got, want := mailer.sendings, 0; got != want {
+				t.Errorf("sent %d emails, want %d", got, want)
+			}
+
+			gl, err := dataStore.GetGuestLink(picoshare.GuestLinkID(response.ID))
+			if err != nil {
+				t.Fatalf("failed to retrieve guest link from datastore: %v", err)
+			}
+			if got, want := gl.NotifyOnUpload, tt.wantNotify; got != want {
+				t.Errorf("notifyOnUpload=%v, want=%v", got, want)
+			}
+		})
+	}
+}
+
 func makeGuestUploadMaxFileBytes(i uint64) picoshare.GuestUploadMaxFileBytes {
 	return picoshare.GuestUploadMaxFileBytes(&i)
 }
@@ -289,7 +547,7 @@ func TestDeleteExistingGuestLink(t *testing.T) {
 		Created:    mustParseTime("2025-05-25T00:00:00Z"),
 		UrlExpires: mustParseExpirationTime("2030-01-02T03:04:25Z"),
 	})
-	s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock())
+	s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock(), handlers.Options{})
 
 	req, err := http.NewRequest("DELETE", "/api/guest-links/abcdefgh23456789", nil)
 	if err != nil {
@@ -312,7 +570,7 @@ func TestDeleteExistingGuestLink(t *testing.T) {
 
 func TestDeleteNonExistentGuestLink(t *testing.T) {
 	dataStore := test_sqlite.New()
-	s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock())
+	s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock(), handlers.Options{})
 
 	req, err := http.NewRequest("DELETE", "/api/guest-links/abcdefgh23456789", nil)
 	if err != nil {
@@ -331,7 +589,7 @@ func TestDeleteNonExistentGuestLink(t *testing.T) {
 
 func TestDeleteInvalidGuestLink(t *testing.T) {
 	dataStore := test_sqlite.New()
-	s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock())
+	s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock(), handlers.Options{})
 
 	req, err := http.NewRequest("DELETE", "/api/guest-links/i-am-an-invalid-link", nil)
 	if err != nil {
@@ -476,7 +734,7 @@ func TestEnableDisableGuestLink(t *testing.T) {
 				}
 			}
 
-			s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock())
+			s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock(), handlers.Options{})
 
 			req, err := http.NewRequest("PUT", tt.requestRoute, nil)
 			if err != nil {
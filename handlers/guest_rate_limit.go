@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/ratelimit"
+)
+
+// guestUploadRateLimitRetention is how long we remember guest uploads for the
+// purposes of rate limiting. It must be at least as long as the longest
+// rate limiting period we enforce.
+const guestUploadRateLimitRetention = 24 * time.Hour
+
+type (
+	countingReadCloser struct {
+		io.ReadCloser
+		bytesRead uint64
+	}
+
+	throttledReadCloser struct {
+		io.Reader
+		io.Closer
+	}
+)
+
+func (c *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.bytesRead += uint64(n)
+	return n, err
+}
+
+// throttleReadCloser limits reads from rc to bytesPerSecond.
+func throttleReadCloser(rc io.ReadCloser, bytesPerSecond uint64) io.ReadCloser {
+	return throttledReadCloser{
+		Reader: ratelimit.NewReader(rc, ratelimit.NewBucket(bytesPerSecond)),
+		Closer: rc,
+	}
+}
+
+// guestUploadKey identifies a single client uploading through a single guest
+// link.
+func guestUploadKey(id picoshare.GuestLinkID, clientIP string) string {
+	return id.String() + "/" + clientIP
+}
+
+// reserveGuestUpload verifies that the client identified by key is within the
+// given rate limits and, if so, reserves an upload for it. Reserving the upload
+// before reading the request body keeps concurrent uploads from the same client
+// from exceeding the limits together. It returns the reservation and the
+// maximum number of bytes the upload may contain, or nil if the upload's size
+// is unlimited.
+func (s Server) reserveGuestUpload(key string, rl picoshare.GuestUploadRateLimit, maxFileBytes *uint64) (*ratelimit.Reservation, *uint64, error) {
+	now := s.clock.Now()
+
+	var maxBodyBytes *uint64
+	reservation, err := s.guestUploads.TryRecord(key, now, func(count ratelimit.CountFunc) (uint64, error) {
+		if rl.MaxUploadsPerIPPerHour != nil {
+			uploads, _ := count(now.Add(-1 * time.Hour))
+			if uploads >= *rl.MaxUploadsPerIPPerHour {
+				return 0, fmt.Errorf("upload limit reached: at most %d uploads per hour", *rl.MaxUploadsPerIPPerHour)
+			}
+		}
+
+		maxBodyBytes = maxFileBytes
+		if rl.MaxBytesPerIPPerDay == nil {
+			return 0, nil
+		}
+
+		_, bytesUploaded := count(now.Add(-24 * time.Hour))
+		if bytesUploaded >= *rl.MaxBytesPerIPPerDay {
+			return 0, fmt.Errorf("upload limit reached: at most %d bytes per day", *rl.MaxBytesPerIPPerDay)
+		}
+		remaining := *rl.MaxBytesPerIPPerDay - bytesUploaded
+		if maxBodyBytes == nil || remaining < *maxBodyBytes {
+			maxBodyBytes = &remaining
+		}
+
+		// Hold the client's remaining daily bytes until the upload finishes so
+		// that a concurrent upload can't spend them too.
+		return *maxBodyBytes, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return reservation, maxBodyBytes, nil
+}
@@ -0,0 +1,115 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mtlynch/picoshare/handlers"
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/store/test_sqlite"
+)
+
+func TestEntryPostIdleExpiration(t *testing.T) {
+	for _, tt := range []struct {
+		description          string
+		defaultIdleLifetime  picoshare.FileLifetime
+		idleExpirationDays   string
+		status               int
+		idleLifetimeExpected picoshare.FileLifetime
+	}{
+		{
+			description:          "doesn't set idle expiration by default",
+			status:               http.StatusOK,
+			idleLifetimeExpected: picoshare.FileLifetime{},
+		},
+		{
+			description:          "uses default idle expiration from settings",
+			defaultIdleLifetime:  picoshare.NewFileLifetimeInDays(30),
+			status:               http.StatusOK,
+			idleLifetimeExpected: picoshare.NewFileLifetimeInDays(30),
+		},
+		{
+			description:          "requested idle expiration overrides default",
+			defaultIdleLifetime:  picoshare.NewFileLifetimeInDays(30),
+			idleExpirationDays:   "7",
+			status:               http.StatusOK,
+			idleLifetimeExpected: picoshare.NewFileLifetimeInDays(7),
+		},
+		{
+			description:          "zero days turns off default idle expiration",
+			defaultIdleLifetime:  picoshare.NewFileLifetimeInDays(30),
+			idleExpirationDays:   "0",
+			status:               http.StatusOK,
+			idleLifetimeExpected: picoshare.FileLifetime{},
+		},
+		{
+			description:        "rejects invalid idle expiration",
+			idleExpirationDays: "soon",
+			status:             http.StatusBadRequest,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			dataStore := test_sqlite.New()
+			if err := dataStore.UpdateSettings(picoshare.Settings{
+				DefaultFileLifetime: picoshare.NewFileLifetimeInDays(30),
+				DefaultIdleLifetime: tt.defaultIdleLifetime,
+			}); err != nil {
+				t.Fatalf("failed to update settings: %v", err)
+			}
+
+			s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock(), handlers.Options{})
+
+			var b bytes.Buffer
+			mw := multipart.NewWriter(&b)
+			f, err := mw.CreateFormFile("file", "report.pdf")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := f.Write([]byte("dummy bytes")); err != nil {
+				t.Fatal(err)
+			}
+			if tt.idleExpirationDays != "" {
+				if err := mw.WriteField("idleExpirationDays", tt.idleExpirationDays); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := mw.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			req, err := http.NewRequest("POST", "/api/entry?expiration=2040-01-01T00:00:00Z", &b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Add("Content-Type", mw.FormDataContentType())
+			req.Header.Add("Accept", "application/json")
+
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+			res := rec.Result()
+
+			if got, want := res.StatusCode, tt.status; got != want {
+				t.Fatalf("status=%d, want=%d", got, want)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var response handlers.EntryPostResponse
+			if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+				t.Fatalf("response is not valid JSON: %v", err)
+			}
+			entry, err := dataStore.GetEntryMetadata(picoshare.EntryID(response.ID))
+			if err != nil {
+				t.Fatalf("failed to get entry: %v", err)
+			}
+			if got, want := entry.IdleLifetime, tt.idleLifetimeExpected; !got.Equal(want) {
+				t.Errorf("idleLifetime=%v, want=%v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/mtlynch/picoshare/handlers/parse"
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/store"
+)
+
+type LinkEmailPostResponse struct {
+	// Sent is false if the mail server didn't accept the email yet. PicoShare
+	// retries sending it in the background.
+	Sent bool `json:"sent"`
+}
+
+func (s Server) linkEmailPost() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.mailer == nil {
+			http.Error(w, "PicoShare can't send email because no SMTP server is configured", http.StatusServiceUnavailable)
+			return
+		}
+
+		id, err := parseEntryID(mux.Vars(r)["id"])
+		if err != nil {
+			log.Printf("error parsing ID: %v", err)
+			http.Error(w, fmt.Sprintf("bad entry ID: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		var payload struct {
+			Recipients []string `json:"recipients"`
+			Message    string   `json:"message"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			log.Printf("failed to decode JSON request: %v", err)
+			http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		recipients, err := parse.EmailRecipients(payload.Recipients)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid recipients: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		message, err := parse.EmailMessage(payload.Message)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid message: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		entry, err := s.getDB(r).GetEntryMetadata(id)
+		if _, ok := errors.AsType[store.EntryNotFoundError](err); ok {
+			http.Error(w, "entry not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("error retrieving entry with id %v: %v", id, err)
+			http.Error(w, "failed to retrieve entry", http.StatusInternalServerError)
+			return
+		}
+
+		// Recipients can't open a link to a private entry.
+		if entry.Visibility == picoshare.VisibilityPrivate {
+			http.Error(w, "Private files can't be shared by email. Change the file's visibility first.", http.StatusBadRequest)
+			return
+		}
+
+		url := fmt.Sprintf("%s/-%s", baseURLFromRequest(r), id)
+		// Recipients can only open a signed-only entry through a signed link.
+		// Recipients may not read their email right away, so the link lasts as
+		// long as signed links can.
+		if entry.Visibility == picoshare.VisibilitySignedLinkOnly {
+			// Signed links are only precise to the second.
+			expires := s.clock.Now().Add(parse.MaxSignedURLLifetime).Truncate(time.Second)
+			url, err = s.signedEntryURL(r, id, expires, false)
+			if err != nil {
+				log.Printf("failed to sign link to entry %v: %v", id, err)
+				http.Error(w, "Failed to sign link", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		sent, err := s.mailer.SendLink(entry, url, message, recipients)
+		if err != nil {
+			log.Printf("failed to email link to entry %v: %v", id, err)
+			http.Error(w, "Failed to send email", http.StatusInternalServerError)
+			return
+		}
+
+		if err := s.getDB(r).InsertLinkRecipients(id, recipients, s.clock.Now()); err != nil {
+			log.Printf("failed to record recipients of link to entry %v: %v", id, err)
+			http.Error(w, "Failed to record recipients", http.StatusInternalServerError)
+			return
+		}
+
+		respondJSON(w, LinkEmailPostResponse{Sent: sent})
+	}
+}
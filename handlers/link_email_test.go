@@ -0,0 +1,271 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mtlynch/picoshare/handlers"
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/store/test_sqlite"
+)
+
+type mockMailer struct {
+	sent     bool
+	url      string
+	message  string
+	to       []string
+	sendings int
+}
+
+func (m *mockMailer) SendLink(entry picoshare.UploadMetadata, url, message string, to []string) (bool, error) {
+	m.sendings++
+	m.url = url
+	m.message = message
+	m.to = to
+	return m.sent, nil
+}
+
+func (m *mockMailer) SendGuestLink(gl picoshare.GuestLink, url, message string, to []string) (bool, error) {
+	m.sendings++
+	m.url = url
+	m.message = message
+	m.to = to
+	return m.sent, nil
+}
+
+func TestLinkEmailPost(t *testing.T) {
+	for _, tt := range []struct {
+		description        string
+		visibility         picoshare.Visibility
+		noMailer           bool
+		mailServerDown     bool
+		entryID            string
+		payload            string
+		status             int
+		recipientsExpected []string
+		messageExpected    string
+		signedURLExpected  bool
+	}{
+		{
+			description:        "emails link to recipients",
+			entryID:            "AAAAAAAAAA",
+			payload:            `{"recipients": ["alice@example.com", "Bob <bob@example.com>"], "message": "Here's the report."}`,
+			status:             http.StatusOK,
+			recipientsExpected: []string{"alice@example.com", "bob@example.com"},
+			messageExpected:    "Here's the report.",
+		},
+		{
+			description:        "emails link without a message",
+			entryID:            "AAAAAAAAAA",
+			payload:            `{"recipients": ["alice@example.com"]}`,
+			status:             http.StatusOK,
+			recipientsExpected: []string{"alice@example.com"},
+		},
+		{
+			description:        "records recipients when mail server is down",
+			entryID:            "AAAAAAAAAA",
+			mailServerDown:     true,
+			payload:            `{"recipients": ["alice@example.com"]}`,
+			status:             http.StatusOK,
+			recipientsExpected: []string{"alice@example.com"},
+		},
+		{
+			description:        "emails signed link to signed-only entry",
+			visibility:         picoshare.VisibilitySignedLinkOnly,
+			entryID:            "AAAAAAAAAA",
+			payload:            `{"recipients": ["alice@example.com"]}`,
+			status:             http.StatusOK,
+			recipientsExpected: []string{"alice@example.com"},
+			signedURLExpected:  true,
+		},
+		{
+			description: "rejects private entry",
+			visibility:  picoshare.VisibilityPrivate,
+			entryID:     "AAAAAAAAAA",
+			payload:     `{"recipients": ["alice@example.com"]}`,
+			status:      http.StatusBadRequest,
+		},
+		{
+			description: "rejects request without recipients",
+			entryID:     "AAAAAAAAAA",
+			payload:     `{"recipients": [], "message": "Hi"}`,
+			status:      http.StatusBadRequest,
+		},
+		{
+			description: "rejects invalid recipient",
+			entryID:     "AAAAAAAAAA",
+			payload:     `{"recipients": ["alice"]}`,
+			status:      http.StatusBadRequest,
+		},
+		{
+			description: "rejects message that's too long",
+			entryID:     "AAAAAAAAAA",
+			payload:     `{"recipients": ["alice@example.com"], "message": "` + strings.Repeat("A", 2001) + `"}`,
+			status:      http.StatusBadRequest,
+		},
+		{
+			description: "rejects nonexistent entry",
+			entryID:     "BBBBBBBBBB",
+			payload:     `{"recipients": ["alice@example.com"]}`,
+			status:      http.StatusNotFound,
+		},
+		{
+			description: "rejects request when no SMTP server is configured",
+			noMailer:    true,
+			entryID:     "AAAAAAAAAA",
+			payload:     `{"recipients": ["alice@example.com"]}`,
+			status:      http.StatusServiceUnavailable,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			dataStore := test_sqlite.New()
+			contents := "dummy data"
+			if err := dataStore.InsertEntry(strings.NewReader(contents), picoshare.UploadMetadata{
+				ID:         "AAAAAAAAAA",
+				Filename:   "report.pdf",
+				Uploaded:   mustParseTime("2024-01-01T00:00:00Z"),
+				Expires:    picoshare.NeverExpire,
+				Size:       mustParseFileSize(len(contents)),
+				Visibility: tt.visibility,
+			}); err != nil {
+				t.Fatalf("failed to insert dummy entry: %v", err)
+			}
+
+			mailer := &mockMailer{sent: !tt.mailServerDown}
+			var m handlers.Mailer = mailer
+			if tt.noMailer {
+				m = nil
+			}
+			c := mockClock{mustParseTime("2024-01-02T00:00:00Z")}
+			s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, c, handlers.Options{Mailer: m})
+
+			req, err := http.NewRequest("POST", "/api/entry/"+tt.entryID+"/link-emails", strings.NewReader(tt.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Host = "example.com"
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+
+			if got, want := rec.Code, tt.status; got != want {
+				t.Fatalf("status=%d, want=%d", got, want)
+			}
+
+			recipients, err := dataStore.GetLinkRecipients("AAAAAAAAAA")
+			if err != nil {
+				t.Fatalf("failed to get link recipients: %v", err)
+			}
+
+			if tt.status != http.StatusOK {
+				if mailer.sendings != 0 {
+					t.Errorf("sent %d emails for rejected request, want none", mailer.sendings)
+				}
+				if len(recipients) != 0 {
+					t.Errorf("recorded recipients %v for rejected request, want none", recipients)
+				}
+				return
+			}
+
+			var response handlers.LinkEmailPostResponse
+			if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+				t.Fatalf("response is not valid JSON: %v", err)
+			}
+			if got, want := response.Sent, !tt.mailServerDown; got != want {
+				t.Errorf("sent=%v, want=%v", got, want)
+			}
+
+			if tt.signedURLExpected {
+				u, err := url.Parse(mailer.url)
+				if err != nil {
+					t.Fatalf("emailed URL is invalid: %v", err)
+				}
+				if got, want := u.Path, "/-AAAAAAAAAA"; got != want {
+					t.Errorf("path=%s, want=%s", got, want)
+				}
+				if got, want := u.Query().Get("exp"), fmt.Sprintf("%d", c.t.Add(7*24*time.Hour).Unix()); got != want {
+					t.Errorf("exp=%s, want=%s", got, want)
+				}
+				req, err := http.NewRequest("GET", u.RequestURI(), nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				rec := httptest.NewRecorder()
+				s.Router().ServeHTTP(rec, req)
+				if got, want := rec.Code, http.StatusOK; got != want {
+					t.Errorf("GET emailed link status=%d, want=%d", got, want)
+				}
+			} else if got, want := mailer.url, "http://example.com/-AAAAAAAAAA"; got != want {
+				t.Errorf("url=%v, want=%v", got, want)
+			}
+			if got, want := mailer.message, tt.messageExpected; got != want {
+				t.Errorf("message=%q, want=%q", got, want)
+			}
+			if got, want := mailer.to, tt.recipientsExpected; !slices.Equal(got, want) {
+				t.Errorf("to=%v, want=%v", got, want)
+			}
+
+			recorded := []string{}
+			for _, r := range recipients {
+				recorded = append(recorded, r.Email)
+				if got, want := r.Sent, c.t; !got.Equal(want) {
+					t.Errorf("recipient sent time=%v, want=%v", got, want)
+				}
+			}
+			if got, want := recorded, tt.recipientsExpected; !slices.Equal(got, want) {
+				t.Errorf("recorded recipients=%v, want=%v", got, want)
+			}
+		})
+	}
+}
+
+func TestFileInfoShowsLinkRecipients(t *testing.T) {
+	dataStore := test_sqlite.New()
+	contents := "dummy data"
+	if err := dataStore.InsertEntry(strings.NewReader(contents), picoshare.UploadMetadata{
+		ID:       "AAAAAAAAAA",
+		Filename: "report.pdf",
+		Uploaded: mustParseTime("2024-01-01T00:00:00Z"),
+		Expires:  picoshare.NeverExpire,
+		Size:     mustParseFileSize(len(contents)),
+	}); err != nil {
+		t.Fatalf("failed to insert dummy entry: %v", err)
+	}
+	if err := dataStore.InsertLinkRecipients("AAAAAAAAAA", []string{"alice@example.com"}, mustParseTime("2024-01-02T00:00:00Z")); err != nil {
+		t.Fatalf("failed to insert link recipients: %v", err)
+	}
+
+	s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock(), handlers.Options{Mailer: &mockMailer{}})
+
+	for _, route := range []string{"/files/AAAAAAAAAA/info", "/"} {
+		req, err := http.NewRequest("GET", route, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		s.Router().ServeHTTP(rec, req)
+
+		if got, want := rec.Code, http.StatusOK; got != want {
+			t.Fatalf("%s: status=%d, want=%d", route, got, want)
+		}
+		if !strings.Contains(rec.Body.String(), "<send-link-dialog") {
+			t.Errorf("%s is missing send link dialog", route)
+		}
+	}
+
+	req, err := http.NewRequest("GET", "/files/AAAAAAAAAA/info", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, req)
+	if !strings.Contains(rec.Body.String(), "alice@example.com") {
+		t.Errorf("file info page is missing link recipient")
+	}
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/mtlynch/picoshare/notify"
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+// notifyOwner sends an event to the owner's notifiers in the background so that
+// a slow notification service doesn't delay the response to the client.
+func (s Server) notifyOwner(e notify.Event) {
+	if s.notifier == nil {
+		return
+	}
+	go func() {
+		if err := s.notifier.Notify(e); err != nil {
+			log.Printf("failed to send %s notification for entry %v: %v", e.Kind, e.Entry.ID, err)
+		}
+	}()
+}
+
+func (s Server) notifyGuestUpload(r *http.Request, id picoshare.EntryID, gl picoshare.GuestLink) {
+	entry, err := s.getDB(r).GetEntryMetadata(id)
+	if err != nil {
+		log.Printf("failed to read metadata for guest upload %v: %v", id, err)
+		return
+	}
+
+	baseURL := baseURLFromRequest(r)
+	s.notifyOwner(notify.Event{
+		Kind:      notify.EventGuestUpload,
+		Entry:     entry,
+		GuestLink: gl,
+		URL:       fmt.Sprintf("%s/files/%s/info", baseURL, id),
+		EntryURL:  fmt.Sprintf("%s/-%s", baseURL, id),
+	})
+}
+
+// notifySensitiveDownload notifies the owner about the download if it's a
+// sensitive download and the owner asked for notifications about them.
+func (s Server) notifySensitiveDownload(r *http.Request, entry picoshare.UploadMetadata, download picoshare.DownloadRecord) {
+	if s.notifier == nil || !isSensitiveDownload(r, entry) {
+		return
+	}
+
+	settings, err := s.getDB(r).ReadSettings()
+	if err != nil {
+		log.Printf("failed to read settings: %v", err)
+		return
+	}
+	if !settings.Notifications.SensitiveDownloads {
+		return
+	}
+
+	baseURL := baseURLFromRequest(r)
+	s.notifyOwner(notify.Event{
+		Kind:     notify.EventSensitiveDownload,
+		Entry:    entry,
+		Download: download,
+		URL:      fmt.Sprintf("%s/files/%s/downloads", baseURL, entry.ID),
+		EntryURL: fmt.Sprintf("%s/-%s", baseURL, entry.ID),
+	})
+}
+
+// isSensitiveDownload returns true if the request downloads a private or
+// signed-link-only entry on behalf of someone other than the owner.
+func isSensitiveDownload(r *http.Request, entry picoshare.UploadMetadata) bool {
+	if entry.Visibility == picoshare.VisibilityPublic || isAuthenticated(r.Context()) {
+		return false
+	}
+	// Media players and download managers fetch a file in many ranges, so only
+	// count the request that starts from the beginning of the file.
+	if rangeHeader := r.Header.Get("Range"); rangeHeader != "" && !strings.HasPrefix(rangeHeader, "bytes=0-") {
+		return false
+	}
+	return true
+}
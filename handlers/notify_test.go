@@ -0,0 +1,315 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mtlynch/picoshare/handlers"
+	"github.com/mtlynch/picoshare/handlers/auth/shared_secret"
+	"github.com/mtlynch/picoshare/notify"
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/store/sqlite"
+	"github.com/mtlynch/picoshare/store/test_sqlite"
+)
+
+// mockNotifier sends each event it receives on a channel, as the server sends
+// notifications in the background.
+type mockNotifier struct {
+	events chan notify.Event
+}
+
+func newMockNotifier() mockNotifier {
+	return mockNotifier{events: make(chan notify.Event, 10)}
+}
+
+func (n mockNotifier) Notify(e notify.Event) error {
+	n.events <- e
+	return nil
+}
+
+// nextEvent returns the next event the notifier receives, or nil if the
+// notifier receives no event within a short time.
+func (n mockNotifier) nextEvent() *notify.Event {
+	select {
+	case e := <-n.events:
+		return &e
+	case <-time.After(200 * time.Millisecond):
+		return nil
+	}
+}
+
+func TestGuestUploadNotification(t *testing.T) {
+	for _, tt := range []struct {
+		description    string
+		notifyGuests   bool
+		notifyOnUpload bool
+		wantEvent      bool
+	}{
+		{
+			description:  "notifies owner about guest upload",
+			notifyGuests: true,
+			wantEvent:    true,
+		},
+		{
+			description:  "doesn't notify owner when guest upload notifications are off",
+			notifyGuests: false,
+			wantEvent:    false,
+		},
+		{
+			description:    "notifies owner when the guest link asks for notifications, even if guest upload notifications are off",
+			notifyGuests:   false,
+			notifyOnUpload: true,
+			wantEvent:      true,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			dataStore := test_sqlite.New()
+			if err := dataStore.InsertGuestLink(picoshare.GuestLink{
+				ID:              picoshare.GuestLinkID("abcdefgh23456789"),
+				Label:           picoshare.GuestLinkLabel("For Alice"),
+				Created:         mustParseTime("2022-05-26T00:00:00Z"),
+				UrlExpires:      mustParseExpirationTime("2030-01-02T03:04:25Z"),
+				MaxFileLifetime: picoshare.FileLifetimeInfinite,
+				NotifyOnUpload:  tt.notifyOnUpload,
+			}); err != nil {
+				t.Fatalf("failed to insert dummy guest link: %v", err)
+			}
+			setNotificationSettings(t, &dataStore, picoshare.NotificationSettings{GuestUploads: tt.notifyGuests})
+
+			notifier := newMockNotifier()
+			c := mockClock{mustParseTime("2024-01-01T00:00:00Z")}
+			s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, c, handlers.Options{Notifier: notifier})
+
+			formData, contentType := createMultipartFormBody("report.pdf", "", strings.NewReader("dummy bytes"))
+			req, err := http.NewRequest("POST", "/api/guest/abcdefgh23456789?expiration=2030-01-01T00:00:00Z", formData)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Host = "example.com"
+			req.Header.Add("Content-Type", contentType)
+			req.Header.Add("Accept", "application/json")
+
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+
+			if got, want := rec.Code, http.StatusOK; got != want {
+				t.Fatalf("status=%d, want=%d", got, want)
+			}
+
+			e := notifier.nextEvent()
+			if !tt.wantEvent {
+				if e != nil {
+					t.Fatalf("got unexpected %s notification", e.Kind)
+				}
+				return
+			}
+			if e == nil {
+				t.Fatalf("got no notification, want guest upload notification")
+			}
+			if got, want := e.Kind, notify.EventGuestUpload; got != want {
+				t.Errorf("kind=%v, want=%v", got, want)
+			}
+			if got, want := e.Entry.Filename, picoshare.Filename("report.pdf"); got != want {
+				t.Errorf("filename=%v, want=%v", got, want)
+			}
+			if got, want := e.GuestLink.Label, picoshare.GuestLinkLabel("For Alice"); got != want {
+				t.Errorf("guest link label=%v, want=%v", got, want)
+			}
+			if got, want := e.EntryURL, "http://example.com/-"+e.Entry.ID.String(); got != want {
+				t.Errorf("entry url=%v, want=%v", got, want)
+			}
+		})
+	}
+}
+
+func TestSensitiveDownloadNotification(t *testing.T) {
+	unauthenticated, err := shared_secret.New("dummypass")
+	if err != nil {
+		t.Fatalf("failed to create shared secret: %v", err)
+	}
+
+	for _, tt := range []struct {
+		description     string
+		visibility      picoshare.Visibility
+		notifyDownloads bool
+		authenticated   bool
+		rangeHeader     string
+		wantEvent       bool
+	}{
+		{
+			description:     "notifies owner about download of signed-only entry",
+			visibility:      picoshare.VisibilitySignedLinkOnly,
+			notifyDownloads: true,
+			wantEvent:       true,
+		},
+		{
+			description:     "notifies owner about request for start of signed-only entry",
+			visibility:      picoshare.VisibilitySignedLinkOnly,
+			notifyDownloads: true,
+			rangeHeader:     "bytes=0-3",
+			wantEvent:       true,
+		},
+		{
+			description:     "ignores request for later range of signed-only entry",
+			visibility:      picoshare.VisibilitySignedLinkOnly,
+			notifyDownloads: true,
+			rangeHeader:     "bytes=4-",
+			wantEvent:       false,
+		},
+		{
+			description:     "ignores download of public entry",
+			visibility:      picoshare.VisibilityPublic,
+			notifyDownloads: true,
+			wantEvent:       false,
+		},
+		{
+			description:     "ignores owner's own download",
+			visibility:      picoshare.VisibilitySignedLinkOnly,
+			notifyDownloads: true,
+			authenticated:   true,
+			wantEvent:       false,
+		},
+		{
+			description:     "ignores download when download notifications are off",
+			visibility:      picoshare.VisibilitySignedLinkOnly,
+			notifyDownloads: false,
+			wantEvent:       false,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			dataStore := test_sqlite.New()
+			contents := "dummy data"
+			if err := dataStore.InsertEntry(strings.NewReader(contents), picoshare.UploadMetadata{
+				ID:         "AAAAAAAAAA",
+				Filename:   "report.txt",
+				Uploaded:   mustParseTime("2023-01-01T00:00:00Z"),
+				Expires:    picoshare.NeverExpire,
+				Size:       mustParseFileSize(len(contents)),
+				Visibility: tt.visibility,
+			}); err != nil {
+				t.Fatalf("failed to insert dummy entry: %v", err)
+			}
+			setNotificationSettings(t, &dataStore, picoshare.NotificationSettings{SensitiveDownloads: tt.notifyDownloads})
+
+			notifier := newMockNotifier()
+			c := mockClock{mustParseTime("2024-01-01T00:00:00Z")}
+			admin := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, c, handlers.Options{Notifier: notifier})
+			s := admin
+			if !tt.authenticated {
+				s = handlers.New(unauthenticated, &dataStore, nilSpaceChecker, nilGarbageCollector, c, handlers.Options{Notifier: notifier})
+			}
+
+			// Downloads of signed-only entries need a signed link unless the
+			// client is the owner.
+			route := "/-AAAAAAAAAA"
+			if tt.visibility == picoshare.VisibilitySignedLinkOnly {
+				route = mustCreateSignedLink(t, admin, "AAAAAAAAAA")
+			}
+
+			req, err := http.NewRequest("GET", route, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Host = "example.com"
+			req.RemoteAddr = "203.0.113.7:1234"
+			if tt.rangeHeader != "" {
+				req.Header.Set("Range", tt.rangeHeader)
+			}
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK && rec.Code != http.StatusPartialContent {
+				t.Fatalf("status=%d, want success", rec.Code)
+			}
+
+			e := notifier.nextEvent()
+			if !tt.wantEvent {
+				if e != nil {
+					t.Fatalf("got unexpected %s notification", e.Kind)
+				}
+				return
+			}
+			if e == nil {
+				t.Fatalf("got no notification, want download notification")
+			}
+			if got, want := e.Kind, notify.EventSensitiveDownload; got != want {
+				t.Errorf("kind=%v, want=%v", got, want)
+			}
+			if got, want := e.Download.ClientIP, "203.0.113.7"; got != want {
+				t.Errorf("client IP=%v, want=%v", got, want)
+			}
+			if got, want := e.URL, "http://example.com/files/AAAAAAAAAA/downloads"; got != want {
+				t.Errorf("url=%v, want=%v", got, want)
+			}
+		})
+	}
+}
+
+// mustCreateSignedLink returns the path and query of a signed link to the
+// entry.
+func mustCreateSignedLink(t *testing.T, s handlers.Server, id string) string {
+	t.Helper()
+	req, err := http.NewRequest("POST", "/api/entry/"+id+"/signed-urls", strings.NewReader(`{"expiresInMinutes": 15}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, req)
+	var response handlers.SignedURLPostResponse
+	if err := json.NewDecoder(rec.Result().Body).Decode(&response); err != nil {
+		t.Fatalf("response is not valid JSON: %v", err)
+	}
+	signedURL, err := url.Parse(response.URL)
+	if err != nil {
+		t.Fatalf("response URL is invalid: %v", err)
+	}
+	return signedURL.RequestURI()
+}
+
+func setNotificationSettings(t *testing.T, dataStore *sqlite.Store, notifications picoshare.NotificationSettings) {
+	t.Helper()
+	settings, err := dataStore.ReadSettings()
+	if err != nil {
+		t.Fatalf("failed to read settings: %v", err)
+	}
+	settings.Notifications = notifications
+	if err := dataStore.UpdateSettings(settings); err != nil {
+		t.Fatalf("failed to update settings: %v", err)
+	}
+}
+
+func TestNotificationsGet(t *testing.T) {
+	dataStore := test_sqlite.New()
+	if _, err := dataStore.InsertNotificationEmail(picoshare.NotificationEmail{
+		Event:      string(notify.EventGuestUpload),
+		Recipients: []string{"owner@example.com"},
+		Subject:    "PicoShare: A guest uploaded report.pdf",
+		Body:       "A guest uploaded report.pdf.",
+		Created:    mustParseTime("2024-01-01T00:00:00Z"),
+	}); err != nil {
+		t.Fatalf("failed to insert dummy email: %v", err)
+	}
+
+	s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock(), handlers.Options{})
+
+	req, err := http.NewRequest("GET", "/notifications", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, req)
+
+	if got, want := rec.Code, http.StatusOK; got != want {
+		t.Fatalf("status=%d, want=%d", got, want)
+	}
+	for _, want := range []string{"PicoShare: A guest uploaded report.pdf", "owner@example.com", "Pending"} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("notification log is missing %q", want)
+		}
+	}
+}
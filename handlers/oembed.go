@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"image"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/preview"
+	"github.com/mtlynch/picoshare/store"
+)
+
+// Videos don't carry their dimensions in a form PicoShare can read, so oEmbed
+// responses suggest a 16:9 player of this size.
+const (
+	oembedVideoWidth  = 640
+	oembedVideoHeight = 360
+)
+
+// OEmbedResponse is an oEmbed 1.0 response for an image (type "photo") or a
+// video (type "video").
+type OEmbedResponse struct {
+	Type            string `json:"type"`
+	Version         string `json:"version"`
+	Title           string `json:"title"`
+	ProviderName    string `json:"provider_name"`
+	ProviderURL     string `json:"provider_url"`
+	URL             string `json:"url,omitempty"`
+	HTML            string `json:"html,omitempty"`
+	Width           int    `json:"width"`
+	Height          int    `json:"height"`
+	ThumbnailURL    string `json:"thumbnail_url,omitempty"`
+	ThumbnailWidth  int    `json:"thumbnail_width,omitempty"`
+	ThumbnailHeight int    `json:"thumbnail_height,omitempty"`
+}
+
+// oembedGet implements an oEmbed provider (https://oembed.com) for image and
+// video entries.
+func (s Server) oembedGet() http.HandlerFunc {
+	videoTemplate := template.Must(template.New("video").Parse(
+		`<video controls src="{{ .URL }}" width="{{ .Width }}" height="{{ .Height }}"></video>`))
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if format := r.URL.Query().Get("format"); format != "" && format != "json" {
+			http.Error(w, "only the JSON format is supported", http.StatusNotImplemented)
+			return
+		}
+
+		maxWidth, err := parseOEmbedDimension(r.URL.Query().Get("maxwidth"))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid maxwidth: %v", err), http.StatusBadRequest)
+			return
+		}
+		maxHeight, err := parseOEmbedDimension(r.URL.Query().Get("maxheight"))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid maxheight: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		id, err := entryIDFromURL(r.URL.Query().Get("url"))
+		if err != nil {
+			log.Printf("invalid oEmbed URL: %v", err)
+			http.Error(w, fmt.Sprintf("invalid url: %v", err), http.StatusNotFound)
+			return
+		}
+
+		entry, err := s.getDB(r).GetEntryMetadata(id)
+		if _, ok := errors.AsType[store.EntryNotFoundError](err); ok {
+			http.Error(w, "entry not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("error retrieving entry with id %v: %v", id, err)
+			http.Error(w, "failed to retrieve entry", http.StatusInternalServerError)
+			return
+		}
+
+		// Embeds are public, so only public entries that are available have them.
+		if !entry.Scan.IsServable() || entry.Visibility != picoshare.VisibilityPublic || !entry.IsAvailable(s.clock.Now()) {
+			http.Error(w, "entry not found", http.StatusNotFound)
+			return
+		}
+
+		baseURL := baseURLFromRequest(r)
+		response := OEmbedResponse{
+			Version:      "1.0",
+			Title:        entry.Filename.String(),
+			ProviderName: "PicoShare",
+			ProviderURL:  baseURL,
+		}
+
+		if entry.HasThumbnail {
+			thumb, err := s.getDB(r).GetEntryThumbnail(id)
+			if err != nil {
+				log.Printf("error retrieving thumbnail for entry %v: %v", id, err)
+				http.Error(w, "failed to retrieve thumbnail", http.StatusInternalServerError)
+				return
+			}
+			if cfg, _, err := image.DecodeConfig(bytes.NewReader(thumb)); err == nil {
+				response.ThumbnailURL = fmt.Sprintf("%s/-%s/_/thumbnail", baseURL, id)
+				response.ThumbnailWidth = cfg.Width
+				response.ThumbnailHeight = cfg.Height
+			}
+		}
+
+		switch preview.Detect(entry.ContentType, entry.Filename) {
+		case preview.KindImage:
+			entryFile, err := s.getDB(r).ReadEntryFile(id)
+			if err != nil {
+				log.Printf("error retrieving entry data with id %v: %v", id, err)
+				http.Error(w, "failed to retrieve entry", http.StatusInternalServerError)
+				return
+			}
+			cfg, _, err := image.DecodeConfig(entryFile)
+			if err != nil {
+				http.Error(w, "image format doesn't support embedding", http.StatusNotFound)
+				return
+			}
+			response.Type = "photo"
+			response.URL = entryFileURL(baseURL, entry)
+			response.Width, response.Height = fitWithin(cfg.Width, cfg.Height, maxWidth, maxHeight)
+		case preview.KindVideo:
+			response.Type = "video"
+			response.Width, response.Height = fitWithin(oembedVideoWidth, oembedVideoHeight, maxWidth, maxHeight)
+			var html strings.Builder
+			if err := videoTemplate.Execute(&html, struct {
+				URL           string
+				Width, Height int
+			}{entryFileURL(baseURL, entry), response.Width, response.Height}); err != nil {
+				log.Printf("failed to render oEmbed HTML for entry %v: %v", id, err)
+				http.Error(w, "failed to render embed", http.StatusInternalServerError)
+				return
+			}
+			response.HTML = html.String()
+		default:
+			http.Error(w, "only images and videos support embedding", http.StatusNotFound)
+			return
+		}
+
+		respondJSON(w, response)
+	}
+}
+
+// entryIDFromURL parses the entry ID from an entry link, such as
+// https://example.com/-abc123 or https://example.com/-abc123/file.png.
+func entryIDFromURL(raw string) (picoshare.EntryID, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return picoshare.EntryID(""), err
+	}
+
+	segment, _, _ := strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+	idRaw, ok := strings.CutPrefix(segment, "-")
+	if !ok {
+		if idRaw, ok = strings.CutPrefix(segment, "!"); !ok {
+			return picoshare.EntryID(""), errors.New("URL is not a link to an entry")
+		}
+	}
+
+	return parseEntryID(idRaw)
+}
+
+// parseOEmbedDimension parses an optional maxwidth or maxheight parameter. Zero
+// means the consumer has no limit.
+func parseOEmbedDimension(raw string) (int, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, err
+	}
+	if v <= 0 {
+		return 0, errors.New("must be positive")
+	}
+	return v, nil
+}
+
+// fitWithin scales width and height down proportionally so that they fit
+// within maxWidth and maxHeight. A zero maximum means no limit.
+func fitWithin(width, height, maxWidth, maxHeight int) (int, int) {
+	if maxWidth > 0 && width > maxWidth {
+		height = max(1, height*maxWidth/width)
+		width = maxWidth
+	}
+	if maxHeight > 0 && height > maxHeight {
+		width = max(1, width*maxHeight/height)
+		height = maxHeight
+	}
+	return width, height
+}
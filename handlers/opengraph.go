@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/preview"
+)
+
+// linkPreviewCrawlers are substrings of the User-Agent headers that chat apps
+// and social networks send when they fetch a link to build a preview of it.
+var linkPreviewCrawlers = []string{
+	"discordbot",
+	"facebookexternalhit",
+	"facebot",
+	"linkedinbot",
+	"mastodon",
+	"mattermost",
+	"redditbot",
+	"skypeuripreview",
+	"slackbot-linkexpanding",
+	"telegrambot",
+	"twitterbot",
+	"whatsapp",
+}
+
+// isLinkPreviewCrawler returns true if the user agent belongs to a service
+// that's fetching a link to show a preview of it, rather than to download it.
+func isLinkPreviewCrawler(userAgent string) bool {
+	userAgent = strings.ToLower(userAgent)
+	for _, crawler := range linkPreviewCrawlers {
+		if strings.Contains(userAgent, crawler) {
+			return true
+		}
+	}
+	return false
+}
+
+// isBareEntryPath returns true if the request is for an entry's short link
+// (e.g., /-abc123) rather than a link that includes the filename. Link
+// previews use the filename form for the entry's data, so that crawlers can
+// still download the file itself.
+func isBareEntryPath(r *http.Request, id picoshare.EntryID) bool {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	return path == "/-"+id.String() || path == "/!"+id.String()
+}
+
+// isBareSlugPath returns true if the request is for an entry's slug link
+// (e.g., /s/q3-report) rather than a link that includes the filename.
+func isBareSlugPath(r *http.Request, slug picoshare.EntrySlug) bool {
+	return strings.EqualFold(strings.TrimSuffix(r.URL.Path, "/"), "/s/"+slug.String())
+}
+
+// entryFileURL returns the URL that always serves an entry's data, even to
+// link preview crawlers.
+func entryFileURL(baseURL string, entry picoshare.UploadMetadata) string {
+	filename := entry.Filename.String()
+	if filename == "" {
+		filename = "download"
+	}
+	return fmt.Sprintf("%s/-%s/%s", baseURL, entry.ID, url.PathEscape(filename))
+}
+
+func parseEntryLandingPage() *template.Template {
+	fns := template.FuncMap{
+		"formatFileSize": humanReadableFileSize,
+	}
+	return parseTemplatesWithFuncs(fns, "templates/pages/entry-landing.html")
+}
+
+// serveEntryLandingPage renders an HTML page with OpenGraph and Twitter Card
+// metadata so that chat apps can show a preview of the entry.
+func (s Server) serveEntryLandingPage(w http.ResponseWriter, r *http.Request, t *template.Template, entry picoshare.UploadMetadata) {
+	baseURL := baseURLFromRequest(r)
+	kind := preview.Detect(entry.ContentType, entry.Filename)
+
+	var imageURL, videoURL string
+	if entry.HasThumbnail {
+		imageURL = fmt.Sprintf("%s/-%s/_/thumbnail", baseURL, entry.ID)
+	} else if kind == preview.KindImage {
+		imageURL = entryFileURL(baseURL, entry)
+	}
+	if kind == preview.KindVideo {
+		videoURL = entryFileURL(baseURL, entry)
+	}
+
+	var oembedURL string
+	if kind == preview.KindImage || kind == preview.KindVideo {
+		oembedURL = fmt.Sprintf("%s/oembed?format=json&url=%s", baseURL, url.QueryEscape(fmt.Sprintf("%s/-%s", baseURL, entry.ID)))
+	}
+
+	if err := t.Execute(w, struct {
+		commonProps
+		Metadata  picoshare.UploadMetadata
+		PageURL   string
+		FileURL   string
+		ImageURL  string
+		VideoURL  string
+		OEmbedURL string
+	}{
+		commonProps: makeCommonProps(entry.Filename.String(), r.Context()),
+		Metadata:    entry,
+		PageURL:     fmt.Sprintf("%s/-%s", baseURL, entry.ID),
+		FileURL:     entryFileURL(baseURL, entry),
+		ImageURL:    imageURL,
+		VideoURL:    videoURL,
+		OEmbedURL:   oembedURL,
+	}); err != nil {
+		log.Printf("failed to render landing page for entry %v: %v", entry.ID, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,243 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+
+	"github.com/mtlynch/picoshare/handlers"
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/store/test_sqlite"
+)
+
+const slackUserAgent = "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)"
+
+func TestEntryGetLinkPreview(t *testing.T) {
+	for _, tt := range []struct {
+		description  string
+		userAgent    string
+		path         string
+		hasThumbnail bool
+		contains     string
+	}{
+		{
+			description:  "serves OpenGraph tags to crawlers",
+			userAgent:    slackUserAgent,
+			path:         "/-AAAAAAAAAA",
+			hasThumbnail: true,
+			contains:     `<meta property="og:image" content="http://localhost/-AAAAAAAAAA/_/thumbnail" />`,
+		},
+		{
+			description: "serves video tags to crawlers",
+			userAgent:   "Mozilla/5.0 (compatible; Discordbot/2.0; +https://discordapp.com)",
+			path:        "/-AAAAAAAAAA",
+			contains:    `<meta property="og:video" content="http://localhost/-AAAAAAAAAA/clip%20one.mp4" />`,
+		},
+		{
+			description: "serves file data to browsers",
+			userAgent:   "Mozilla/5.0 (X11; Linux x86_64; rv:120.0) Gecko/20100101 Firefox/120.0",
+			path:        "/-AAAAAAAAAA",
+			contains:    "dummy video",
+		},
+		{
+			description: "serves file data to curl",
+			userAgent:   "curl/8.4.0",
+			path:        "/-AAAAAAAAAA",
+			contains:    "dummy video",
+		},
+		{
+			description: "serves file data to crawlers that request the filename link",
+			userAgent:   slackUserAgent,
+			path:        "/-AAAAAAAAAA/clip%20one.mp4",
+			contains:    "dummy video",
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			contents := "dummy video"
+			dataStore := test_sqlite.New()
+			if err := dataStore.InsertEntry(strings.NewReader(contents), picoshare.UploadMetadata{
+				ID:          "AAAAAAAAAA",
+				Filename:    "clip one.mp4",
+				ContentType: "video/mp4",
+				Uploaded:    mustParseTime("2023-01-01T00:00:00Z"),
+				Expires:     picoshare.NeverExpire,
+				Size:        mustParseFileSize(len(contents)),
+			}); err != nil {
+				panic(err)
+			}
+			if tt.hasThumbnail {
+				if err := dataStore.InsertEntryThumbnail("AAAAAAAAAA", []byte("dummy thumbnail")); err != nil {
+					panic(err)
+				}
+			}
+
+			s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock(), handlers.Options{})
+
+			req, err := http.NewRequest("GET", "http://localhost"+tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("User-Agent", tt.userAgent)
+
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+			res := rec.Result()
+
+			if got, want := res.StatusCode, http.StatusOK; got != want {
+				t.Fatalf("status=%d, want=%d", got, want)
+			}
+
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("failed to read response body: %v", err)
+			}
+			if !strings.Contains(string(body), tt.contains) {
+				t.Errorf("body=%s, want it to contain %s", body, tt.contains)
+			}
+		})
+	}
+}
+
+func TestOEmbedGet(t *testing.T) {
+	var imageData bytes.Buffer
+	if err := png.Encode(&imageData, image.NewRGBA(image.Rect(0, 0, 800, 600))); err != nil {
+		panic(err)
+	}
+
+	dataStore := test_sqlite.New()
+	for _, entry := range []struct {
+		id          picoshare.EntryID
+		filename    picoshare.Filename
+		contentType picoshare.ContentType
+		contents    []byte
+	}{
+		{"AAAAAAAAAA", "photo.png", "image/png", imageData.Bytes()},
+		{"BBBBBBBBBB", "clip.mp4", "video/mp4", []byte("dummy video")},
+		{"CCCCCCCCCC", "notes.txt", "text/plain", []byte("dummy text")},
+	} {
+		if err := dataStore.InsertEntry(bytes.NewReader(entry.contents), picoshare.UploadMetadata{
+			ID:          entry.id,
+			Filename:    entry.filename,
+			ContentType: entry.contentType,
+			Uploaded:    mustParseTime("2023-01-01T00:00:00Z"),
+			Expires:     picoshare.NeverExpire,
+			Size:        mustParseFileSize(len(entry.contents)),
+		}); err != nil {
+			panic(err)
+		}
+	}
+
+	s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock(), handlers.Options{})
+
+	for _, tt := range []struct {
+		description string
+		query       string
+		status      int
+		response    handlers.OEmbedResponse
+	}{
+		{
+			description: "describes an image as a photo",
+			query:       "url=http%3A%2F%2Flocalhost%2F-AAAAAAAAAA",
+			status:      http.StatusOK,
+			response: handlers.OEmbedResponse{
+				Type:         "photo",
+				Version:      "1.0",
+				Title:        "photo.png",
+				ProviderName: "PicoShare",
+				ProviderURL:  "http://localhost",
+				URL:          "http://localhost/-AAAAAAAAAA/photo.png",
+				Width:        800,
+				Height:       600,
+			},
+		},
+		{
+			description: "scales a photo to fit within maximum dimensions",
+			query:       "url=http%3A%2F%2Flocalhost%2F-AAAAAAAAAA%2Fphoto.png&maxwidth=400",
+			status:      http.StatusOK,
+			response: handlers.OEmbedResponse{
+				Type:         "photo",
+				Version:      "1.0",
+				Title:        "photo.png",
+				ProviderName: "PicoShare",
+				ProviderURL:  "http://localhost",
+				URL:          "http://localhost/-AAAAAAAAAA/photo.png",
+				Width:        400,
+				Height:       300,
+			},
+		},
+		{
+			description: "describes a video with an embeddable player",
+			query:       "url=http%3A%2F%2Flocalhost%2F-BBBBBBBBBB&format=json",
+			status:      http.StatusOK,
+			response: handlers.OEmbedResponse{
+				Type:         "video",
+				Version:      "1.0",
+				Title:        "clip.mp4",
+				ProviderName: "PicoShare",
+				ProviderURL:  "http://localhost",
+				HTML:         `<video controls src="http://localhost/-BBBBBBBBBB/clip.mp4" width="640" height="360"></video>`,
+				Width:        640,
+				Height:       360,
+			},
+		},
+		{
+			description: "rejects entries that aren't images or videos",
+			query:       "url=http%3A%2F%2Flocalhost%2F-CCCCCCCCCC",
+			status:      http.StatusNotFound,
+		},
+		{
+			description: "rejects non-existent entries",
+			query:       "url=http%3A%2F%2Flocalhost%2F-DDDDDDDDDD",
+			status:      http.StatusNotFound,
+		},
+		{
+			description: "rejects URLs that aren't entry links",
+			query:       "url=http%3A%2F%2Flocalhost%2Ffiles",
+			status:      http.StatusNotFound,
+		},
+		{
+			description: "rejects XML format",
+			query:       "url=http%3A%2F%2Flocalhost%2F-AAAAAAAAAA&format=xml",
+			status:      http.StatusNotImplemented,
+		},
+		{
+			description: "rejects invalid maxwidth",
+			query:       "url=http%3A%2F%2Flocalhost%2F-AAAAAAAAAA&maxwidth=-5",
+			status:      http.StatusBadRequest,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "http://localhost/oembed?"+tt.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+			res := rec.Result()
+
+			if got, want := res.StatusCode, tt.status; got != want {
+				t.Fatalf("status=%d, want=%d", got, want)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var response handlers.OEmbedResponse
+			if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+				t.Fatalf("response is not valid JSON: %v", err)
+			}
+			if diff := deep.Equal(response, tt.response); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
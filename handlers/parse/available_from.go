@@ -0,0 +1,32 @@
+package parse
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+var ErrAvailableFromUnrecognizedFormat = fmt.Errorf("unrecognized format for availability time, must be in %s format", expirationTimeFormat)
+var ErrAvailableFromAfterExpiration = errors.New("file must become available before it expires")
+
+// AvailableFrom parses the time when an entry becomes available for download.
+// An empty string means the entry is available immediately, which AvailableFrom
+// represents as the zero time.
+func AvailableFrom(availableFromRaw string, expiration picoshare.ExpirationTime) (time.Time, error) {
+	if availableFromRaw == "" {
+		return time.Time{}, nil
+	}
+
+	availableFrom, err := time.Parse(expirationTimeFormat, availableFromRaw)
+	if err != nil {
+		return time.Time{}, ErrAvailableFromUnrecognizedFormat
+	}
+
+	if expiration != picoshare.NeverExpire && !availableFrom.Before(expiration.Time()) {
+		return time.Time{}, ErrAvailableFromAfterExpiration
+	}
+
+	return availableFrom, nil
+}
@@ -0,0 +1,73 @@
+package parse_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mtlynch/picoshare/handlers/parse"
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+func TestAvailableFrom(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		input       string
+		expiration  picoshare.ExpirationTime
+		output      time.Time
+		err         error
+	}{
+		{
+			description: "valid availability time",
+			input:       "2025-01-01T09:00:00Z",
+			expiration:  mustParseExpiration("2025-06-01T00:00:00Z"),
+			output:      time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC),
+			err:         nil,
+		},
+		{
+			description: "valid availability time for entry that never expires",
+			input:       "2025-01-01T09:00:00Z",
+			expiration:  picoshare.NeverExpire,
+			output:      time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC),
+			err:         nil,
+		},
+		{
+			description: "empty string means available immediately",
+			input:       "",
+			expiration:  mustParseExpiration("2025-06-01T00:00:00Z"),
+			output:      time.Time{},
+			err:         nil,
+		},
+		{
+			description: "reject availability time after expiration",
+			input:       "2025-07-01T00:00:00Z",
+			expiration:  mustParseExpiration("2025-06-01T00:00:00Z"),
+			output:      time.Time{},
+			err:         parse.ErrAvailableFromAfterExpiration,
+		},
+		{
+			description: "reject availability time equal to expiration",
+			input:       "2025-06-01T00:00:00Z",
+			expiration:  mustParseExpiration("2025-06-01T00:00:00Z"),
+			output:      time.Time{},
+			err:         parse.ErrAvailableFromAfterExpiration,
+		},
+		{
+			description: "string with letters causes error",
+			input:       "banana",
+			expiration:  picoshare.NeverExpire,
+			output:      time.Time{},
+			err:         parse.ErrAvailableFromUnrecognizedFormat,
+		},
+	} {
+		t.Run(fmt.Sprintf("%s [%s]", tt.description, tt.input), func(t *testing.T) {
+			availableFrom, err := parse.AvailableFrom(tt.input, tt.expiration)
+			if got, want := err, tt.err; got != want {
+				t.Fatalf("err=%v, want=%v", got, want)
+			}
+			if got, want := availableFrom, tt.output; !got.Equal(want) {
+				t.Errorf("availableFrom=%v, want=%v", got, want)
+			}
+		})
+	}
+}
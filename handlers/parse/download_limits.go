@@ -0,0 +1,43 @@
+package parse
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+// MinDownloadBytesPerSecond is the lowest download bandwidth limit we accept.
+// Anything slower would make even small downloads time out.
+const MinDownloadBytesPerSecond = 1024
+
+var (
+	ErrDownloadBytesPerSecondTooSmall = fmt.Errorf("download bandwidth limit must be at least %d bytes per second", MinDownloadBytesPerSecond)
+	ErrConcurrentDownloadsInvalid     = errors.New("concurrent download limit must be a positive number")
+)
+
+// DownloadBytesPerSecond validates a raw download bandwidth limit from a
+// client. A nil value means no limit.
+func DownloadBytesPerSecond(bytesPerSecond *uint64) (*uint64, error) {
+	if bytesPerSecond != nil && *bytesPerSecond < MinDownloadBytesPerSecond {
+		return nil, ErrDownloadBytesPerSecondTooSmall
+	}
+	return bytesPerSecond, nil
+}
+
+// DownloadLimits validates raw server-wide download limits from a client. A
+// nil value means that the limit is unset.
+func DownloadLimits(bytesPerSecond *uint64, concurrentPerIP *int) (picoshare.DownloadLimits, error) {
+	bytesPerSecond, err := DownloadBytesPerSecond(bytesPerSecond)
+	if err != nil {
+		return picoshare.DownloadLimits{}, err
+	}
+	if concurrentPerIP != nil && *concurrentPerIP <= 0 {
+		return picoshare.DownloadLimits{}, ErrConcurrentDownloadsInvalid
+	}
+
+	return picoshare.DownloadLimits{
+		MaxBytesPerSecond:  bytesPerSecond,
+		MaxConcurrentPerIP: concurrentPerIP,
+	}, nil
+}
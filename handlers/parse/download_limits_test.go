@@ -0,0 +1,55 @@
+package parse_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mtlynch/picoshare/handlers/parse"
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+func TestDownloadLimits(t *testing.T) {
+	for _, tt := range []struct {
+		description     string
+		bytesPerSecond  *uint64
+		concurrentPerIP *int
+		output          picoshare.DownloadLimits
+		err             error
+	}{
+		{
+			description: "accept no limits",
+			output:      picoshare.DownloadLimits{},
+			err:         nil,
+		},
+		{
+			description:     "accept all limits",
+			bytesPerSecond:  makeUint64(parse.MinDownloadBytesPerSecond),
+			concurrentPerIP: makeInt(3),
+			output: picoshare.DownloadLimits{
+				MaxBytesPerSecond:  makeUint64(parse.MinDownloadBytesPerSecond),
+				MaxConcurrentPerIP: makeInt(3),
+			},
+			err: nil,
+		},
+		{
+			description:    "reject bandwidth below the minimum",
+			bytesPerSecond: makeUint64(parse.MinDownloadBytesPerSecond - 1),
+			err:            parse.ErrDownloadBytesPerSecondTooSmall,
+		},
+		{
+			description:     "reject zero concurrent downloads",
+			concurrentPerIP: makeInt(0),
+			err:             parse.ErrConcurrentDownloadsInvalid,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			limits, err := parse.DownloadLimits(tt.bytesPerSecond, tt.concurrentPerIP)
+			if got, want := err, tt.err; got != want {
+				t.Fatalf("err=%v, want=%v", got, want)
+			}
+			if got, want := limits, tt.output; !reflect.DeepEqual(got, want) {
+				t.Errorf("limits=%+v, want=%+v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package parse
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+const (
+	// MaxEmailRecipients is the maximum number of recipients of a single email.
+	MaxEmailRecipients = 20
+
+	// MaxEmailMessageBytes is the maximum number of bytes in a message that the
+	// owner includes in an email.
+	MaxEmailMessageBytes = 2000
+)
+
+// EmailRecipients parses a list of email addresses. It accepts addresses with
+// display names, such as "Alice <alice@example.com>", but returns only the
+// bare addresses, and it drops duplicates.
+func EmailRecipients(raw []string) ([]string, error) {
+	recipients := []string{}
+	seen := map[string]bool{}
+	for _, s := range raw {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		addr, err := mail.ParseAddress(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid email address %q", s)
+		}
+		if seen[strings.ToLower(addr.Address)] {
+			continue
+		}
+		seen[strings.ToLower(addr.Address)] = true
+		recipients = append(recipients, addr.Address)
+	}
+
+	if len(recipients) == 0 {
+		return nil, errors.New("at least one recipient is required")
+	}
+	if len(recipients) > MaxEmailRecipients {
+		return nil, fmt.Errorf("too many recipients, maximum is %d", MaxEmailRecipients)
+	}
+
+	return recipients, nil
+}
+
+// EmailMessage parses a message that the owner includes in an email. An empty
+// message is valid.
+func EmailMessage(s string) (string, error) {
+	if len(s) > MaxEmailMessageBytes {
+		return "", errors.New("message is too long")
+	}
+	if err := checkJavaScriptNullOrUndefined(s); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(s), nil
+}
@@ -0,0 +1,138 @@
+package parse_test
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mtlynch/picoshare/handlers/parse"
+)
+
+func TestEmailRecipients(t *testing.T) {
+	tooMany := []string{}
+	for i := range parse.MaxEmailRecipients + 1 {
+		tooMany = append(tooMany, fmt.Sprintf("user%d@example.com", i))
+	}
+
+	for _, tt := range []struct {
+		description string
+		input       []string
+		valid       bool
+		output      []string
+	}{
+		{
+			description: "single address",
+			input:       []string{"alice@example.com"},
+			valid:       true,
+			output:      []string{"alice@example.com"},
+		},
+		{
+			description: "address with display name",
+			input:       []string{"Alice <alice@example.com>"},
+			valid:       true,
+			output:      []string{"alice@example.com"},
+		},
+		{
+			description: "skips blank entries and surrounding whitespace",
+			input:       []string{" alice@example.com ", "", "bob@example.com"},
+			valid:       true,
+			output:      []string{"alice@example.com", "bob@example.com"},
+		},
+		{
+			description: "drops duplicate addresses",
+			input:       []string{"alice@example.com", "Alice@Example.com"},
+			valid:       true,
+			output:      []string{"alice@example.com"},
+		},
+		{
+			description: "maximum number of recipients",
+			input:       tooMany[:parse.MaxEmailRecipients],
+			valid:       true,
+			output:      tooMany[:parse.MaxEmailRecipients],
+		},
+		{
+			description: "rejects too many recipients",
+			input:       tooMany,
+			valid:       false,
+		},
+		{
+			description: "rejects empty list",
+			input:       []string{},
+			valid:       false,
+		},
+		{
+			description: "rejects list of blank entries",
+			input:       []string{" ", ""},
+			valid:       false,
+		},
+		{
+			description: "rejects invalid address",
+			input:       []string{"alice"},
+			valid:       false,
+		},
+		{
+			description: "rejects address with line break",
+			input:       []string{"alice@example.com\r\nBcc: victim@example.com"},
+			valid:       false,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			recipients, err := parse.EmailRecipients(tt.input)
+			if tt.valid != (err == nil) {
+				t.Fatalf("err=%v, want=%v", err, !tt.valid)
+			}
+			if got, want := recipients, tt.output; !slices.Equal(got, want) {
+				t.Errorf("recipients=%v, want=%v", got, want)
+			}
+		})
+	}
+}
+
+func TestEmailMessage(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		input       string
+		valid       bool
+		output      string
+	}{
+		{
+			description: "empty message",
+			input:       "",
+			valid:       true,
+			output:      "",
+		},
+		{
+			description: "trims surrounding whitespace",
+			input:       "  Here's the report.\n",
+			valid:       true,
+			output:      "Here's the report.",
+		},
+		{
+			description: "message of maximum bytes",
+			input:       strings.Repeat("A", parse.MaxEmailMessageBytes),
+			valid:       true,
+			output:      strings.Repeat("A", parse.MaxEmailMessageBytes),
+		},
+		{
+			description: "rejects message that's too long",
+			input:       strings.Repeat("A", parse.MaxEmailMessageBytes+1),
+			valid:       false,
+		},
+		{
+			description: "rejects literal null string",
+			input:       "null",
+			valid:       false,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			message, err := parse.EmailMessage(tt.input)
+			if tt.valid != (err == nil) {
+				t.Fatalf("err=%v, want=%v", err, !tt.valid)
+			}
+			if got, want := message, tt.output; got != want {
+				t.Errorf("message=%q, want=%q", got, want)
+			}
+		})
+	}
+}
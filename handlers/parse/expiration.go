@@ -25,3 +25,38 @@ func Expiration(expirationRaw string, now time.Time) (picoshare.ExpirationTime,
 
 	return picoshare.ExpirationTime(expiration), nil
 }
+
+// ExpirationPreset is a shorthand for a common entry lifetime.
+type ExpirationPreset struct {
+	Name     string
+	Label    string
+	Duration time.Duration
+}
+
+// ExpirationPresets are the shorthand lifetimes clients can choose for an
+// entry, in order of increasing lifetime. A zero duration means the entry never
+// expires.
+var ExpirationPresets = []ExpirationPreset{
+	{Name: "1h", Label: "1 hour", Duration: time.Hour},
+	{Name: "1d", Label: "1 day", Duration: 24 * time.Hour},
+	{Name: "7d", Label: "7 days", Duration: 7 * 24 * time.Hour},
+	{Name: "30d", Label: "30 days", Duration: 30 * 24 * time.Hour},
+	{Name: "never", Label: "Never"},
+}
+
+var ErrExpirationPresetUnrecognized = errors.New("unrecognized expiration preset")
+
+// ExpirationFromPreset converts the name of an expiration preset into the
+// expiration time relative to now.
+func ExpirationFromPreset(name string, now time.Time) (picoshare.ExpirationTime, error) {
+	for _, preset := range ExpirationPresets {
+		if preset.Name != name {
+			continue
+		}
+		if preset.Duration == 0 {
+			return picoshare.NeverExpire, nil
+		}
+		return picoshare.ExpirationTime(now.Add(preset.Duration)), nil
+	}
+	return picoshare.ExpirationTime{}, ErrExpirationPresetUnrecognized
+}
@@ -73,3 +73,48 @@ func mustParseDate(s string) time.Time {
 	}
 	return t
 }
+
+func TestExpirationFromPreset(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		currentTime time.Time
+		input       string
+		output      picoshare.ExpirationTime
+		err         error
+	}{
+		{
+			description: "one hour preset",
+			currentTime: mustParseExpiration("2024-06-01T00:00:00Z").Time(),
+			input:       "1h",
+			output:      mustParseExpiration("2024-06-01T01:00:00Z"),
+		},
+		{
+			description: "thirty day preset",
+			currentTime: mustParseExpiration("2024-06-01T00:00:00Z").Time(),
+			input:       "30d",
+			output:      mustParseExpiration("2024-07-01T00:00:00Z"),
+		},
+		{
+			description: "never preset",
+			currentTime: mustParseExpiration("2024-06-01T00:00:00Z").Time(),
+			input:       "never",
+			output:      picoshare.NeverExpire,
+		},
+		{
+			description: "unrecognized preset",
+			currentTime: mustParseExpiration("2024-06-01T00:00:00Z").Time(),
+			input:       "2w",
+			err:         parse.ErrExpirationPresetUnrecognized,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			expiration, err := parse.ExpirationFromPreset(tt.input, tt.currentTime)
+			if got, want := err, tt.err; got != want {
+				t.Fatalf("err=%v, want=%v", got, want)
+			}
+			if got, want := expiration, tt.output; !got.Time().Equal(want.Time()) {
+				t.Errorf("expiration=%v, want=%v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package parse
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	minExpiringSoonDays = 1
+	maxExpiringSoonDays = 365
+)
+
+var ErrExpiringSoonDaysInvalid = fmt.Errorf("days must be a whole number from %d to %d", minExpiringSoonDays, maxExpiringSoonDays)
+
+// ExpiringSoonWindow parses the number of days ahead to look for entries that
+// are about to expire.
+func ExpiringSoonWindow(daysRaw string) (time.Duration, error) {
+	days, err := strconv.ParseUint(daysRaw, 10, 16)
+	if err != nil || days < minExpiringSoonDays || days > maxExpiringSoonDays {
+		return 0, ErrExpiringSoonDaysInvalid
+	}
+	return time.Duration(days) * 24 * time.Hour, nil
+}
@@ -0,0 +1,59 @@
+package parse_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mtlynch/picoshare/handlers/parse"
+)
+
+func TestExpiringSoonWindow(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		input       string
+		output      time.Duration
+		err         error
+	}{
+		{
+			description: "one day",
+			input:       "1",
+			output:      24 * time.Hour,
+		},
+		{
+			description: "one year",
+			input:       "365",
+			output:      365 * 24 * time.Hour,
+		},
+		{
+			description: "rejects zero days",
+			input:       "0",
+			err:         parse.ErrExpiringSoonDaysInvalid,
+		},
+		{
+			description: "rejects more than one year",
+			input:       "366",
+			err:         parse.ErrExpiringSoonDaysInvalid,
+		},
+		{
+			description: "rejects negative days",
+			input:       "-7",
+			err:         parse.ErrExpiringSoonDaysInvalid,
+		},
+		{
+			description: "rejects non-numeric days",
+			input:       "week",
+			err:         parse.ErrExpiringSoonDaysInvalid,
+		},
+	} {
+		t.Run(fmt.Sprintf("%s [%s]", tt.description, tt.input), func(t *testing.T) {
+			window, err := parse.ExpiringSoonWindow(tt.input)
+			if got, want := err, tt.err; got != want {
+				t.Fatalf("err=%v, want=%v", got, want)
+			}
+			if got, want := window, tt.output; got != want {
+				t.Errorf("window=%v, want=%v", got, want)
+			}
+		})
+	}
+}
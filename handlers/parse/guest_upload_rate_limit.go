@@ -0,0 +1,38 @@
+package parse
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+// MinGuestUploadBytesPerSecond is the lowest upload bandwidth limit we accept.
+// Anything slower would make even small uploads time out.
+const MinGuestUploadBytesPerSecond = 1024
+
+var (
+	ErrGuestUploadsPerHourInvalid  = errors.New("guest uploads per hour limit must be a positive number")
+	ErrGuestBytesPerDayInvalid     = errors.New("guest bytes per day limit must be a positive number")
+	ErrGuestBytesPerSecondTooSmall = fmt.Errorf("guest upload bandwidth limit must be at least %d bytes per second", MinGuestUploadBytesPerSecond)
+)
+
+// GuestUploadRateLimit validates raw rate limit values from a client. A nil
+// value means that the limit is unset.
+func GuestUploadRateLimit(uploadsPerHour *int, bytesPerDay, bytesPerSecond *uint64) (picoshare.GuestUploadRateLimit, error) {
+	if uploadsPerHour != nil && *uploadsPerHour <= 0 {
+		return picoshare.GuestUploadRateLimit{}, ErrGuestUploadsPerHourInvalid
+	}
+	if bytesPerDay != nil && *bytesPerDay == 0 {
+		return picoshare.GuestUploadRateLimit{}, ErrGuestBytesPerDayInvalid
+	}
+	if bytesPerSecond != nil && *bytesPerSecond < MinGuestUploadBytesPerSecond {
+		return picoshare.GuestUploadRateLimit{}, ErrGuestBytesPerSecondTooSmall
+	}
+
+	return picoshare.GuestUploadRateLimit{
+		MaxUploadsPerIPPerHour: uploadsPerHour,
+		MaxBytesPerIPPerDay:    bytesPerDay,
+		MaxBytesPerSecond:      bytesPerSecond,
+	}, nil
+}
@@ -0,0 +1,76 @@
+package parse_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mtlynch/picoshare/handlers/parse"
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+func TestGuestUploadRateLimit(t *testing.T) {
+	for _, tt := range []struct {
+		description    string
+		uploadsPerHour *int
+		bytesPerDay    *uint64
+		bytesPerSecond *uint64
+		output         picoshare.GuestUploadRateLimit
+		err            error
+	}{
+		{
+			description: "accept no limits",
+			output:      picoshare.GuestUploadRateLimit{},
+			err:         nil,
+		},
+		{
+			description:    "accept all limits",
+			uploadsPerHour: makeInt(5),
+			bytesPerDay:    makeUint64(1024 * 1024),
+			bytesPerSecond: makeUint64(parse.MinGuestUploadBytesPerSecond),
+			output: picoshare.GuestUploadRateLimit{
+				MaxUploadsPerIPPerHour: makeInt(5),
+				MaxBytesPerIPPerDay:    makeUint64(1024 * 1024),
+				MaxBytesPerSecond:      makeUint64(parse.MinGuestUploadBytesPerSecond),
+			},
+			err: nil,
+		},
+		{
+			description:    "reject zero uploads per hour",
+			uploadsPerHour: makeInt(0),
+			err:            parse.ErrGuestUploadsPerHourInvalid,
+		},
+		{
+			description:    "reject negative uploads per hour",
+			uploadsPerHour: makeInt(-1),
+			err:            parse.ErrGuestUploadsPerHourInvalid,
+		},
+		{
+			description: "reject zero bytes per day",
+			bytesPerDay: makeUint64(0),
+			err:         parse.ErrGuestBytesPerDayInvalid,
+		},
+		{
+			description:    "reject bandwidth below the minimum",
+			bytesPerSecond: makeUint64(parse.MinGuestUploadBytesPerSecond - 1),
+			err:            parse.ErrGuestBytesPerSecondTooSmall,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			rl, err := parse.GuestUploadRateLimit(tt.uploadsPerHour, tt.bytesPerDay, tt.bytesPerSecond)
+			if got, want := err, tt.err; got != want {
+				t.Fatalf("err=%v, want=%v", got, want)
+			}
+			if got, want := rl, tt.output; !reflect.DeepEqual(got, want) {
+				t.Errorf("rateLimit=%+v, want=%+v", got, want)
+			}
+		})
+	}
+}
+
+func makeInt(i int) *int {
+	return &i
+}
+
+func makeUint64(i uint64) *uint64 {
+	return &i
+}
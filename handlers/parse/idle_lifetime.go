@@ -0,0 +1,29 @@
+package parse
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+var ErrIdleLifetimeUnrecognizedFormat = errors.New("idle expiration must be a whole number of days")
+
+// IdleLifetime parses how many days an entry can go without a download before
+// it expires. Zero days means the entry doesn't expire from inactivity, which
+// IdleLifetime represents as the zero lifetime.
+func IdleLifetime(days uint16) (picoshare.FileLifetime, error) {
+	if days == 0 {
+		return picoshare.FileLifetime{}, nil
+	}
+	return FileLifetime(days)
+}
+
+// IdleLifetimeFromString parses the idle lifetime in days from a string.
+func IdleLifetimeFromString(daysRaw string) (picoshare.FileLifetime, error) {
+	days, err := strconv.ParseUint(daysRaw, 10, 16)
+	if err != nil {
+		return picoshare.FileLifetime{}, ErrIdleLifetimeUnrecognizedFormat
+	}
+	return IdleLifetime(uint16(days))
+}
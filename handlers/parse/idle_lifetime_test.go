@@ -0,0 +1,65 @@
+package parse_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mtlynch/picoshare/handlers/parse"
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+func TestIdleLifetimeFromString(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		input       string
+		output      picoshare.FileLifetime
+		err         error
+	}{
+		{
+			description: "valid idle lifetime",
+			input:       "30",
+			output:      picoshare.NewFileLifetimeInDays(30),
+			err:         nil,
+		},
+		{
+			description: "zero means no idle expiration",
+			input:       "0",
+			output:      picoshare.FileLifetime{},
+			err:         nil,
+		},
+		{
+			description: "rejects too long an idle lifetime",
+			input:       "3651",
+			output:      picoshare.FileLifetime{},
+			err:         parse.ErrFileLifetimeTooLong,
+		},
+		{
+			description: "rejects negative number",
+			input:       "-5",
+			output:      picoshare.FileLifetime{},
+			err:         parse.ErrIdleLifetimeUnrecognizedFormat,
+		},
+		{
+			description: "rejects fractional days",
+			input:       "1.5",
+			output:      picoshare.FileLifetime{},
+			err:         parse.ErrIdleLifetimeUnrecognizedFormat,
+		},
+		{
+			description: "rejects empty string",
+			input:       "",
+			output:      picoshare.FileLifetime{},
+			err:         parse.ErrIdleLifetimeUnrecognizedFormat,
+		},
+	} {
+		t.Run(fmt.Sprintf("%s [%s]", tt.description, tt.input), func(t *testing.T) {
+			lifetime, err := parse.IdleLifetimeFromString(tt.input)
+			if got, want := err, tt.err; got != want {
+				t.Fatalf("err=%v, want=%v", got, want)
+			}
+			if got, want := lifetime, tt.output; !got.Equal(want) {
+				t.Errorf("lifetime=%v, want=%v", got, want)
+			}
+		})
+	}
+}
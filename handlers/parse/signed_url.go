@@ -0,0 +1,32 @@
+package parse
+
+import (
+	"fmt"
+	"time"
+)
+
+const minSignedURLLifetimeInMinutes = 1
+
+// Signed links are for short-term sharing, so if a link needs to last longer
+// than a week, the entry's own expiration is a better fit.
+const maxSignedURLLifetimeInMinutes = 7 * 24 * 60
+
+// MaxSignedURLLifetime is the longest that a signed link can remain valid.
+const MaxSignedURLLifetime = maxSignedURLLifetimeInMinutes * time.Minute
+
+var (
+	ErrSignedURLLifetimeTooShort = fmt.Errorf("signed link lifetime must be at least %d minute", minSignedURLLifetimeInMinutes)
+	ErrSignedURLLifetimeTooLong  = fmt.Errorf("signed link lifetime must be at most %d minutes", maxSignedURLLifetimeInMinutes)
+)
+
+// SignedURLLifetime parses the number of minutes that a signed link remains
+// valid.
+func SignedURLLifetime(lifetimeInMinutes uint32) (time.Duration, error) {
+	if lifetimeInMinutes < minSignedURLLifetimeInMinutes {
+		return 0, ErrSignedURLLifetimeTooShort
+	}
+	if lifetimeInMinutes > maxSignedURLLifetimeInMinutes {
+		return 0, ErrSignedURLLifetimeTooLong
+	}
+	return time.Duration(lifetimeInMinutes) * time.Minute, nil
+}
@@ -0,0 +1,53 @@
+package parse_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mtlynch/picoshare/handlers/parse"
+)
+
+func TestSignedURLLifetime(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		input       uint32
+		output      time.Duration
+		err         error
+	}{
+		{
+			description: "valid lifetime",
+			input:       15,
+			output:      15 * time.Minute,
+		},
+		{
+			description: "accepts the minimum valid lifetime",
+			input:       1,
+			output:      time.Minute,
+		},
+		{
+			description: "accepts the maximum valid lifetime",
+			input:       7 * 24 * 60,
+			output:      7 * 24 * time.Hour,
+		},
+		{
+			description: "rejects too short a lifetime",
+			input:       0,
+			err:         parse.ErrSignedURLLifetimeTooShort,
+		},
+		{
+			description: "rejects too long a lifetime",
+			input:       7*24*60 + 1,
+			err:         parse.ErrSignedURLLifetimeTooLong,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			lifetime, err := parse.SignedURLLifetime(tt.input)
+			if got, want := err, tt.err; got != want {
+				t.Fatalf("err=%v, want=%v", got, want)
+			}
+			if got, want := lifetime, tt.output; got != want {
+				t.Errorf("lifetime=%v, want=%v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package parse
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+const (
+	// MinSlugLength is the minimum number of characters in an entry slug.
+	MinSlugLength = 3
+	// MaxSlugLength is the maximum number of characters in an entry slug.
+	MaxSlugLength = 64
+)
+
+var (
+	ErrSlugTooShort         = fmt.Errorf("slug too short - must be at least %d characters", MinSlugLength)
+	ErrSlugTooLong          = fmt.Errorf("slug too long - limit %d characters", MaxSlugLength)
+	ErrSlugInvalidCharacter = errors.New("slug can contain only letters, numbers, and hyphens")
+	ErrSlugInvalidHyphen    = errors.New("slug can't start or end with a hyphen or contain consecutive hyphens")
+	ErrSlugReserved         = errors.New("slug is reserved")
+)
+
+// reservedSlugs are words that could pass for PicoShare's own pages or
+// mislead recipients about who shared the link.
+var reservedSlugs = map[string]bool{
+	"admin":       true,
+	"api":         true,
+	"auth":        true,
+	"css":         true,
+	"download":    true,
+	"files":       true,
+	"guest-links": true,
+	"information": true,
+	"js":          true,
+	"login":       true,
+	"logout":      true,
+	"picoshare":   true,
+	"raw":         true,
+	"settings":    true,
+	"snippets":    true,
+	"thumbnail":   true,
+	"trash":       true,
+	"upload":      true,
+	"view":        true,
+}
+
+// Slug parses a custom slug for an entry's URL. Slugs are case-insensitive, so
+// Slug normalizes them to lowercase. An empty string means no slug.
+func Slug(s string) (picoshare.EntrySlug, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return picoshare.EntrySlug(""), nil
+	}
+	if len(s) < MinSlugLength {
+		return picoshare.EntrySlug(""), ErrSlugTooShort
+	}
+	if len(s) > MaxSlugLength {
+		return picoshare.EntrySlug(""), ErrSlugTooLong
+	}
+	for _, c := range s {
+		if !(c >= 'a' && c <= 'z') && !(c >= '0' && c <= '9') && c != '-' {
+			return picoshare.EntrySlug(""), ErrSlugInvalidCharacter
+		}
+	}
+	if strings.HasPrefix(s, "-") || strings.HasSuffix(s, "-") || strings.Contains(s, "--") {
+		return picoshare.EntrySlug(""), ErrSlugInvalidHyphen
+	}
+	if reservedSlugs[s] {
+		return picoshare.EntrySlug(""), ErrSlugReserved
+	}
+	return picoshare.EntrySlug(s), nil
+}
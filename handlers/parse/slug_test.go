@@ -0,0 +1,104 @@
+package parse_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mtlynch/picoshare/handlers/parse"
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+func TestSlug(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		input       string
+		output      picoshare.EntrySlug
+		err         error
+	}{
+		{
+			description: "accepts slug with letters, numbers, and hyphens",
+			input:       "q3-report-2024",
+			output:      picoshare.EntrySlug("q3-report-2024"),
+		},
+		{
+			description: "normalizes slug to lowercase without surrounding whitespace",
+			input:       " Holiday-Photos ",
+			output:      picoshare.EntrySlug("holiday-photos"),
+		},
+		{
+			description: "treats empty string as no slug",
+			input:       "",
+			output:      picoshare.EntrySlug(""),
+		},
+		{
+			description: "accepts slug of minimum length",
+			input:       strings.Repeat("a", parse.MinSlugLength),
+			output:      picoshare.EntrySlug(strings.Repeat("a", parse.MinSlugLength)),
+		},
+		{
+			description: "accepts slug of maximum length",
+			input:       strings.Repeat("a", parse.MaxSlugLength),
+			output:      picoshare.EntrySlug(strings.Repeat("a", parse.MaxSlugLength)),
+		},
+		{
+			description: "rejects slug that's too short",
+			input:       "ab",
+			err:         parse.ErrSlugTooShort,
+		},
+		{
+			description: "rejects slug that's too long",
+			input:       strings.Repeat("a", parse.MaxSlugLength+1),
+			err:         parse.ErrSlugTooLong,
+		},
+		{
+			description: "rejects slug with a slash",
+			input:       "reports/q3",
+			err:         parse.ErrSlugInvalidCharacter,
+		},
+		{
+			description: "rejects slug with a space",
+			input:       "q3 report",
+			err:         parse.ErrSlugInvalidCharacter,
+		},
+		{
+			description: "rejects slug with non-ASCII letters",
+			input:       "bücher",
+			err:         parse.ErrSlugInvalidCharacter,
+		},
+		{
+			description: "rejects slug with a leading hyphen",
+			input:       "-report",
+			err:         parse.ErrSlugInvalidHyphen,
+		},
+		{
+			description: "rejects slug with a trailing hyphen",
+			input:       "report-",
+			err:         parse.ErrSlugInvalidHyphen,
+		},
+		{
+			description: "rejects slug with consecutive hyphens",
+			input:       "q3--report",
+			err:         parse.ErrSlugInvalidHyphen,
+		},
+		{
+			description: "rejects reserved slug",
+			input:       "settings",
+			err:         parse.ErrSlugReserved,
+		},
+		{
+			description: "rejects reserved slug regardless of case",
+			input:       "Admin",
+			err:         parse.ErrSlugReserved,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			slug, err := parse.Slug(tt.input)
+			if got, want := err, tt.err; got != want {
+				t.Fatalf("err=%v, want=%v", got, want)
+			}
+			if got, want := slug, tt.output; got != want {
+				t.Errorf("slug=%v, want=%v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package parse
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/alecthomas/chroma/v2/lexers"
+
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+// MaxSnippetBytes is the maximum size of a text snippet. Snippets are meant for
+// short pastes, and PicoShare renders them in full, so larger text belongs in
+// a file upload.
+const MaxSnippetBytes = 1024 * 1024
+
+var (
+	ErrSnippetEmpty               = errors.New("snippet must be non-empty")
+	ErrSnippetTooLarge            = errors.New("snippet is too large")
+	ErrSnippetInvalidUTF8         = errors.New("snippet must be valid UTF-8 text")
+	ErrSnippetLanguageUnsupported = errors.New("unsupported snippet language")
+)
+
+// SnippetContent validates the text of a snippet.
+func SnippetContent(s string) (string, error) {
+	if s == "" {
+		return "", ErrSnippetEmpty
+	}
+	if len(s) > MaxSnippetBytes {
+		return "", ErrSnippetTooLarge
+	}
+	if !utf8.ValidString(s) {
+		return "", ErrSnippetInvalidUTF8
+	}
+	return s, nil
+}
+
+// SnippetLanguage parses a language name or alias (e.g., "Python" or "py")
+// into the canonical name of a language PicoShare can highlight. An empty
+// string means PicoShare should detect the language.
+func SnippetLanguage(s string) (picoshare.SnippetLanguage, error) {
+	if s == "" {
+		return picoshare.SnippetLanguage(""), nil
+	}
+	lexer := lexers.Get(s)
+	if lexer == nil {
+		return picoshare.SnippetLanguage(""), ErrSnippetLanguageUnsupported
+	}
+	return picoshare.SnippetLanguage(strings.ToLower(lexer.Config().Name)), nil
+}
+
+// SnippetFilename derives a filename for a snippet from its title. If the
+// title is empty, the filename is "snippet" with an extension that matches the
+// language.
+func SnippetFilename(title string, language picoshare.SnippetLanguage) (picoshare.Filename, error) {
+	if title != "" {
+		return Filename(title)
+	}
+
+	ext := ".txt"
+	if language != "" {
+		if lexer := lexers.Get(language.String()); lexer != nil {
+			for _, pattern := range lexer.Config().Filenames {
+				// Use the first simple extension pattern, like "*.py".
+				if candidate, ok := strings.CutPrefix(pattern, "*"); ok && !strings.ContainsAny(candidate, "*?[") {
+					ext = candidate
+					break
+				}
+			}
+		}
+	}
+
+	return picoshare.Filename("snippet" + ext), nil
+}
@@ -0,0 +1,134 @@
+package parse_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mtlynch/picoshare/handlers/parse"
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+func TestSnippetContent(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		input       string
+		err         error
+	}{
+		{
+			description: "valid snippet",
+			input:       "print('hello')",
+			err:         nil,
+		},
+		{
+			description: "snippet of maximum size",
+			input:       strings.Repeat("A", parse.MaxSnippetBytes),
+			err:         nil,
+		},
+		{
+			description: "empty snippet",
+			input:       "",
+			err:         parse.ErrSnippetEmpty,
+		},
+		{
+			description: "snippet that's too large",
+			input:       strings.Repeat("A", parse.MaxSnippetBytes+1),
+			err:         parse.ErrSnippetTooLarge,
+		},
+		{
+			description: "snippet with invalid UTF-8",
+			input:       "hello \xff",
+			err:         parse.ErrSnippetInvalidUTF8,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			_, err := parse.SnippetContent(tt.input)
+			if got, want := err, tt.err; got != want {
+				t.Fatalf("err=%v, want=%v", got, want)
+			}
+		})
+	}
+}
+
+func TestSnippetLanguage(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		input       string
+		output      picoshare.SnippetLanguage
+		err         error
+	}{
+		{
+			description: "empty language",
+			input:       "",
+			output:      "",
+		},
+		{
+			description: "canonical name",
+			input:       "python",
+			output:      "python",
+		},
+		{
+			description: "alias with different case",
+			input:       "PY",
+			output:      "python",
+		},
+		{
+			description: "unknown language",
+			input:       "not-a-real-language",
+			err:         parse.ErrSnippetLanguageUnsupported,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			language, err := parse.SnippetLanguage(tt.input)
+			if got, want := err, tt.err; got != want {
+				t.Fatalf("err=%v, want=%v", got, want)
+			}
+			if got, want := language, tt.output; got != want {
+				t.Errorf("language=%s, want=%s", got, want)
+			}
+		})
+	}
+}
+
+func TestSnippetFilename(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		title       string
+		language    picoshare.SnippetLanguage
+		output      picoshare.Filename
+		err         error
+	}{
+		{
+			description: "uses title as filename",
+			title:       "deploy.sh",
+			language:    "bash",
+			output:      "deploy.sh",
+		},
+		{
+			description: "derives extension from language",
+			title:       "",
+			language:    "python",
+			output:      "snippet.py",
+		},
+		{
+			description: "uses txt without language",
+			title:       "",
+			language:    "",
+			output:      "snippet.txt",
+		},
+		{
+			description: "rejects invalid title",
+			title:       "../etc/passwd",
+			err:         parse.ErrFilenameHasDotPrefix,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			filename, err := parse.SnippetFilename(tt.title, tt.language)
+			if got, want := err, tt.err; got != want {
+				t.Fatalf("err=%v, want=%v", got, want)
+			}
+			if got, want := filename, tt.output; got != want {
+				t.Errorf("filename=%s, want=%s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package parse
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+const (
+	// MaxTagLength is the maximum number of characters in a tag.
+	MaxTagLength = 40
+	// MaxTagsPerEntry is the maximum number of tags on a single entry.
+	MaxTagsPerEntry = 20
+	// MaxFolderLength is the maximum number of characters in a folder path.
+	MaxFolderLength = 200
+)
+
+var (
+	ErrTagEmpty               = errors.New("tag must be non-empty")
+	ErrTagTooLong             = fmt.Errorf("tag too long - limit %d characters", MaxTagLength)
+	ErrTagInvalidCharacter    = errors.New("tag must not contain commas or control characters")
+	ErrTooManyTags            = fmt.Errorf("too many tags - limit %d per file", MaxTagsPerEntry)
+	ErrFolderTooLong          = fmt.Errorf("folder too long - limit %d characters", MaxFolderLength)
+	ErrFolderInvalidCharacter = errors.New("folder must not contain control characters")
+	ErrFolderInvalidSegment   = errors.New("folder names must be non-empty and can't be . or ..")
+)
+
+// Tag parses a tag. Tags are case-insensitive, so Tag normalizes them to
+// lowercase.
+func Tag(s string) (picoshare.Tag, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return picoshare.Tag(""), ErrTagEmpty
+	}
+	if len([]rune(s)) > MaxTagLength {
+		return picoshare.Tag(""), ErrTagTooLong
+	}
+	// Commas separate tags in the UI, so they can't be part of a tag.
+	if strings.ContainsFunc(s, func(r rune) bool {
+		return r == ',' || unicode.IsControl(r)
+	}) {
+		return picoshare.Tag(""), ErrTagInvalidCharacter
+	}
+	if err := checkJavaScriptNullOrUndefined(s); err != nil {
+		return picoshare.Tag(""), err
+	}
+	return picoshare.Tag(s), nil
+}
+
+// Tags parses a list of tags and removes duplicates.
+func Tags(raw []string) ([]picoshare.Tag, error) {
+	tags := []picoshare.Tag{}
+	seen := map[picoshare.Tag]bool{}
+	for _, s := range raw {
+		tag, err := Tag(s)
+		if err != nil {
+			return []picoshare.Tag{}, err
+		}
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	if len(tags) > MaxTagsPerEntry {
+		return []picoshare.Tag{}, ErrTooManyTags
+	}
+	return tags, nil
+}
+
+// Folder parses a folder path such as "clients/acme". It ignores leading and
+// trailing slashes and whitespace around each folder name. An empty string
+// means no folder.
+func Folder(s string) (picoshare.Folder, error) {
+	s = strings.Trim(strings.TrimSpace(s), "/")
+	if s == "" {
+		return picoshare.Folder(""), nil
+	}
+	if len([]rune(s)) > MaxFolderLength {
+		return picoshare.Folder(""), ErrFolderTooLong
+	}
+	if strings.ContainsFunc(s, unicode.IsControl) {
+		return picoshare.Folder(""), ErrFolderInvalidCharacter
+	}
+	if err := checkJavaScriptNullOrUndefined(s); err != nil {
+		return picoshare.Folder(""), err
+	}
+
+	segments := strings.Split(s, "/")
+	for i, segment := range segments {
+		segment = strings.TrimSpace(segment)
+		if segment == "" || segment == "." || segment == ".." {
+			return picoshare.Folder(""), ErrFolderInvalidSegment
+		}
+		segments[i] = segment
+	}
+
+	return picoshare.Folder(strings.Join(segments, "/")), nil
+}
@@ -0,0 +1,141 @@
+package parse_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+
+	"github.com/mtlynch/picoshare/handlers/parse"
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+func TestTags(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		input       []string
+		output      []picoshare.Tag
+		err         error
+	}{
+		{
+			description: "normalizes tags to lowercase without surrounding whitespace",
+			input:       []string{" Invoices ", "2024"},
+			output:      []picoshare.Tag{"invoices", "2024"},
+		},
+		{
+			description: "removes duplicate tags",
+			input:       []string{"photos", "Photos", "PHOTOS"},
+			output:      []picoshare.Tag{"photos"},
+		},
+		{
+			description: "accepts empty list",
+			input:       []string{},
+			output:      []picoshare.Tag{},
+		},
+		{
+			description: "accepts tag of maximum length with multibyte characters",
+			input:       []string{strings.Repeat("ö", parse.MaxTagLength)},
+			output:      []picoshare.Tag{picoshare.Tag(strings.Repeat("ö", parse.MaxTagLength))},
+		},
+		{
+			description: "rejects empty tag",
+			input:       []string{"photos", "  "},
+			output:      []picoshare.Tag{},
+			err:         parse.ErrTagEmpty,
+		},
+		{
+			description: "rejects tag that's too long",
+			input:       []string{strings.Repeat("a", parse.MaxTagLength+1)},
+			output:      []picoshare.Tag{},
+			err:         parse.ErrTagTooLong,
+		},
+		{
+			description: "rejects tag with a comma",
+			input:       []string{"a,b"},
+			output:      []picoshare.Tag{},
+			err:         parse.ErrTagInvalidCharacter,
+		},
+		{
+			description: "rejects tag with a control character",
+			input:       []string{"a\nb"},
+			output:      []picoshare.Tag{},
+			err:         parse.ErrTagInvalidCharacter,
+		},
+		{
+			description: "rejects too many tags",
+			input:       strings.Split("a,b,c,d,e,f,g,h,i,j,k,l,m,n,o,p,q,r,s,t,u", ","),
+			output:      []picoshare.Tag{},
+			err:         parse.ErrTooManyTags,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			tags, err := parse.Tags(tt.input)
+			if got, want := err, tt.err; got != want {
+				t.Fatalf("err=%v, want=%v", got, want)
+			}
+			if diff := deep.Equal(tags, tt.output); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestFolder(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		input       string
+		output      picoshare.Folder
+		err         error
+	}{
+		{
+			description: "accepts single folder",
+			input:       "Invoices",
+			output:      "Invoices",
+		},
+		{
+			description: "normalizes slashes and whitespace in nested folders",
+			input:       " /clients / Acme Corp/ ",
+			output:      "clients/Acme Corp",
+		},
+		{
+			description: "treats empty string as no folder",
+			input:       "",
+			output:      "",
+		},
+		{
+			description: "treats lone slash as no folder",
+			input:       "/",
+			output:      "",
+		},
+		{
+			description: "rejects empty folder name",
+			input:       "clients//acme",
+			err:         parse.ErrFolderInvalidSegment,
+		},
+		{
+			description: "rejects parent folder reference",
+			input:       "clients/../secrets",
+			err:         parse.ErrFolderInvalidSegment,
+		},
+		{
+			description: "rejects control characters",
+			input:       "clients\tacme",
+			err:         parse.ErrFolderInvalidCharacter,
+		},
+		{
+			description: "rejects folder that's too long",
+			input:       strings.Repeat("a", parse.MaxFolderLength+1),
+			err:         parse.ErrFolderTooLong,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			folder, err := parse.Folder(tt.input)
+			if got, want := err, tt.err; got != want {
+				t.Fatalf("err=%v, want=%v", got, want)
+			}
+			if got, want := folder, tt.output; got != want {
+				t.Errorf("folder=%v, want=%v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package parse
+
+import (
+	"errors"
+
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+var ErrVisibilityInvalid = errors.New("visibility must be public, private, or signed")
+
+// Visibility parses the name of an entry visibility. An empty string means
+// public.
+func Visibility(s string) (picoshare.Visibility, error) {
+	switch s {
+	case "", picoshare.VisibilityPublic.String():
+		return picoshare.VisibilityPublic, nil
+	case picoshare.VisibilityPrivate.String():
+		return picoshare.VisibilityPrivate, nil
+	case picoshare.VisibilitySignedLinkOnly.String():
+		return picoshare.VisibilitySignedLinkOnly, nil
+	}
+	return picoshare.VisibilityPublic, ErrVisibilityInvalid
+}
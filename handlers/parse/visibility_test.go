@@ -0,0 +1,58 @@
+package parse_test
+
+import (
+	"testing"
+
+	"github.com/mtlynch/picoshare/handlers/parse"
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+func TestVisibility(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		input       string
+		output      picoshare.Visibility
+		err         error
+	}{
+		{
+			description: "accepts public",
+			input:       "public",
+			output:      picoshare.VisibilityPublic,
+		},
+		{
+			description: "treats empty string as public",
+			input:       "",
+			output:      picoshare.VisibilityPublic,
+		},
+		{
+			description: "accepts private",
+			input:       "private",
+			output:      picoshare.VisibilityPrivate,
+		},
+		{
+			description: "accepts signed",
+			input:       "signed",
+			output:      picoshare.VisibilitySignedLinkOnly,
+		},
+		{
+			description: "rejects unknown visibility",
+			input:       "secret",
+			err:         parse.ErrVisibilityInvalid,
+		},
+		{
+			description: "rejects visibility with wrong case",
+			input:       "Private",
+			err:         parse.ErrVisibilityInvalid,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			visibility, err := parse.Visibility(tt.input)
+			if got, want := err, tt.err; got != want {
+				t.Fatalf("err=%v, want=%v", got, want)
+			}
+			if got, want := visibility, tt.output; got != want {
+				t.Errorf("visibility=%v, want=%v", got, want)
+			}
+		})
+	}
+}
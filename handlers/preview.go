@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/preview"
+	"github.com/mtlynch/picoshare/store"
+)
+
+func (s Server) entryViewGet() http.HandlerFunc {
+	fns := template.FuncMap{
+		"formatFileSize":  humanReadableFileSize,
+		"formatDiskUsage": humanReadableDiskUsage,
+	}
+	t := parseTemplatesWithFuncs(fns, "templates/pages/file-view.html")
+
+	codeCSS, err := preview.CodeCSS()
+	if err != nil {
+		panic(err)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseEntryID(mux.Vars(r)["id"])
+		if err != nil {
+			log.Printf("error parsing ID: %v", err)
+			http.Error(w, fmt.Sprintf("bad entry ID: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		entry, err := s.getDB(r).GetEntryMetadata(id)
+		if _, ok := errors.AsType[store.EntryNotFoundError](err); ok {
+			http.Error(w, "entry not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("error retrieving entry with id %v: %v", id, err)
+			http.Error(w, "failed to retrieve entry", http.StatusInternalServerError)
+			return
+		}
+
+		if !authorizeEntryView(w, r, entry) {
+			return
+		}
+
+		if !s.checkEntryAvailable(w, r, nil, entry) {
+			return
+		}
+
+		if !checkEntryScan(w, entry) {
+			return
+		}
+
+		kind := preview.Detect(entry.ContentType, entry.Filename)
+		tooLarge := false
+		var rendered template.HTML
+		var archive preview.ArchiveListing
+		var archiveErr error
+
+		switch kind {
+		case preview.KindMarkdown, preview.KindCode:
+			if entry.Size.UInt64() > preview.MaxTextBytes {
+				tooLarge = true
+				break
+			}
+			src, err := s.readEntryText(r, id)
+			if err != nil {
+				log.Printf("failed to read entry %v for preview: %v", id, err)
+				http.Error(w, "failed to retrieve entry", http.StatusInternalServerError)
+				return
+			}
+			if !utf8.Valid(src) {
+				// The file isn't text after all, so we can't preview it.
+				kind = preview.KindNone
+				break
+			}
+			if kind == preview.KindMarkdown {
+				rendered, err = preview.RenderMarkdown(src)
+			} else {
+				rendered, err = preview.HighlightCode(src, entry.Filename, entry.ContentType)
+			}
+			if err != nil {
+				log.Printf("failed to render preview of entry %v: %v", id, err)
+				http.Error(w, "failed to render preview", http.StatusInternalServerError)
+				return
+			}
+			download, err := recordDownload(s.getDB(r), entry, s.clock.Now(), r.RemoteAddr, r.Header.Get("User-Agent"))
+			if err != nil {
+				log.Printf("failed to record download of file %s: %v", id.String(), err)
+			}
+			s.notifySensitiveDownload(r, entry, download)
+		case preview.KindZip, preview.KindTar:
+			entryFile, err := s.getDB(r).ReadEntryFile(id)
+			if err != nil {
+				log.Printf("error retrieving entry data with id %v: %v", id, err)
+				http.Error(w, "failed to retrieve entry", http.StatusInternalServerError)
+				return
+			}
+			if kind == preview.KindZip {
+				archive, archiveErr = preview.ListZip(entryFile, int64(entry.Size.UInt64()))
+			} else {
+				archive, archiveErr = preview.ListTar(entryFile, entry.Filename)
+			}
+			if archiveErr != nil {
+				log.Printf("failed to list contents of archive %v: %v", id, archiveErr)
+			}
+		}
+
+		if err := t.Execute(w, struct {
+			commonProps
+			Metadata     picoshare.UploadMetadata
+			Kind         preview.Kind
+			TooLarge     bool
+			Rendered     template.HTML
+			CodeCSS      template.CSS
+			Archive      preview.ArchiveListing
+			ArchiveError error
+		}{
+			commonProps:  makeCommonProps(fmt.Sprintf("PicoShare - %s", entry.Filename), r.Context()),
+			Metadata:     entry,
+			Kind:         kind,
+			TooLarge:     tooLarge,
+			Rendered:     rendered,
+			CodeCSS:      codeCSS,
+			Archive:      archive,
+			ArchiveError: archiveErr,
+		}); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func (s Server) readEntryText(r *http.Request, id picoshare.EntryID) ([]byte, error) {
+	entryFile, err := s.getDB(r).ReadEntryFile(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(io.LimitReader(entryFile, preview.MaxTextBytes))
+}
@@ -0,0 +1,134 @@
+package handlers_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mtlynch/picoshare/handlers"
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/store/test_sqlite"
+)
+
+func TestEntryViewGet(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		filename    picoshare.Filename
+		contentType picoshare.ContentType
+		contents    string
+		path        string
+		status      int
+		contains    string
+	}{
+		{
+			description: "renders markdown",
+			filename:    "notes.md",
+			contentType: "text/markdown",
+			contents:    "# Hello, world",
+			path:        "/-AAAAAAAAAA/_/view",
+			status:      http.StatusOK,
+			contains:    "<h1>Hello, world</h1>",
+		},
+		{
+			description: "highlights source code",
+			filename:    "main.py",
+			contentType: "text/x-python",
+			contents:    "print('hi')",
+			path:        "/-AAAAAAAAAA/_/view",
+			status:      http.StatusOK,
+			contains:    `class="chroma"`,
+		},
+		{
+			description: "shows media player for video",
+			filename:    "clip.mp4",
+			contentType: "video/mp4",
+			contents:    "dummy video",
+			path:        "/-AAAAAAAAAA/_/view",
+			status:      http.StatusOK,
+			contains:    `<video class="preview-media" controls src="/-AAAAAAAAAA">`,
+		},
+		{
+			description: "returns 404 for non-existent entry",
+			filename:    "notes.md",
+			contentType: "text/markdown",
+			contents:    "# Hello, world",
+			path:        "/-BBBBBBBBBB/_/view",
+			status:      http.StatusNotFound,
+		},
+		{
+			description: "serves raw file for other filenames",
+			filename:    "notes.md",
+			contentType: "text/markdown",
+			contents:    "# Hello, world",
+			path:        "/-AAAAAAAAAA/notes.md",
+			status:      http.StatusOK,
+			contains:    "# Hello, world",
+		},
+		{
+			description: "serves file whose filename is view",
+			filename:    "view",
+			contentType: "text/markdown",
+			contents:    "# Hello, world",
+			path:        "/-AAAAAAAAAA/view",
+			status:      http.StatusOK,
+			contains:    "# Hello, world",
+		},
+		{
+			description: "serves file whose filename is raw",
+			filename:    "raw",
+			contentType: "text/markdown",
+			contents:    "# Hello, world",
+			path:        "/-AAAAAAAAAA/raw",
+			status:      http.StatusOK,
+			contains:    "# Hello, world",
+		},
+		{
+			description: "serves file whose filename is thumbnail",
+			filename:    "thumbnail",
+			contentType: "text/markdown",
+			contents:    "# Hello, world",
+			path:        "/-AAAAAAAAAA/thumbnail",
+			status:      http.StatusOK,
+			contains:    "# Hello, world",
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			dataStore := test_sqlite.New()
+			if err := dataStore.InsertEntry(strings.NewReader(tt.contents), picoshare.UploadMetadata{
+				ID:          "AAAAAAAAAA",
+				Filename:    tt.filename,
+				ContentType: tt.contentType,
+				Uploaded:    mustParseTime("2023-01-01T00:00:00Z"),
+				Expires:     picoshare.NeverExpire,
+				Size:        mustParseFileSize(len(tt.contents)),
+			}); err != nil {
+				panic(err)
+			}
+
+			s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock(), handlers.Options{})
+
+			req, err := http.NewRequest("GET", tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+			res := rec.Result()
+
+			if got, want := res.StatusCode, tt.status; got != want {
+				t.Fatalf("status=%d, want=%d", got, want)
+			}
+
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("failed to read response body: %v", err)
+			}
+			if !strings.Contains(string(body), tt.contains) {
+				t.Errorf("body=%s, want it to contain %s", body, tt.contains)
+			}
+		})
+	}
+}
@@ -12,6 +12,15 @@ func (s *Server) routes() {
 	authenticatedApis.HandleFunc("/entry", s.entryPost()).Methods(http.MethodPost)
 	authenticatedApis.HandleFunc("/entry/{id}", s.entryPut()).Methods(http.MethodPut)
 	authenticatedApis.HandleFunc("/entry/{id}", s.entryDelete()).Methods(http.MethodDelete)
+	authenticatedApis.HandleFunc("/entry/{id}/versions", s.entryVersionPost()).Methods(http.MethodPost)
+	authenticatedApis.HandleFunc("/entry/{id}/signed-urls", s.signedURLPost()).Methods(http.MethodPost)
+	authenticatedApis.HandleFunc("/entry/{id}/link-emails", s.linkEmailPost()).Methods(http.MethodPost)
+	authenticatedApis.HandleFunc("/entry/{id}/extend", s.entryExtendPost()).Methods(http.MethodPost)
+	authenticatedApis.HandleFunc("/entries/expiring", s.expiringEntriesGet()).Methods(http.MethodGet)
+	authenticatedApis.HandleFunc("/entries/batch", s.entriesBatchPost()).Methods(http.MethodPost)
+	authenticatedApis.HandleFunc("/trash/{id}", s.trashDelete()).Methods(http.MethodDelete)
+	authenticatedApis.HandleFunc("/trash/{id}/restore", s.trashRestorePost()).Methods(http.MethodPost)
+	authenticatedApis.HandleFunc("/snippet", s.snippetPost()).Methods(http.MethodPost)
 	authenticatedApis.HandleFunc("/guest-links", s.guestLinksPost()).Methods(http.MethodPost)
 	authenticatedApis.HandleFunc("/guest-links/{id}", s.guestLinksDelete()).Methods(http.MethodDelete)
 	authenticatedApis.HandleFunc("/guest-links/{id}/enable", s.guestLinksEnableDisable()).Methods(http.MethodPut)
@@ -20,6 +29,7 @@ func (s *Server) routes() {
 
 	publicApis := s.router.PathPrefix("/api").Subrouter()
 	publicApis.HandleFunc("/guest/{guestLinkID}", s.guestEntryPost()).Methods(http.MethodPost)
+	publicApis.HandleFunc("/guest/{guestLinkID}/challenge", s.guestChallengeGet()).Methods(http.MethodGet)
 
 	static := s.router.PathPrefix("/").Subrouter()
 	static.PathPrefix("/css/").HandlerFunc(serveStaticResource()).Methods(http.MethodGet)
@@ -47,20 +57,36 @@ func (s *Server) routes() {
 	authenticatedViews.Use(enforceContentSecurityPolicy)
 	authenticatedViews.HandleFunc("/information", s.systemInformationGet()).Methods(http.MethodGet)
 	authenticatedViews.HandleFunc("/files", s.fileIndexGet()).Methods(http.MethodGet)
+	authenticatedViews.HandleFunc("/files/expiring", s.filesExpiringGet()).Methods(http.MethodGet)
 	authenticatedViews.HandleFunc("/files/{id}/downloads", s.fileDownloadsGet()).Methods(http.MethodGet)
 	authenticatedViews.HandleFunc("/files/{id}/edit", s.fileEditGet()).Methods(http.MethodGet)
 	authenticatedViews.HandleFunc("/files/{id}/info", s.fileInfoGet()).Methods(http.MethodGet)
+	authenticatedViews.HandleFunc("/files/{id}/thumbnail", s.fileThumbnailGet()).Methods(http.MethodGet)
 	authenticatedViews.HandleFunc("/files/{id}/confirm-delete", s.fileConfirmDeleteGet()).Methods(http.MethodGet)
+	authenticatedViews.HandleFunc("/trash", s.trashGet()).Methods(http.MethodGet)
+	authenticatedViews.HandleFunc("/snippets/new", s.snippetNewGet()).Methods(http.MethodGet)
 	authenticatedViews.HandleFunc("/guest-links", s.guestLinkIndexGet()).Methods(http.MethodGet)
 	authenticatedViews.HandleFunc("/guest-links/new", s.guestLinksNewGet()).Methods(http.MethodGet)
 	authenticatedViews.HandleFunc("/settings", s.settingsGet()).Methods(http.MethodGet)
+	authenticatedViews.HandleFunc("/notifications", s.notificationsGet()).Methods(http.MethodGet)
 
 	views := s.router.PathPrefix("/").Subrouter()
 	views.Use(upgradeToHttps)
 	views.Use(enforceContentSecurityPolicy)
 	views.HandleFunc("/login", s.authGet()).Methods(http.MethodGet)
+	views.HandleFunc("/oembed", s.oembedGet()).Methods(http.MethodGet)
+	// The preview, raw, and thumbnail routes live under /_/ rather than directly
+	// under the entry, because /-{id}/{filename} downloads the entry for any
+	// filename, so /-{id}/view would hijack download links to a file named
+	// "view". /_/ can't collide with a filename because filenames can't contain
+	// slashes. These routes must come before the entry routes, which match any
+	// path under the entry.
+	views.HandleFunc("/-{id}/_/view", s.entryViewGet()).Methods(http.MethodGet)
+	views.HandleFunc("/-{id}/_/raw", s.entryRawGet()).Methods(http.MethodGet)
+	views.HandleFunc("/-{id}/_/thumbnail", s.entryThumbnailGet()).Methods(http.MethodGet)
 	views.PathPrefix("/-{id}").HandlerFunc(s.entryGet()).Methods(http.MethodGet)
 	views.PathPrefix("/-{id}/{filename}").HandlerFunc(s.entryGet()).Methods(http.MethodGet)
+	views.PathPrefix("/s/{slug}").HandlerFunc(s.entrySlugGet()).Methods(http.MethodGet)
 	// Legacy routes for entries. We stopped using them because the ! has
 	// unintended side effects within the bash shell.
 	views.PathPrefix("/!{id}").HandlerFunc(s.entryGet()).Methods(http.MethodGet)
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+// scanPendingRetryAfter is how long clients should wait before retrying a
+// download whose malware scan hasn't finished.
+const scanPendingRetryAfter = "10"
+
+// checkEntryScan writes an error response and returns false if the entry's
+// malware scan prevents serving it, either because the scan found malware or
+// because no scan has succeeded yet.
+func checkEntryScan(w http.ResponseWriter, entry picoshare.UploadMetadata) bool {
+	switch {
+	case entry.Scan.IsInfected():
+		log.Printf("refusing to serve quarantined entry %v", entry.ID)
+		http.Error(w, "This file has been quarantined because it contains malware", http.StatusForbidden)
+		return false
+	case entry.Scan.IsPending():
+		w.Header().Set("Retry-After", scanPendingRetryAfter)
+		http.Error(w, "This file is still being scanned for malware. Try again in a few seconds.", http.StatusServiceUnavailable)
+		return false
+	case !entry.Scan.IsServable():
+		log.Printf("refusing to serve entry %v because its malware scan failed", entry.ID)
+		http.Error(w, "This file couldn't be scanned for malware yet. PicoShare will try again later.", http.StatusServiceUnavailable)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,235 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mtlynch/picoshare/handlers"
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/scan"
+	"github.com/mtlynch/picoshare/store/test_sqlite"
+)
+
+type mockScanner struct {
+	result picoshare.ScanResult
+	err    error
+	// release blocks scans until the test closes it.
+	release chan struct{}
+	// scanned holds the data that the server sent to the scanner.
+	scanned []byte
+}
+
+func newMockScanner(result picoshare.ScanResult, err error) *mockScanner {
+	return &mockScanner{
+		result:  result,
+		err:     err,
+		release: make(chan struct{}),
+	}
+}
+
+func (ms *mockScanner) Scan(r io.Reader) (picoshare.ScanResult, error) {
+	data := mustReadAll(r)
+	<-ms.release
+	ms.scanned = data
+	return ms.result, ms.err
+}
+
+// waitForScan waits for the background malware scans of every version of the
+// given entry to finish and returns the entry's metadata.
+func waitForScan(t *testing.T, dataStore handlers.Store, id picoshare.EntryID) picoshare.UploadMetadata {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		versions, err := dataStore.GetEntryVersions(id)
+		if err != nil {
+			t.Fatalf("failed to get versions of entry %v from data store: %v", id, err)
+		}
+		if !slices.ContainsFunc(versions, func(v picoshare.EntryVersion) bool {
+			return v.Scan.IsPending()
+		}) {
+			entry, err := dataStore.GetEntryMetadata(id)
+			if err != nil {
+				t.Fatalf("failed to get entry %v from data store: %v", id, err)
+			}
+			return entry
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for malware scan of entry %v", id)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestUploadScan(t *testing.T) {
+	for _, tt := range []struct {
+		description    string
+		scanner        *mockScanner
+		expectedScan   picoshare.ScanResult
+		downloadStatus int
+	}{
+		{
+			description:    "serves clean file",
+			scanner:        newMockScanner(picoshare.ScanResult{Status: picoshare.ScanStatusClean}, nil),
+			expectedScan:   picoshare.ScanResult{Status: picoshare.ScanStatusClean},
+			downloadStatus: http.StatusOK,
+		},
+		{
+			description: "quarantines infected file",
+			scanner: newMockScanner(picoshare.ScanResult{
+				Status: picoshare.ScanStatusInfected,
+				Detail: "Eicar-Test-Signature",
+			}, nil),
+			expectedScan: picoshare.ScanResult{
+				Status: picoshare.ScanStatusInfected,
+				Detail: "Eicar-Test-Signature",
+			},
+			downloadStatus: http.StatusForbidden,
+		},
+		{
+			description: "withholds file and records failure when scan fails",
+			scanner:     newMockScanner(picoshare.ScanResult{}, errors.New("dummy clamd error")),
+			expectedScan: picoshare.ScanResult{
+				Status: picoshare.ScanStatusError,
+				Detail: "dummy clamd error",
+			},
+			downloadStatus: http.StatusServiceUnavailable,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			dataStore := test_sqlite.New()
+			scans := scan.NewWorker(tt.scanner, 1)
+			scans.StartAsync()
+			s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock(), handlers.Options{Scans: scans})
+
+			contents := "dummy bytes"
+			formData, contentType := createMultipartFormBody("dummyimage.png", "", strings.NewReader(contents))
+			req, err := http.NewRequest("POST", "/api/entry?expiration=2040-01-01T00:00:00Z", formData)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Add("Content-Type", contentType)
+
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+			res := rec.Result()
+
+			if got, want := res.StatusCode, http.StatusOK; got != want {
+				t.Fatalf("upload status=%d, want=%d", got, want)
+			}
+
+			var response handlers.EntryPostResponse
+			if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+				t.Fatalf("response is not valid JSON: %v", err)
+			}
+
+			id := picoshare.EntryID(response.ID)
+			entry, err := dataStore.GetEntryMetadata(id)
+			if err != nil {
+				t.Fatalf("failed to get entry %v from data store: %v", id, err)
+			}
+			if got, want := entry.Scan, (picoshare.ScanResult{Status: picoshare.ScanStatusPending}); got != want {
+				t.Errorf("scan before scanner finished=%+v, want=%+v", got, want)
+			}
+
+			req, err = http.NewRequest("GET", "/-"+response.ID, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rec = httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+
+			if got, want := rec.Result().StatusCode, http.StatusServiceUnavailable; got != want {
+				t.Errorf("download status before scanner finished=%d, want=%d", got, want)
+			}
+
+			close(tt.scanner.release)
+			entry = waitForScan(t, &dataStore, id)
+
+			if got, want := string(tt.scanner.scanned), contents; got != want {
+				t.Errorf("scanned data=%s, want=%s", got, want)
+			}
+			if got, want := entry.Scan, tt.expectedScan; got != want {
+				t.Errorf("scan=%+v, want=%+v", got, want)
+			}
+
+			req, err = http.NewRequest("GET", "/-"+response.ID, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rec = httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+
+			if got, want := rec.Result().StatusCode, tt.downloadStatus; got != want {
+				t.Errorf("download status=%d, want=%d", got, want)
+			}
+		})
+	}
+}
+
+func TestUploadScanOfReplacedVersion(t *testing.T) {
+	dataStore := test_sqlite.New()
+	scanner := newMockScanner(picoshare.ScanResult{
+		Status: picoshare.ScanStatusInfected,
+		Detail: "Eicar-Test-Signature",
+	}, nil)
+	scans := scan.NewWorker(scanner, 2)
+	scans.StartAsync()
+	s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock(), handlers.Options{Scans: scans})
+
+	formData, contentType := createMultipartFormBody("build-1.txt", "", strings.NewReader("first build"))
+	req, err := http.NewRequest("POST", "/api/entry?expiration=2040-01-01T00:00:00Z", formData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Content-Type", contentType)
+	rec := httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, req)
+	if got, want := rec.Result().StatusCode, http.StatusOK; got != want {
+		t.Fatalf("upload status=%d, want=%d", got, want)
+	}
+	var response handlers.EntryPostResponse
+	if err := json.NewDecoder(rec.Result().Body).Decode(&response); err != nil {
+		t.Fatalf("response is not valid JSON: %v", err)
+	}
+
+	// Replace the contents while the scan of the first version is still queued.
+	formData, contentType = createMultipartFormBody("build-2.txt", "", strings.NewReader("second build"))
+	req, err = http.NewRequest("POST", "/api/entry/"+response.ID+"/versions", formData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Content-Type", contentType)
+	rec = httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, req)
+	if got, want := rec.Result().StatusCode, http.StatusOK; got != want {
+		t.Fatalf("version upload status=%d, want=%d", got, want)
+	}
+
+	downloadStatus := func() int {
+		req, err := http.NewRequest("GET", "/-"+response.ID+"?v=1", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		s.Router().ServeHTTP(rec, req)
+		return rec.Result().StatusCode
+	}
+
+	if got, want := downloadStatus(), http.StatusServiceUnavailable; got != want {
+		t.Errorf("status of replaced version before scanner finished=%d, want=%d", got, want)
+	}
+
+	close(scanner.release)
+	waitForScan(t, &dataStore, picoshare.EntryID(response.ID))
+
+	if got, want := downloadStatus(), http.StatusForbidden; got != want {
+		t.Errorf("status of replaced version after scanner finished=%d, want=%d", got, want)
+	}
+}
@@ -6,8 +6,15 @@ import (
 
 	"github.com/gorilla/mux"
 
+	"github.com/mtlynch/picoshare/challenge"
 	"github.com/mtlynch/picoshare/garbagecollect"
+	"github.com/mtlynch/picoshare/notify"
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/random"
+	"github.com/mtlynch/picoshare/ratelimit"
+	"github.com/mtlynch/picoshare/scan"
 	"github.com/mtlynch/picoshare/space"
+	"github.com/mtlynch/picoshare/thumbnail"
 )
 
 type (
@@ -15,6 +22,12 @@ type (
 		Check() (space.Usage, error)
 	}
 
+	// Mailer sends email on the owner's behalf.
+	Mailer interface {
+		SendLink(entry picoshare.UploadMetadata, url, message string, to []string) (bool, error)
+		SendGuestLink(gl picoshare.GuestLink, url, message string, to []string) (bool, error)
+	}
+
 	Clock interface {
 		Now() time.Time
 	}
@@ -25,6 +38,29 @@ type (
 		Authenticate(r *http.Request) bool
 	}
 
+	// Options configures the server's optional features. The zero value
+	// disables all of them.
+	Options struct {
+		// DownloadLimitOverrides take precedence over the download limits in the
+		// server settings.
+		DownloadLimitOverrides picoshare.DownloadLimits
+		// Scans checks uploads for malware in the background. A nil worker
+		// disables malware scanning.
+		Scans *scan.Worker
+		// CompressUploads enables compression of uploads whose content types
+		// aren't already compressed.
+		CompressUploads bool
+		// Thumbnails generates thumbnails of uploaded images. A nil worker
+		// disables thumbnails.
+		Thumbnails *thumbnail.Worker
+		// Notifier notifies the owner about events. A nil Notifier disables
+		// notifications.
+		Notifier notify.Notifier
+		// Mailer sends email on the owner's behalf. A nil Mailer disables
+		// email.
+		Mailer Mailer
+	}
+
 	Server struct {
 		router        *mux.Router
 		authenticator Authenticator
@@ -32,6 +68,28 @@ type (
 		spaceChecker  SpaceChecker
 		collector     *garbagecollect.Collector
 		clock         Clock
+		guestUploads  *ratelimit.Window
+		// guestChallenges issues proof-of-work challenges for guest links that
+		// require them. Its key only lives in memory, so restarting the server
+		// invalidates outstanding challenges.
+		guestChallenges *challenge.Issuer
+		// downloadLimitOverrides take precedence over the download limits in the
+		// server settings.
+		downloadLimitOverrides picoshare.DownloadLimits
+		downloadBandwidth      *ratelimit.Bucket
+		entryBandwidth         *ratelimit.Buckets
+		activeDownloads        *ratelimit.ConcurrencyLimiter
+		// scans is nil if malware scanning is disabled.
+		scans *scan.Worker
+		// compressUploads enables compression of uploads whose content types
+		// aren't already compressed.
+		compressUploads bool
+		// thumbnails is nil if thumbnail generation is disabled.
+		thumbnails *thumbnail.Worker
+		// notifier is nil if the owner hasn't configured any notifications.
+		notifier notify.Notifier
+		// mailer is nil if no SMTP server is configured.
+		mailer Mailer
 	}
 )
 
@@ -42,7 +100,7 @@ func (s Server) Router() *mux.Router {
 
 // New creates a new server with all the state it needs to satisfy HTTP
 // requests.
-func New(authenticator Authenticator, store Store, spaceChecker SpaceChecker, collector *garbagecollect.Collector, clock Clock) Server {
+func New(authenticator Authenticator, store Store, spaceChecker SpaceChecker, collector *garbagecollect.Collector, clock Clock, opts Options) Server {
 	s := Server{
 		router:        mux.NewRouter(),
 		authenticator: authenticator,
@@ -50,6 +108,19 @@ func New(authenticator Authenticator, store Store, spaceChecker SpaceChecker, co
 		spaceChecker:  spaceChecker,
 		collector:     collector,
 		clock:         clock,
+		guestUploads:  ratelimit.NewWindow(guestUploadRateLimitRetention),
+
+		guestChallenges: challenge.NewIssuer(random.Bytes(32), guestChallengeDifficulty, guestChallengeLifetime),
+
+		downloadLimitOverrides: opts.DownloadLimitOverrides,
+		downloadBandwidth:      ratelimit.NewBucket(0),
+		entryBandwidth:         ratelimit.NewBuckets(entryBandwidthIdleTimeout),
+		activeDownloads:        ratelimit.NewConcurrencyLimiter(),
+		scans:                  opts.Scans,
+		compressUploads:        opts.CompressUploads,
+		thumbnails:             opts.Thumbnails,
+		notifier:               opts.Notifier,
+		mailer:                 opts.Mailer,
 	}
 
 	s.routes()
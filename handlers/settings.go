@@ -30,6 +30,23 @@ func settingsFromRequest(r *http.Request) (picoshare.Settings, error) {
 	var payload struct {
 		DefaultExpirationDays uint16 `json:"defaultExpirationDays"`
 		DefaultNeverExpire    bool   `json:"defaultNeverExpire"`
+
+		GuestMaxUploadsPerIPPerHour  *int    `json:"guestMaxUploadsPerIpPerHour"`
+		GuestMaxBytesPerIPPerDay     *uint64 `json:"guestMaxBytesPerIpPerDay"`
+		GuestMaxUploadBytesPerSecond *uint64 `json:"guestMaxUploadBytesPerSecond"`
+
+		MaxDownloadBytesPerSecond   *uint64 `json:"maxDownloadBytesPerSecond"`
+		MaxConcurrentDownloadsPerIP *int    `json:"maxConcurrentDownloadsPerIp"`
+
+		TrashRetentionDays *uint16 `json:"trashRetentionDays"`
+
+		DefaultVisibility string `json:"defaultVisibility"`
+
+		DefaultIdleExpirationDays uint16 `json:"defaultIdleExpirationDays"`
+
+		NotifyGuestUploads       bool `json:"notifyGuestUploads"`
+		NotifySensitiveDownloads bool `json:"notifySensitiveDownloads"`
+		NotifyExpiringEntries    bool `json:"notifyExpiringEntries"`
 	}
 	err := json.NewDecoder(r.Body).Decode(&payload)
 	if err != nil {
@@ -46,7 +63,46 @@ func settingsFromRequest(r *http.Request) (picoshare.Settings, error) {
 		return picoshare.Settings{}, err
 	}
 
+	guestRateLimit, err := parse.GuestUploadRateLimit(payload.GuestMaxUploadsPerIPPerHour, payload.GuestMaxBytesPerIPPerDay, payload.GuestMaxUploadBytesPerSecond)
+	if err != nil {
+		return picoshare.Settings{}, err
+	}
+
+	downloadLimits, err := parse.DownloadLimits(payload.MaxDownloadBytesPerSecond, payload.MaxConcurrentDownloadsPerIP)
+	if err != nil {
+		return picoshare.Settings{}, err
+	}
+
+	// Leave the trash retention unset if the request omits it, so that the
+	// default applies.
+	var trashRetention picoshare.FileLifetime
+	if payload.TrashRetentionDays != nil {
+		if trashRetention, err = parse.FileLifetime(*payload.TrashRetentionDays); err != nil {
+			return picoshare.Settings{}, fmt.Errorf("invalid trash retention: %w", err)
+		}
+	}
+
+	defaultVisibility, err := parse.Visibility(payload.DefaultVisibility)
+	if err != nil {
+		return picoshare.Settings{}, fmt.Errorf("invalid default visibility: %w", err)
+	}
+
+	defaultIdleLifetime, err := parse.IdleLifetime(payload.DefaultIdleExpirationDays)
+	if err != nil {
+		return picoshare.Settings{}, fmt.Errorf("invalid default idle expiration: %w", err)
+	}
+
 	return picoshare.Settings{
-		DefaultFileLifetime: defaultLifetime,
+		DefaultFileLifetime:         defaultLifetime,
+		DefaultGuestUploadRateLimit: guestRateLimit,
+		DownloadLimits:              downloadLimits,
+		TrashRetention:              trashRetention,
+		DefaultVisibility:           defaultVisibility,
+		DefaultIdleLifetime:         defaultIdleLifetime,
+		Notifications: picoshare.NotificationSettings{
+			GuestUploads:       payload.NotifyGuestUploads,
+			SensitiveDownloads: payload.NotifySensitiveDownloads,
+			ExpiringEntries:    payload.NotifyExpiringEntries,
+		},
 	}, nil
 }
@@ -39,6 +39,138 @@ func TestSettingsPut(t *testing.T) {
 			},
 			status: http.StatusOK,
 		},
+		{
+			description: "valid request with default guest rate limits",
+			payload: `{
+					"defaultExpirationDays": 7,
+					"guestMaxUploadsPerIpPerHour": 10,
+					"guestMaxBytesPerIpPerDay": 1048576,
+					"guestMaxUploadBytesPerSecond": 2048
+				}`,
+			settings: picoshare.Settings{
+				DefaultFileLifetime: picoshare.NewFileLifetimeInDays(7),
+				DefaultGuestUploadRateLimit: picoshare.GuestUploadRateLimit{
+					MaxUploadsPerIPPerHour: makeIntPointer(10),
+					MaxBytesPerIPPerDay:    makeUint64Pointer(1048576),
+					MaxBytesPerSecond:      makeUint64Pointer(2048),
+				},
+			},
+			status: http.StatusOK,
+		},
+		{
+			description: "rejects guest bandwidth limit that's too low",
+			payload: `{
+					"defaultExpirationDays": 7,
+					"guestMaxUploadBytesPerSecond": 1
+				}`,
+			settings: picoshare.Settings{},
+			status:   http.StatusBadRequest,
+		},
+		{
+			description: "valid request with download limits",
+			payload: `{
+					"defaultExpirationDays": 7,
+					"maxDownloadBytesPerSecond": 4096,
+					"maxConcurrentDownloadsPerIp": 2
+				}`,
+			settings: picoshare.Settings{
+				DefaultFileLifetime: picoshare.NewFileLifetimeInDays(7),
+				DownloadLimits: picoshare.DownloadLimits{
+					MaxBytesPerSecond:  makeUint64Pointer(4096),
+					MaxConcurrentPerIP: makeIntPointer(2),
+				},
+			},
+			status: http.StatusOK,
+		},
+		{
+			description: "rejects zero concurrent downloads per IP",
+			payload: `{
+					"defaultExpirationDays": 7,
+					"maxConcurrentDownloadsPerIp": 0
+				}`,
+			settings: picoshare.Settings{},
+			status:   http.StatusBadRequest,
+		},
+		{
+			description: "valid request with trash retention",
+			payload: `{
+					"defaultExpirationDays": 7,
+					"trashRetentionDays": 14
+				}`,
+			settings: picoshare.Settings{
+				DefaultFileLifetime: picoshare.NewFileLifetimeInDays(7),
+				TrashRetention:      picoshare.NewFileLifetimeInDays(14),
+			},
+			status: http.StatusOK,
+		},
+		{
+			description: "rejects zero trash retention",
+			payload: `{
+					"defaultExpirationDays": 7,
+					"trashRetentionDays": 0
+				}`,
+			settings: picoshare.Settings{},
+			status:   http.StatusBadRequest,
+		},
+		{
+			description: "valid request with default visibility",
+			payload: `{
+					"defaultExpirationDays": 7,
+					"defaultVisibility": "private"
+				}`,
+			settings: picoshare.Settings{
+				DefaultFileLifetime: picoshare.NewFileLifetimeInDays(7),
+				DefaultVisibility:   picoshare.VisibilityPrivate,
+			},
+			status: http.StatusOK,
+		},
+		{
+			description: "rejects invalid default visibility",
+			payload: `{
+					"defaultExpirationDays": 7,
+					"defaultVisibility": "secret"
+				}`,
+			settings: picoshare.Settings{},
+			status:   http.StatusBadRequest,
+		},
+		{
+			description: "valid request with default idle expiration",
+			payload: `{
+					"defaultExpirationDays": 7,
+					"defaultIdleExpirationDays": 30
+				}`,
+			settings: picoshare.Settings{
+				DefaultFileLifetime: picoshare.NewFileLifetimeInDays(7),
+				DefaultIdleLifetime: picoshare.NewFileLifetimeInDays(30),
+			},
+			status: http.StatusOK,
+		},
+		{
+			description: "rejects default idle expiration that's too long",
+			payload: `{
+					"defaultExpirationDays": 7,
+					"defaultIdleExpirationDays": 4000
+				}`,
+			settings: picoshare.Settings{},
+			status:   http.StatusBadRequest,
+		},
+		{
+			description: "valid request with notification toggles",
+			payload: `{
+					"defaultExpirationDays": 7,
+					"notifyGuestUploads": true,
+					"notifySensitiveDownloads": false,
+					"notifyExpiringEntries": true
+				}`,
+			settings: picoshare.Settings{
+				DefaultFileLifetime: picoshare.NewFileLifetimeInDays(7),
+				Notifications: picoshare.NotificationSettings{
+					GuestUploads:    true,
+					ExpiringEntries: true,
+				},
+			},
+			status: http.StatusOK,
+		},
 		{
 			description: "rejects invalid expiration days (too low)",
 			payload: `{
@@ -66,7 +198,7 @@ func TestSettingsPut(t *testing.T) {
 	} {
 		t.Run(tt.description, func(t *testing.T) {
 			dataStore := test_sqlite.New()
-			s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock())
+			s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock(), handlers.Options{})
 
 			req, err := http.NewRequest("PUT", "/api/settings", strings.NewReader(tt.payload))
 			if err != nil {
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/mtlynch/picoshare/handlers/parse"
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/signedurl"
+	"github.com/mtlynch/picoshare/store"
+)
+
+type SignedURLPostResponse struct {
+	URL     string    `json:"url"`
+	Expires time.Time `json:"expires"`
+}
+
+func (s Server) signedURLPost() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseEntryID(mux.Vars(r)["id"])
+		if err != nil {
+			log.Printf("error parsing ID: %v", err)
+			http.Error(w, fmt.Sprintf("bad entry ID: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		var payload struct {
+			ExpiresInMinutes uint32 `json:"expiresInMinutes"`
+			SingleUse        bool   `json:"singleUse"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			log.Printf("failed to decode JSON request: %v", err)
+			http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		lifetime, err := parse.SignedURLLifetime(payload.ExpiresInMinutes)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid lifetime: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		if _, err := s.getDB(r).GetEntryMetadata(id); err != nil {
+			if _, ok := errors.AsType[store.EntryNotFoundError](err); ok {
+				http.Error(w, "entry not found", http.StatusNotFound)
+				return
+			}
+			log.Printf("error retrieving entry with id %v: %v", id, err)
+			http.Error(w, "failed to retrieve entry", http.StatusInternalServerError)
+			return
+		}
+
+		// Signed links are only precise to the second.
+		expires := s.clock.Now().Add(lifetime).Truncate(time.Second)
+		url, err := s.signedEntryURL(r, id, expires, payload.SingleUse)
+		if err != nil {
+			log.Printf("failed to sign link to entry %v: %v", id, err)
+			http.Error(w, "Failed to sign link", http.StatusInternalServerError)
+			return
+		}
+
+		respondJSON(w, SignedURLPostResponse{
+			URL:     url,
+			Expires: expires,
+		})
+	}
+}
+
+// signedEntryURL returns a link to the entry with the given ID that remains
+// valid until expires.
+func (s Server) signedEntryURL(r *http.Request, id picoshare.EntryID, expires time.Time, singleUse bool) (string, error) {
+	key, err := s.getDB(r).ReadURLSigningKey()
+	if err != nil {
+		return "", err
+	}
+	query := signedurl.Query(key, id, expires, singleUse)
+	return fmt.Sprintf("%s/-%s?%s", baseURLFromRequest(r), id, query.Encode()), nil
+}
+
+// verifySignedURL checks the signed link in the request for the entry with the
+// given ID without using it up. If the link is invalid, verifySignedURL writes
+// an error response and returns false.
+func (s Server) verifySignedURL(w http.ResponseWriter, r *http.Request, id picoshare.EntryID) (signedurl.Grant, bool) {
+	key, err := s.getDB(r).ReadURLSigningKey()
+	if err != nil {
+		log.Printf("failed to read URL signing key: %v", err)
+		http.Error(w, "Failed to verify link", http.StatusInternalServerError)
+		return signedurl.Grant{}, false
+	}
+
+	grant, err := signedurl.Verify(key, id, r.URL.Query(), s.clock.Now())
+	if errors.Is(err, signedurl.ErrExpired) {
+		http.Error(w, "This link has expired", http.StatusGone)
+		return signedurl.Grant{}, false
+	} else if err != nil {
+		log.Printf("rejecting signed link for entry %v: %v", id, err)
+		http.Error(w, "Invalid link", http.StatusForbidden)
+		return signedurl.Grant{}, false
+	}
+
+	return grant, true
+}
+
+// redeemSignedURL verifies the signed link in the request for the entry with
+// the given ID and uses it up if it's single-use. If the link is invalid or
+// already used, redeemSignedURL writes an error response and returns false.
+func (s Server) redeemSignedURL(w http.ResponseWriter, r *http.Request, id picoshare.EntryID) bool {
+	grant, ok := s.verifySignedURL(w, r, id)
+	if !ok {
+		return false
+	}
+
+	if !grant.SingleUse {
+		return true
+	}
+
+	// Chat apps fetch links to render previews, which would use up the link
+	// before the recipient ever clicks it.
+	if isLinkPreviewCrawler(r.Header.Get("User-Agent")) {
+		http.Error(w, "Single-use links don't support link previews", http.StatusForbidden)
+		return false
+	}
+
+	if err := s.getDB(r).ClaimSignedURL(grant.Signature, grant.Expires); err != nil {
+		if _, ok := errors.AsType[store.SignedURLUsedError](err); ok {
+			http.Error(w, "This link has already been used", http.StatusGone)
+			return false
+		}
+		log.Printf("failed to record use of signed link for entry %v: %v", id, err)
+		http.Error(w, "Failed to verify link", http.StatusInternalServerError)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,225 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mtlynch/picoshare/handlers"
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/store/sqlite"
+	"github.com/mtlynch/picoshare/store/test_sqlite"
+)
+
+func TestSignedURLPost(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		route       string
+		payload     string
+		status      int
+	}{
+		{
+			description: "creates signed link",
+			route:       "/api/entry/AAAAAAAAAA/signed-urls",
+			payload:     `{"expiresInMinutes": 15, "singleUse": true}`,
+			status:      http.StatusOK,
+		},
+		{
+			description: "rejects missing lifetime",
+			route:       "/api/entry/AAAAAAAAAA/signed-urls",
+			payload:     `{"singleUse": true}`,
+			status:      http.StatusBadRequest,
+		},
+		{
+			description: "rejects lifetime that's too long",
+			route:       "/api/entry/AAAAAAAAAA/signed-urls",
+			payload:     `{"expiresInMinutes": 20000}`,
+			status:      http.StatusBadRequest,
+		},
+		{
+			description: "rejects malformed JSON",
+			route:       "/api/entry/AAAAAAAAAA/signed-urls",
+			payload:     `{"expiresInMinutes": "soon"}`,
+			status:      http.StatusBadRequest,
+		},
+		{
+			description: "rejects nonexistent entry",
+			route:       "/api/entry/BBBBBBBBBB/signed-urls",
+			payload:     `{"expiresInMinutes": 15}`,
+			status:      http.StatusNotFound,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			dataStore := test_sqlite.New()
+			insertSignedURLTestEntry(t, dataStore)
+
+			s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, mockClock{mustParseTime("2024-01-01T00:00:00Z")}, handlers.Options{})
+
+			req, err := http.NewRequest("POST", tt.route, strings.NewReader(tt.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+			res := rec.Result()
+
+			if got, want := res.StatusCode, tt.status; got != want {
+				t.Fatalf("status=%d, want=%d", got, want)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var response handlers.SignedURLPostResponse
+			if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+				t.Fatalf("response is not valid JSON: %v", err)
+			}
+			if got, want := response.Expires, mustParseTime("2024-01-01T00:15:00Z"); !got.Equal(want) {
+				t.Errorf("expires=%v, want=%v", got, want)
+			}
+			u, err := url.Parse(response.URL)
+			if err != nil {
+				t.Fatalf("response URL is invalid: %v", err)
+			}
+			if got, want := u.Path, "/-AAAAAAAAAA"; got != want {
+				t.Errorf("path=%s, want=%s", got, want)
+			}
+		})
+	}
+}
+
+func TestSignedURLGet(t *testing.T) {
+	created := mustParseTime("2024-01-01T00:00:00Z")
+
+	type request struct {
+		now       time.Time
+		userAgent string
+		tamper    func(q url.Values)
+		status    int
+	}
+	for _, tt := range []struct {
+		description string
+		singleUse   bool
+		requests    []request
+	}{
+		{
+			description: "allows repeated downloads of multi-use link until it expires",
+			singleUse:   false,
+			requests: []request{
+				{now: created.Add(time.Minute), status: http.StatusOK},
+				{now: created.Add(14 * time.Minute), status: http.StatusOK},
+				{now: created.Add(16 * time.Minute), status: http.StatusGone},
+			},
+		},
+		{
+			description: "allows only one download of single-use link",
+			singleUse:   true,
+			requests: []request{
+				{now: created.Add(time.Minute), status: http.StatusOK},
+				{now: created.Add(2 * time.Minute), status: http.StatusGone},
+			},
+		},
+		{
+			description: "doesn't use up single-use link on link previews",
+			singleUse:   true,
+			requests: []request{
+				{now: created.Add(time.Minute), userAgent: "Slackbot-LinkExpanding 1.0", status: http.StatusForbidden},
+				{now: created.Add(2 * time.Minute), status: http.StatusOK},
+			},
+		},
+		{
+			description: "rejects link with altered expiration",
+			singleUse:   false,
+			requests: []request{
+				{
+					now: created.Add(time.Minute),
+					tamper: func(q url.Values) {
+						q.Set("exp", "1893456000")
+					},
+					status: http.StatusForbidden,
+				},
+			},
+		},
+		{
+			description: "rejects link with altered signature",
+			singleUse:   false,
+			requests: []request{
+				{
+					now: created.Add(time.Minute),
+					tamper: func(q url.Values) {
+						q.Set("sig", "invalid-signature")
+					},
+					status: http.StatusForbidden,
+				},
+			},
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			dataStore := test_sqlite.New()
+			insertSignedURLTestEntry(t, dataStore)
+
+			s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, mockClock{created}, handlers.Options{})
+
+			payload := `{"expiresInMinutes": 15, "singleUse": false}`
+			if tt.singleUse {
+				payload = `{"expiresInMinutes": 15, "singleUse": true}`
+			}
+			req, err := http.NewRequest("POST", "/api/entry/AAAAAAAAAA/signed-urls", strings.NewReader(payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+			if got, want := rec.Result().StatusCode, http.StatusOK; got != want {
+				t.Fatalf("POST status=%d, want=%d", got, want)
+			}
+			var response handlers.SignedURLPostResponse
+			if err := json.NewDecoder(rec.Result().Body).Decode(&response); err != nil {
+				t.Fatalf("response is not valid JSON: %v", err)
+			}
+			signedURL, err := url.Parse(response.URL)
+			if err != nil {
+				t.Fatalf("response URL is invalid: %v", err)
+			}
+
+			for i, rr := range tt.requests {
+				q := signedURL.Query()
+				if rr.tamper != nil {
+					rr.tamper(q)
+				}
+
+				s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, mockClock{rr.now}, handlers.Options{})
+				req, err := http.NewRequest("GET", signedURL.Path+"?"+q.Encode(), nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if rr.userAgent != "" {
+					req.Header.Set("User-Agent", rr.userAgent)
+				}
+				rec := httptest.NewRecorder()
+				s.Router().ServeHTTP(rec, req)
+
+				if got, want := rec.Result().StatusCode, rr.status; got != want {
+					t.Fatalf("request %d: status=%d, want=%d", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func insertSignedURLTestEntry(t *testing.T, dataStore sqlite.Store) {
+	contents := "dummy data"
+	if err := dataStore.InsertEntry(strings.NewReader(contents), picoshare.UploadMetadata{
+		ID:       "AAAAAAAAAA",
+		Filename: "report.pdf",
+		Uploaded: mustParseTime("2023-01-01T00:00:00Z"),
+		Expires:  picoshare.NeverExpire,
+		Size:     mustParseFileSize(len(contents)),
+	}); err != nil {
+		t.Fatalf("failed to insert dummy entry: %v", err)
+	}
+}
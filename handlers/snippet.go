@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/mtlynch/picoshare/handlers/parse"
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/preview"
+	"github.com/mtlynch/picoshare/store"
+)
+
+const snippetContentType = picoshare.ContentType("text/plain; charset=utf-8")
+
+func (s Server) snippetPost() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Leave headroom for the rest of the JSON and for escaped characters in
+		// the content.
+		r.Body = http.MaxBytesReader(w, r.Body, 2*parse.MaxSnippetBytes)
+
+		metadata, content, err := s.snippetFromRequest(r)
+		if err != nil {
+			log.Printf("invalid snippet: %v", err)
+			http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		metadata.ID = generateEntryID()
+		metadata.Uploaded = s.clock.Now()
+
+		metadata.Visibility, err = s.newEntryVisibility(r, "")
+		if err != nil {
+			log.Printf("failed to determine visibility of snippet: %v", err)
+			http.Error(w, "failed to read settings", http.StatusInternalServerError)
+			return
+		}
+
+		metadata.IdleLifetime, err = s.newEntryIdleLifetime(r, "")
+		if err != nil {
+			log.Printf("failed to determine idle expiration of snippet: %v", err)
+			http.Error(w, "failed to read settings", http.StatusInternalServerError)
+			return
+		}
+
+		if err := s.insertEntry(r, strings.NewReader(content), metadata); err != nil {
+			log.Printf("failed to insert snippet into data store: %v", err)
+			http.Error(w, "failed to insert snippet into database", http.StatusInternalServerError)
+			return
+		}
+
+		respondJSON(w, EntryPostResponse{ID: metadata.ID.String()})
+	}
+}
+
+func (s Server) snippetFromRequest(r *http.Request) (picoshare.UploadMetadata, string, error) {
+	var payload struct {
+		Content    string `json:"content"`
+		Language   string `json:"language"`
+		Title      string `json:"title"`
+		Expiration string `json:"expiration"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		log.Printf("failed to decode JSON request: %v", err)
+		return picoshare.UploadMetadata{}, "", err
+	}
+
+	content, err := parse.SnippetContent(payload.Content)
+	if err != nil {
+		return picoshare.UploadMetadata{}, "", err
+	}
+
+	language, err := parse.SnippetLanguage(payload.Language)
+	if err != nil {
+		return picoshare.UploadMetadata{}, "", err
+	}
+
+	filename, err := parse.SnippetFilename(payload.Title, language)
+	if err != nil {
+		return picoshare.UploadMetadata{}, "", err
+	}
+
+	size, err := picoshare.FileSizeFromInt(len(content))
+	if err != nil {
+		return picoshare.UploadMetadata{}, "", err
+	}
+
+	var expiration picoshare.ExpirationTime
+	if payload.Expiration == "" {
+		settings, err := s.getDB(r).ReadSettings()
+		if err != nil {
+			return picoshare.UploadMetadata{}, "", err
+		}
+		expiration = settings.DefaultFileLifetime.ExpirationFromTime(s.clock.Now())
+	} else {
+		expiration, err = parse.ExpirationFromPreset(payload.Expiration, s.clock.Now())
+		if err != nil {
+			return picoshare.UploadMetadata{}, "", err
+		}
+	}
+
+	return picoshare.UploadMetadata{
+		Filename:        filename,
+		ContentType:     snippetContentType,
+		Expires:         expiration,
+		Size:            size,
+		IsSnippet:       true,
+		SnippetLanguage: language,
+	}, content, nil
+}
+
+func parseSnippetPage() *template.Template {
+	fns := template.FuncMap{
+		"formatFileSize": humanReadableFileSize,
+	}
+	return parseTemplatesWithFuncs(fns, "templates/pages/snippet.html")
+}
+
+// serveSnippetPage renders a text snippet as a web page with syntax
+// highlighting.
+func (s Server) serveSnippetPage(w http.ResponseWriter, r *http.Request, t *template.Template, entry picoshare.UploadMetadata) {
+	codeCSS, err := preview.CodeCSS()
+	if err != nil {
+		log.Printf("failed to generate code highlighting styles: %v", err)
+		http.Error(w, "failed to render snippet", http.StatusInternalServerError)
+		return
+	}
+
+	src, err := s.readEntryText(r, entry.ID)
+	if err != nil {
+		log.Printf("failed to read snippet %v: %v", entry.ID, err)
+		http.Error(w, "failed to retrieve entry", http.StatusInternalServerError)
+		return
+	}
+
+	rendered, err := preview.HighlightSnippet(src, entry.SnippetLanguage)
+	if err != nil {
+		log.Printf("failed to render snippet %v: %v", entry.ID, err)
+		http.Error(w, "failed to render snippet", http.StatusInternalServerError)
+		return
+	}
+
+	download, err := recordDownload(s.getDB(r), entry, s.clock.Now(), r.RemoteAddr, r.Header.Get("User-Agent"))
+	if err != nil {
+		log.Printf("failed to record download of file %s: %v", entry.ID.String(), err)
+	}
+	s.notifySensitiveDownload(r, entry, download)
+
+	if err := t.Execute(w, struct {
+		commonProps
+		Metadata picoshare.UploadMetadata
+		Rendered template.HTML
+		CodeCSS  template.CSS
+	}{
+		commonProps: makeCommonProps(fmt.Sprintf("PicoShare - %s", entry.Filename), r.Context()),
+		Metadata:    entry,
+		Rendered:    rendered,
+		CodeCSS:     codeCSS,
+	}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// entryRawGet serves the plain text of a snippet.
+func (s Server) entryRawGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseEntryID(mux.Vars(r)["id"])
+		if err != nil {
+			log.Printf("error parsing ID: %v", err)
+			http.Error(w, fmt.Sprintf("bad entry ID: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		entry, err := s.getDB(r).GetEntryMetadata(id)
+		if _, ok := errors.AsType[store.EntryNotFoundError](err); ok {
+			http.Error(w, "entry not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("error retrieving entry with id %v: %v", id, err)
+			http.Error(w, "failed to retrieve entry", http.StatusInternalServerError)
+			return
+		}
+
+		if !authorizeEntryView(w, r, entry) {
+			return
+		}
+
+		// Only snippets have a raw view. Uploaded files are already served as-is.
+		if !entry.IsSnippet {
+			http.Error(w, "entry is not a snippet", http.StatusNotFound)
+			return
+		}
+
+		if !s.checkEntryAvailable(w, r, nil, entry) {
+			return
+		}
+
+		if !checkEntryScan(w, entry) {
+			return
+		}
+
+		// Prevent browsers from interpreting the snippet as HTML or script.
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		s.serveEntryFile(w, r, entry, snippetContentType)
+	}
+}
+
+func (s Server) snippetNewGet() http.HandlerFunc {
+	t := parseTemplates("templates/pages/snippet-create.html")
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := t.Execute(w, struct {
+			commonProps
+			ExpirationPresets []parse.ExpirationPreset
+		}{
+			commonProps:       makeCommonProps("PicoShare - New Snippet", r.Context()),
+			ExpirationPresets: parse.ExpirationPresets,
+		}); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
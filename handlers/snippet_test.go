@@ -0,0 +1,201 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mtlynch/picoshare/handlers"
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/store/test_sqlite"
+)
+
+func TestSnippetPost(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		payload     string
+		status      int
+		filename    picoshare.Filename
+		language    picoshare.SnippetLanguage
+		expires     picoshare.ExpirationTime
+	}{
+		{
+			description: "creates snippet with language and title",
+			payload:     `{"content": "print('hi')", "language": "py", "title": "hello.py", "expiration": "1d"}`,
+			status:      http.StatusOK,
+			filename:    "hello.py",
+			language:    "python",
+			expires:     mustParseExpirationTime("2024-01-02T00:00:00Z"),
+		},
+		{
+			description: "derives filename from language",
+			payload:     `{"content": "print('hi')", "language": "python", "expiration": "never"}`,
+			status:      http.StatusOK,
+			filename:    "snippet.py",
+			language:    "python",
+			expires:     picoshare.NeverExpire,
+		},
+		{
+			description: "uses default file lifetime when expiration is omitted",
+			payload:     `{"content": "hello"}`,
+			status:      http.StatusOK,
+			filename:    "snippet.txt",
+			expires:     mustParseExpirationTime("2024-01-31T00:00:00Z"),
+		},
+		{
+			description: "rejects empty content",
+			payload:     `{"content": ""}`,
+			status:      http.StatusBadRequest,
+		},
+		{
+			description: "rejects unsupported language",
+			payload:     `{"content": "hello", "language": "not-a-real-language"}`,
+			status:      http.StatusBadRequest,
+		},
+		{
+			description: "rejects unrecognized expiration preset",
+			payload:     `{"content": "hello", "expiration": "2w"}`,
+			status:      http.StatusBadRequest,
+		},
+		{
+			description: "rejects malformed JSON",
+			payload:     `{"content": `,
+			status:      http.StatusBadRequest,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			dataStore := test_sqlite.New()
+			c := mockClock{mustParseTime("2024-01-01T00:00:00Z")}
+			s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, c, handlers.Options{})
+
+			req, err := http.NewRequest("POST", "/api/snippet", strings.NewReader(tt.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+			res := rec.Result()
+
+			if got, want := res.StatusCode, tt.status; got != want {
+				t.Fatalf("status=%d, want=%d", got, want)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var response handlers.EntryPostResponse
+			if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+				t.Fatalf("response is not valid JSON: %v", err)
+			}
+
+			entry, err := dataStore.GetEntryMetadata(picoshare.EntryID(response.ID))
+			if err != nil {
+				t.Fatalf("failed to get snippet from data store: %v", err)
+			}
+
+			if !entry.IsSnippet {
+				t.Errorf("IsSnippet=false, want true")
+			}
+			if got, want := entry.Filename, tt.filename; got != want {
+				t.Errorf("filename=%v, want=%v", got, want)
+			}
+			if got, want := entry.SnippetLanguage, tt.language; got != want {
+				t.Errorf("language=%v, want=%v", got, want)
+			}
+			if got, want := entry.Expires, tt.expires; got != want {
+				t.Errorf("expiration=%v, want=%v", got, want)
+			}
+		})
+	}
+}
+
+func TestSnippetGet(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		isSnippet   bool
+		path        string
+		status      int
+		contentType string
+		contains    string
+	}{
+		{
+			description: "renders snippet with line anchors",
+			isSnippet:   true,
+			path:        "/-AAAAAAAAAA",
+			status:      http.StatusOK,
+			contentType: "text/html; charset=utf-8",
+			contains:    `id="L1"`,
+		},
+		{
+			description: "serves raw snippet as plain text",
+			isSnippet:   true,
+			path:        "/-AAAAAAAAAA/_/raw",
+			status:      http.StatusOK,
+			contentType: "text/plain; charset=utf-8",
+			contains:    "<script>alert('hi')</script>",
+		},
+		{
+			description: "has no raw endpoint for uploaded files",
+			isSnippet:   false,
+			path:        "/-AAAAAAAAAA/_/raw",
+			status:      http.StatusNotFound,
+		},
+		{
+			description: "returns 404 for raw endpoint of non-existent entry",
+			isSnippet:   true,
+			path:        "/-BBBBBBBBBB/_/raw",
+			status:      http.StatusNotFound,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			contents := "<script>alert('hi')</script>"
+			dataStore := test_sqlite.New()
+			if err := dataStore.InsertEntry(strings.NewReader(contents), picoshare.UploadMetadata{
+				ID:              "AAAAAAAAAA",
+				Filename:        "snippet.html",
+				ContentType:     "text/plain; charset=utf-8",
+				Uploaded:        mustParseTime("2023-01-01T00:00:00Z"),
+				Expires:         picoshare.NeverExpire,
+				Size:            mustParseFileSize(len(contents)),
+				IsSnippet:       tt.isSnippet,
+				SnippetLanguage: "html",
+			}); err != nil {
+				panic(err)
+			}
+
+			s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock(), handlers.Options{})
+
+			req, err := http.NewRequest("GET", tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+			res := rec.Result()
+
+			if got, want := res.StatusCode, tt.status; got != want {
+				t.Fatalf("status=%d, want=%d", got, want)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			if got, want := res.Header.Get("Content-Type"), tt.contentType; got != want {
+				t.Errorf("Content-Type=%s, want=%s", got, want)
+			}
+
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("failed to read response body: %v", err)
+			}
+			if !strings.Contains(string(body), tt.contains) {
+				t.Errorf("body=%s, want it to contain %s", body, tt.contains)
+			}
+		})
+	}
+}
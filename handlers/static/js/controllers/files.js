@@ -1,12 +1,15 @@
 "use strict";
 
-function uploadFormData(url, formData, progressFn) {
+function uploadFormData(url, formData, progressFn, headers = {}) {
   return new Promise((resolve, reject) => {
     // We have to use XHR instead of fetch because fetch currently doesn't
     // support a mechanism for reporting upload progress.
     const xhr = new XMLHttpRequest();
     xhr.open("POST", url, true);
     xhr.setRequestHeader("Accept", "application/json");
+    for (const [name, value] of Object.entries(headers)) {
+      xhr.setRequestHeader(name, value);
+    }
     xhr.upload.addEventListener("progress", (event) => {
       if (event.lengthComputable) {
         if (progressFn) {
@@ -44,12 +47,25 @@ function uploadFormData(url, formData, progressFn) {
     });
 }
 
-export async function uploadFile(file, expirationTime, note, progressFn) {
+export async function uploadFile(
+  file,
+  expirationTime,
+  note,
+  progressFn,
+  availableFrom = null,
+  idleExpirationDays = null
+) {
   const formData = new FormData();
   formData.append("file", file);
   if (note) {
     formData.append("note", note);
   }
+  if (availableFrom) {
+    formData.append("availableFrom", availableFrom);
+  }
+  if (idleExpirationDays !== null) {
+    formData.append("idleExpirationDays", idleExpirationDays);
+  }
   return uploadFormData(
     `/api/entry?expiration=${encodeURIComponent(expirationTime)}`,
     formData,
@@ -61,23 +77,51 @@ export async function guestUploadFile(
   file,
   guestLinkID,
   expirationTime,
-  progressFn
+  progressFn,
+  challengeSolution = null
 ) {
   const formData = new FormData();
   formData.append("file", file);
+  let headers = {};
+  if (challengeSolution) {
+    headers = {
+      "PicoShare-Challenge-Token": challengeSolution.token,
+      "PicoShare-Challenge-Solution": challengeSolution.solution,
+    };
+  }
   return uploadFormData(
     `/api/guest/${guestLinkID}?expiration=${encodeURIComponent(
       expirationTime
     )}`,
     formData,
-    progressFn
+    progressFn,
+    headers
   );
 }
 
-export async function editFile(id, filename, expiration, note) {
+export async function editFile(
+  id,
+  filename,
+  expiration,
+  note,
+  maxDownloadBytesPerSecond = null,
+  tags = [],
+  folder = null,
+  slug = null,
+  visibility = null,
+  availableFrom = null,
+  idleExpirationDays = null
+) {
   let payload = {
     filename,
     note,
+    maxDownloadBytesPerSecond,
+    tags,
+    folder,
+    slug,
+    visibility,
+    availableFrom,
+    idleExpirationDays,
   };
   if (expiration) {
     payload.expiration = expiration;
@@ -106,6 +150,12 @@ export async function editFile(id, filename, expiration, note) {
     });
 }
 
+export async function uploadFileVersion(id, file, progressFn) {
+  const formData = new FormData();
+  formData.append("file", file);
+  return uploadFormData(`/api/entry/${id}/versions`, formData, progressFn);
+}
+
 export async function deleteFile(id) {
   return fetch(`/api/entry/${id}`, {
     method: "DELETE",
@@ -129,3 +179,177 @@ export async function deleteFile(id) {
       return Promise.reject(error);
     });
 }
+
+export async function restoreFile(id) {
+  return fetch(`/api/trash/${id}/restore`, {
+    method: "POST",
+    credentials: "include",
+  })
+    .then((response) => {
+      if (!response.ok) {
+        return response.text().then((error) => {
+          return Promise.reject(error);
+        });
+      }
+      return Promise.resolve();
+    })
+    .catch((error) => {
+      if (error.message) {
+        return Promise.reject(
+          "Failed to communicate with server" +
+            (error.message ? `: ${error.message}` : ".")
+        );
+      }
+      return Promise.reject(error);
+    });
+}
+
+export async function deleteFilePermanently(id) {
+  return fetch(`/api/trash/${id}`, {
+    method: "DELETE",
+    credentials: "include",
+  })
+    .then((response) => {
+      if (!response.ok) {
+        return response.text().then((error) => {
+          return Promise.reject(error);
+        });
+      }
+      return Promise.resolve();
+    })
+    .catch((error) => {
+      if (error.message) {
+        return Promise.reject(
+          "Failed to communicate with server" +
+            (error.message ? `: ${error.message}` : ".")
+        );
+      }
+      return Promise.reject(error);
+    });
+}
+
+// entriesBatch applies an action to several entries at once. It resolves to
+// the server's response so that callers can read exported data from it.
+export async function entriesBatch(action, ids, params = {}) {
+  return fetch("/api/entries/batch", {
+    method: "POST",
+    credentials: "include",
+    body: JSON.stringify({ action, ids, ...params }),
+  })
+    .then((response) => {
+      if (!response.ok) {
+        return response.text().then((error) => {
+          return Promise.reject(error);
+        });
+      }
+      return Promise.resolve(response);
+    })
+    .catch((error) => {
+      if (error.message) {
+        return Promise.reject(
+          "Failed to communicate with server" +
+            (error.message ? `: ${error.message}` : ".")
+        );
+      }
+      return Promise.reject(error);
+    });
+}
+
+export async function createSnippet(content, language, title, expiration) {
+  return fetch("/api/snippet", {
+    method: "POST",
+    credentials: "include",
+    body: JSON.stringify({ content, language, title, expiration }),
+  })
+    .then((response) => {
+      if (!response.ok) {
+        return response.text().then((error) => {
+          return Promise.reject(error);
+        });
+      }
+      return response.json();
+    })
+    .catch((error) => {
+      if (error.message) {
+        return Promise.reject(
+          "Failed to communicate with server" +
+            (error.message ? `: ${error.message}` : ".")
+        );
+      }
+      return Promise.reject(error);
+    });
+}
+
+export async function createSignedUrl(id, expiresInMinutes, singleUse) {
+  return fetch(`/api/entry/${encodeURIComponent(id)}/signed-urls`, {
+    method: "POST",
+    credentials: "include",
+    body: JSON.stringify({ expiresInMinutes, singleUse }),
+  })
+    .then((response) => {
+      if (!response.ok) {
+        return response.text().then((error) => {
+          return Promise.reject(error);
+        });
+      }
+      return response.json();
+    })
+    .catch((error) => {
+      if (error.message) {
+        return Promise.reject(
+          "Failed to communicate with server" +
+            (error.message ? `: ${error.message}` : ".")
+        );
+      }
+      return Promise.reject(error);
+    });
+}
+
+export async function emailLink(id, recipients, message) {
+  return fetch(`/api/entry/${encodeURIComponent(id)}/link-emails`, {
+    method: "POST",
+    credentials: "include",
+    body: JSON.stringify({ recipients, message }),
+  })
+    .then((response) => {
+      if (!response.ok) {
+        return response.text().then((error) => {
+          return Promise.reject(error);
+        });
+      }
+      return response.json();
+    })
+    .catch((error) => {
+      if (error.message) {
+        return Promise.reject(
+          "Failed to communicate with server" +
+            (error.message ? `: ${error.message}` : ".")
+        );
+      }
+      return Promise.reject(error);
+    });
+}
+
+export async function extendFile(id) {
+  return fetch(`/api/entry/${encodeURIComponent(id)}/extend`, {
+    method: "POST",
+    credentials: "include",
+  })
+    .then((response) => {
+      if (!response.ok) {
+        return response.text().then((error) => {
+          return Promise.reject(error);
+        });
+      }
+      return response.json();
+    })
+    .catch((error) => {
+      if (error.message) {
+        return Promise.reject(
+          "Failed to communicate with server" +
+            (error.message ? `: ${error.message}` : ".")
+        );
+      }
+      return Promise.reject(error);
+    });
+}
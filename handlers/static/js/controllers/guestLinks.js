@@ -5,7 +5,11 @@ export async function guestLinkNew(
   urlExpirationTime,
   fileLifetime,
   maxFileBytes,
-  maxFileUploads
+  maxFileUploads,
+  rateLimit = {},
+  requireChallenge = false,
+  autoTag = null,
+  invitation = {}
 ) {
   return fetch("/api/guest-links", {
     method: "POST",
@@ -16,6 +20,10 @@ export async function guestLinkNew(
       fileLifetime,
       maxFileBytes,
       maxFileUploads,
+      ...rateLimit,
+      requireChallenge,
+      autoTag,
+      ...invitation,
     }),
   })
     .then((response) => {
@@ -24,7 +32,7 @@ export async function guestLinkNew(
           return Promise.reject(error);
         });
       }
-      return Promise.resolve();
+      return response.json();
     })
     .catch((error) => {
       if (error.message) {
@@ -109,3 +117,26 @@ export async function guestLinkDisable(id) {
     });
 }
 ``;
+
+export async function guestChallengeGet(guestLinkID) {
+  return fetch(`/api/guest/${guestLinkID}/challenge`, {
+    method: "GET",
+  })
+    .then((response) => {
+      if (!response.ok) {
+        return response.text().then((error) => {
+          return Promise.reject(error);
+        });
+      }
+      return response.json();
+    })
+    .catch((error) => {
+      if (error.message) {
+        return Promise.reject(
+          "Failed to communicate with server" +
+            (error.message ? `: ${error.message}` : ".")
+        );
+      }
+      return Promise.reject(error);
+    });
+}
@@ -0,0 +1,34 @@
+"use strict";
+
+function leadingZeroBits(bytes) {
+  let count = 0;
+  for (const b of bytes) {
+    if (b !== 0) {
+      return count + Math.clz32(b) - 24;
+    }
+    count += 8;
+  }
+  return count;
+}
+
+// Finds a solution to a proof-of-work challenge from the server: a number
+// such that the SHA-256 hash of "<token>:<number>" starts with at least
+// `difficulty` zero bits.
+export async function solveChallenge(token, difficulty) {
+  if (!window.crypto || !window.crypto.subtle) {
+    return Promise.reject(
+      "Your browser can't verify this upload because the page isn't loaded over HTTPS."
+    );
+  }
+  const encoder = new TextEncoder();
+  for (let n = 0; ; n++) {
+    const solution = n.toString();
+    const hash = await window.crypto.subtle.digest(
+      "SHA-256",
+      encoder.encode(`${token}:${solution}`)
+    );
+    if (leadingZeroBits(new Uint8Array(hash)) >= difficulty) {
+      return solution;
+    }
+  }
+}
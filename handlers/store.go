@@ -2,17 +2,35 @@ package handlers
 
 import (
 	"io"
+	"time"
 
 	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/store"
 )
 
 type Store interface {
 	GetEntriesMetadata() ([]picoshare.UploadMetadata, error)
+	QueryEntries(store.EntryQuery) (store.EntryPage, error)
 	ReadEntryFile(picoshare.EntryID) (io.ReadSeeker, error)
 	GetEntryMetadata(id picoshare.EntryID) (picoshare.UploadMetadata, error)
+	GetEntryIDBySlug(slug picoshare.EntrySlug) (picoshare.EntryID, error)
 	InsertEntry(reader io.Reader, metadata picoshare.UploadMetadata) error
+	InsertEntryVersion(reader io.Reader, metadata picoshare.UploadMetadata) error
+	GetEntryVersions(id picoshare.EntryID) ([]picoshare.EntryVersion, error)
+	ReadEntryVersionFile(id picoshare.EntryID, version int) (io.ReadSeeker, error)
 	UpdateEntryMetadata(id picoshare.EntryID, metadata picoshare.UploadMetadata) error
+	UpdateEntryVersionScanResult(id picoshare.EntryID, version int, result picoshare.ScanResult) error
+	InsertEntryThumbnail(id picoshare.EntryID, jpeg []byte) error
+	GetEntryThumbnail(id picoshare.EntryID) ([]byte, error)
 	DeleteEntry(id picoshare.EntryID) error
+	DeleteEntries(ids []picoshare.EntryID) error
+	TrashEntries(ids []picoshare.EntryID) error
+	RestoreEntry(id picoshare.EntryID) error
+	GetTrashedEntries() ([]picoshare.UploadMetadata, error)
+	UpdateEntriesExpiration(ids []picoshare.EntryID, expiration picoshare.ExpirationTime) error
+	AddEntriesTags(ids []picoshare.EntryID, tags []picoshare.Tag, maxTagsPerEntry int) error
+	GetTags() ([]picoshare.Tag, error)
+	GetFolders() ([]picoshare.Folder, error)
 	GetGuestLink(picoshare.GuestLinkID) (picoshare.GuestLink, error)
 	GetGuestLinks() ([]picoshare.GuestLink, error)
 	InsertGuestLink(picoshare.GuestLink) error
@@ -21,6 +39,11 @@ type Store interface {
 	EnableGuestLink(picoshare.GuestLinkID) error
 	InsertEntryDownload(picoshare.EntryID, picoshare.DownloadRecord) error
 	GetEntryDownloads(id picoshare.EntryID) ([]picoshare.DownloadRecord, error)
+	InsertLinkRecipients(id picoshare.EntryID, recipients []string, sent time.Time) error
+	GetLinkRecipients(id picoshare.EntryID) ([]picoshare.LinkRecipient, error)
 	ReadSettings() (picoshare.Settings, error)
 	UpdateSettings(picoshare.Settings) error
+	ReadURLSigningKey() ([]byte, error)
+	ClaimSignedURL(signature string, expires time.Time) error
+	GetNotificationEmails(limit int) ([]picoshare.NotificationEmail, error)
 }
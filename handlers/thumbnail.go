@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/store"
+)
+
+func (s Server) fileThumbnailGet() http.HandlerFunc {
+	// Thumbnails on the file index are only visible to the admin, so browsers
+	// may cache them but shared caches may not.
+	return s.serveThumbnail("private, max-age=3600")
+}
+
+// entryThumbnailGet serves an entry's thumbnail to anyone who can view the
+// entry, so that link previews in chat apps can show it.
+func (s Server) entryThumbnailGet() http.HandlerFunc {
+	servePublic := s.serveThumbnail("public, max-age=3600")
+	servePrivate := s.serveThumbnail("private, max-age=3600")
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseEntryID(mux.Vars(r)["id"])
+		if err != nil {
+			log.Printf("error parsing ID: %v", err)
+			http.Error(w, fmt.Sprintf("bad entry ID: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		entry, err := s.getDB(r).GetEntryMetadata(id)
+		if _, ok := errors.AsType[store.EntryNotFoundError](err); ok {
+			http.Error(w, "thumbnail not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("error retrieving entry with id %v: %v", id, err)
+			http.Error(w, "failed to retrieve thumbnail", http.StatusInternalServerError)
+			return
+		}
+
+		if !authorizeEntryView(w, r, entry) {
+			return
+		}
+
+		if !s.checkEntryAvailable(w, r, nil, entry) {
+			return
+		}
+
+		if !checkEntryScan(w, entry) {
+			return
+		}
+
+		if entry.Visibility == picoshare.VisibilityPublic && entry.IsAvailable(s.clock.Now()) {
+			servePublic(w, r)
+		} else {
+			servePrivate(w, r)
+		}
+	}
+}
+
+func (s Server) serveThumbnail(cacheControl string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseEntryID(mux.Vars(r)["id"])
+		if err != nil {
+			log.Printf("error parsing ID: %v", err)
+			http.Error(w, fmt.Sprintf("bad entry ID: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		thumb, err := s.getDB(r).GetEntryThumbnail(id)
+		if _, ok := errors.AsType[store.ThumbnailNotFoundError](err); ok {
+			http.Error(w, "thumbnail not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("error retrieving thumbnail for entry %v: %v", id, err)
+			http.Error(w, "failed to retrieve thumbnail", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Header().Set("Content-Length", strconv.Itoa(len(thumb)))
+		w.Header().Set("Cache-Control", cacheControl)
+		if _, err := w.Write(thumb); err != nil {
+			log.Printf("failed to write thumbnail for entry %v: %v", id, err)
+		}
+	}
+}
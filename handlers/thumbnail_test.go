@@ -0,0 +1,151 @@
+package handlers_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mtlynch/picoshare/handlers"
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/store/test_sqlite"
+)
+
+func TestFileThumbnailGet(t *testing.T) {
+	dataStore := test_sqlite.New()
+	for _, id := range []picoshare.EntryID{"AAAAAAAAAA", "BBBBBBBBBB"} {
+		data := "dummy image data"
+		if err := dataStore.InsertEntry(strings.NewReader(data), picoshare.UploadMetadata{
+			ID:          id,
+			Filename:    "dummy.png",
+			ContentType: "image/png",
+			Uploaded:    mustParseTime("2023-01-01T00:00:00Z"),
+			Expires:     picoshare.NeverExpire,
+			Size:        mustParseFileSize(len(data)),
+		}); err != nil {
+			panic(err)
+		}
+	}
+	if err := dataStore.InsertEntryThumbnail("AAAAAAAAAA", []byte("dummy thumbnail")); err != nil {
+		panic(err)
+	}
+
+	s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock(), handlers.Options{})
+
+	for _, tt := range []struct {
+		description string
+		id          string
+		status      int
+		body        string
+	}{
+		{
+			description: "returns thumbnail for entry that has one",
+			id:          "AAAAAAAAAA",
+			status:      http.StatusOK,
+			body:        "dummy thumbnail",
+		},
+		{
+			description: "returns 404 for entry without a thumbnail",
+			id:          "BBBBBBBBBB",
+			status:      http.StatusNotFound,
+		},
+		{
+			description: "returns 400 for invalid entry ID",
+			id:          "invalid!!!",
+			status:      http.StatusBadRequest,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/files/"+tt.id+"/thumbnail", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+			res := rec.Result()
+
+			if got, want := res.StatusCode, tt.status; got != want {
+				t.Fatalf("status=%d, want=%d", got, want)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			if got, want := res.Header.Get("Content-Type"), "image/jpeg"; got != want {
+				t.Errorf("Content-Type=%s, want=%s", got, want)
+			}
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("failed to read response body: %v", err)
+			}
+			if got, want := string(body), tt.body; got != want {
+				t.Errorf("body=%s, want=%s", got, want)
+			}
+		})
+	}
+}
+
+func TestEntryThumbnailGetChecksScan(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		scan        picoshare.ScanResult
+		status      int
+	}{
+		{
+			description: "serves thumbnail of clean entry",
+			scan:        picoshare.ScanResult{Status: picoshare.ScanStatusClean},
+			status:      http.StatusOK,
+		},
+		{
+			description: "withholds thumbnail of infected entry",
+			scan:        picoshare.ScanResult{Status: picoshare.ScanStatusInfected, Detail: "Eicar-Test-Signature"},
+			status:      http.StatusForbidden,
+		},
+		{
+			description: "withholds thumbnail of entry pending scan",
+			scan:        picoshare.ScanResult{Status: picoshare.ScanStatusPending},
+			status:      http.StatusServiceUnavailable,
+		},
+		{
+			description: "withholds thumbnail of entry whose scan failed",
+			scan:        picoshare.ScanResult{Status: picoshare.ScanStatusError, Detail: "dummy clamd error"},
+			status:      http.StatusServiceUnavailable,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			dataStore := test_sqlite.New()
+			data := "dummy image data"
+			if err := dataStore.InsertEntry(strings.NewReader(data), picoshare.UploadMetadata{
+				ID:          "AAAAAAAAAA",
+				Filename:    "dummy.png",
+				ContentType: "image/png",
+				Uploaded:    mustParseTime("2023-01-01T00:00:00Z"),
+				Expires:     picoshare.NeverExpire,
+				Size:        mustParseFileSize(len(data)),
+			}); err != nil {
+				t.Fatalf("failed to insert dummy entry: %v", err)
+			}
+			if err := dataStore.UpdateEntryVersionScanResult("AAAAAAAAAA", 1, tt.scan); err != nil {
+				t.Fatalf("failed to save scan result: %v", err)
+			}
+			if err := dataStore.InsertEntryThumbnail("AAAAAAAAAA", []byte("dummy thumbnail")); err != nil {
+				t.Fatalf("failed to insert thumbnail: %v", err)
+			}
+
+			s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock(), handlers.Options{})
+
+			req, err := http.NewRequest("GET", "/-AAAAAAAAAA/_/thumbnail", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+
+			if got, want := rec.Result().StatusCode, tt.status; got != want {
+				t.Errorf("status=%d, want=%d", got, want)
+			}
+		})
+	}
+}
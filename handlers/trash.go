@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/mtlynch/picoshare/store"
+)
+
+func (s Server) trashRestorePost() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseEntryID(mux.Vars(r)["id"])
+		if err != nil {
+			log.Printf("error parsing ID: %v", err)
+			http.Error(w, fmt.Sprintf("bad entry ID: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		if err := s.getDB(r).RestoreEntry(id); err != nil {
+			if _, ok := errors.AsType[store.EntryNotFoundError](err); ok {
+				http.Error(w, "entry not found in trash", http.StatusNotFound)
+				return
+			}
+			log.Printf("failed to restore entry %v: %v", id, err)
+			http.Error(w, "failed to restore entry", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func (s Server) trashDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseEntryID(mux.Vars(r)["id"])
+		if err != nil {
+			log.Printf("error parsing ID: %v", err)
+			http.Error(w, fmt.Sprintf("bad entry ID: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		db := s.getDB(r)
+
+		// Only permanently delete entries that the user already moved to the
+		// trash. GetEntryMetadata succeeds only for entries outside the trash.
+		if _, err := db.GetEntryMetadata(id); err == nil {
+			http.Error(w, "entry not found in trash", http.StatusNotFound)
+			return
+		} else if _, ok := errors.AsType[store.EntryNotFoundError](err); !ok {
+			log.Printf("error retrieving entry with id %v: %v", id, err)
+			http.Error(w, "failed to retrieve entry", http.StatusInternalServerError)
+			return
+		}
+
+		if err := db.DeleteEntry(id); err != nil {
+			log.Printf("failed to delete entry %v: %v", id, err)
+			http.Error(w, "failed to delete entry", http.StatusInternalServerError)
+			return
+		}
+	}
+}
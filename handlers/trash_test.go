@@ -0,0 +1,168 @@
+package handlers_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mtlynch/picoshare/handlers"
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/store"
+	"github.com/mtlynch/picoshare/store/test_sqlite"
+)
+
+func TestTrash(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		method      string
+		route       string
+		status      int
+		// available indicates whether the entry should be outside the trash after
+		// the request.
+		available bool
+		// exists indicates whether the entry should exist in any form after the
+		// request.
+		exists bool
+	}{
+		{
+			description: "restores trashed entry",
+			method:      "POST",
+			route:       "/api/trash/AAAAAAAAAA/restore",
+			status:      http.StatusOK,
+			available:   true,
+			exists:      true,
+		},
+		{
+			description: "permanently deletes trashed entry",
+			method:      "DELETE",
+			route:       "/api/trash/AAAAAAAAAA",
+			status:      http.StatusOK,
+			available:   false,
+			exists:      false,
+		},
+		{
+			description: "rejects restore of entry that isn't in the trash",
+			method:      "POST",
+			route:       "/api/trash/BBBBBBBBBB/restore",
+			status:      http.StatusNotFound,
+			available:   false,
+			exists:      true,
+		},
+		{
+			description: "rejects permanent deletion of entry that isn't in the trash",
+			method:      "DELETE",
+			route:       "/api/trash/BBBBBBBBBB",
+			status:      http.StatusNotFound,
+			available:   false,
+			exists:      true,
+		},
+		{
+			description: "rejects invalid entry ID",
+			method:      "POST",
+			route:       "/api/trash/invalid-entry-id/restore",
+			status:      http.StatusBadRequest,
+			available:   false,
+			exists:      true,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			dataStore := test_sqlite.New()
+			for _, id := range []picoshare.EntryID{"AAAAAAAAAA", "BBBBBBBBBB"} {
+				contents := "dummy data"
+				if err := dataStore.InsertEntry(strings.NewReader(contents), picoshare.UploadMetadata{
+					ID:       id,
+					Filename: picoshare.Filename(id.String() + ".txt"),
+					Uploaded: mustParseTime("2023-01-01T00:00:00Z"),
+					Expires:  picoshare.NeverExpire,
+					Size:     mustParseFileSize(len(contents)),
+				}); err != nil {
+					t.Fatalf("failed to insert dummy entry: %v", err)
+				}
+			}
+
+			s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock(), handlers.Options{})
+
+			req, err := http.NewRequest("DELETE", "/api/entry/AAAAAAAAAA", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+			if got, want := rec.Result().StatusCode, http.StatusOK; got != want {
+				t.Fatalf("DELETE /api/entry status=%d, want=%d", got, want)
+			}
+
+			req, err = http.NewRequest(tt.method, tt.route, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rec = httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+			res := rec.Result()
+
+			if got, want := res.StatusCode, tt.status; got != want {
+				t.Fatalf("status=%d, want=%d", got, want)
+			}
+
+			_, err = dataStore.GetEntryMetadata("AAAAAAAAAA")
+			if _, ok := err.(store.EntryNotFoundError); ok == tt.available {
+				t.Errorf("available=%v, want=%v (err=%v)", !ok, tt.available, err)
+			}
+
+			trashed, err := dataStore.GetTrashedEntries()
+			if err != nil {
+				t.Fatalf("failed to get trashed entries: %v", err)
+			}
+			inTrash := len(trashed) > 0
+			if got, want := inTrash || tt.available, tt.exists; got != want {
+				t.Errorf("exists=%v, want=%v", got, want)
+			}
+		})
+	}
+}
+
+func TestTrashGet(t *testing.T) {
+	dataStore := test_sqlite.New()
+	for _, id := range []picoshare.EntryID{"AAAAAAAAAA", "BBBBBBBBBB"} {
+		contents := "dummy data"
+		if err := dataStore.InsertEntry(strings.NewReader(contents), picoshare.UploadMetadata{
+			ID:       id,
+			Filename: picoshare.Filename(id.String() + ".txt"),
+			Uploaded: mustParseTime("2023-01-01T00:00:00Z"),
+			Expires:  picoshare.NeverExpire,
+			Size:     mustParseFileSize(len(contents)),
+		}); err != nil {
+			t.Fatalf("failed to insert dummy entry: %v", err)
+		}
+	}
+	if err := dataStore.TrashEntries([]picoshare.EntryID{"AAAAAAAAAA"}); err != nil {
+		t.Fatalf("failed to trash entry: %v", err)
+	}
+
+	s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock(), handlers.Options{})
+
+	req, err := http.NewRequest("GET", "/trash", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, req)
+	res := rec.Result()
+
+	if got, want := res.StatusCode, http.StatusOK; got != want {
+		t.Fatalf("status=%d, want=%d", got, want)
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("failed to read response body: %v", err)
+	}
+	if !strings.Contains(string(body), "AAAAAAAAAA.txt") {
+		t.Errorf("trash doesn't list trashed entry")
+	}
+	if strings.Contains(string(body), "BBBBBBBBBB.txt") {
+		t.Errorf("trash lists entry that isn't in the trash")
+	}
+}
@@ -4,15 +4,18 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/mtlynch/picoshare/handlers/parse"
 	"github.com/mtlynch/picoshare/picoshare"
 	"github.com/mtlynch/picoshare/random"
 	"github.com/mtlynch/picoshare/store"
+	"github.com/mtlynch/picoshare/thumbnail"
 )
 
 const EntryIDLength = 10
@@ -25,6 +28,11 @@ type (
 		ID string `json:"id"`
 	}
 
+	EntryVersionPostResponse struct {
+		ID      string `json:"id"`
+		Version int    `json:"version"`
+	}
+
 	dbError struct {
 		Err error
 	}
@@ -50,9 +58,11 @@ func (s Server) entryPost() http.HandlerFunc {
 		// We're intentionally not limiting the size of the request because we
 		// assume that the uploading user is trusted, so they can upload files of
 		// any size they want.
-		id, err := s.insertFileFromRequest(r, expiration, picoshare.GuestLinkID(""))
+		id, err := s.insertFileFromRequest(r, expiration, picoshare.GuestLink{})
 		if err != nil {
-			if _, ok := errors.AsType[*dbError](err); ok {
+			if _, ok := errors.AsType[store.EntrySlugTakenError](err); ok {
+				http.Error(w, err.Error(), http.StatusConflict)
+			} else if _, ok := errors.AsType[*dbError](err); ok {
 				log.Printf("failed to insert uploaded file into data store: %v", err)
 				http.Error(w, "failed to insert file into database", http.StatusInternalServerError)
 			} else {
@@ -75,8 +85,17 @@ func (s Server) entryPut() http.HandlerFunc {
 			return
 		}
 
-		metadata, err := s.entryMetadataFromRequest(r)
+		existing, err := s.getDB(r).GetEntryMetadata(id)
+		if _, ok := errors.AsType[store.EntryNotFoundError](err); ok {
+			http.Error(w, "Invalid entry ID", http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("error retrieving entry with id %v: %v", id, err)
+			http.Error(w, "failed to retrieve entry", http.StatusInternalServerError)
+			return
+		}
 
+		metadata, err := s.entryMetadataFromRequest(r, existing)
 		if err != nil {
 			log.Printf("error parsing entry edit request: %v", err)
 			http.Error(w, fmt.Sprintf("Bad request: %v", err), http.StatusBadRequest)
@@ -88,6 +107,10 @@ func (s Server) entryPut() http.HandlerFunc {
 				http.Error(w, "Invalid entry ID", http.StatusNotFound)
 				return
 			}
+			if _, ok := errors.AsType[store.EntrySlugTakenError](err); ok {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
 			log.Printf("error saving entry metadata: %v", err)
 			http.Error(w, fmt.Sprintf("Failed to save new entry data: %v", err), http.StatusInternalServerError)
 			return
@@ -95,6 +118,53 @@ func (s Server) entryPut() http.HandlerFunc {
 	}
 }
 
+func (s Server) entryVersionPost() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parseEntryID(mux.Vars(r)["id"])
+		if err != nil {
+			log.Printf("error parsing ID: %v", err)
+			http.Error(w, fmt.Sprintf("bad entry ID: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		entry, err := s.getDB(r).GetEntryMetadata(id)
+		if _, ok := errors.AsType[store.EntryNotFoundError](err); ok {
+			http.Error(w, "entry not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("error retrieving entry with id %v: %v", id, err)
+			http.Error(w, "failed to retrieve entry", http.StatusInternalServerError)
+			return
+		}
+		if entry.IsSnippet {
+			http.Error(w, "Snippets don't support new versions", http.StatusBadRequest)
+			return
+		}
+
+		if err := s.insertVersionFromRequest(r, id); err != nil {
+			if _, ok := errors.AsType[store.EntryNotFoundError](err); ok {
+				http.Error(w, "entry not found", http.StatusNotFound)
+			} else if _, ok := errors.AsType[*dbError](err); ok {
+				log.Printf("failed to insert new version into data store: %v", err)
+				http.Error(w, "failed to insert file into database", http.StatusInternalServerError)
+			} else {
+				log.Printf("invalid upload: %v", err)
+				http.Error(w, fmt.Sprintf("invalid request: %s", err), http.StatusBadRequest)
+			}
+			return
+		}
+
+		entry, err = s.getDB(r).GetEntryMetadata(id)
+		if err != nil {
+			log.Printf("error retrieving entry with id %v: %v", id, err)
+			http.Error(w, "failed to retrieve entry", http.StatusInternalServerError)
+			return
+		}
+
+		respondJSON(w, EntryVersionPostResponse{ID: id.String(), Version: entry.Version})
+	}
+}
+
 func (s Server) guestEntryPost() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		guestLinkID, err := parseGuestLinkID(mux.Vars(r)["guestLinkID"])
@@ -118,11 +188,53 @@ func (s Server) guestEntryPost() http.HandlerFunc {
 			http.Error(w, "Guest link is no longer active", http.StatusUnauthorized)
 		}
 
+		if gl.RequireChallenge {
+			if err := s.guestChallenges.Verify(guestLinkID.String(), r.Header.Get(guestChallengeTokenHeader), r.Header.Get(guestChallengeSolutionHeader), s.clock.Now()); err != nil {
+				log.Printf("rejecting guest upload from %s: %v", r.RemoteAddr, err)
+				http.Error(w, fmt.Sprintf("Challenge failed: %v", err), http.StatusForbidden)
+				return
+			}
+		}
+
+		settings, err := s.getDB(r).ReadSettings()
+		if err != nil {
+			log.Printf("failed to read settings: %v", err)
+			http.Error(w, "Failed to read settings", http.StatusInternalServerError)
+			return
+		}
+
+		rateLimit := gl.RateLimit.WithDefaults(settings.DefaultGuestUploadRateLimit)
+		uploadKey := guestUploadKey(guestLinkID, clientIPFromRemoteAddr(r.RemoteAddr))
+		var maxFileBytes *uint64
 		if gl.MaxFileBytes != picoshare.GuestUploadUnlimitedFileSize {
+			maxFileBytes = gl.MaxFileBytes
+		}
+		reservation, maxBodyBytes, err := s.reserveGuestUpload(uploadKey, rateLimit, maxFileBytes)
+		if err != nil {
+			log.Printf("rejecting guest upload from %s: %v", r.RemoteAddr, err)
+			http.Error(w, fmt.Sprintf("Too many uploads: %v", err), http.StatusTooManyRequests)
+			return
+		}
+
+		body := &countingReadCloser{ReadCloser: r.Body}
+		r.Body = body
+
+		// Uploads count against the client's limits even if they fail, so that
+		// a client can't spend bandwidth without limit by sending uploads that
+		// PicoShare rejects.
+		defer func() {
+			reservation.Settle(body.bytesRead)
+		}()
+
+		if rateLimit.MaxBytesPerSecond != nil {
+			r.Body = throttleReadCloser(r.Body, *rateLimit.MaxBytesPerSecond)
+		}
+
+		if maxBodyBytes != nil {
 			// We technically allow slightly less than the user specified because
 			// other fields in the request take up some space, but it's a difference
 			// of only a few hundred bytes.
-			r.Body = http.MaxBytesReader(w, r.Body, int64(*gl.MaxFileBytes))
+			r.Body = http.MaxBytesReader(w, r.Body, int64(*maxBodyBytes))
 		}
 
 		expiration, err := s.parseGuestExpirationFromRequest(r, gl)
@@ -132,7 +244,7 @@ func (s Server) guestEntryPost() http.HandlerFunc {
 			return
 		}
 
-		id, err := s.insertFileFromRequest(r, expiration, guestLinkID)
+		id, err := s.insertFileFromRequest(r, expiration, gl)
 		if err != nil {
 			if _, ok := errors.AsType[*dbError](err); ok {
 				log.Printf("failed to insert uploaded file into data store: %v", err)
@@ -144,6 +256,10 @@ func (s Server) guestEntryPost() http.HandlerFunc {
 			return
 		}
 
+		if settings.Notifications.GuestUploads || gl.NotifyOnUpload {
+			s.notifyGuestUpload(r, id, gl)
+		}
+
 		if clientAcceptsJson(r) {
 			respondJSON(w, EntryPostResponse{ID: id.String()})
 		} else {
@@ -157,12 +273,43 @@ func (s Server) guestEntryPost() http.HandlerFunc {
 	}
 }
 
-func (s Server) entryMetadataFromRequest(r *http.Request) (picoshare.UploadMetadata, error) {
+// entryMetadataFromRequest parses the new metadata for an existing entry. The
+// fields that PicoShare added after the filename, expiration, and note are
+// optional, and the entry keeps its current value for any that the request
+// omits. An explicit null clears the field.
+func (s Server) entryMetadataFromRequest(r *http.Request, existing picoshare.UploadMetadata) (picoshare.UploadMetadata, error) {
 	var payload struct {
 		Filename   string `json:"filename"`
 		Expiration string `json:"expiration"`
 		Note       string `json:"note"`
+
+		MaxDownloadBytesPerSecond *uint64 `json:"maxDownloadBytesPerSecond"`
+
+		Tags   *[]string `json:"tags"`
+		Folder *string   `json:"folder"`
+		Slug   *string   `json:"slug"`
+
+		Visibility         *string `json:"visibility"`
+		AvailableFrom      *string `json:"availableFrom"`
+		IdleExpirationDays *uint16 `json:"idleExpirationDays"`
+	}
+
+	// Start from the entry's current values so that decoding only replaces the
+	// fields that are present in the request.
+	payload.MaxDownloadBytesPerSecond = existing.MaxDownloadBytesPerSecond
+	existingTags := make([]string, len(existing.Tags))
+	for i, tag := range existing.Tags {
+		existingTags[i] = tag.String()
+	}
+	payload.Tags = &existingTags
+	payload.Folder = new(existing.Folder.String())
+	payload.Slug = new(existing.Slug.String())
+	payload.Visibility = new(string(existing.Visibility))
+	if !existing.AvailableFrom.IsZero() {
+		payload.AvailableFrom = new(existing.AvailableFrom.UTC().Format(time.RFC3339))
 	}
+	payload.IdleExpirationDays = new(existing.IdleLifetime.Days())
+
 	err := json.NewDecoder(r.Body).Decode(&payload)
 	if err != nil {
 		log.Printf("failed to decode JSON request: %v", err)
@@ -183,18 +330,70 @@ func (s Server) entryMetadataFromRequest(r *http.Request) (picoshare.UploadMetad
 		}
 	}
 
+	availableFrom, err := parse.AvailableFrom(valueOrZero(payload.AvailableFrom), expiration)
+	if err != nil {
+		return picoshare.UploadMetadata{}, err
+	}
+
 	note, err := parse.FileNote(payload.Note)
 	if err != nil {
 		return picoshare.UploadMetadata{}, err
 	}
 
+	maxDownloadBytesPerSecond, err := parse.DownloadBytesPerSecond(payload.MaxDownloadBytesPerSecond)
+	if err != nil {
+		return picoshare.UploadMetadata{}, err
+	}
+
+	tags, err := parse.Tags(valueOrZero(payload.Tags))
+	if err != nil {
+		return picoshare.UploadMetadata{}, err
+	}
+
+	folder, err := parse.Folder(valueOrZero(payload.Folder))
+	if err != nil {
+		return picoshare.UploadMetadata{}, err
+	}
+
+	slug, err := parse.Slug(valueOrZero(payload.Slug))
+	if err != nil {
+		return picoshare.UploadMetadata{}, err
+	}
+
+	visibility, err := parse.Visibility(valueOrZero(payload.Visibility))
+	if err != nil {
+		return picoshare.UploadMetadata{}, err
+	}
+
+	idleLifetime, err := parse.IdleLifetime(valueOrZero(payload.IdleExpirationDays))
+	if err != nil {
+		return picoshare.UploadMetadata{}, err
+	}
+
 	return picoshare.UploadMetadata{
-		Filename: filename,
-		Expires:  expiration,
-		Note:     note,
+		Filename:                  filename,
+		Expires:                   expiration,
+		Note:                      note,
+		MaxDownloadBytesPerSecond: maxDownloadBytesPerSecond,
+		Tags:                      tags,
+		Folder:                    folder,
+		Slug:                      slug,
+		Visibility:                visibility,
+		AvailableFrom:             availableFrom,
+		IdleLifetime:              idleLifetime,
 	}, nil
 }
 
+// valueOrZero returns the value that p points to, or the zero value if p is
+// nil.
+func valueOrZero[T any](p *T) T {
+	if p == nil {
+		var zero T
+		return zero
+	}
+	return *p
+}
+
 func generateEntryID() picoshare.EntryID {
 	return picoshare.EntryID(random.String(EntryIDLength, entryIDCharacters))
 }
@@ -218,7 +417,7 @@ func parseEntryID(s string) (picoshare.EntryID, error) {
 	return picoshare.EntryID(s), nil
 }
 
-func (s Server) insertFileFromRequest(r *http.Request, expiration picoshare.ExpirationTime, guestLinkID picoshare.GuestLinkID) (picoshare.EntryID, error) {
+func (s Server) insertFileFromRequest(r *http.Request, expiration picoshare.ExpirationTime, guestLink picoshare.GuestLink) (picoshare.EntryID, error) {
 	// ParseMultipartForm can go above the limit we set, so set a conservative RAM
 	// limit to avoid exhausting RAM on servers with limited resources.
 	multipartMaxMemory := mibToBytes(1)
@@ -256,30 +455,209 @@ func (s Server) insertFileFromRequest(r *http.Request, expiration picoshare.Expi
 		return picoshare.EntryID(""), err
 	}
 
-	if guestLinkID != "" && note.Value != nil {
+	if guestLink.ID != "" && note.Value != nil {
 		return picoshare.EntryID(""), errors.New("guest uploads cannot have file notes")
 	}
 
+	slug, err := parse.Slug(r.FormValue("slug"))
+	if err != nil {
+		return picoshare.EntryID(""), err
+	}
+
+	if guestLink.ID != "" && slug != "" {
+		return picoshare.EntryID(""), errors.New("guest uploads cannot have custom slugs")
+	}
+
+	if guestLink.ID != "" && r.FormValue("visibility") != "" {
+		return picoshare.EntryID(""), errors.New("guest uploads cannot set visibility")
+	}
+
+	visibility, err := s.newEntryVisibility(r, r.FormValue("visibility"))
+	if err != nil {
+		return picoshare.EntryID(""), err
+	}
+
+	if guestLink.ID != "" && r.FormValue("availableFrom") != "" {
+		return picoshare.EntryID(""), errors.New("guest uploads cannot schedule availability")
+	}
+
+	availableFrom, err := parse.AvailableFrom(r.FormValue("availableFrom"), expiration)
+	if err != nil {
+		return picoshare.EntryID(""), err
+	}
+
+	if guestLink.ID != "" && r.FormValue("idleExpirationDays") != "" {
+		return picoshare.EntryID(""), errors.New("guest uploads cannot set idle expiration")
+	}
+
+	idleLifetime, err := s.newEntryIdleLifetime(r, r.FormValue("idleExpirationDays"))
+	if err != nil {
+		return picoshare.EntryID(""), err
+	}
+
+	tags := []picoshare.Tag{}
+	if guestLink.AutoTag != "" {
+		tags = append(tags, guestLink.AutoTag)
+	}
+
 	id := generateEntryID()
-	err = s.getDB(r).InsertEntry(reader,
-		picoshare.UploadMetadata{
-			ID:          id,
-			Filename:    filename,
-			ContentType: contentType,
-			Note:        note,
-			GuestLink: picoshare.GuestLink{
-				ID: guestLinkID,
-			},
-			Uploaded: s.clock.Now(),
-			Expires:  expiration,
-			Size:     fileSize,
-		})
+	if err := s.insertEntry(r, reader, picoshare.UploadMetadata{
+		ID:          id,
+		Filename:    filename,
+		ContentType: contentType,
+		Note:        note,
+		GuestLink: picoshare.GuestLink{
+			ID: guestLink.ID,
+		},
+		Tags:          tags,
+		Slug:          slug,
+		Visibility:    visibility,
+		AvailableFrom: availableFrom,
+		IdleLifetime:  idleLifetime,
+		Uploaded:      s.clock.Now(),
+		Expires:       expiration,
+		Size:          fileSize,
+	}); err != nil {
+		return picoshare.EntryID(""), err
+	}
+
+	return id, nil
+}
+
+// newEntryVisibility parses the visibility that the client requested for a new
+// entry. If the client didn't request a visibility, the new entry gets the
+// default visibility from the settings.
+func (s Server) newEntryVisibility(r *http.Request, requested string) (picoshare.Visibility, error) {
+	if requested != "" {
+		return parse.Visibility(requested)
+	}
+
+	settings, err := s.getDB(r).ReadSettings()
+	if err != nil {
+		return picoshare.VisibilityPublic, dbError{err}
+	}
+
+	return settings.DefaultVisibility, nil
+}
+
+// newEntryIdleLifetime parses the idle lifetime in days that the client
+// requested for a new entry. If the client didn't request an idle lifetime, the
+// new entry gets the default idle lifetime from the settings.
+func (s Server) newEntryIdleLifetime(r *http.Request, requestedDays string) (picoshare.FileLifetime, error) {
+	if requestedDays != "" {
+		return parse.IdleLifetimeFromString(requestedDays)
+	}
+
+	settings, err := s.getDB(r).ReadSettings()
+	if err != nil {
+		return picoshare.FileLifetime{}, dbError{err}
+	}
+
+	return settings.DefaultIdleLifetime, nil
+}
+
+// insertVersionFromRequest saves the file in the request as the new version of
+// an existing entry's contents.
+func (s Server) insertVersionFromRequest(r *http.Request, id picoshare.EntryID) error {
+	// ParseMultipartForm can go above the limit we set, so set a conservative RAM
+	// limit to avoid exhausting RAM on servers with limited resources.
+	multipartMaxMemory := mibToBytes(1)
+	if err := r.ParseMultipartForm(multipartMaxMemory); err != nil {
+		return err
+	}
+	defer func() {
+		if err := r.MultipartForm.RemoveAll(); err != nil {
+			log.Printf("failed to free multipart form resources: %v", err)
+		}
+	}()
+
+	reader, metadata, err := r.FormFile("file")
+	if err != nil {
+		return err
+	}
+
+	fileSize, err := picoshare.FileSizeFromInt64(metadata.Size)
 	if err != nil {
+		return err
+	}
+
+	filename, err := parse.Filename(metadata.Filename)
+	if err != nil {
+		return err
+	}
+
+	contentType, err := parseContentType(metadata.Header.Get("Content-Type"))
+	if err != nil {
+		return err
+	}
+
+	version := picoshare.UploadMetadata{
+		ID:          id,
+		Filename:    filename,
+		ContentType: contentType,
+		Uploaded:    s.clock.Now(),
+		Size:        fileSize,
+		Compressed:  s.compressUploads && !contentType.IsCompressed(),
+		Scan:        s.pendingScan(),
+	}
+	if err := s.getDB(r).InsertEntryVersion(reader, version); err != nil {
+		if _, ok := errors.AsType[store.EntryNotFoundError](err); ok {
+			return err
+		}
+		log.Printf("failed to save entry version: %v", err)
+		return dbError{err}
+	}
+
+	s.processNewContents(r, version)
+
+	return nil
+}
+
+// insertEntry saves a new entry to the data store and then runs the
+// post-upload processing on it.
+func (s Server) insertEntry(r *http.Request, reader io.Reader, metadata picoshare.UploadMetadata) error {
+	metadata.Compressed = s.compressUploads && !metadata.ContentType.IsCompressed()
+	metadata.Scan = s.pendingScan()
+	if err := s.getDB(r).InsertEntry(reader, metadata); err != nil {
+		if _, ok := errors.AsType[store.EntrySlugTakenError](err); ok {
+			return err
+		}
 		log.Printf("failed to save entry: %v", err)
-		return picoshare.EntryID(""), dbError{err}
+		return dbError{err}
 	}
 
-	return id, nil
+	s.processNewContents(r, metadata)
+
+	return nil
+}
+
+// processNewContents runs the post-upload processing on an entry's newly-saved
+// contents: scanning them for malware and generating a thumbnail. Both happen
+// in the background, so callers must mark the scan as pending with
+// pendingScan before saving the contents.
+func (s Server) processNewContents(r *http.Request, metadata picoshare.UploadMetadata) {
+	db := s.getDB(r)
+	enqueueThumbnail := func(result picoshare.ScanResult) {
+		// Don't decode images that might contain malware.
+		if s.thumbnails != nil && thumbnail.Supports(metadata.ContentType) && result.IsServable() {
+			s.thumbnails.Enqueue(db, metadata.ID)
+		}
+	}
+
+	if s.scans == nil {
+		enqueueThumbnail(picoshare.ScanResult{})
+		return
+	}
+	s.scans.Enqueue(db, metadata.ID, enqueueThumbnail)
+}
+
+// pendingScan returns the scan result to save with new contents, which is
+// pending if malware scanning is enabled.
+func (s Server) pendingScan() picoshare.ScanResult {
+	if s.scans == nil {
+		return picoshare.ScanResult{}
+	}
+	return picoshare.ScanResult{Status: picoshare.ScanStatusPending}
 }
 
 func parseContentType(s string) (picoshare.ContentType, error) {
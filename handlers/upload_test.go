@@ -10,10 +10,12 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/mtlynch/picoshare/challenge"
 	"github.com/mtlynch/picoshare/handlers"
 	"github.com/mtlynch/picoshare/handlers/auth/shared_secret"
 	"github.com/mtlynch/picoshare/handlers/parse"
@@ -102,7 +104,7 @@ func TestEntryPost(t *testing.T) {
 	} {
 		t.Run(tt.description, func(t *testing.T) {
 			dataStore := test_sqlite.New()
-			s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock())
+			s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock(), handlers.Options{})
 
 			formData, contentType := createMultipartFormBody(tt.filename, tt.note, bytes.NewBuffer([]byte(tt.contents)))
 
@@ -177,7 +179,16 @@ func TestEntryPut(t *testing.T) {
 		filenameExpected string
 		expiresExpected  picoshare.ExpirationTime
 		noteExpected     picoshare.FileNote
-		status           int
+		// maxDownloadBytesPerSecondExpected is nil when the entry should have
+		// no bandwidth limit.
+		maxDownloadBytesPerSecondExpected *uint64
+		tagsExpected                      []picoshare.Tag
+		folderExpected                    picoshare.Folder
+		slugExpected                      picoshare.EntrySlug
+		visibilityExpected                picoshare.Visibility
+		availableFromExpected             time.Time
+		idleLifetimeExpected              picoshare.FileLifetime
+		status                            int
 	}{
 		{
 			description: "updates metadata for valid request",
@@ -204,6 +215,160 @@ func TestEntryPut(t *testing.T) {
 			expiresExpected:  picoshare.NeverExpire,
 			status:           http.StatusOK,
 		},
+		{
+			description: "sets download bandwidth limit",
+			targetID:    "AAAAAAAAAA",
+			payload: `{
+				"filename": "cool-song.mp3",
+				"maxDownloadBytesPerSecond": 65536
+			}`,
+			filenameExpected:                  "cool-song.mp3",
+			expiresExpected:                   picoshare.NeverExpire,
+			maxDownloadBytesPerSecondExpected: makeUint64Pointer(65536),
+			status:                            http.StatusOK,
+		},
+		{
+			description: "sets tags and folder",
+			targetID:    "AAAAAAAAAA",
+			payload: `{
+				"filename": "cool-song.mp3",
+				"tags": ["Music", "demos", "music"],
+				"folder": "/albums/2024/"
+			}`,
+			filenameExpected: "cool-song.mp3",
+			expiresExpected:  picoshare.NeverExpire,
+			tagsExpected:     []picoshare.Tag{"demos", "music"},
+			folderExpected:   "albums/2024",
+			status:           http.StatusOK,
+		},
+		{
+			description: "sets custom slug",
+			targetID:    "AAAAAAAAAA",
+			payload: `{
+				"filename": "cool-song.mp3",
+				"slug": "Cool-Song"
+			}`,
+			filenameExpected: "cool-song.mp3",
+			expiresExpected:  picoshare.NeverExpire,
+			slugExpected:     "cool-song",
+			status:           http.StatusOK,
+		},
+		{
+			description: "rejects update when slug is reserved",
+			targetID:    "AAAAAAAAAA",
+			payload: `{
+				"filename": "cool-song.mp3",
+				"slug": "settings"
+			}`,
+			filenameExpected: "original-filename.mp3",
+			expiresExpected:  mustParseExpirationTime("2024-12-15T21:52:33Z"),
+			status:           http.StatusBadRequest,
+		},
+		{
+			description: "makes entry private",
+			targetID:    "AAAAAAAAAA",
+			payload: `{
+				"filename": "cool-song.mp3",
+				"visibility": "private"
+			}`,
+			filenameExpected:   "cool-song.mp3",
+			expiresExpected:    picoshare.NeverExpire,
+			visibilityExpected: picoshare.VisibilityPrivate,
+			status:             http.StatusOK,
+		},
+		{
+			description: "rejects update when visibility is invalid",
+			targetID:    "AAAAAAAAAA",
+			payload: `{
+				"filename": "cool-song.mp3",
+				"visibility": "hidden"
+			}`,
+			filenameExpected: "original-filename.mp3",
+			expiresExpected:  mustParseExpirationTime("2024-12-15T21:52:33Z"),
+			status:           http.StatusBadRequest,
+		},
+		{
+			description: "schedules availability",
+			targetID:    "AAAAAAAAAA",
+			payload: `{
+				"filename": "cool-song.mp3",
+				"expiration": "2029-01-02T01:02:03Z",
+				"availableFrom": "2028-12-01T09:00:00Z"
+			}`,
+			filenameExpected:      "cool-song.mp3",
+			expiresExpected:       mustParseExpirationTime("2029-01-02T01:02:03Z"),
+			availableFromExpected: mustParseTime("2028-12-01T09:00:00Z"),
+			status:                http.StatusOK,
+		},
+		{
+			description: "rejects update when entry becomes available after it expires",
+			targetID:    "AAAAAAAAAA",
+			payload: `{
+				"filename": "cool-song.mp3",
+				"expiration": "2029-01-02T01:02:03Z",
+				"availableFrom": "2029-02-01T00:00:00Z"
+			}`,
+			filenameExpected: "original-filename.mp3",
+			expiresExpected:  mustParseExpirationTime("2024-12-15T21:52:33Z"),
+			status:           http.StatusBadRequest,
+		},
+		{
+			description: "sets idle expiration",
+			targetID:    "AAAAAAAAAA",
+			payload: `{
+				"filename": "cool-song.mp3",
+				"expiration": "2029-01-02T01:02:03Z",
+				"idleExpirationDays": 30
+			}`,
+			filenameExpected:     "cool-song.mp3",
+			expiresExpected:      mustParseExpirationTime("2029-01-02T01:02:03Z"),
+			idleLifetimeExpected: picoshare.NewFileLifetimeInDays(30),
+			status:               http.StatusOK,
+		},
+		{
+			description: "rejects update when idle expiration is too long",
+			targetID:    "AAAAAAAAAA",
+			payload: `{
+				"filename": "cool-song.mp3",
+				"idleExpirationDays": 4000
+			}`,
+			filenameExpected: "original-filename.mp3",
+			expiresExpected:  mustParseExpirationTime("2024-12-15T21:52:33Z"),
+			status:           http.StatusBadRequest,
+		},
+		{
+			description: "rejects update when a tag is invalid",
+			targetID:    "AAAAAAAAAA",
+			payload: `{
+				"filename": "cool-song.mp3",
+				"tags": ["rock, pop"]
+			}`,
+			filenameExpected: "original-filename.mp3",
+			expiresExpected:  mustParseExpirationTime("2024-12-15T21:52:33Z"),
+			status:           http.StatusBadRequest,
+		},
+		{
+			description: "rejects update when folder is invalid",
+			targetID:    "AAAAAAAAAA",
+			payload: `{
+				"filename": "cool-song.mp3",
+				"folder": "albums/../secrets"
+			}`,
+			filenameExpected: "original-filename.mp3",
+			expiresExpected:  mustParseExpirationTime("2024-12-15T21:52:33Z"),
+			status:           http.StatusBadRequest,
+		},
+		{
+			description: "rejects update when download bandwidth limit is too low",
+			targetID:    "AAAAAAAAAA",
+			payload: `{
+				"filename": "cool-song.mp3",
+				"maxDownloadBytesPerSecond": 1
+			}`,
+			filenameExpected: "original-filename.mp3",
+			expiresExpected:  mustParseExpirationTime("2024-12-15T21:52:33Z"),
+			status:           http.StatusBadRequest,
+		},
 		{
 			description: "rejects update when filename is invalid",
 			targetID:    "AAAAAAAAAA",
@@ -250,7 +415,7 @@ func TestEntryPut(t *testing.T) {
 			metadata := originalEntry
 			metadata.Size = mustParseFileSize(len(originalData))
 			dataStore.InsertEntry(strings.NewReader((originalData)), metadata)
-			s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock())
+			s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock(), handlers.Options{})
 
 			req, err := http.NewRequest("PUT", "/api/entry/"+tt.targetID, strings.NewReader(tt.payload))
 			if err != nil {
@@ -278,6 +443,146 @@ func TestEntryPut(t *testing.T) {
 			if got, want := entry.Note.String(), tt.noteExpected.String(); got != want {
 				t.Errorf("note=%v, want=%v", got, want)
 			}
+
+			if got, want := entry.MaxDownloadBytesPerSecond, tt.maxDownloadBytesPerSecondExpected; !reflect.DeepEqual(got, want) {
+				t.Errorf("maxDownloadBytesPerSecond=%v, want=%v", got, want)
+			}
+
+			if got, want := entry.Tags, tt.tagsExpected; !slices.Equal(got, want) {
+				t.Errorf("tags=%v, want=%v", got, want)
+			}
+
+			if got, want := entry.Folder, tt.folderExpected; got != want {
+				t.Errorf("folder=%v, want=%v", got, want)
+			}
+
+			if got, want := entry.Slug, tt.slugExpected; got != want {
+				t.Errorf("slug=%v, want=%v", got, want)
+			}
+
+			if got, want := entry.Visibility, tt.visibilityExpected; got != want {
+				t.Errorf("visibility=%v, want=%v", got, want)
+			}
+
+			if got, want := entry.AvailableFrom, tt.availableFromExpected; !got.Equal(want) {
+				t.Errorf("availableFrom=%v, want=%v", got, want)
+			}
+
+			if got, want := entry.IdleLifetime, tt.idleLifetimeExpected; !got.Equal(want) {
+				t.Errorf("idleLifetime=%v, want=%v", got, want)
+			}
+		})
+	}
+}
+
+func TestEntryPutKeepsOmittedFields(t *testing.T) {
+	fullEntry := picoshare.UploadMetadata{
+		ID:                        picoshare.EntryID("AAAAAAAAAA"),
+		Filename:                  picoshare.Filename("original-filename.mp3"),
+		ContentType:               picoshare.ContentType("audio/mpeg"),
+		Uploaded:                  mustParseTime("2023-01-01T00:00:00Z"),
+		Expires:                   mustParseExpirationTime("2029-12-15T21:52:33Z"),
+		MaxDownloadBytesPerSecond: makeUint64Pointer(65536),
+		Tags:                      []picoshare.Tag{"demos", "music"},
+		Folder:                    picoshare.Folder("albums/2024"),
+		Slug:                      picoshare.EntrySlug("cool-song"),
+		Visibility:                picoshare.VisibilityPrivate,
+		AvailableFrom:             mustParseTime("2029-01-01T00:00:00Z"),
+		IdleLifetime:              picoshare.NewFileLifetimeInDays(30),
+	}
+	for _, tt := range []struct {
+		description string
+		payload     string
+		expected    picoshare.UploadMetadata
+	}{
+		{
+			description: "keeps fields that the request omits",
+			payload: `{
+				"filename": "cool-song.mp3",
+				"expiration": "2029-12-15T21:52:33Z",
+				"note": "My latest track"
+			}`,
+			expected: func() picoshare.UploadMetadata {
+				e := fullEntry
+				e.Filename = "cool-song.mp3"
+				e.Note = makeNote("My latest track")
+				return e
+			}(),
+		},
+		{
+			description: "clears fields that the request sets to null",
+			payload: `{
+				"filename": "cool-song.mp3",
+				"expiration": "2029-12-15T21:52:33Z",
+				"maxDownloadBytesPerSecond": null,
+				"tags": null,
+				"folder": null,
+				"slug": null,
+				"visibility": null,
+				"availableFrom": null,
+				"idleExpirationDays": null
+			}`,
+			expected: picoshare.UploadMetadata{
+				Filename: "cool-song.mp3",
+				Expires:  mustParseExpirationTime("2029-12-15T21:52:33Z"),
+			},
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			dataStore := test_sqlite.New()
+			originalData := "dummy original data"
+			metadata := fullEntry
+			metadata.Size = mustParseFileSize(len(originalData))
+			if err := dataStore.InsertEntry(strings.NewReader(originalData), metadata); err != nil {
+				t.Fatalf("failed to insert dummy entry: %v", err)
+			}
+			s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock(), handlers.Options{})
+
+			req, err := http.NewRequest("PUT", "/api/entry/AAAAAAAAAA", strings.NewReader(tt.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Add("Content-Type", "text/json")
+
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+
+			if got, want := rec.Code, http.StatusOK; got != want {
+				t.Fatalf("status=%d, want=%d: %s", got, want, rec.Body.String())
+			}
+
+			entry, err := dataStore.GetEntryMetadata("AAAAAAAAAA")
+			if err != nil {
+				t.Fatalf("failed to get entry from data store: %v", err)
+			}
+
+			if got, want := entry.Filename, tt.expected.Filename; got != want {
+				t.Errorf("filename=%v, want=%v", got, want)
+			}
+			if got, want := entry.Note.String(), tt.expected.Note.String(); got != want {
+				t.Errorf("note=%v, want=%v", got, want)
+			}
+			if got, want := entry.MaxDownloadBytesPerSecond, tt.expected.MaxDownloadBytesPerSecond; !reflect.DeepEqual(got, want) {
+				t.Errorf("maxDownloadBytesPerSecond=%v, want=%v", got, want)
+			}
+			if got, want := entry.Tags, tt.expected.Tags; !slices.Equal(got, want) {
+				t.Errorf("tags=%v, want=%v", got, want)
+			}
+			if got, want := entry.Folder, tt.expected.Folder; got != want {
+				t.Errorf("folder=%v, want=%v", got, want)
+			}
+			if got, want := entry.Slug, tt.expected.Slug; got != want {
+				t.Errorf("slug=%v, want=%v", got, want)
+			}
+			if got, want := entry.Visibility, tt.expected.Visibility; got != want {
+				t.Errorf("visibility=%v, want=%v", got, want)
+			}
+			if got, want := entry.AvailableFrom, tt.expected.AvailableFrom; !got.Equal(want) {
+				t.Errorf("availableFrom=%v, want=%v", got, want)
+			}
+			if got, want := entry.IdleLifetime, tt.expected.IdleLifetime; !got.Equal(want) {
+				t.Errorf("idleLifetime=%v, want=%v", got, want)
+			}
 		})
 	}
 }
@@ -297,6 +602,7 @@ func TestGuestUpload(t *testing.T) {
 		note                       string
 		status                     int
 		fileExpirationTimeExpected picoshare.ExpirationTime
+		tagsExpected               []picoshare.Tag
 	}{
 		{
 			description: "valid upload to guest link whose files never expire",
@@ -531,6 +837,21 @@ func TestGuestUpload(t *testing.T) {
 			status:                     http.StatusOK,
 			fileExpirationTimeExpected: picoshare.NeverExpire,
 		},
+		{
+			description: "applies the guest link's tag to uploads",
+			guestLinkInStore: picoshare.GuestLink{
+				ID:              picoshare.GuestLinkID("abcdefgh23456789"),
+				Created:         mustParseTime("2022-05-26T00:00:00Z"),
+				UrlExpires:      mustParseExpirationTime("2030-01-02T03:04:25Z"),
+				MaxFileLifetime: picoshare.FileLifetimeInfinite,
+				AutoTag:         picoshare.Tag("acme"),
+			},
+			currentTime:                mustParseTime("2024-01-01T00:00:00Z"),
+			url:                        "/api/guest/abcdefgh23456789",
+			status:                     http.StatusOK,
+			fileExpirationTimeExpected: picoshare.NeverExpire,
+			tagsExpected:               []picoshare.Tag{"acme"},
+		},
 		{
 			description: "guest upload with empty expiration defaults to never expire (infinite)",
 			guestLinkInStore: picoshare.GuestLink{
@@ -559,7 +880,7 @@ func TestGuestUpload(t *testing.T) {
 			}
 
 			c := mockClock{tt.currentTime}
-			s := handlers.New(authenticator, &dataStore, nilSpaceChecker, nilGarbageCollector, c)
+			s := handlers.New(authenticator, &dataStore, nilSpaceChecker, nilGarbageCollector, c, handlers.Options{})
 
 			filename := "dummyimage.png"
 			contents := "dummy bytes"
@@ -609,6 +930,10 @@ func TestGuestUpload(t *testing.T) {
 				t.Errorf("file expiration=%v, want=%v", got, want)
 			}
 
+			if got, want := entry.Tags, tt.tagsExpected; !slices.Equal(got, want) {
+				t.Errorf("tags=%v, want=%v", got, want)
+			}
+
 			entryFile, err := dataStore.ReadEntryFile(entry.ID)
 			if err != nil {
 				t.Fatalf("failed to read entry file for %v: %v", entry.ID, err)
@@ -670,7 +995,7 @@ func TestGuestUploadAcceptHeader(t *testing.T) {
 			}
 
 			c := mockClock{mustParseTime("2024-01-01T00:00:00Z")}
-			s := handlers.New(authenticator, &dataStore, nilSpaceChecker, nilGarbageCollector, c)
+			s := handlers.New(authenticator, &dataStore, nilSpaceChecker, nilGarbageCollector, c, handlers.Options{})
 
 			filename := "dummyimage.png"
 			contents := "dummy bytes"
@@ -725,6 +1050,316 @@ func TestGuestUploadAcceptHeader(t *testing.T) {
 	}
 }
 
+func TestGuestUploadRateLimit(t *testing.T) {
+	authenticator, err := shared_secret.New("dummypass")
+	if err != nil {
+		t.Fatalf("failed to create shared secret: %v", err)
+	}
+
+	type upload struct {
+		remoteAddr string
+		status     int
+	}
+
+	for _, tt := range []struct {
+		description      string
+		guestRateLimit   picoshare.GuestUploadRateLimit
+		defaultRateLimit picoshare.GuestUploadRateLimit
+		uploads          []upload
+	}{
+		{
+			description: "allows unlimited uploads when no limits are set",
+			uploads: []upload{
+				{"10.0.0.1:1234", http.StatusOK},
+				{"10.0.0.1:1234", http.StatusOK},
+				{"10.0.0.1:1234", http.StatusOK},
+			},
+		},
+		{
+			description: "rejects uploads beyond the guest link's hourly limit",
+			guestRateLimit: picoshare.GuestUploadRateLimit{
+				MaxUploadsPerIPPerHour: makeIntPointer(2),
+			},
+			uploads: []upload{
+				{"10.0.0.1:1234", http.StatusOK},
+				{"10.0.0.1:1234", http.StatusOK},
+				{"10.0.0.1:1234", http.StatusTooManyRequests},
+			},
+		},
+		{
+			description: "tracks each client IP separately",
+			guestRateLimit: picoshare.GuestUploadRateLimit{
+				MaxUploadsPerIPPerHour: makeIntPointer(1),
+			},
+			uploads: []upload{
+				{"10.0.0.1:1234", http.StatusOK},
+				{"10.0.0.2:1234", http.StatusOK},
+				{"10.0.0.1:5678", http.StatusTooManyRequests},
+			},
+		},
+		{
+			description: "applies default from settings when guest link has no limit",
+			defaultRateLimit: picoshare.GuestUploadRateLimit{
+				MaxUploadsPerIPPerHour: makeIntPointer(1),
+			},
+			uploads: []upload{
+				{"10.0.0.1:1234", http.StatusOK},
+				{"10.0.0.1:1234", http.StatusTooManyRequests},
+			},
+		},
+		{
+			description: "guest link limit takes precedence over default from settings",
+			guestRateLimit: picoshare.GuestUploadRateLimit{
+				MaxUploadsPerIPPerHour: makeIntPointer(2),
+			},
+			defaultRateLimit: picoshare.GuestUploadRateLimit{
+				MaxUploadsPerIPPerHour: makeIntPointer(1),
+			},
+			uploads: []upload{
+				{"10.0.0.1:1234", http.StatusOK},
+				{"10.0.0.1:1234", http.StatusOK},
+				{"10.0.0.1:1234", http.StatusTooManyRequests},
+			},
+		},
+		{
+			description: "rejects upload that exceeds remaining daily bytes",
+			guestRateLimit: picoshare.GuestUploadRateLimit{
+				MaxBytesPerIPPerDay: makeUint64Pointer(600),
+			},
+			uploads: []upload{
+				{"10.0.0.1:1234", http.StatusOK},
+				{"10.0.0.1:1234", http.StatusBadRequest},
+				{"10.0.0.2:1234", http.StatusOK},
+			},
+		},
+		{
+			description: "counts bytes of rejected uploads against daily limit",
+			guestRateLimit: picoshare.GuestUploadRateLimit{
+				MaxBytesPerIPPerDay: makeUint64Pointer(600),
+			},
+			uploads: []upload{
+				{"10.0.0.1:1234", http.StatusOK},
+				{"10.0.0.1:1234", http.StatusBadRequest},
+				{"10.0.0.1:1234", http.StatusTooManyRequests},
+			},
+		},
+		{
+			description: "accepts upload within bandwidth limit",
+			guestRateLimit: picoshare.GuestUploadRateLimit{
+				MaxBytesPerSecond: makeUint64Pointer(1024 * 1024),
+			},
+			uploads: []upload{
+				{"10.0.0.1:1234", http.StatusOK},
+			},
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			dataStore := test_sqlite.New()
+			if err := dataStore.InsertGuestLink(picoshare.GuestLink{
+				ID:              picoshare.GuestLinkID("abcdefgh23456789"),
+				Created:         mustParseTime("2022-05-26T00:00:00Z"),
+				UrlExpires:      mustParseExpirationTime("2030-01-02T03:04:25Z"),
+				MaxFileLifetime: picoshare.FileLifetimeInfinite,
+				RateLimit:       tt.guestRateLimit,
+			}); err != nil {
+				t.Fatalf("failed to insert dummy guest link: %v", err)
+			}
+			if err := dataStore.UpdateSettings(picoshare.Settings{
+				DefaultFileLifetime:         picoshare.NewFileLifetimeInDays(30),
+				DefaultGuestUploadRateLimit: tt.defaultRateLimit,
+			}); err != nil {
+				t.Fatalf("failed to update settings: %v", err)
+			}
+
+			c := mockClock{mustParseTime("2024-01-01T00:00:00Z")}
+			s := handlers.New(authenticator, &dataStore, nilSpaceChecker, nilGarbageCollector, c, handlers.Options{})
+
+			for i, u := range tt.uploads {
+				formData, contentType := createMultipartFormBody("dummyimage.png", "", strings.NewReader("dummy bytes"))
+
+				req, err := http.NewRequest("POST", "/api/guest/abcdefgh23456789", formData)
+				if err != nil {
+					t.Fatal(err)
+				}
+				req.RemoteAddr = u.remoteAddr
+				req.Header.Add("Content-Type", contentType)
+				req.Header.Add("Accept", "application/json")
+
+				rec := httptest.NewRecorder()
+				s.Router().ServeHTTP(rec, req)
+				res := rec.Result()
+
+				if got, want := res.StatusCode, u.status; got != want {
+					t.Fatalf("upload %d: status=%d, want=%d", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGuestUploadRateLimitCountsFailedUploads(t *testing.T) {
+	dataStore := test_sqlite.New()
+	if err := dataStore.InsertGuestLink(picoshare.GuestLink{
+		ID:              picoshare.GuestLinkID("abcdefgh23456789"),
+		Created:         mustParseTime("2022-05-26T00:00:00Z"),
+		UrlExpires:      mustParseExpirationTime("2030-01-02T03:04:25Z"),
+		MaxFileLifetime: picoshare.FileLifetimeInfinite,
+		RateLimit: picoshare.GuestUploadRateLimit{
+			MaxUploadsPerIPPerHour: makeIntPointer(1),
+		},
+	}); err != nil {
+		t.Fatalf("failed to insert dummy guest link: %v", err)
+	}
+
+	c := mockClock{mustParseTime("2024-01-01T00:00:00Z")}
+	s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, c, handlers.Options{})
+
+	for i, tt := range []struct {
+		path   string
+		status int
+	}{
+		{"/api/guest/abcdefgh23456789?expiration=invalid-expiration", http.StatusBadRequest},
+		{"/api/guest/abcdefgh23456789", http.StatusTooManyRequests},
+	} {
+		formData, contentType := createMultipartFormBody("dummyimage.png", "", strings.NewReader("dummy bytes"))
+
+		req, err := http.NewRequest("POST", tt.path, formData)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Add("Content-Type", contentType)
+		req.Header.Add("Accept", "application/json")
+
+		rec := httptest.NewRecorder()
+		s.Router().ServeHTTP(rec, req)
+
+		if got, want := rec.Result().StatusCode, tt.status; got != want {
+			t.Fatalf("upload %d: status=%d, want=%d", i, got, want)
+		}
+	}
+}
+
+func TestGuestUploadChallenge(t *testing.T) {
+	authenticator, err := shared_secret.New("dummypass")
+	if err != nil {
+		t.Fatalf("failed to create shared secret: %v", err)
+	}
+
+	for _, tt := range []struct {
+		description      string
+		requireChallenge bool
+		// solve returns the challenge headers to send with the upload, or
+		// empty strings to send none.
+		solve  func(c challenge.Challenge) (token, solution string)
+		status int
+	}{
+		{
+			description:      "accepts upload without a challenge when link doesn't require one",
+			requireChallenge: false,
+			solve: func(challenge.Challenge) (string, string) {
+				return "", ""
+			},
+			status: http.StatusOK,
+		},
+		{
+			description:      "accepts upload with a solved challenge",
+			requireChallenge: true,
+			solve: func(c challenge.Challenge) (string, string) {
+				return c.Token, challenge.Solve(c)
+			},
+			status: http.StatusOK,
+		},
+		{
+			description:      "rejects upload without a challenge when link requires one",
+			requireChallenge: true,
+			solve: func(challenge.Challenge) (string, string) {
+				return "", ""
+			},
+			status: http.StatusForbidden,
+		},
+		{
+			description:      "rejects upload with an incorrect solution",
+			requireChallenge: true,
+			solve: func(c challenge.Challenge) (string, string) {
+				return c.Token, challenge.Solve(c) + "0"
+			},
+			status: http.StatusForbidden,
+		},
+		{
+			description:      "rejects upload with a forged token",
+			requireChallenge: true,
+			solve: func(challenge.Challenge) (string, string) {
+				forged := challenge.NewIssuer([]byte("attacker-key"), 1, time.Hour).Issue("abcdefgh23456789", mustParseTime("2024-01-01T00:00:00Z"))
+				return forged.Token, challenge.Solve(forged)
+			},
+			status: http.StatusForbidden,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			dataStore := test_sqlite.New()
+			if err := dataStore.InsertGuestLink(picoshare.GuestLink{
+				ID:               picoshare.GuestLinkID("abcdefgh23456789"),
+				Created:          mustParseTime("2022-05-26T00:00:00Z"),
+				UrlExpires:       mustParseExpirationTime("2030-01-02T03:04:25Z"),
+				MaxFileLifetime:  picoshare.FileLifetimeInfinite,
+				RequireChallenge: tt.requireChallenge,
+			}); err != nil {
+				t.Fatalf("failed to insert dummy guest link: %v", err)
+			}
+
+			c := mockClock{mustParseTime("2024-01-01T00:00:00Z")}
+			s := handlers.New(authenticator, &dataStore, nilSpaceChecker, nilGarbageCollector, c, handlers.Options{})
+
+			token, solution := tt.solve(mustGetGuestChallenge(s, "abcdefgh23456789"))
+
+			formData, contentType := createMultipartFormBody("dummyimage.png", "", strings.NewReader("dummy bytes"))
+			req, err := http.NewRequest("POST", "/api/guest/abcdefgh23456789", formData)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Add("Content-Type", contentType)
+			req.Header.Add("Accept", "application/json")
+			if token != "" {
+				req.Header.Add("PicoShare-Challenge-Token", token)
+				req.Header.Add("PicoShare-Challenge-Solution", solution)
+			}
+
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+			res := rec.Result()
+
+			if got, want := res.StatusCode, tt.status; got != want {
+				t.Fatalf("status=%d, want=%d", got, want)
+			}
+		})
+	}
+}
+
+func mustGetGuestChallenge(s handlers.Server, guestLinkID string) challenge.Challenge {
+	req, err := http.NewRequest("GET", "/api/guest/"+guestLinkID+"/challenge", nil)
+	if err != nil {
+		panic(err)
+	}
+
+	rec := httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, req)
+	res := rec.Result()
+	if res.StatusCode != http.StatusOK {
+		panic(fmt.Sprintf("challenge request failed with status %d", res.StatusCode))
+	}
+
+	var response handlers.GuestChallengeResponse
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		panic(err)
+	}
+
+	return challenge.Challenge{
+		Token:      response.Token,
+		Difficulty: response.Difficulty,
+	}
+}
+
 func createMultipartFormBody(filename, note string, r io.Reader) (io.Reader, string) {
 	var b bytes.Buffer
 	bw := bufio.NewWriter(&b)
@@ -780,3 +1415,341 @@ func mustParseFileSize(val int) picoshare.FileSize {
 
 	return fileSize
 }
+
+func makeIntPointer(i int) *int {
+	return &i
+}
+
+func makeUint64Pointer(i uint64) *uint64 {
+	return &i
+}
+
+func TestEntryVersionPost(t *testing.T) {
+	dataStore := test_sqlite.New()
+	for _, e := range []struct {
+		id        picoshare.EntryID
+		filename  picoshare.Filename
+		isSnippet bool
+	}{
+		{"AAAAAAAAAA", "build-1.txt", false},
+		{"SSSSSSSSSS", "snippet.txt", true},
+	} {
+		contents := "first build"
+		if err := dataStore.InsertEntry(strings.NewReader(contents), picoshare.UploadMetadata{
+			ID:          e.id,
+			Filename:    e.filename,
+			ContentType: "text/plain",
+			Uploaded:    mustParseTime("2024-01-01T00:00:00Z"),
+			Expires:     picoshare.NeverExpire,
+			Size:        mustParseFileSize(len(contents)),
+			IsSnippet:   e.isSnippet,
+		}); err != nil {
+			t.Fatalf("failed to insert dummy entry: %v", err)
+		}
+	}
+
+	s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, mockClock{mustParseTime("2024-02-01T00:00:00Z")}, handlers.Options{})
+
+	for _, tt := range []struct {
+		description string
+		route       string
+		status      int
+	}{
+		{
+			description: "rejects new version of nonexistent entry",
+			route:       "/api/entry/BBBBBBBBBB/versions",
+			status:      http.StatusNotFound,
+		},
+		{
+			description: "rejects new version of snippet",
+			route:       "/api/entry/SSSSSSSSSS/versions",
+			status:      http.StatusBadRequest,
+		},
+		{
+			description: "rejects invalid entry ID",
+			route:       "/api/entry/invalid-entry-id/versions",
+			status:      http.StatusBadRequest,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			formData, contentType := createMultipartFormBody("build-2.txt", "", strings.NewReader("second build"))
+			req, err := http.NewRequest("POST", tt.route, formData)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Add("Content-Type", contentType)
+
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+			if got, want := rec.Result().StatusCode, tt.status; got != want {
+				t.Fatalf("status=%d, want=%d", got, want)
+			}
+		})
+	}
+
+	formData, contentType := createMultipartFormBody("build-2.txt", "", strings.NewReader("second build"))
+	req, err := http.NewRequest("POST", "/api/entry/AAAAAAAAAA/versions", formData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Content-Type", contentType)
+
+	rec := httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, req)
+	res := rec.Result()
+	if got, want := res.StatusCode, http.StatusOK; got != want {
+		t.Fatalf("status=%d, want=%d", got, want)
+	}
+
+	var response handlers.EntryVersionPostResponse
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		t.Fatalf("response is not valid JSON: %v", err)
+	}
+	if got, want := response, (handlers.EntryVersionPostResponse{ID: "AAAAAAAAAA", Version: 2}); got != want {
+		t.Errorf("response=%+v, want=%+v", got, want)
+	}
+
+	for _, tt := range []struct {
+		description                string
+		route                      string
+		status                     int
+		expectedContents           string
+		expectedContentDisposition string
+	}{
+		{
+			description:                "serves latest version by default",
+			route:                      "/-AAAAAAAAAA",
+			status:                     http.StatusOK,
+			expectedContents:           "second build",
+			expectedContentDisposition: `filename="build-2.txt"`,
+		},
+		{
+			description:                "serves previous version",
+			route:                      "/-AAAAAAAAAA?v=1",
+			status:                     http.StatusOK,
+			expectedContents:           "first build",
+			expectedContentDisposition: `filename="build-1.txt"`,
+		},
+		{
+			description:                "serves latest version by number",
+			route:                      "/-AAAAAAAAAA?v=2",
+			status:                     http.StatusOK,
+			expectedContents:           "second build",
+			expectedContentDisposition: `filename="build-2.txt"`,
+		},
+		{
+			description: "rejects nonexistent version",
+			route:       "/-AAAAAAAAAA?v=3",
+			status:      http.StatusNotFound,
+		},
+		{
+			description: "rejects invalid version",
+			route:       "/-AAAAAAAAAA?v=0",
+			status:      http.StatusBadRequest,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tt.route, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+			res := rec.Result()
+
+			if got, want := res.StatusCode, tt.status; got != want {
+				t.Fatalf("status=%d, want=%d", got, want)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("failed to read response body: %v", err)
+			}
+			if got, want := string(body), tt.expectedContents; got != want {
+				t.Errorf("body=%s, want=%s", got, want)
+			}
+			if got, want := res.Header.Get("Content-Disposition"), tt.expectedContentDisposition; got != want {
+				t.Errorf("Content-Disposition=%s, want=%s", got, want)
+			}
+		})
+	}
+
+	downloads, err := dataStore.GetEntryDownloads("AAAAAAAAAA")
+	if err != nil {
+		t.Fatalf("failed to get downloads: %v", err)
+	}
+	versionsDownloaded := []int{}
+	for _, d := range downloads {
+		versionsDownloaded = append(versionsDownloaded, d.Version)
+	}
+	slices.Sort(versionsDownloaded)
+	if got, want := versionsDownloaded, []int{1, 2, 2}; !slices.Equal(got, want) {
+		t.Errorf("versions downloaded=%v, want=%v", got, want)
+	}
+}
+
+func TestEntryPostWithSlug(t *testing.T) {
+	for _, tt := range []struct {
+		description  string
+		slug         string
+		guestLinkID  string
+		status       int
+		slugExpected picoshare.EntrySlug
+	}{
+		{
+			description:  "sets custom slug",
+			slug:         "Holiday-Photos",
+			status:       http.StatusOK,
+			slugExpected: "holiday-photos",
+		},
+		{
+			description: "rejects slug that another entry uses",
+			slug:        "q3-report",
+			status:      http.StatusConflict,
+		},
+		{
+			description: "rejects reserved slug",
+			slug:        "login",
+			status:      http.StatusBadRequest,
+		},
+		{
+			description: "rejects slug with invalid characters",
+			slug:        "holiday photos",
+			status:      http.StatusBadRequest,
+		},
+		{
+			description: "rejects slug from guest",
+			slug:        "holiday-photos",
+			guestLinkID: "abcdefgh23456789",
+			status:      http.StatusBadRequest,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			dataStore := test_sqlite.New()
+			contents := "dummy data"
+			if err := dataStore.InsertEntry(strings.NewReader(contents), picoshare.UploadMetadata{
+				ID:       "AAAAAAAAAA",
+				Filename: "q3-report.pdf",
+				Uploaded: mustParseTime("2024-01-01T00:00:00Z"),
+				Expires:  picoshare.NeverExpire,
+				Size:     mustParseFileSize(len(contents)),
+				Slug:     "q3-report",
+			}); err != nil {
+				t.Fatalf("failed to insert dummy entry: %v", err)
+			}
+			if err := dataStore.InsertGuestLink(picoshare.GuestLink{
+				ID:              picoshare.GuestLinkID("abcdefgh23456789"),
+				Created:         mustParseTime("2024-01-01T00:00:00Z"),
+				UrlExpires:      picoshare.NeverExpire,
+				MaxFileLifetime: picoshare.FileLifetimeInfinite,
+				IsDisabled:      false,
+			}); err != nil {
+				t.Fatalf("failed to insert dummy guest link: %v", err)
+			}
+
+			s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock(), handlers.Options{})
+
+			var b bytes.Buffer
+			mw := multipart.NewWriter(&b)
+			f, err := mw.CreateFormFile("file", "photos.zip")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := f.Write([]byte("dummy bytes")); err != nil {
+				t.Fatal(err)
+			}
+			if err := mw.WriteField("slug", tt.slug); err != nil {
+				t.Fatal(err)
+			}
+			if err := mw.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			route := "/api/entry?expiration=2040-01-01T00:00:00Z"
+			if tt.guestLinkID != "" {
+				route = "/api/guest/" + tt.guestLinkID
+			}
+			req, err := http.NewRequest("POST", route, &b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Add("Content-Type", mw.FormDataContentType())
+			req.Header.Add("Accept", "application/json")
+
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+			res := rec.Result()
+
+			if got, want := res.StatusCode, tt.status; got != want {
+				t.Fatalf("status=%d, want=%d", got, want)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var response handlers.EntryPostResponse
+			if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+				t.Fatalf("response is not valid JSON: %v", err)
+			}
+
+			id, err := dataStore.GetEntryIDBySlug(tt.slugExpected)
+			if err != nil {
+				t.Fatalf("failed to get entry by slug: %v", err)
+			}
+			if got, want := id.String(), response.ID; got != want {
+				t.Errorf("id=%v, want=%v", got, want)
+			}
+		})
+	}
+}
+
+func TestEntryPutSlugConflict(t *testing.T) {
+	dataStore := test_sqlite.New()
+	for _, e := range []struct {
+		id   picoshare.EntryID
+		slug picoshare.EntrySlug
+	}{
+		{"AAAAAAAAAA", "q3-report"},
+		{"BBBBBBBBBB", ""},
+	} {
+		contents := "dummy data"
+		if err := dataStore.InsertEntry(strings.NewReader(contents), picoshare.UploadMetadata{
+			ID:       e.id,
+			Filename: picoshare.Filename(e.id.String() + ".pdf"),
+			Uploaded: mustParseTime("2024-01-01T00:00:00Z"),
+			Expires:  picoshare.NeverExpire,
+			Size:     mustParseFileSize(len(contents)),
+			Slug:     e.slug,
+		}); err != nil {
+			t.Fatalf("failed to insert dummy entry: %v", err)
+		}
+	}
+
+	s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock(), handlers.Options{})
+
+	req, err := http.NewRequest("PUT", "/api/entry/BBBBBBBBBB", strings.NewReader(`{
+		"filename": "BBBBBBBBBB.pdf",
+		"slug": "q3-report"
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, req)
+	if got, want := rec.Result().StatusCode, http.StatusConflict; got != want {
+		t.Fatalf("status=%d, want=%d", got, want)
+	}
+
+	entry, err := dataStore.GetEntryMetadata("BBBBBBBBBB")
+	if err != nil {
+		t.Fatalf("failed to get entry: %v", err)
+	}
+	if got, want := entry.Slug, picoshare.EntrySlug(""); got != want {
+		t.Errorf("slug=%v, want=%v", got, want)
+	}
+}
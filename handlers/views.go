@@ -10,12 +10,14 @@ import (
 	"math"
 	"net/http"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/mileusna/useragent"
 	"github.com/mtlynch/picoshare/build"
 	"github.com/mtlynch/picoshare/handlers/parse"
+	"github.com/mtlynch/picoshare/notify"
 	"github.com/mtlynch/picoshare/picoshare"
 	"github.com/mtlynch/picoshare/store"
 )
@@ -142,8 +144,12 @@ func (s Server) guestLinksNewGet() http.HandlerFunc {
 			commonProps
 			ExpirationOptions   []expirationOption
 			FileLifetimeOptions []fileLifetimeOption
+			EmailConfigured     bool
+			NotifiersConfigured bool
 		}{
-			commonProps: makeCommonProps("PicoShare - New Guest Link", r.Context()),
+			commonProps:         makeCommonProps("PicoShare - New Guest Link", r.Context()),
+			EmailConfigured:     s.mailer != nil,
+			NotifiersConfigured: s.notifier != nil,
 			ExpirationOptions: []expirationOption{
 				{"1 day", s.clock.Now().AddDate(0, 0, 1), false},
 				{"7 days", s.clock.Now().AddDate(0, 0, 7), false},
@@ -180,26 +186,194 @@ func (s Server) fileIndexGet() http.HandlerFunc {
 			return fmt.Sprintf("%s (%.0f days)", t.Format(time.DateOnly), daysRemaining)
 		},
 		"formatFileSize": humanReadableFileSize,
+		"isAvailable": func(m picoshare.UploadMetadata) bool {
+			return m.IsAvailable(s.clock.Now())
+		},
+		"sortHeader": func(p fileIndexParams, field, label string) any {
+			return struct {
+				URL   string
+				Label string
+				Icon  string
+			}{p.SortURL(field), label, p.SortIcon(field)}
+		},
+		"add": func(vals ...int) int {
+			sum := 0
+			for _, v := range vals {
+				sum += v
+			}
+			return sum
+		},
 	}
 
 	t := parseTemplatesWithFuncs(fns, "templates/pages/file-index.html")
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		em, err := s.getDB(r).GetEntriesMetadata()
+		query, params, err := entryQueryFromRequest(r, s.clock.Now())
+		if err != nil {
+			log.Printf("invalid file index query: %v", err)
+			http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		page, err := s.getDB(r).QueryEntries(query)
 		if err != nil {
 			log.Printf("failed to retrieve entries metadata: %v", err)
 			http.Error(w, "failed to retrieve file index", http.StatusInternalServerError)
 			return
 		}
-		sort.Slice(em, func(i, j int) bool {
-			return em[i].Uploaded.After(em[j].Uploaded)
-		})
+
+		guestLinks, err := s.getDB(r).GetGuestLinks()
+		if err != nil {
+			log.Printf("failed to retrieve guest links: %v", err)
+			http.Error(w, "failed to retrieve guest links", http.StatusInternalServerError)
+			return
+		}
+
+		tags, err := s.getDB(r).GetTags()
+		if err != nil {
+			log.Printf("failed to retrieve tags: %v", err)
+			http.Error(w, "failed to retrieve tags", http.StatusInternalServerError)
+			return
+		}
+
+		folders, err := s.getDB(r).GetFolders()
+		if err != nil {
+			log.Printf("failed to retrieve folders: %v", err)
+			http.Error(w, "failed to retrieve folders", http.StatusInternalServerError)
+			return
+		}
+
+		pageCount := max(1, (page.Total+fileIndexPageSize-1)/fileIndexPageSize)
+
+		if err := t.Execute(w, struct {
+			commonProps
+			Files             []picoshare.UploadMetadata
+			Total             int
+			FirstIndex        int
+			Params            fileIndexParams
+			PageCount         int
+			GuestLinks        []picoshare.GuestLink
+			Tags              []picoshare.Tag
+			Folders           []picoshare.Folder
+			FileTypes         []fileTypeFilter
+			ExpirationPresets []parse.ExpirationPreset
+		}{
+			commonProps:       makeCommonProps("PicoShare - Files", r.Context()),
+			Files:             page.Entries,
+			Total:             page.Total,
+			FirstIndex:        query.Offset + 1,
+			Params:            params,
+			PageCount:         pageCount,
+			GuestLinks:        guestLinks,
+			Tags:              tags,
+			Folders:           folders,
+			FileTypes:         fileTypeFilters,
+			ExpirationPresets: parse.ExpirationPresets,
+		}); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func (s Server) trashGet() http.HandlerFunc {
+	fns := template.FuncMap{
+		"formatDate": func(t time.Time) string {
+			return t.Format(time.DateOnly)
+		},
+		"formatFileSize": humanReadableFileSize,
+	}
+
+	t := parseTemplatesWithFuncs(fns, "templates/pages/trash.html")
+
+	type trashedEntry struct {
+		picoshare.UploadMetadata
+		// Purges is when PicoShare will delete the entry permanently.
+		Purges time.Time
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		db := s.getDB(r)
+
+		entries, err := db.GetTrashedEntries()
+		if err != nil {
+			log.Printf("failed to retrieve trashed entries: %v", err)
+			http.Error(w, "failed to retrieve trash", http.StatusInternalServerError)
+			return
+		}
+
+		settings, err := db.ReadSettings()
+		if err != nil {
+			log.Printf("failed to read settings from database: %v", err)
+			http.Error(w, "Failed to read settings", http.StatusInternalServerError)
+			return
+		}
+		retention := settings.EffectiveTrashRetention()
+
+		trashed := make([]trashedEntry, len(entries))
+		for i, entry := range entries {
+			trashed[i] = trashedEntry{
+				UploadMetadata: entry,
+				Purges:         retention.ExpirationFromTime(entry.Deleted).Time(),
+			}
+		}
+
+		if err := t.Execute(w, struct {
+			commonProps
+			Entries       []trashedEntry
+			RetentionDays uint16
+		}{
+			commonProps:   makeCommonProps("PicoShare - Trash", r.Context()),
+			Entries:       trashed,
+			RetentionDays: retention.Days(),
+		}); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func (s Server) filesExpiringGet() http.HandlerFunc {
+	fns := template.FuncMap{
+		"formatDate": func(t time.Time) string {
+			return t.Format(time.DateOnly)
+		},
+		"formatFileSize": humanReadableFileSize,
+	}
+
+	t := parseTemplatesWithFuncs(fns, "templates/pages/files-expiring.html")
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		window, err := expiringSoonWindowFromRequest(r)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		entries, err := s.getExpiringEntries(r, window)
+		if err != nil {
+			log.Printf("failed to retrieve expiring entries: %v", err)
+			http.Error(w, "Failed to retrieve expiring files", http.StatusInternalServerError)
+			return
+		}
+
+		settings, err := s.getDB(r).ReadSettings()
+		if err != nil {
+			log.Printf("failed to read settings from database: %v", err)
+			http.Error(w, "Failed to read settings", http.StatusInternalServerError)
+			return
+		}
+
 		if err := t.Execute(w, struct {
 			commonProps
-			Files []picoshare.UploadMetadata
+			Entries  []picoshare.UploadMetadata
+			Days     int
+			ExtendBy string
 		}{
-			commonProps: makeCommonProps("PicoShare - Files", r.Context()),
-			Files:       em,
+			commonProps: makeCommonProps("PicoShare - Expiring Files", r.Context()),
+			Entries:     entries,
+			Days:        int(window.Hours() / 24),
+			ExtendBy:    settings.DefaultFileLifetime.FriendlyName(),
 		}); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -218,6 +392,26 @@ func (s Server) fileEditGet() http.HandlerFunc {
 			}
 			return time.Time(et).Format(time.RFC3339)
 		},
+		"formatKilobytesPerSecond": func(bytesPerSecond *uint64) string {
+			if bytesPerSecond == nil {
+				return ""
+			}
+			return fmt.Sprintf("%d", *bytesPerSecond/1024)
+		},
+		"joinTags": func(tags []picoshare.Tag) string {
+			names := make([]string, len(tags))
+			for i, tag := range tags {
+				names[i] = tag.String()
+			}
+			return strings.Join(names, ", ")
+		},
+		"formatDate": func(t time.Time) string {
+			return t.Format(time.DateOnly)
+		},
+		"formatTimestamp": func(t time.Time) string {
+			return t.UTC().Format(time.RFC3339)
+		},
+		"formatFileSize": humanReadableFileSize,
 	}
 
 	t := parseTemplatesWithFuncs(fns,
@@ -242,12 +436,30 @@ func (s Server) fileEditGet() http.HandlerFunc {
 			return
 		}
 
+		folders, err := s.getDB(r).GetFolders()
+		if err != nil {
+			log.Printf("failed to retrieve folders: %v", err)
+			http.Error(w, "failed to retrieve folders", http.StatusInternalServerError)
+			return
+		}
+
+		versions, err := s.getDB(r).GetEntryVersions(id)
+		if err != nil {
+			log.Printf("failed to retrieve versions of entry %v: %v", id, err)
+			http.Error(w, "failed to retrieve versions", http.StatusInternalServerError)
+			return
+		}
+
 		if err := t.Execute(w, struct {
 			commonProps
 			Metadata picoshare.UploadMetadata
+			Folders  []picoshare.Folder
+			Versions []picoshare.EntryVersion
 		}{
 			commonProps: makeCommonProps("PicoShare - Edit", r.Context()),
 			Metadata:    metadata,
+			Folders:     folders,
+			Versions:    versions,
 		}); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -270,12 +482,16 @@ func (s Server) fileInfoGet() http.HandlerFunc {
 			return t.Format(time.RFC3339)
 		},
 		"formatFileSize": humanReadableFileSize,
+		"formatCompressionRatio": func(ratio float64) string {
+			return fmt.Sprintf("%.1fx", ratio)
+		},
 	}
 
 	t := parseTemplatesWithFuncs(
 		fns,
 		"templates/custom-elements/upload-link-box.html",
 		"templates/custom-elements/upload-links.html",
+		"templates/custom-elements/send-link-dialog.html",
 		"templates/pages/file-info.html")
 
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -303,14 +519,25 @@ func (s Server) fileInfoGet() http.HandlerFunc {
 			return
 		}
 
+		linkRecipients, err := s.getDB(r).GetLinkRecipients(id)
+		if err != nil {
+			log.Printf("error retrieving link recipients for id %v: %v", id, err)
+			http.Error(w, "failed to retrieve link recipients", http.StatusInternalServerError)
+			return
+		}
+
 		if err := t.Execute(w, struct {
 			commonProps
-			Metadata      picoshare.UploadMetadata
-			DownloadCount int
+			Metadata        picoshare.UploadMetadata
+			DownloadCount   int
+			LinkRecipients  []picoshare.LinkRecipient
+			EmailConfigured bool
 		}{
-			commonProps:   makeCommonProps("PicoShare - File Information", r.Context()),
-			Metadata:      metadata,
-			DownloadCount: len(downloads),
+			commonProps:     makeCommonProps("PicoShare - File Information", r.Context()),
+			Metadata:        metadata,
+			DownloadCount:   len(downloads),
+			LinkRecipients:  linkRecipients,
+			EmailConfigured: s.mailer != nil,
 		}); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -378,6 +605,7 @@ func (s Server) fileDownloadsGet() http.HandlerFunc {
 			ClientIP string
 			Browser  string
 			Platform string
+			Version  int
 		}
 		records := make([]downloadRecord, len(filteredDownloads))
 		for i, d := range filteredDownloads {
@@ -387,6 +615,7 @@ func (s Server) fileDownloadsGet() http.HandlerFunc {
 				ClientIP: d.ClientIP,
 				Browser:  agent.Name,
 				Platform: agent.OS,
+				Version:  d.Version,
 			}
 		}
 
@@ -427,12 +656,22 @@ func (s Server) fileConfirmDeleteGet() http.HandlerFunc {
 			http.Error(w, "failed to retrieve entry", http.StatusInternalServerError)
 			return
 		}
+
+		settings, err := s.getDB(r).ReadSettings()
+		if err != nil {
+			log.Printf("failed to read settings from database: %v", err)
+			http.Error(w, "Failed to read settings", http.StatusInternalServerError)
+			return
+		}
+
 		if err := t.Execute(w, struct {
 			commonProps
-			Metadata picoshare.UploadMetadata
+			Metadata           picoshare.UploadMetadata
+			TrashRetentionDays uint16
 		}{
-			commonProps: makeCommonProps("PicoShare - Delete", r.Context()),
-			Metadata:    metadata,
+			commonProps:        makeCommonProps("PicoShare - Delete", r.Context()),
+			Metadata:           metadata,
+			TrashRetentionDays: settings.EffectiveTrashRetention().Days(),
 		}); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -470,6 +709,7 @@ func (s Server) uploadGet() http.HandlerFunc {
 		"templates/custom-elements/expiration-picker.html",
 		"templates/custom-elements/upload-link-box.html",
 		"templates/custom-elements/upload-links.html",
+		"templates/custom-elements/send-link-dialog.html",
 		"templates/pages/upload.html")
 
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -532,10 +772,17 @@ func (s Server) uploadGet() http.HandlerFunc {
 			ExpirationOptions []expirationOption
 			MaxNoteLength     int
 			GuestLinkMetadata picoshare.GuestLink
+			// DefaultIdleExpirationDays is zero if new files don't expire from
+			// inactivity by default.
+			DefaultIdleExpirationDays uint16
+			// EmailConfigured is true if PicoShare can email links to uploads.
+			EmailConfigured bool
 		}{
-			commonProps:       makeCommonProps("PicoShare - Upload", r.Context()),
-			MaxNoteLength:     parse.MaxFileNoteBytes,
-			ExpirationOptions: expirationOptions,
+			commonProps:               makeCommonProps("PicoShare - Upload", r.Context()),
+			MaxNoteLength:             parse.MaxFileNoteBytes,
+			ExpirationOptions:         expirationOptions,
+			DefaultIdleExpirationDays: settings.DefaultIdleLifetime.Days(),
+			EmailConfigured:           s.mailer != nil,
 		}); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -554,6 +801,7 @@ func (s Server) guestUploadGet() http.HandlerFunc {
 		"templates/custom-elements/expiration-picker.html",
 		"templates/custom-elements/upload-link-box.html",
 		"templates/custom-elements/upload-links.html",
+		"templates/custom-elements/send-link-dialog.html",
 		"templates/pages/upload.html")
 
 	tInactive := parseTemplates("templates/pages/guest-link-inactive.html")
@@ -677,16 +925,114 @@ func (s Server) settingsGet() http.HandlerFunc {
 			}
 		}
 
+		// Express the guest rate limits in the units the settings form uses. An
+		// empty string means the limit is unset.
+		guestRateLimit := settings.DefaultGuestUploadRateLimit
+		var guestUploadsPerHour, guestMegabytesPerDay, guestKilobytesPerSecond string
+		if guestRateLimit.MaxUploadsPerIPPerHour != nil {
+			guestUploadsPerHour = fmt.Sprintf("%d", *guestRateLimit.MaxUploadsPerIPPerHour)
+		}
+		if guestRateLimit.MaxBytesPerIPPerDay != nil {
+			guestMegabytesPerDay = fmt.Sprintf("%d", *guestRateLimit.MaxBytesPerIPPerDay/uint64(mibToBytes(1)))
+		}
+		if guestRateLimit.MaxBytesPerSecond != nil {
+			guestKilobytesPerSecond = fmt.Sprintf("%d", *guestRateLimit.MaxBytesPerSecond/1024)
+		}
+
+		// Limits that the server's environment overrides can't be changed from
+		// the settings page, so show the effective value instead.
+		downloadLimits := s.downloadLimitOverrides.WithDefaults(settings.DownloadLimits)
+		var downloadKilobytesPerSecond, concurrentDownloadsPerIP string
+		if downloadLimits.MaxBytesPerSecond != nil {
+			downloadKilobytesPerSecond = fmt.Sprintf("%d", *downloadLimits.MaxBytesPerSecond/1024)
+		}
+		if downloadLimits.MaxConcurrentPerIP != nil {
+			concurrentDownloadsPerIP = fmt.Sprintf("%d", *downloadLimits.MaxConcurrentPerIP)
+		}
+
+		if err := t.Execute(w, struct {
+			commonProps
+			DefaultExpiration       uint16
+			ExpirationTimeUnit      string
+			DefaultNeverExpire      bool
+			GuestUploadsPerHour     string
+			GuestMegabytesPerDay    string
+			GuestKilobytesPerSecond string
+
+			DownloadKilobytesPerSecond         string
+			DownloadBandwidthFromEnvironment   bool
+			ConcurrentDownloadsPerIP           string
+			ConcurrentDownloadsFromEnvironment bool
+
+			TrashRetentionDays uint16
+
+			DefaultVisibility string
+
+			DefaultIdleExpirationDays uint16
+
+			Notifications       picoshare.NotificationSettings
+			NotifiersConfigured bool
+		}{
+			commonProps:             makeCommonProps("PicoShare - Settings", r.Context()),
+			DefaultExpiration:       defaultExpiration,
+			ExpirationTimeUnit:      expirationTimeUnit,
+			DefaultNeverExpire:      defaultNeverExpire,
+			GuestUploadsPerHour:     guestUploadsPerHour,
+			GuestMegabytesPerDay:    guestMegabytesPerDay,
+			GuestKilobytesPerSecond: guestKilobytesPerSecond,
+
+			DownloadKilobytesPerSecond:         downloadKilobytesPerSecond,
+			DownloadBandwidthFromEnvironment:   s.downloadLimitOverrides.MaxBytesPerSecond != nil,
+			ConcurrentDownloadsPerIP:           concurrentDownloadsPerIP,
+			ConcurrentDownloadsFromEnvironment: s.downloadLimitOverrides.MaxConcurrentPerIP != nil,
+
+			TrashRetentionDays: settings.EffectiveTrashRetention().Days(),
+
+			DefaultVisibility: settings.DefaultVisibility.String(),
+
+			DefaultIdleExpirationDays: settings.DefaultIdleLifetime.Days(),
+
+			Notifications:       settings.Notifications,
+			NotifiersConfigured: s.notifier != nil,
+		}); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// notificationLogLimit is the maximum number of emails that the notification
+// log page shows.
+const notificationLogLimit = 200
+
+func (s Server) notificationsGet() http.HandlerFunc {
+	fns := template.FuncMap{
+		"formatTime": func(t time.Time) string {
+			return t.Format(time.RFC3339)
+		},
+		"joinRecipients": func(recipients []string) string {
+			return strings.Join(recipients, ", ")
+		},
+		"willRetry": func(m picoshare.NotificationEmail) bool {
+			return m.Attempts < notify.MaxEmailAttempts
+		},
+	}
+	t := parseTemplatesWithFuncs(fns, "templates/pages/notifications.html")
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		emails, err := s.getDB(r).GetNotificationEmails(notificationLogLimit)
+		if err != nil {
+			log.Printf("failed to retrieve notification emails: %v", err)
+			http.Error(w, "Failed to retrieve notification log", http.StatusInternalServerError)
+			return
+		}
+
 		if err := t.Execute(w, struct {
 			commonProps
-			DefaultExpiration  uint16
-			ExpirationTimeUnit string
-			DefaultNeverExpire bool
+			Emails []picoshare.NotificationEmail
 		}{
-			commonProps:        makeCommonProps("PicoShare - Settings", r.Context()),
-			DefaultExpiration:  defaultExpiration,
-			ExpirationTimeUnit: expirationTimeUnit,
-			DefaultNeverExpire: defaultNeverExpire,
+			commonProps: makeCommonProps("PicoShare - Notifications", r.Context()),
+			Emails:      emails,
 		}); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -714,6 +1060,7 @@ func (s Server) systemInformationGet() http.HandlerFunc {
 		if err := t.Execute(w, struct {
 			commonProps
 			TotalServingBytes uint64
+			TotalStoredBytes  uint64
 			DatabaseFileBytes uint64
 			UsedBytes         uint64
 			TotalBytes        uint64
@@ -723,6 +1070,7 @@ func (s Server) systemInformationGet() http.HandlerFunc {
 		}{
 			commonProps:       makeCommonProps("PicoShare - System Information", r.Context()),
 			TotalServingBytes: spaceUsage.TotalServingBytes,
+			TotalStoredBytes:  spaceUsage.TotalStoredBytes,
 			DatabaseFileBytes: spaceUsage.DatabaseFileSize,
 			UsedBytes:         spaceUsage.FileSystemUsedBytes,
 			TotalBytes:        spaceUsage.FileSystemTotalBytes,
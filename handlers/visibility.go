@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/signedurl"
+)
+
+// authorizeEntryDownload checks that the client can download the entry, given
+// the entry's visibility and the request's signed link, if any. It doesn't use
+// up single-use links, so the caller must call redeemSignedURL for signed
+// requests once it's ready to serve the entry. If the client can't download
+// the entry, authorizeEntryDownload writes an error response and returns false.
+func (s Server) authorizeEntryDownload(w http.ResponseWriter, r *http.Request, entry picoshare.UploadMetadata) bool {
+	signed := signedurl.IsSigned(r.URL.Query())
+
+	// A signed link is enough for entries that are only available through
+	// signed links, as long as the link is valid.
+	signedAccess := signed && entry.Visibility == picoshare.VisibilitySignedLinkOnly
+	if !signedAccess && !authorizeEntryView(w, r, entry) {
+		return false
+	}
+
+	if signed {
+		if _, ok := s.verifySignedURL(w, r, entry.ID); !ok {
+			return false
+		}
+	}
+
+	// Shared caches must not serve the response to clients that lack the
+	// authentication or signed link that this request had.
+	if signed || entry.Visibility != picoshare.VisibilityPublic {
+		w.Header().Set("Cache-Control", "private")
+	}
+
+	return true
+}
+
+// authorizeEntryView checks that the client can view the entry without a
+// signed link. If not, authorizeEntryView writes an error response and returns
+// false.
+func authorizeEntryView(w http.ResponseWriter, r *http.Request, entry picoshare.UploadMetadata) bool {
+	if entry.Visibility == picoshare.VisibilityPublic || isAuthenticated(r.Context()) {
+		return true
+	}
+
+	switch entry.Visibility {
+	case picoshare.VisibilitySignedLinkOnly:
+		http.Error(w, "This file is only available through a signed link", http.StatusForbidden)
+	default:
+		http.Error(w, "This file is private", http.StatusUnauthorized)
+	}
+	return false
+}
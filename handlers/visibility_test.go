@@ -0,0 +1,308 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mtlynch/picoshare/handlers"
+	"github.com/mtlynch/picoshare/handlers/auth/shared_secret"
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/store/test_sqlite"
+)
+
+func TestEntryGetVisibility(t *testing.T) {
+	unauthenticated, err := shared_secret.New("dummypass")
+	if err != nil {
+		t.Fatalf("failed to create shared secret: %v", err)
+	}
+
+	for _, tt := range []struct {
+		description   string
+		visibility    picoshare.Visibility
+		authenticated bool
+		signed        bool
+		snippet       bool
+		availableFrom time.Time
+		scan          picoshare.ScanResult
+		route         string
+		status        int
+	}{
+		{
+			description: "anyone can download public entry",
+			visibility:  picoshare.VisibilityPublic,
+			route:       "/-AAAAAAAAAA",
+			status:      http.StatusOK,
+		},
+		{
+			description:   "admin can download private entry",
+			visibility:    picoshare.VisibilityPrivate,
+			authenticated: true,
+			route:         "/-AAAAAAAAAA",
+			status:        http.StatusOK,
+		},
+		{
+			description: "rejects download of private entry by anonymous client",
+			visibility:  picoshare.VisibilityPrivate,
+			route:       "/-AAAAAAAAAA",
+			status:      http.StatusUnauthorized,
+		},
+		{
+			description: "rejects signed link to private entry",
+			visibility:  picoshare.VisibilityPrivate,
+			signed:      true,
+			route:       "/-AAAAAAAAAA",
+			status:      http.StatusUnauthorized,
+		},
+		{
+			description: "rejects preview of private entry by anonymous client",
+			visibility:  picoshare.VisibilityPrivate,
+			route:       "/-AAAAAAAAAA/_/view",
+			status:      http.StatusUnauthorized,
+		},
+		{
+			description: "rejects download of signed-only entry without signed link",
+			visibility:  picoshare.VisibilitySignedLinkOnly,
+			route:       "/-AAAAAAAAAA",
+			status:      http.StatusForbidden,
+		},
+		{
+			description: "allows download of signed-only entry with signed link",
+			visibility:  picoshare.VisibilitySignedLinkOnly,
+			signed:      true,
+			route:       "/-AAAAAAAAAA",
+			status:      http.StatusOK,
+		},
+		{
+			description:   "admin can download signed-only entry without signed link",
+			visibility:    picoshare.VisibilitySignedLinkOnly,
+			authenticated: true,
+			route:         "/-AAAAAAAAAA",
+			status:        http.StatusOK,
+		},
+		{
+			description: "hides quarantine of private entry from anonymous client",
+			visibility:  picoshare.VisibilityPrivate,
+			scan:        picoshare.ScanResult{Status: picoshare.ScanStatusInfected, Detail: "Eicar-Test-Signature"},
+			route:       "/-AAAAAAAAAA",
+			status:      http.StatusUnauthorized,
+		},
+		{
+			description:   "hides embargo of private entry from anonymous client",
+			visibility:    picoshare.VisibilityPrivate,
+			availableFrom: mustParseTime("2024-06-01T09:00:00Z"),
+			route:         "/-AAAAAAAAAA",
+			status:        http.StatusUnauthorized,
+		},
+		{
+			description:   "hides embargo of signed-only entry from client with invalid signed link",
+			visibility:    picoshare.VisibilitySignedLinkOnly,
+			availableFrom: mustParseTime("2024-06-01T09:00:00Z"),
+			route:         "/-AAAAAAAAAA?exp=1893456000&sig=invalid-signature",
+			status:        http.StatusForbidden,
+		},
+		{
+			description: "hides quarantine of private entry preview from anonymous client",
+			visibility:  picoshare.VisibilityPrivate,
+			scan:        picoshare.ScanResult{Status: picoshare.ScanStatusInfected, Detail: "Eicar-Test-Signature"},
+			route:       "/-AAAAAAAAAA/_/view",
+			status:      http.StatusUnauthorized,
+		},
+		{
+			description:   "hides embargo of private snippet from anonymous client",
+			visibility:    picoshare.VisibilityPrivate,
+			snippet:       true,
+			availableFrom: mustParseTime("2024-06-01T09:00:00Z"),
+			route:         "/-AAAAAAAAAA/_/raw",
+			status:        http.StatusUnauthorized,
+		},
+		{
+			description: "hides quarantine of private snippet from anonymous client",
+			visibility:  picoshare.VisibilityPrivate,
+			snippet:     true,
+			scan:        picoshare.ScanResult{Status: picoshare.ScanStatusInfected, Detail: "Eicar-Test-Signature"},
+			route:       "/-AAAAAAAAAA/_/raw",
+			status:      http.StatusUnauthorized,
+		},
+		{
+			description: "hides private entry from oEmbed",
+			visibility:  picoshare.VisibilityPrivate,
+			route:       "/oembed?url=" + url.QueryEscape("https://example.com/-AAAAAAAAAA"),
+			status:      http.StatusNotFound,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			dataStore := test_sqlite.New()
+			contents := "dummy data"
+			if err := dataStore.InsertEntry(strings.NewReader(contents), picoshare.UploadMetadata{
+				ID:            "AAAAAAAAAA",
+				Filename:      "report.txt",
+				Uploaded:      mustParseTime("2023-01-01T00:00:00Z"),
+				Expires:       picoshare.NeverExpire,
+				Size:          mustParseFileSize(len(contents)),
+				Visibility:    tt.visibility,
+				IsSnippet:     tt.snippet,
+				AvailableFrom: tt.availableFrom,
+			}); err != nil {
+				t.Fatalf("failed to insert dummy entry: %v", err)
+			}
+			if tt.scan != (picoshare.ScanResult{}) {
+				if err := dataStore.UpdateEntryVersionScanResult("AAAAAAAAAA", 1, tt.scan); err != nil {
+					t.Fatalf("failed to set scan result: %v", err)
+				}
+			}
+
+			c := mockClock{mustParseTime("2024-01-01T00:00:00Z")}
+			admin := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, c, handlers.Options{})
+			s := admin
+			if !tt.authenticated {
+				s = handlers.New(unauthenticated, &dataStore, nilSpaceChecker, nilGarbageCollector, c, handlers.Options{})
+			}
+
+			route := tt.route
+			if tt.signed {
+				req, err := http.NewRequest("POST", "/api/entry/AAAAAAAAAA/signed-urls", strings.NewReader(`{"expiresInMinutes": 15}`))
+				if err != nil {
+					t.Fatal(err)
+				}
+				rec := httptest.NewRecorder()
+				admin.Router().ServeHTTP(rec, req)
+				var response handlers.SignedURLPostResponse
+				if err := json.NewDecoder(rec.Result().Body).Decode(&response); err != nil {
+					t.Fatalf("response is not valid JSON: %v", err)
+				}
+				signedURL, err := url.Parse(response.URL)
+				if err != nil {
+					t.Fatalf("response URL is invalid: %v", err)
+				}
+				route = signedURL.RequestURI()
+			}
+
+			req, err := http.NewRequest("GET", route, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+			res := rec.Result()
+
+			if got, want := res.StatusCode, tt.status; got != want {
+				t.Fatalf("status=%d, want=%d", got, want)
+			}
+			if got := res.Header.Get("Retry-After"); got != "" {
+				t.Errorf("Retry-After=%q, want none", got)
+			}
+			if tt.status != http.StatusOK || tt.route != "/-AAAAAAAAAA" {
+				return
+			}
+
+			wantCacheControl := ""
+			if tt.signed || tt.visibility != picoshare.VisibilityPublic {
+				wantCacheControl = "private"
+			}
+			if got, want := res.Header.Get("Cache-Control"), wantCacheControl; got != want {
+				t.Errorf("Cache-Control=%q, want=%q", got, want)
+			}
+		})
+	}
+}
+
+func TestEntryPostVisibility(t *testing.T) {
+	for _, tt := range []struct {
+		description        string
+		defaultVisibility  picoshare.Visibility
+		visibility         string
+		status             int
+		visibilityExpected picoshare.Visibility
+	}{
+		{
+			description:        "uses public visibility by default",
+			status:             http.StatusOK,
+			visibilityExpected: picoshare.VisibilityPublic,
+		},
+		{
+			description:        "uses default visibility from settings",
+			defaultVisibility:  picoshare.VisibilityPrivate,
+			status:             http.StatusOK,
+			visibilityExpected: picoshare.VisibilityPrivate,
+		},
+		{
+			description:        "requested visibility overrides default",
+			defaultVisibility:  picoshare.VisibilityPrivate,
+			visibility:         "signed",
+			status:             http.StatusOK,
+			visibilityExpected: picoshare.VisibilitySignedLinkOnly,
+		},
+		{
+			description: "rejects invalid visibility",
+			visibility:  "hidden",
+			status:      http.StatusBadRequest,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			dataStore := test_sqlite.New()
+			if err := dataStore.UpdateSettings(picoshare.Settings{
+				DefaultFileLifetime: picoshare.NewFileLifetimeInDays(30),
+				DefaultVisibility:   tt.defaultVisibility,
+			}); err != nil {
+				t.Fatalf("failed to update settings: %v", err)
+			}
+
+			s := handlers.New(mockAuthenticator{}, &dataStore, nilSpaceChecker, nilGarbageCollector, handlers.NewClock(), handlers.Options{})
+
+			var b bytes.Buffer
+			mw := multipart.NewWriter(&b)
+			f, err := mw.CreateFormFile("file", "report.pdf")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := f.Write([]byte("dummy bytes")); err != nil {
+				t.Fatal(err)
+			}
+			if tt.visibility != "" {
+				if err := mw.WriteField("visibility", tt.visibility); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := mw.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			req, err := http.NewRequest("POST", "/api/entry?expiration=2040-01-01T00:00:00Z", &b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Add("Content-Type", mw.FormDataContentType())
+			req.Header.Add("Accept", "application/json")
+
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+			res := rec.Result()
+
+			if got, want := res.StatusCode, tt.status; got != want {
+				t.Fatalf("status=%d, want=%d", got, want)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var response handlers.EntryPostResponse
+			if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+				t.Fatalf("response is not valid JSON: %v", err)
+			}
+			entry, err := dataStore.GetEntryMetadata(picoshare.EntryID(response.ID))
+			if err != nil {
+				t.Fatalf("failed to get entry: %v", err)
+			}
+			if got, want := entry.Visibility, tt.visibilityExpected; got != want {
+				t.Errorf("visibility=%v, want=%v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package integrity
+
+import (
+	"log"
+	"time"
+)
+
+type Scheduler struct {
+	verifier *Verifier
+	ticker   *time.Ticker
+}
+
+func NewScheduler(verifier *Verifier, interval time.Duration) Scheduler {
+	return Scheduler{
+		verifier: verifier,
+		ticker:   time.NewTicker(interval),
+	}
+}
+
+func (s *Scheduler) StartAsync() {
+	go func() {
+		for range s.ticker.C {
+			log.Printf("verifying integrity of stored files")
+			mismatches, err := s.verifier.Verify()
+			if err != nil {
+				log.Printf("integrity verification failed: %v", err)
+				continue
+			}
+			if len(mismatches) > 0 {
+				log.Printf("found %d file(s) with corrupted data: %v", len(mismatches), mismatches)
+			}
+		}
+	}()
+}
@@ -0,0 +1,114 @@
+package integrity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/store"
+)
+
+type (
+	EntryStore interface {
+		GetEntriesMetadata() ([]picoshare.UploadMetadata, error)
+		GetEntryMetadata(id picoshare.EntryID) (picoshare.UploadMetadata, error)
+		ReadEntryFile(picoshare.EntryID) (io.ReadSeeker, error)
+		UpdateEntryIntegrityCheck(id picoshare.EntryID, check picoshare.IntegrityCheck) error
+		BackfillEntrySHA256(id picoshare.EntryID, sha256Hex string) error
+	}
+
+	Clock interface {
+		Now() time.Time
+	}
+
+	Verifier struct {
+		store EntryStore
+		clock Clock
+		mu    sync.Mutex
+	}
+)
+
+func NewVerifier(store EntryStore, clock Clock) Verifier {
+	return Verifier{
+		store: store,
+		clock: clock,
+	}
+}
+
+// Verify re-hashes the stored data for every entry and compares it to the hash
+// from upload time, recording the result on each entry. It returns the IDs of
+// entries whose data no longer matches. Entries uploaded before PicoShare
+// hashed files have no hash to compare against, so Verify stores their current
+// hash for later checks to compare against instead. Entries that fail to
+// verify are skipped so that one unreadable entry doesn't stop the others from
+// being checked.
+func (v *Verifier) Verify() ([]picoshare.EntryID, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	entries, err := v.store.GetEntriesMetadata()
+	if err != nil {
+		return nil, err
+	}
+
+	mismatches := []picoshare.EntryID{}
+	for _, e := range entries {
+		// GetEntriesMetadata doesn't include hashes, so look up each entry
+		// individually.
+		entry, err := v.store.GetEntryMetadata(e.ID)
+		if _, ok := errors.AsType[store.EntryNotFoundError](err); ok {
+			// The entry was deleted since we listed it.
+			continue
+		} else if err != nil {
+			log.Printf("failed to retrieve entry %v for integrity check: %v", e.ID, err)
+			continue
+		}
+
+		actual, err := v.hashEntry(entry.ID)
+		if err != nil {
+			log.Printf("failed to hash entry %v for integrity check: %v", entry.ID, err)
+			continue
+		}
+
+		if entry.SHA256 == "" {
+			if err := v.store.BackfillEntrySHA256(entry.ID, actual); err != nil {
+				log.Printf("failed to save hash of entry %v: %v", entry.ID, err)
+			}
+			continue
+		}
+
+		check := picoshare.IntegrityCheck{
+			Checked:  v.clock.Now(),
+			Mismatch: actual != entry.SHA256,
+		}
+		if check.Mismatch {
+			log.Printf("integrity check failed for entry %v: stored data has SHA-256 %s, want %s", entry.ID, actual, entry.SHA256)
+			mismatches = append(mismatches, entry.ID)
+		}
+
+		if err := v.store.UpdateEntryIntegrityCheck(entry.ID, check); err != nil {
+			log.Printf("failed to save integrity check for entry %v: %v", entry.ID, err)
+		}
+	}
+
+	return mismatches, nil
+}
+
+func (v *Verifier) hashEntry(id picoshare.EntryID) (string, error) {
+	entryFile, err := v.store.ReadEntryFile(id)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, entryFile); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,193 @@
+package integrity_test
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mtlynch/picoshare/integrity"
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/store/sqlite"
+	"github.com/mtlynch/picoshare/store/test_sqlite"
+)
+
+// corruptingStore simulates corruption of stored data by serving different
+// data for some entries and failing to read others.
+type corruptingStore struct {
+	sqlite.Store
+	corrupted  map[picoshare.EntryID]string
+	unreadable map[picoshare.EntryID]bool
+}
+
+func (cs corruptingStore) ReadEntryFile(id picoshare.EntryID) (io.ReadSeeker, error) {
+	if cs.unreadable[id] {
+		return nil, errors.New("dummy read error")
+	}
+	if data, ok := cs.corrupted[id]; ok {
+		return strings.NewReader(data), nil
+	}
+	return cs.Store.ReadEntryFile(id)
+}
+
+type mockClock struct {
+	t time.Time
+}
+
+func (c mockClock) Now() time.Time {
+	return c.t
+}
+
+func TestVerify(t *testing.T) {
+	dataStore := test_sqlite.New()
+	for _, id := range []picoshare.EntryID{"AAAAAAAAAA", "BBBBBBBBBB", "CCCCCCCCCC"} {
+		d := "dummy data"
+		if err := dataStore.InsertEntry(strings.NewReader(d), picoshare.UploadMetadata{
+			ID:       id,
+			Uploaded: mustParseTime("2024-01-01T00:00:00Z"),
+			Expires:  picoshare.NeverExpire,
+			Size:     mustParseFileSize(len(d)),
+		}); err != nil {
+			t.Fatalf("failed to insert entry: %v", err)
+		}
+	}
+
+	now := mustParseTime("2024-06-01T00:00:00Z")
+	v := integrity.NewVerifier(corruptingStore{
+		Store: dataStore,
+		corrupted: map[picoshare.EntryID]string{
+			"BBBBBBBBBB": "dummy dat4",
+		},
+		unreadable: map[picoshare.EntryID]bool{
+			"AAAAAAAAAA": true,
+		},
+	}, mockClock{now})
+
+	mismatches, err := v.Verify()
+	if err != nil {
+		t.Fatalf("verification failed: %v", err)
+	}
+
+	if got, want := mismatches, []picoshare.EntryID{"BBBBBBBBBB"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatches=%v, want=%v", got, want)
+	}
+
+	for _, tt := range []struct {
+		id       picoshare.EntryID
+		checked  bool
+		mismatch bool
+	}{
+		{"AAAAAAAAAA", false, false},
+		{"BBBBBBBBBB", true, true},
+		{"CCCCCCCCCC", true, false},
+	} {
+		entry, err := dataStore.GetEntryMetadata(tt.id)
+		if err != nil {
+			t.Fatalf("failed to get entry %v: %v", tt.id, err)
+		}
+		if got, want := entry.Integrity.HasRun(), tt.checked; got != want {
+			t.Errorf("entry %v has integrity check=%v, want=%v", tt.id, got, want)
+			continue
+		}
+		if tt.checked && !entry.Integrity.Checked.Equal(now) {
+			t.Errorf("entry %v checked=%v, want=%v", tt.id, entry.Integrity.Checked, now)
+		}
+		if got, want := entry.Integrity.Mismatch, tt.mismatch; got != want {
+			t.Errorf("entry %v mismatch=%v, want=%v", tt.id, got, want)
+		}
+	}
+}
+
+// legacyStore simulates entries uploaded before PicoShare hashed files, which
+// have no hash until the verifier backfills one.
+type legacyStore struct {
+	sqlite.Store
+	legacy     map[picoshare.EntryID]bool
+	backfilled map[picoshare.EntryID]string
+}
+
+func (ls legacyStore) GetEntryMetadata(id picoshare.EntryID) (picoshare.UploadMetadata, error) {
+	entry, err := ls.Store.GetEntryMetadata(id)
+	if err == nil && ls.legacy[id] {
+		entry.SHA256 = ls.backfilled[id]
+	}
+	return entry, err
+}
+
+func (ls legacyStore) BackfillEntrySHA256(id picoshare.EntryID, sha256Hex string) error {
+	ls.backfilled[id] = sha256Hex
+	return ls.Store.BackfillEntrySHA256(id, sha256Hex)
+}
+
+func TestVerifyBackfillsMissingHash(t *testing.T) {
+	dataStore := test_sqlite.New()
+	d := "dummy data"
+	if err := dataStore.InsertEntry(strings.NewReader(d), picoshare.UploadMetadata{
+		ID:       "AAAAAAAAAA",
+		Uploaded: mustParseTime("2024-01-01T00:00:00Z"),
+		Expires:  picoshare.NeverExpire,
+		Size:     mustParseFileSize(len(d)),
+	}); err != nil {
+		t.Fatalf("failed to insert entry: %v", err)
+	}
+
+	ls := legacyStore{
+		Store:      dataStore,
+		legacy:     map[picoshare.EntryID]bool{"AAAAAAAAAA": true},
+		backfilled: map[picoshare.EntryID]string{},
+	}
+	now := mustParseTime("2024-06-01T00:00:00Z")
+	v := integrity.NewVerifier(ls, mockClock{now})
+
+	mismatches, err := v.Verify()
+	if err != nil {
+		t.Fatalf("verification failed: %v", err)
+	}
+	if len(mismatches) != 0 {
+		t.Errorf("mismatches=%v, want none", mismatches)
+	}
+	// SHA-256 of "dummy data".
+	if got, want := ls.backfilled["AAAAAAAAAA"], "797bb0abff798d7200af7685dca7901edffc52bf26500d5bd97282658ee24152"; got != want {
+		t.Errorf("backfilled hash=%s, want=%s", got, want)
+	}
+	entry, err := ls.GetEntryMetadata("AAAAAAAAAA")
+	if err != nil {
+		t.Fatalf("failed to get entry: %v", err)
+	}
+	if entry.Integrity.HasRun() {
+		t.Errorf("entry has integrity check after backfilling its hash, want none")
+	}
+
+	mismatches, err = v.Verify()
+	if err != nil {
+		t.Fatalf("second verification failed: %v", err)
+	}
+	if len(mismatches) != 0 {
+		t.Errorf("mismatches on second verification=%v, want none", mismatches)
+	}
+	entry, err = ls.GetEntryMetadata("AAAAAAAAAA")
+	if err != nil {
+		t.Fatalf("failed to get entry: %v", err)
+	}
+	if !entry.Integrity.Checked.Equal(now) || entry.Integrity.Mismatch {
+		t.Errorf("integrity check=%+v, want passing check at %v", entry.Integrity, now)
+	}
+}
+
+func mustParseTime(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func mustParseFileSize(val int) picoshare.FileSize {
+	fileSize, err := picoshare.FileSizeFromInt(val)
+	if err != nil {
+		panic(err)
+	}
+	return fileSize
+}
@@ -0,0 +1,173 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"mime"
+	"net/smtp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+// MaxEmailAttempts is how many times PicoShare tries to send an email before
+// giving up on it.
+const MaxEmailAttempts = 5
+
+// EmailEntryLink is the kind of email in the send log that shares an entry's
+// link on the owner's behalf. Unlike events, notifiers never receive it.
+const EmailEntryLink = EventKind("entry.link")
+
+// EmailGuestLink is the kind of email in the send log that invites guests to
+// upload through a guest link on the owner's behalf.
+const EmailGuestLink = EventKind("guest.link")
+
+type (
+	// EmailLog stores every email that PicoShare sends so that the owner can
+	// review them and PicoShare can retry failed deliveries.
+	EmailLog interface {
+		InsertNotificationEmail(picoshare.NotificationEmail) (picoshare.NotificationEmailID, error)
+		RecordNotificationEmailAttempt(id picoshare.NotificationEmailID, attempted time.Time, sendErr error) error
+		GetNotificationEmailsToRetry(maxAttempts int) ([]picoshare.NotificationEmail, error)
+	}
+
+	// Email sends events as plain text email through an SMTP server.
+	Email struct {
+		address string
+		auth    smtp.Auth
+		from    string
+		to      []string
+		log     EmailLog
+		// mu prevents a retry from sending an email at the same time as its first
+		// attempt.
+		mu sync.Mutex
+	}
+)
+
+// NewEmail creates a notifier that sends email through the SMTP server at the
+// given address (host:port). If auth is nil, the notifier doesn't
+// authenticate to the server. The notifier records every email in the log.
+func NewEmail(address string, auth smtp.Auth, from string, to []string, log EmailLog) *Email {
+	return &Email{
+		address: address,
+		auth:    auth,
+		from:    from,
+		to:      to,
+		log:     log,
+	}
+}
+
+// Notify records an email describing the event in the send log and tries to
+// send it. If the SMTP server rejects the email, Notify returns nil because
+// RetryFailed tries the email again later.
+func (m *Email) Notify(e Event) error {
+	subject, body, err := renderEmail(e)
+	if err != nil {
+		return fmt.Errorf("failed to render email: %w", err)
+	}
+
+	_, err = m.send(e.Kind, m.to, subject, body)
+	return err
+}
+
+// SendLink emails a link to the entry to the recipients, along with an
+// optional message from the owner. It returns true if the SMTP server accepted
+// the email. If the server rejected it, RetryFailed tries the email again
+// later.
+func (m *Email) SendLink(entry picoshare.UploadMetadata, url, message string, to []string) (bool, error) {
+	subject, body, err := renderLinkEmail(entry, url, message)
+	if err != nil {
+		return false, fmt.Errorf("failed to render email: %w", err)
+	}
+
+	return m.send(EmailEntryLink, to, subject, body)
+}
+
+// SendGuestLink emails a guest link to the recipients, along with an optional
+// message from the owner. It returns true if the SMTP server accepted the
+// email. If the server rejected it, RetryFailed tries the email again later.
+func (m *Email) SendGuestLink(gl picoshare.GuestLink, url, message string, to []string) (bool, error) {
+	subject, body, err := renderGuestLinkEmail(gl, url, message)
+	if err != nil {
+		return false, fmt.Errorf("failed to render email: %w", err)
+	}
+
+	return m.send(EmailGuestLink, to, subject, body)
+}
+
+// send records an email in the send log and tries to send it. It returns true
+// if the SMTP server accepted the email, and it only returns an error if it
+// can't record the email.
+func (m *Email) send(kind EventKind, to []string, subject, body string) (bool, error) {
+	msg := picoshare.NotificationEmail{
+		Event:      string(kind),
+		Recipients: to,
+		Subject:    subject,
+		Body:       body,
+		Created:    time.Now(),
+	}
+	var err error
+	msg.ID, err = m.log.InsertNotificationEmail(msg)
+	if err != nil {
+		return false, fmt.Errorf("failed to save email to send log: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.deliver(msg, time.Now()); err != nil {
+		log.Printf("failed to send email %d, will retry: %v", msg.ID, err)
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// RetryFailed tries again to send each email in the send log that PicoShare
+// failed to send and returns how many emails it sent successfully.
+func (m *Email) RetryFailed(now time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	msgs, err := m.log.GetNotificationEmailsToRetry(MaxEmailAttempts)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, msg := range msgs {
+		if err := m.deliver(msg, now); err != nil {
+			log.Printf("failed to send email %d (attempt %d of %d): %v", msg.ID, msg.Attempts+1, MaxEmailAttempts, err)
+			continue
+		}
+		sent++
+	}
+
+	return sent, nil
+}
+
+// deliver sends an email from the send log and records the attempt.
+func (m *Email) deliver(msg picoshare.NotificationEmail, now time.Time) error {
+	sendErr := smtp.SendMail(m.address, m.auth, m.from, msg.Recipients, m.message(msg, now))
+	if err := m.log.RecordNotificationEmailAttempt(msg.ID, now, sendErr); err != nil {
+		return fmt.Errorf("failed to record email attempt: %w", err)
+	}
+	return sendErr
+}
+
+func (m *Email) message(e picoshare.NotificationEmail, now time.Time) []byte {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", m.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(e.Recipients, ", "))
+	// Encoding the subject also keeps user-controlled text like filenames from
+	// injecting line breaks into the headers.
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", e.Subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(strings.ReplaceAll(e.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return msg.Bytes()
+}
@@ -0,0 +1,80 @@
+// Package notify tells the PicoShare owner about events through external
+// services, such as webhooks and email.
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+type (
+	EventKind string
+
+	// Event is something that happened in PicoShare that the owner may want to
+	// know about.
+	Event struct {
+		Kind  EventKind
+		Entry picoshare.UploadMetadata
+		// GuestLink is the guest link that a guest used to upload the entry. It's
+		// only set for guest uploads.
+		GuestLink picoshare.GuestLink
+		// Download describes the download that triggered the event. It's only set
+		// for downloads.
+		Download picoshare.DownloadRecord
+		// URL is a link to the page where the owner can act on the event, or an
+		// empty string if PicoShare doesn't know its own URL.
+		URL string
+		// EntryURL is the entry's download link, or an empty string if PicoShare
+		// doesn't know its own URL.
+		EntryURL string
+	}
+
+	Notifier interface {
+		Notify(Event) error
+	}
+
+	// Notifiers sends each event to every notifier in the list.
+	Notifiers []Notifier
+)
+
+const (
+	// EventEntryExpiring means that an entry expires soon.
+	EventEntryExpiring = EventKind("entry.expiring")
+	// EventGuestUpload means that a guest uploaded an entry through a guest
+	// link.
+	EventGuestUpload = EventKind("guest.upload")
+	// EventSensitiveDownload means that someone other than the owner downloaded
+	// a private or signed-link-only entry.
+	EventSensitiveDownload = EventKind("entry.download")
+)
+
+// Summary returns a one-line description of the event.
+func (e Event) Summary() string {
+	switch e.Kind {
+	case EventEntryExpiring:
+		return fmt.Sprintf("%s expires on %s", e.Entry.Filename, e.Entry.Expires.Time().UTC().Format(time.DateOnly))
+	case EventGuestUpload:
+		if e.GuestLink.Label.Empty() {
+			return fmt.Sprintf("A guest uploaded %s", e.Entry.Filename)
+		}
+		return fmt.Sprintf("A guest uploaded %s through %s", e.Entry.Filename, e.GuestLink.Label)
+	case EventSensitiveDownload:
+		return fmt.Sprintf("%s downloaded %s", e.Download.ClientIP, e.Entry.Filename)
+	default:
+		return fmt.Sprintf("%s: %s", e.Kind, e.Entry.Filename)
+	}
+}
+
+// Notify sends the event to every notifier, even if some of them fail.
+func (nn Notifiers) Notify(e Event) error {
+	var errs []error
+	for _, n := range nn {
+		if err := n.Notify(e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,425 @@
+package notify_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mtlynch/picoshare/notify"
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+var dummyEvent = notify.Event{
+	Kind: notify.EventEntryExpiring,
+	Entry: picoshare.UploadMetadata{
+		ID:       "AAAAAAAAAA",
+		Filename: "report.pdf",
+		Expires:  picoshare.ExpirationTime(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)),
+	},
+	URL: "https://example.com/files/expiring?days=3",
+}
+
+func TestWebhook(t *testing.T) {
+	var payload map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("webhook payload is not valid JSON: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	if err := notify.NewWebhook(srv.URL).Notify(dummyEvent); err != nil {
+		t.Fatalf("failed to send webhook: %v", err)
+	}
+
+	if got, want := payload["event"], "entry.expiring"; got != want {
+		t.Errorf("event=%v, want=%v", got, want)
+	}
+	if got, want := payload["summary"], "report.pdf expires on 2024-01-02"; got != want {
+		t.Errorf("summary=%v, want=%v", got, want)
+	}
+	if got, want := payload["url"], dummyEvent.URL; got != want {
+		t.Errorf("url=%v, want=%v", got, want)
+	}
+}
+
+func TestWebhookRejected(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "dummy error", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	if err := notify.NewWebhook(srv.URL).Notify(dummyEvent); err == nil {
+		t.Errorf("webhook succeeded when server failed, want error")
+	}
+}
+
+func TestEmail(t *testing.T) {
+	address, messages := startFakeSMTPServer(t)
+
+	event := dummyEvent
+	event.Entry.Filename = "evil\r\nBcc: victim@example.com.pdf"
+	var sendLog fakeEmailLog
+	if err := notify.NewEmail(address, nil, "picoshare@example.com", []string{"owner@example.com"}, &sendLog).Notify(event); err != nil {
+		t.Fatalf("failed to send email: %v", err)
+	}
+
+	msg := <-messages
+	headers, _, _ := strings.Cut(msg, "\r\n\r\n")
+	if !strings.Contains(headers, "To: owner@example.com\r\n") {
+		t.Errorf("message is missing recipient: %q", msg)
+	}
+	if strings.Contains(headers, "\r\nBcc:") {
+		t.Errorf("filename injected a header into the message: %q", msg)
+	}
+	if !strings.Contains(msg, dummyEvent.URL) {
+		t.Errorf("message is missing link: %q", msg)
+	}
+
+	if got, want := len(sendLog.emails), 1; got != want {
+		t.Fatalf("send log has %d emails, want %d", got, want)
+	}
+	if !sendLog.emails[0].IsSent() {
+		t.Errorf("send log doesn't record email as sent")
+	}
+}
+
+func TestEmailTemplates(t *testing.T) {
+	size, err := picoshare.FileSizeFromInt(2048)
+	if err != nil {
+		t.Fatalf("failed to create file size: %v", err)
+	}
+	entry := picoshare.UploadMetadata{
+		ID:         "AAAAAAAAAA",
+		Filename:   "report.pdf",
+		Uploaded:   time.Date(2024, 1, 1, 15, 4, 0, 0, time.UTC),
+		Expires:    picoshare.ExpirationTime(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)),
+		Size:       size,
+		Visibility: picoshare.VisibilityPrivate,
+	}
+	for _, tt := range []struct {
+		description string
+		event       notify.Event
+		subject     string
+		bodyParts   []string
+	}{
+		{
+			description: "expiring entry",
+			event: notify.Event{
+				Kind:     notify.EventEntryExpiring,
+				Entry:    entry,
+				URL:      "https://example.com/files/expiring?days=3",
+				EntryURL: "https://example.com/-AAAAAAAAAA",
+			},
+			subject: "PicoShare: report.pdf expires on 2024-01-02",
+			bodyParts: []string{
+				"report.pdf (2.00 kB) expires on 2024-01-02.",
+				"Download link: https://example.com/-AAAAAAAAAA",
+				"https://example.com/files/expiring?days=3",
+			},
+		},
+		{
+			description: "guest upload through labeled link",
+			event: notify.Event{
+				Kind:  notify.EventGuestUpload,
+				Entry: entry,
+				GuestLink: picoshare.GuestLink{
+					ID:    "abcdefgh23456789",
+					Label: "For Alice",
+				},
+				URL:      "https://example.com/files/AAAAAAAAAA/info",
+				EntryURL: "https://example.com/-AAAAAAAAAA",
+			},
+			subject: "PicoShare: A guest uploaded report.pdf",
+			bodyParts: []string{
+				"A guest uploaded report.pdf (2.00 kB) on 2024-01-01 15:04 UTC.",
+				"Guest link: For Alice",
+				"Download link: https://example.com/-AAAAAAAAAA",
+				"Details: https://example.com/files/AAAAAAAAAA/info",
+			},
+		},
+		{
+			description: "guest upload through unlabeled link",
+			event: notify.Event{
+				Kind:  notify.EventGuestUpload,
+				Entry: entry,
+				GuestLink: picoshare.GuestLink{
+					ID: "abcdefgh23456789",
+				},
+			},
+			subject: "PicoShare: A guest uploaded report.pdf",
+			bodyParts: []string{
+				"Guest link: abcdefgh23456789",
+			},
+		},
+		{
+			description: "sensitive download",
+			event: notify.Event{
+				Kind:  notify.EventSensitiveDownload,
+				Entry: entry,
+				Download: picoshare.DownloadRecord{
+					Time:      time.Date(2024, 1, 1, 16, 0, 0, 0, time.UTC),
+					ClientIP:  "203.0.113.7",
+					UserAgent: "curl/8.0",
+				},
+				URL: "https://example.com/files/AAAAAAAAAA/downloads",
+			},
+			subject: "PicoShare: report.pdf was downloaded",
+			bodyParts: []string{
+				"Someone downloaded report.pdf (2.00 kB) on 2024-01-01 16:00 UTC.",
+				"Visibility: private",
+				"IP address: 203.0.113.7",
+				"Browser: curl/8.0",
+				"Download history: https://example.com/files/AAAAAAAAAA/downloads",
+			},
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			address, messages := startFakeSMTPServer(t)
+			var sendLog fakeEmailLog
+			if err := notify.NewEmail(address, nil, "picoshare@example.com", []string{"owner@example.com"}, &sendLog).Notify(tt.event); err != nil {
+				t.Fatalf("failed to send email: %v", err)
+			}
+			<-messages
+
+			if got, want := sendLog.emails[0].Subject, tt.subject; got != want {
+				t.Errorf("subject=%q, want=%q", got, want)
+			}
+			for _, part := range tt.bodyParts {
+				if !strings.Contains(sendLog.emails[0].Body, part) {
+					t.Errorf("body is missing %q: %q", part, sendLog.emails[0].Body)
+				}
+			}
+		})
+	}
+}
+
+func TestEmailRetriesFailedDelivery(t *testing.T) {
+	// Reserve a port, then close it so that the first attempt can't connect.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	unreachable := ln.Addr().String()
+	ln.Close()
+
+	var sendLog fakeEmailLog
+	if err := notify.NewEmail(unreachable, nil, "picoshare@example.com", []string{"owner@example.com"}, &sendLog).Notify(dummyEvent); err != nil {
+		t.Fatalf("notify returned an error for a failed delivery, want nil: %v", err)
+	}
+	if got, want := len(sendLog.emails), 1; got != want {
+		t.Fatalf("send log has %d emails, want %d", got, want)
+	}
+	if sendLog.emails[0].IsSent() {
+		t.Fatalf("send log records undeliverable email as sent")
+	}
+	if sendLog.emails[0].LastError == "" {
+		t.Errorf("send log doesn't record why delivery failed")
+	}
+
+	address, messages := startFakeSMTPServer(t)
+	sent, err := notify.NewEmail(address, nil, "picoshare@example.com", []string{"owner@example.com"}, &sendLog).RetryFailed(time.Now())
+	if err != nil {
+		t.Fatalf("failed to retry emails: %v", err)
+	}
+	if got, want := sent, 1; got != want {
+		t.Errorf("sent=%d, want=%d", got, want)
+	}
+	if msg := <-messages; !strings.Contains(msg, "Subject: PicoShare: report.pdf expires on 2024-01-02") {
+		t.Errorf("resent message has wrong subject: %q", msg)
+	}
+	if !sendLog.emails[0].IsSent() {
+		t.Errorf("send log doesn't record retried email as sent")
+	}
+	if got, want := sendLog.emails[0].Attempts, 2; got != want {
+		t.Errorf("attempts=%d, want=%d", got, want)
+	}
+}
+
+func TestEmailSendLink(t *testing.T) {
+	address, messages := startFakeSMTPServer(t)
+
+	size, err := picoshare.FileSizeFromInt(10)
+	if err != nil {
+		t.Fatalf("failed to create file size: %v", err)
+	}
+	entry := picoshare.UploadMetadata{
+		ID:       "AAAAAAAAAA",
+		Filename: "report.pdf",
+		Expires:  picoshare.ExpirationTime(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)),
+		Size:     size,
+	}
+
+	var sendLog fakeEmailLog
+	sent, err := notify.NewEmail(address, nil, "picoshare@example.com", []string{"owner@example.com"}, &sendLog).SendLink(entry, "https://example.com/-AAAAAAAAAA", "Here's the report.", []string{"alice@example.com", "bob@example.com"})
+	if err != nil {
+		t.Fatalf("failed to send link: %v", err)
+	}
+	if !sent {
+		t.Errorf("sent=false, want true")
+	}
+
+	msg := <-messages
+	headers, body, _ := strings.Cut(msg, "\r\n\r\n")
+	if !strings.Contains(headers, "To: alice@example.com, bob@example.com\r\n") {
+		t.Errorf("message has wrong recipients: %q", msg)
+	}
+	if !strings.Contains(headers, "Subject: File shared with you: report.pdf\r\n") {
+		t.Errorf("message has wrong subject: %q", msg)
+	}
+	for _, want := range []string{
+		"Here's the report.\r\n",
+		"https://example.com/-AAAAAAAAAA\r\n",
+		"This link expires on 2024-01-02.",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("message body is missing %q: %q", want, body)
+		}
+	}
+
+	if got, want := sendLog.emails[0].Event, "entry.link"; got != want {
+		t.Errorf("send log event=%v, want=%v", got, want)
+	}
+}
+
+func TestEmailSendGuestLink(t *testing.T) {
+	address, messages := startFakeSMTPServer(t)
+
+	gl := picoshare.GuestLink{
+		ID:             "abcdefgh23456789",
+		UrlExpires:     picoshare.ExpirationTime(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)),
+		MaxFileBytes:   new(uint64(5 * 1024 * 1024)),
+		MaxFileUploads: new(3),
+	}
+
+	var sendLog fakeEmailLog
+	sent, err := notify.NewEmail(address, nil, "picoshare@example.com", []string{"owner@example.com"}, &sendLog).SendGuestLink(gl, "https://example.com/g/abcdefgh23456789", "Please send me your tax forms.", []string{"alice@example.com"})
+	if err != nil {
+		t.Fatalf("failed to send guest link: %v", err)
+	}
+	if !sent {
+		t.Errorf("sent=false, want true")
+	}
+
+	msg := <-messages
+	headers, body, _ := strings.Cut(msg, "\r\n\r\n")
+	if !strings.Contains(headers, "To: alice@example.com\r\n") {
+		t.Errorf("message has wrong recipients: %q", msg)
+	}
+	if !strings.Contains(headers, "Subject: You're invited to upload files\r\n") {
+		t.Errorf("message has wrong subject: %q", msg)
+	}
+	for _, want := range []string{
+		"Please send me your tax forms.\r\n",
+		"https://example.com/g/abcdefgh23456789\r\n",
+		"Each file can be up to 5.00 MB.\r\n",
+		"You can upload up to 3 files.\r\n",
+		"This link expires on 2024-01-02.",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("message body is missing %q: %q", want, body)
+		}
+	}
+
+	if got, want := sendLog.emails[0].Event, "guest.link"; got != want {
+		t.Errorf("send log event=%v, want=%v", got, want)
+	}
+}
+
+type fakeEmailLog struct {
+	emails []picoshare.NotificationEmail
+}
+
+func (l *fakeEmailLog) InsertNotificationEmail(m picoshare.NotificationEmail) (picoshare.NotificationEmailID, error) {
+	m.ID = picoshare.NotificationEmailID(len(l.emails) + 1)
+	l.emails = append(l.emails, m)
+	return m.ID, nil
+}
+
+func (l *fakeEmailLog) RecordNotificationEmailAttempt(id picoshare.NotificationEmailID, attempted time.Time, sendErr error) error {
+	m := &l.emails[id-1]
+	m.Attempts++
+	m.LastAttempt = attempted
+	if sendErr == nil {
+		m.Sent = attempted
+		m.LastError = ""
+	} else {
+		m.LastError = sendErr.Error()
+	}
+	return nil
+}
+
+func (l *fakeEmailLog) GetNotificationEmailsToRetry(maxAttempts int) ([]picoshare.NotificationEmail, error) {
+	var unsent []picoshare.NotificationEmail
+	for _, m := range l.emails {
+		if !m.IsSent() && m.Attempts < maxAttempts {
+			unsent = append(unsent, m)
+		}
+	}
+	return unsent, nil
+}
+
+// startFakeSMTPServer starts an SMTP server that accepts one message and sends
+// the message's data on the returned channel.
+func startFakeSMTPServer(t *testing.T) (string, <-chan string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	messages := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(s string) {
+			if _, err := conn.Write([]byte(s + "\r\n")); err != nil {
+				t.Errorf("fake SMTP server failed to reply: %v", err)
+			}
+		}
+
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.Fields(line)[0]); cmd {
+			case "EHLO", "HELO":
+				reply("250 localhost")
+			case "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				var data strings.Builder
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				messages <- data.String()
+				reply("250 OK")
+			case "QUIT":
+				reply("221 Bye")
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+
+	return ln.Addr().String(), messages
+}
@@ -0,0 +1,33 @@
+package notify
+
+import (
+	"log"
+	"time"
+)
+
+// RetryScheduler periodically retries emails that PicoShare failed to send.
+type RetryScheduler struct {
+	email  *Email
+	ticker *time.Ticker
+}
+
+func NewRetryScheduler(email *Email, interval time.Duration) RetryScheduler {
+	return RetryScheduler{
+		email:  email,
+		ticker: time.NewTicker(interval),
+	}
+}
+
+func (s *RetryScheduler) StartAsync() {
+	go func() {
+		for range s.ticker.C {
+			sent, err := s.email.RetryFailed(time.Now())
+			if sent > 0 {
+				log.Printf("resent %d notification email(s)", sent)
+			}
+			if err != nil {
+				log.Printf("retrying notification emails failed: %v", err)
+			}
+		}
+	}()
+}
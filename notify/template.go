@@ -0,0 +1,139 @@
+package notify
+
+import (
+	"embed"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+//go:embed templates/*.txt
+var templatesFS embed.FS
+
+// templateFiles maps each kind of event to the template that describes it in
+// email.
+var templateFiles = map[EventKind]string{
+	EventEntryExpiring:     "entry-expiring.txt",
+	EventGuestUpload:       "guest-upload.txt",
+	EventSensitiveDownload: "entry-download.txt",
+}
+
+var templateFuncs = template.FuncMap{
+	"formatDate": func(t time.Time) string {
+		return t.UTC().Format(time.DateOnly)
+	},
+	"formatTime": func(t time.Time) string {
+		return t.UTC().Format("2006-01-02 15:04 UTC")
+	},
+	"formatSize": formatSize,
+}
+
+func formatSize(s picoshare.FileSize) string {
+	const unit = 1024
+
+	b := s.UInt64()
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := uint64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.2f %cB", float64(b)/float64(div), "kMGTPE"[exp])
+}
+
+// renderLinkEmail returns the subject and plain text body of an email that
+// shares the entry's link.
+func renderLinkEmail(entry picoshare.UploadMetadata, url, message string) (string, string, error) {
+	// Leave the expiration out of the email if the entry never expires.
+	var expires time.Time
+	if entry.Expires != picoshare.NeverExpire {
+		expires = entry.Expires.Time()
+	}
+
+	return renderTemplate("entry-link.txt", struct {
+		Entry   picoshare.UploadMetadata
+		URL     string
+		Message string
+		Expires time.Time
+	}{
+		Entry:   entry,
+		URL:     url,
+		Message: message,
+		Expires: expires,
+	})
+}
+
+// renderGuestLinkEmail returns the subject and plain text body of an email
+// that invites guests to upload through the guest link.
+func renderGuestLinkEmail(gl picoshare.GuestLink, url, message string) (string, string, error) {
+	// Leave the expiration out of the email if the link never expires.
+	var expires time.Time
+	if gl.UrlExpires != picoshare.NeverExpire {
+		expires = gl.UrlExpires.Time()
+	}
+
+	var maxFileSize string
+	if gl.MaxFileBytes != picoshare.GuestUploadUnlimitedFileSize {
+		size, err := picoshare.FileSizeFromUint64(*gl.MaxFileBytes)
+		if err != nil {
+			return "", "", err
+		}
+		maxFileSize = formatSize(size)
+	}
+
+	return renderTemplate("guest-link.txt", struct {
+		URL         string
+		Message     string
+		Expires     time.Time
+		MaxFileSize string
+		MaxUploads  int
+	}{
+		URL:         url,
+		Message:     message,
+		Expires:     expires,
+		MaxFileSize: maxFileSize,
+		MaxUploads:  maxUploads(gl),
+	})
+}
+
+// maxUploads returns how many files guests can upload through the link, or
+// zero if there's no limit.
+func maxUploads(gl picoshare.GuestLink) int {
+	if gl.MaxFileUploads == picoshare.GuestUploadUnlimitedFileUploads {
+		return 0
+	}
+	return *gl.MaxFileUploads
+}
+
+// renderEmail returns the subject and plain text body of an email that
+// describes the event.
+func renderEmail(e Event) (string, string, error) {
+	name, ok := templateFiles[e.Kind]
+	if !ok {
+		return "PicoShare: " + e.Summary(), e.Summary() + ".\n", nil
+	}
+
+	return renderTemplate(name, e)
+}
+
+func renderTemplate(name string, data any) (string, string, error) {
+	t, err := template.New(name).Funcs(templateFuncs).ParseFS(templatesFS, "templates/"+name)
+	if err != nil {
+		return "", "", err
+	}
+
+	var subject, body strings.Builder
+	if err := t.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return "", "", err
+	}
+	if err := t.ExecuteTemplate(&body, "body", data); err != nil {
+		return "", "", err
+	}
+
+	return subject.String(), body.String(), nil
+}
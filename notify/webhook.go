@@ -0,0 +1,62 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Webhook sends events as JSON in POST requests to a URL.
+type Webhook struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhook(url string) Webhook {
+	return Webhook{
+		url:    url,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (w Webhook) Notify(e Event) error {
+	type entry struct {
+		ID       string    `json:"id"`
+		Filename string    `json:"filename"`
+		Expires  time.Time `json:"expires"`
+		Size     uint64    `json:"size"`
+	}
+	body, err := json.Marshal(struct {
+		Event   EventKind `json:"event"`
+		Summary string    `json:"summary"`
+		Entry   entry     `json:"entry"`
+		URL     string    `json:"url,omitempty"`
+	}{
+		Event:   e.Kind,
+		Summary: e.Summary(),
+		Entry: entry{
+			ID:       e.Entry.ID.String(),
+			Filename: e.Entry.Filename.String(),
+			Expires:  e.Entry.Expires.Time().UTC(),
+			Size:     e.Entry.Size.UInt64(),
+		},
+		URL: e.URL,
+	})
+	if err != nil {
+		return err
+	}
+
+	res, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", res.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,9 @@
+package picoshare
+
+import "time"
+
+// IsAvailable returns true if the entry's embargo, if any, has ended by the
+// given time.
+func (m UploadMetadata) IsAvailable(now time.Time) bool {
+	return m.AvailableFrom.IsZero() || !now.Before(m.AvailableFrom)
+}
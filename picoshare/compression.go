@@ -0,0 +1,61 @@
+package picoshare
+
+import "strings"
+
+// alreadyCompressedTypes are content types whose formats compress their data
+// internally, so compressing them again costs CPU without saving space.
+var alreadyCompressedTypes = map[ContentType]bool{
+	"application/epub+zip":         true,
+	"application/gzip":             true,
+	"application/java-archive":     true,
+	"application/pdf":              true,
+	"application/vnd.rar":          true,
+	"application/x-7z-compressed":  true,
+	"application/x-bzip2":          true,
+	"application/x-gzip":           true,
+	"application/x-rar-compressed": true,
+	"application/x-xz":             true,
+	"application/x-zip-compressed": true,
+	"application/zip":              true,
+	"application/zstd":             true,
+}
+
+// IsCompressed returns true if data of this content type is typically already
+// compressed.
+func (ct ContentType) IsCompressed() bool {
+	mediaType, _, _ := strings.Cut(strings.ToLower(ct.String()), ";")
+	mediaType = strings.TrimSpace(mediaType)
+
+	if alreadyCompressedTypes[ContentType(mediaType)] {
+		return true
+	}
+
+	// Office Open XML and OpenDocument files are zip archives.
+	if strings.HasPrefix(mediaType, "application/vnd.openxmlformats-officedocument.") ||
+		strings.HasPrefix(mediaType, "application/vnd.oasis.opendocument.") {
+		return true
+	}
+
+	// Nearly all audio, video, and image formats are compressed, with a few
+	// uncompressed exceptions.
+	switch mediaType {
+	case "image/bmp", "image/svg+xml", "image/tiff", "image/x-icon", "audio/wav", "audio/x-wav":
+		return false
+	}
+	for _, prefix := range []string{"audio/", "image/", "video/"} {
+		if strings.HasPrefix(mediaType, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CompressionRatio returns the ratio of the entry's size to the size of its
+// data in storage. It returns 1 if the stored size is unknown.
+func (m UploadMetadata) CompressionRatio() float64 {
+	if m.StoredSize.UInt64() == 0 {
+		return 1
+	}
+	return float64(m.Size.UInt64()) / float64(m.StoredSize.UInt64())
+}
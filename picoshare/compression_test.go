@@ -0,0 +1,34 @@
+package picoshare_test
+
+import (
+	"testing"
+
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+func TestContentTypeIsCompressed(t *testing.T) {
+	for _, tt := range []struct {
+		contentType picoshare.ContentType
+		want        bool
+	}{
+		{"text/plain", false},
+		{"text/csv; charset=utf-8", false},
+		{"application/json", false},
+		{"application/octet-stream", false},
+		{"image/svg+xml", false},
+		{"image/bmp", false},
+		{"audio/wav", false},
+		{"image/jpeg", true},
+		{"video/mp4", true},
+		{"audio/mpeg", true},
+		{"application/zip", true},
+		{"Application/GZIP", true},
+		{"application/vnd.openxmlformats-officedocument.wordprocessingml.document", true},
+	} {
+		t.Run(tt.contentType.String(), func(t *testing.T) {
+			if got, want := tt.contentType.IsCompressed(), tt.want; got != want {
+				t.Errorf("IsCompressed=%v, want=%v", got, want)
+			}
+		})
+	}
+}
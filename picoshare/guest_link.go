@@ -10,6 +10,14 @@ type (
 	GuestUploadMaxFileBytes *uint64
 	GuestUploadCountLimit   *int
 
+	// GuestUploadRateLimit restricts how quickly a single client IP can upload
+	// files through a guest link. A nil field means that limit doesn't apply.
+	GuestUploadRateLimit struct {
+		MaxUploadsPerIPPerHour *int
+		MaxBytesPerIPPerDay    *uint64
+		MaxBytesPerSecond      *uint64
+	}
+
 	GuestLink struct {
 		ID              GuestLinkID
 		Label           GuestLinkLabel
@@ -18,8 +26,20 @@ type (
 		MaxFileLifetime FileLifetime
 		MaxFileBytes    GuestUploadMaxFileBytes
 		MaxFileUploads  GuestUploadCountLimit
-		IsDisabled      bool
-		FilesUploaded   int
+		RateLimit       GuestUploadRateLimit
+		// RequireChallenge means that guests must solve a proof-of-work
+		// challenge in their browser before uploading, which blocks uploads from
+		// the command line.
+		RequireChallenge bool
+		// AutoTag is a tag that PicoShare applies to every file that guests upload
+		// through this link. An empty tag means no tag.
+		AutoTag Tag
+		// NotifyOnUpload means that PicoShare notifies the owner when a guest
+		// uploads through this link, even if guest upload notifications are off
+		// in the settings.
+		NotifyOnUpload bool
+		IsDisabled     bool
+		FilesUploaded  int
 	}
 )
 
@@ -58,6 +78,21 @@ func (gl GuestLink) IsActive() bool {
 	return !gl.IsExpired() && gl.CanAcceptMoreFiles() && !gl.IsDisabled
 }
 
+// WithDefaults returns a copy of the rate limit where any unset limit is
+// replaced with the corresponding limit from defaults.
+func (rl GuestUploadRateLimit) WithDefaults(defaults GuestUploadRateLimit) GuestUploadRateLimit {
+	if rl.MaxUploadsPerIPPerHour == nil {
+		rl.MaxUploadsPerIPPerHour = defaults.MaxUploadsPerIPPerHour
+	}
+	if rl.MaxBytesPerIPPerDay == nil {
+		rl.MaxBytesPerIPPerDay = defaults.MaxBytesPerIPPerDay
+	}
+	if rl.MaxBytesPerSecond == nil {
+		rl.MaxBytesPerSecond = defaults.MaxBytesPerSecond
+	}
+	return rl
+}
+
 func (label GuestLinkLabel) Empty() bool {
 	return label.String() == ""
 }
@@ -0,0 +1,17 @@
+package picoshare
+
+import "time"
+
+// IntegrityCheck records the last time we re-hashed an entry's stored data to
+// verify that it still matches the hash we computed at upload time.
+type IntegrityCheck struct {
+	// Checked is the time of the last check, or the zero time if we've never
+	// checked the entry.
+	Checked time.Time
+	// Mismatch is true if the stored data no longer matches its hash.
+	Mismatch bool
+}
+
+func (ic IntegrityCheck) HasRun() bool {
+	return !ic.Checked.IsZero()
+}
@@ -0,0 +1,42 @@
+package picoshare
+
+import "time"
+
+type (
+	// NotificationSettings controls which events PicoShare notifies the owner
+	// about.
+	NotificationSettings struct {
+		GuestUploads bool
+		// SensitiveDownloads means downloads of private and signed-link-only
+		// entries by anyone other than the owner.
+		SensitiveDownloads bool
+		ExpiringEntries    bool
+	}
+
+	NotificationEmailID int64
+
+	// NotificationEmail is an email in PicoShare's send log.
+	NotificationEmail struct {
+		ID         NotificationEmailID
+		Event      string
+		Recipients []string
+		Subject    string
+		Body       string
+		Created    time.Time
+		Attempts   int
+		// LastAttempt is the zero time if PicoShare hasn't tried to send the
+		// email yet.
+		LastAttempt time.Time
+		// Sent is the time the SMTP server accepted the email, or the zero time
+		// if PicoShare hasn't delivered it.
+		Sent time.Time
+		// LastError describes why the most recent attempt to send the email
+		// failed, or is empty if it succeeded.
+		LastError string
+	}
+)
+
+// IsSent returns true if the SMTP server accepted the email.
+func (m NotificationEmail) IsSent() bool {
+	return !m.Sent.IsZero()
+}
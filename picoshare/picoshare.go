@@ -25,12 +25,71 @@ type (
 		Size          FileSize
 		GuestLink     GuestLink
 		DownloadCount uint64
+		// MaxDownloadBytesPerSecond limits the combined bandwidth of all
+		// downloads of this entry. A nil value means no limit.
+		MaxDownloadBytesPerSecond *uint64
+		Scan                      ScanResult
+		// SHA256 is the hex-encoded SHA-256 hash of the entry's data at upload
+		// time. For entries uploaded before PicoShare hashed files, it's the hash
+		// from their first integrity check, and it's empty until then.
+		SHA256    string
+		Integrity IntegrityCheck
+		// Compressed indicates whether PicoShare compresses the entry's data in
+		// storage.
+		Compressed bool
+		// StoredSize is the number of bytes the entry's data occupies in storage,
+		// which is smaller than Size if the data is compressed.
+		StoredSize FileSize
+		// HasThumbnail indicates whether PicoShare has generated a thumbnail
+		// preview of the entry.
+		HasThumbnail bool
+		// IsSnippet indicates that the entry is a text snippet rather than an
+		// uploaded file.
+		IsSnippet       bool
+		SnippetLanguage SnippetLanguage
+		Tags            []Tag
+		Folder          Folder
+		// Deleted is the time the entry moved to the trash, or the zero time if
+		// the entry isn't in the trash.
+		Deleted time.Time
+		// Version is the number of the entry's current version of its contents,
+		// starting at 1.
+		Version    int
+		Slug       EntrySlug
+		Visibility Visibility
+		// AvailableFrom is the time when clients can start downloading the entry,
+		// or the zero time if the entry is available as soon as it's uploaded.
+		AvailableFrom time.Time
+		// IdleLifetime is how long the entry can go without a download before it
+		// expires, or the zero value if the entry doesn't expire from inactivity.
+		IdleLifetime FileLifetime
+	}
+
+	// EntryVersion describes one version of an entry's contents.
+	EntryVersion struct {
+		Number      int
+		Filename    Filename
+		ContentType ContentType
+		Uploaded    time.Time
+		Size        FileSize
+		SHA256      string
+		Scan        ScanResult
 	}
 
 	DownloadRecord struct {
 		Time      time.Time
 		ClientIP  string
 		UserAgent string
+		// Version is the version of the entry's contents that the client
+		// downloaded, or zero for downloads that predate versioning.
+		Version int
+	}
+
+	// LinkRecipient is someone to whom the owner emailed an entry's link from
+	// PicoShare.
+	LinkRecipient struct {
+		Email string
+		Sent  time.Time
 	}
 
 	UploadEntry struct {
@@ -0,0 +1,43 @@
+package picoshare
+
+type (
+	ScanStatus string
+
+	// ScanResult records the outcome of scanning an entry for malware.
+	ScanResult struct {
+		Status ScanStatus
+		// Detail is the name of the signature that matched for infected files or
+		// the reason a scan failed.
+		Detail string
+	}
+)
+
+const (
+	ScanStatusNotScanned = ScanStatus("")
+	ScanStatusClean      = ScanStatus("clean")
+	ScanStatusInfected   = ScanStatus("infected")
+	ScanStatusError      = ScanStatus("error")
+	ScanStatusPending    = ScanStatus("pending")
+)
+
+func (ss ScanStatus) String() string {
+	return string(ss)
+}
+
+// IsInfected returns true if the scanner found malware in the entry.
+func (sr ScanResult) IsInfected() bool {
+	return sr.Status == ScanStatusInfected
+}
+
+// IsPending returns true if the entry is waiting for its malware scan to
+// finish.
+func (sr ScanResult) IsPending() bool {
+	return sr.Status == ScanStatusPending
+}
+
+// IsServable returns true if PicoShare may serve the entry's contents, which
+// requires that a scan found no malware or that the entry was never scanned.
+// Contents whose scan is pending or failed might still contain malware.
+func (sr ScanResult) IsServable() bool {
+	return sr.Status == ScanStatusClean || sr.Status == ScanStatusNotScanned
+}
@@ -2,10 +2,60 @@ package picoshare
 
 import "fmt"
 
-type Settings struct {
-	DefaultFileLifetime FileLifetime
-}
+// DefaultTrashRetention is how long deleted entries stay in the trash unless
+// the settings specify otherwise.
+var DefaultTrashRetention = NewFileLifetimeInDays(30)
+
+type (
+	Settings struct {
+		DefaultFileLifetime FileLifetime
+		// DefaultGuestUploadRateLimit applies to any guest link that doesn't
+		// specify its own rate limits.
+		DefaultGuestUploadRateLimit GuestUploadRateLimit
+		DownloadLimits              DownloadLimits
+		// TrashRetention is how long deleted entries stay in the trash before
+		// PicoShare deletes them permanently. A zero value means
+		// DefaultTrashRetention.
+		TrashRetention FileLifetime
+		// DefaultVisibility is the visibility of new entries.
+		DefaultVisibility Visibility
+		// DefaultIdleLifetime is the idle lifetime of new entries. A zero value
+		// means new entries don't expire from inactivity.
+		DefaultIdleLifetime FileLifetime
+		Notifications       NotificationSettings
+	}
+
+	// DownloadLimits restrict how much of the server's bandwidth downloads can
+	// consume. A nil field means that limit doesn't apply.
+	DownloadLimits struct {
+		// MaxBytesPerSecond is the combined bandwidth limit across all downloads.
+		MaxBytesPerSecond *uint64
+		// MaxConcurrentPerIP is the number of downloads a single client IP can
+		// have in progress at once.
+		MaxConcurrentPerIP *int
+	}
+)
 
 func (s Settings) String() string {
 	return fmt.Sprintf("{lifetime=%s}", s.DefaultFileLifetime.FriendlyName())
 }
+
+// EffectiveTrashRetention returns how long deleted entries stay in the trash.
+func (s Settings) EffectiveTrashRetention() FileLifetime {
+	if s.TrashRetention.Equal(FileLifetime{}) {
+		return DefaultTrashRetention
+	}
+	return s.TrashRetention
+}
+
+// WithDefaults returns a copy of the download limits where any unset limit is
+// replaced with the corresponding limit from defaults.
+func (dl DownloadLimits) WithDefaults(defaults DownloadLimits) DownloadLimits {
+	if dl.MaxBytesPerSecond == nil {
+		dl.MaxBytesPerSecond = defaults.MaxBytesPerSecond
+	}
+	if dl.MaxConcurrentPerIP == nil {
+		dl.MaxConcurrentPerIP = defaults.MaxConcurrentPerIP
+	}
+	return dl
+}
@@ -0,0 +1,10 @@
+package picoshare
+
+// EntrySlug is a human-readable alias for an entry's ID that the user chooses
+// (e.g., "q3-report"). Each slug belongs to at most one entry. An empty slug
+// means the entry has no alias.
+type EntrySlug string
+
+func (s EntrySlug) String() string {
+	return string(s)
+}
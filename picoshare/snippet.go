@@ -0,0 +1,10 @@
+package picoshare
+
+// SnippetLanguage is the name of the language PicoShare uses to highlight a
+// text snippet's syntax, such as "python". An empty language means PicoShare
+// detects the language from the snippet's contents.
+type SnippetLanguage string
+
+func (l SnippetLanguage) String() string {
+	return string(l)
+}
@@ -0,0 +1,20 @@
+package picoshare
+
+type (
+	// Tag is a user-defined label for organizing entries. An entry can have any
+	// number of tags.
+	Tag string
+
+	// Folder is the path of the folder that contains an entry, with parent and
+	// child folders separated by slashes (e.g., "clients/acme"). An empty folder
+	// means the entry isn't in a folder.
+	Folder string
+)
+
+func (t Tag) String() string {
+	return string(t)
+}
+
+func (f Folder) String() string {
+	return string(f)
+}
@@ -0,0 +1,22 @@
+package picoshare
+
+// Visibility controls who can download an entry.
+type Visibility string
+
+const (
+	// VisibilityPublic lets anyone with the entry's link download it. It's the
+	// zero value, as entries were public before they had a visibility.
+	VisibilityPublic Visibility = ""
+	// VisibilityPrivate limits downloads to authenticated users.
+	VisibilityPrivate Visibility = "private"
+	// VisibilitySignedLinkOnly limits downloads to authenticated users and
+	// clients with a valid signed link.
+	VisibilitySignedLinkOnly Visibility = "signed"
+)
+
+func (v Visibility) String() string {
+	if v == VisibilityPublic {
+		return "public"
+	}
+	return string(v)
+}
@@ -0,0 +1,113 @@
+package preview
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+// MaxArchiveEntries is the most entries we list from an archive.
+const MaxArchiveEntries = 1000
+
+type (
+	ArchiveEntry struct {
+		Name     string
+		Size     uint64
+		Modified time.Time
+		IsDir    bool
+	}
+
+	// ArchiveListing describes the contents of an archive. If the archive has
+	// more than MaxArchiveEntries entries, Truncated is true and Entries holds
+	// only the first MaxArchiveEntries.
+	ArchiveListing struct {
+		Entries   []ArchiveEntry
+		Truncated bool
+	}
+)
+
+// ListZip lists the files in a ZIP archive of the given size.
+func ListZip(r io.ReadSeeker, size int64) (ArchiveListing, error) {
+	zr, err := zip.NewReader(&readerAt{r: r}, size)
+	if err != nil {
+		return ArchiveListing{}, err
+	}
+
+	listing := ArchiveListing{}
+	for _, f := range zr.File {
+		if len(listing.Entries) == MaxArchiveEntries {
+			listing.Truncated = true
+			break
+		}
+		listing.Entries = append(listing.Entries, ArchiveEntry{
+			Name:     f.Name,
+			Size:     f.UncompressedSize64,
+			Modified: f.Modified,
+			IsDir:    f.FileInfo().IsDir(),
+		})
+	}
+
+	return listing, nil
+}
+
+// ListTar lists the files in a tar archive, decompressing it first if the
+// filename indicates that it's gzipped.
+func ListTar(r io.Reader, filename picoshare.Filename) (ArchiveListing, error) {
+	if isGzipped(filename.String()) {
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			return ArchiveListing{}, err
+		}
+		defer gr.Close()
+		r = gr
+	}
+
+	tr := tar.NewReader(r)
+	listing := ArchiveListing{}
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return ArchiveListing{}, err
+		}
+		if len(listing.Entries) == MaxArchiveEntries {
+			listing.Truncated = true
+			break
+		}
+		size := uint64(0)
+		if h.Size > 0 {
+			size = uint64(h.Size)
+		}
+		listing.Entries = append(listing.Entries, ArchiveEntry{
+			Name:     h.Name,
+			Size:     size,
+			Modified: h.ModTime,
+			IsDir:    h.Typeflag == tar.TypeDir,
+		})
+	}
+
+	return listing, nil
+}
+
+// readerAt adapts an io.ReadSeeker to the io.ReaderAt interface that the zip
+// package requires. It's not safe for concurrent use.
+type readerAt struct {
+	r io.ReadSeeker
+}
+
+func (ra *readerAt) ReadAt(p []byte, off int64) (int, error) {
+	if _, err := ra.r.Seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+	n, err := io.ReadFull(ra.r, p)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		err = io.EOF
+	}
+	return n, err
+}
@@ -0,0 +1,80 @@
+package preview
+
+import (
+	"bytes"
+	"html/template"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+// LineAnchorPrefix is the prefix for the ID of each line in highlighted code,
+// so the third line is at #L3.
+const LineAnchorPrefix = "L"
+
+// The formatter emits CSS classes rather than inline style attributes, which
+// the Content Security Policy would block.
+var codeFormatter = html.New(
+	html.WithClasses(true),
+	html.WithLineNumbers(true),
+	html.WithLinkableLineNumbers(true, LineAnchorPrefix),
+)
+
+var codeStyle = styles.Get("github")
+
+// HighlightCode renders source code as HTML with syntax highlighting and
+// linkable line numbers. It picks a language based on the filename, then the
+// content type, and finally the contents of the code itself.
+func HighlightCode(src []byte, filename picoshare.Filename, contentType picoshare.ContentType) (template.HTML, error) {
+	lexer := lexers.Match(filename.String())
+	if lexer == nil {
+		lexer = lexers.MatchMimeType(mediaTypeOf(contentType))
+	}
+	return highlight(src, lexer)
+}
+
+// HighlightSnippet renders a text snippet as HTML with syntax highlighting for
+// the given language and linkable line numbers. If the language is empty,
+// HighlightSnippet detects it from the snippet's contents.
+func HighlightSnippet(src []byte, language picoshare.SnippetLanguage) (template.HTML, error) {
+	var lexer chroma.Lexer
+	if language != "" {
+		lexer = lexers.Get(language.String())
+	}
+	return highlight(src, lexer)
+}
+
+func highlight(src []byte, lexer chroma.Lexer) (template.HTML, error) {
+	if lexer == nil {
+		lexer = lexers.Analyse(string(src))
+	}
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	lexer = chroma.Coalesce(lexer)
+
+	iterator, err := lexer.Tokenise(nil, string(src))
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := codeFormatter.Format(&buf, codeStyle, iterator); err != nil {
+		return "", err
+	}
+	// Chroma escapes all tokens, so its output is trusted.
+	return template.HTML(buf.String()), nil
+}
+
+// CodeCSS returns the stylesheet for the classes in HighlightCode's output.
+func CodeCSS() (template.CSS, error) {
+	var buf bytes.Buffer
+	if err := codeFormatter.WriteCSS(&buf, codeStyle); err != nil {
+		return "", err
+	}
+	return template.CSS(buf.String()), nil
+}
@@ -0,0 +1,27 @@
+package preview
+
+import (
+	"bytes"
+	"html/template"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// markdown renders GitHub Flavored Markdown. Goldmark omits raw HTML and
+// dangerous link URLs unless configured otherwise, so the output is safe to
+// embed in a page.
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+)
+
+// RenderMarkdown converts Markdown source into HTML that's safe to embed in a
+// page.
+func RenderMarkdown(src []byte) (template.HTML, error) {
+	var buf bytes.Buffer
+	if err := markdown.Convert(src, &buf); err != nil {
+		return "", err
+	}
+	// The renderer escapes text and drops raw HTML, so its output is trusted.
+	return template.HTML(buf.String()), nil
+}
@@ -0,0 +1,84 @@
+package preview
+
+import (
+	"mime"
+	"path/filepath"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2/lexers"
+
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+// Kind is the way the preview page presents a file.
+type Kind string
+
+const (
+	KindNone     Kind = ""
+	KindMarkdown Kind = "markdown"
+	KindCode     Kind = "code"
+	KindImage    Kind = "image"
+	KindAudio    Kind = "audio"
+	KindVideo    Kind = "video"
+	KindZip      Kind = "zip"
+	KindTar      Kind = "tar"
+)
+
+// MaxTextBytes is the largest text file that we render as Markdown or
+// highlighted source code. Larger files are only available as downloads.
+const MaxTextBytes = 1024 * 1024
+
+// Detect determines how to preview a file based on its content type and
+// filename.
+func Detect(contentType picoshare.ContentType, filename picoshare.Filename) Kind {
+	mediaType := mediaTypeOf(contentType)
+	if mediaType == "" || mediaType == "application/octet-stream" {
+		mediaType = mediaTypeOf(picoshare.ContentType(mime.TypeByExtension(filepath.Ext(filename.String()))))
+	}
+	name := strings.ToLower(filename.String())
+
+	switch {
+	case mediaType == "text/markdown" || mediaType == "text/x-markdown" ||
+		strings.HasSuffix(name, ".md") || strings.HasSuffix(name, ".markdown"):
+		return KindMarkdown
+	case mediaType == "image/svg+xml":
+		// SVGs can contain scripts, so we show their source rather than render
+		// them.
+		return KindCode
+	case strings.HasPrefix(mediaType, "image/"):
+		return KindImage
+	case strings.HasPrefix(mediaType, "audio/"):
+		return KindAudio
+	case strings.HasPrefix(mediaType, "video/"):
+		return KindVideo
+	case mediaType == "application/zip" || mediaType == "application/x-zip-compressed" ||
+		strings.HasSuffix(name, ".zip"):
+		return KindZip
+	case isTarball(name) || mediaType == "application/x-tar":
+		return KindTar
+	case strings.HasPrefix(mediaType, "text/") || mediaType == "application/json" ||
+		mediaType == "application/xml" || mediaType == "application/javascript" ||
+		lexers.Match(filename.String()) != nil:
+		return KindCode
+	}
+
+	return KindNone
+}
+
+func mediaTypeOf(ct picoshare.ContentType) string {
+	mediaType, _, _ := strings.Cut(strings.ToLower(ct.String()), ";")
+	return strings.TrimSpace(mediaType)
+}
+
+func isTarball(name string) bool {
+	for _, suffix := range []string{".tar", ".tar.gz", ".tgz"} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func isGzipped(name string) bool {
+	return strings.HasSuffix(name, ".gz") || strings.HasSuffix(name, ".tgz")
+}
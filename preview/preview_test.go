@@ -0,0 +1,157 @@
+package preview_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/preview"
+)
+
+func TestDetect(t *testing.T) {
+	for _, tt := range []struct {
+		contentType picoshare.ContentType
+		filename    picoshare.Filename
+		want        preview.Kind
+	}{
+		{"text/markdown", "README", preview.KindMarkdown},
+		{"", "notes.md", preview.KindMarkdown},
+		{"text/x-python", "script.py", preview.KindCode},
+		{"application/octet-stream", "main.go", preview.KindCode},
+		{"text/plain; charset=utf-8", "log.txt", preview.KindCode},
+		{"image/svg+xml", "logo.svg", preview.KindCode},
+		{"image/png", "screenshot.png", preview.KindImage},
+		{"audio/mpeg", "song.mp3", preview.KindAudio},
+		{"video/mp4", "clip.mp4", preview.KindVideo},
+		{"application/zip", "archive.zip", preview.KindZip},
+		{"application/gzip", "backup.tar.gz", preview.KindTar},
+		{"application/octet-stream", "disk.img", preview.KindNone},
+	} {
+		t.Run(tt.filename.String(), func(t *testing.T) {
+			if got, want := preview.Detect(tt.contentType, tt.filename), tt.want; got != want {
+				t.Errorf("kind=%q, want=%q", got, want)
+			}
+		})
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		src         string
+		contains    []string
+		excludes    []string
+	}{
+		{
+			description: "renders headings and emphasis",
+			src:         "# Title\n\nSome *emphasis*.",
+			contains:    []string{"<h1>Title</h1>", "<em>emphasis</em>"},
+		},
+		{
+			description: "omits raw HTML",
+			src:         "Hello <script>alert(1)</script>",
+			excludes:    []string{"<script>"},
+		},
+		{
+			description: "drops javascript links",
+			src:         "[click](javascript:alert(1))",
+			excludes:    []string{"javascript:"},
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			rendered, err := preview.RenderMarkdown([]byte(tt.src))
+			if err != nil {
+				t.Fatalf("failed to render markdown: %v", err)
+			}
+			for _, s := range tt.contains {
+				if !strings.Contains(string(rendered), s) {
+					t.Errorf("rendered=%s, want it to contain %s", rendered, s)
+				}
+			}
+			for _, s := range tt.excludes {
+				if strings.Contains(string(rendered), s) {
+					t.Errorf("rendered=%s, want it not to contain %s", rendered, s)
+				}
+			}
+		})
+	}
+}
+
+func TestHighlightCode(t *testing.T) {
+	rendered, err := preview.HighlightCode([]byte("def greet():\n    return \"<hi>\"\n"), "greet.py", "text/x-python")
+	if err != nil {
+		t.Fatalf("failed to highlight code: %v", err)
+	}
+
+	for _, s := range []string{`class="chroma"`, `id="L2"`, "&lt;hi&gt;"} {
+		if !strings.Contains(string(rendered), s) {
+			t.Errorf("rendered=%s, want it to contain %s", rendered, s)
+		}
+	}
+	if strings.Contains(string(rendered), "style=") {
+		t.Errorf("rendered=%s, want no inline styles", rendered)
+	}
+}
+
+func TestListZip(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range []string{"docs/", "docs/readme.txt", "main.go"} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasSuffix(name, "/") {
+			if _, err := w.Write([]byte("hello")); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	listing, err := preview.ListZip(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("failed to list zip: %v", err)
+	}
+
+	if got, want := len(listing.Entries), 3; got != want {
+		t.Fatalf("entries=%d, want=%d", got, want)
+	}
+	if !listing.Entries[0].IsDir {
+		t.Errorf("entry %s is not a directory", listing.Entries[0].Name)
+	}
+	if got, want := listing.Entries[1].Size, uint64(5); got != want {
+		t.Errorf("size=%d, want=%d", got, want)
+	}
+}
+
+func TestListTar(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	if err := tw.WriteHeader(&tar.Header{Name: "hello.txt", Mode: 0600, Size: 5}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	listing, err := preview.ListTar(&buf, "archive.tar")
+	if err != nil {
+		t.Fatalf("failed to list tar: %v", err)
+	}
+
+	if got, want := len(listing.Entries), 1; got != want {
+		t.Fatalf("entries=%d, want=%d", got, want)
+	}
+	if got, want := listing.Entries[0].Name, "hello.txt"; got != want {
+		t.Errorf("name=%s, want=%s", got, want)
+	}
+}
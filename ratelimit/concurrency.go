@@ -0,0 +1,43 @@
+package ratelimit
+
+import "sync"
+
+// ConcurrencyLimiter caps how many operations can be in progress at once for
+// each key.
+type ConcurrencyLimiter struct {
+	mu     sync.Mutex
+	active map[string]int
+}
+
+// NewConcurrencyLimiter creates a ConcurrencyLimiter with no operations in
+// progress.
+func NewConcurrencyLimiter() *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{
+		active: map[string]int{},
+	}
+}
+
+// Acquire reserves a slot for key if fewer than max operations are in progress
+// for it. It returns false if the key is already at its limit. Callers must
+// call Release for every successful Acquire.
+func (cl *ConcurrencyLimiter) Acquire(key string, max int) bool {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+
+	if cl.active[key] >= max {
+		return false
+	}
+	cl.active[key]++
+	return true
+}
+
+// Release frees a slot that a previous call to Acquire reserved for key.
+func (cl *ConcurrencyLimiter) Release(key string) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+
+	cl.active[key]--
+	if cl.active[key] <= 0 {
+		delete(cl.active, key)
+	}
+}
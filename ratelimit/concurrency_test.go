@@ -0,0 +1,30 @@
+package ratelimit_test
+
+import (
+	"testing"
+
+	"github.com/mtlynch/picoshare/ratelimit"
+)
+
+func TestConcurrencyLimiter(t *testing.T) {
+	cl := ratelimit.NewConcurrencyLimiter()
+
+	if !cl.Acquire("1.2.3.4", 2) {
+		t.Fatalf("first acquire failed, want success")
+	}
+	if !cl.Acquire("1.2.3.4", 2) {
+		t.Fatalf("second acquire failed, want success")
+	}
+	if cl.Acquire("1.2.3.4", 2) {
+		t.Fatalf("third acquire succeeded, want failure")
+	}
+	if !cl.Acquire("5.6.7.8", 2) {
+		t.Fatalf("acquire for different key failed, want success")
+	}
+
+	cl.Release("1.2.3.4")
+
+	if !cl.Acquire("1.2.3.4", 2) {
+		t.Fatalf("acquire after release failed, want success")
+	}
+}
@@ -0,0 +1,173 @@
+package ratelimit
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxReadSize caps the size of a single throttled read so that a large buffer
+// from the caller doesn't produce one long burst followed by a long pause.
+const maxReadSize = 32 * 1024
+
+type (
+	// Bucket paces a stream of bytes to a fixed rate. A single Bucket can be
+	// shared among several readers to limit their combined throughput.
+	Bucket struct {
+		bytesPerSecond atomic.Uint64
+		mu             sync.Mutex
+		next           time.Time
+	}
+
+	// Buckets holds a set of Buckets by key so that every reader for the same
+	// key shares the same Bucket. Buckets that go unused for longer than the
+	// idle timeout are discarded.
+	Buckets struct {
+		idleTimeout time.Duration
+		mu          sync.Mutex
+		buckets     map[string]*Bucket
+	}
+
+	reader struct {
+		r       io.Reader
+		buckets []*Bucket
+	}
+
+	readSeeker struct {
+		reader
+		s io.Seeker
+	}
+)
+
+// NewBucket creates a Bucket that allows bytesPerSecond bytes through per
+// second.
+func NewBucket(bytesPerSecond uint64) *Bucket {
+	b := &Bucket{}
+	b.bytesPerSecond.Store(bytesPerSecond)
+	return b
+}
+
+// SetBytesPerSecond changes the rate at which the bucket allows bytes through.
+// It only updates the bucket if the rate differs from the current one, so
+// callers can pass the configured rate on every use without contending with
+// readers.
+func (b *Bucket) SetBytesPerSecond(bytesPerSecond uint64) {
+	if b.bytesPerSecond.Load() == bytesPerSecond {
+		return
+	}
+	b.bytesPerSecond.Store(bytesPerSecond)
+}
+
+// Wait blocks until the bucket has capacity for the caller to have consumed n
+// bytes.
+func (b *Bucket) Wait(n int) {
+	if n <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	now := time.Now()
+	if b.next.Before(now) {
+		b.next = now
+	}
+	wait := b.next.Sub(now)
+	b.next = b.next.Add(time.Duration(float64(n) / float64(b.bytesPerSecond.Load()) * float64(time.Second)))
+	b.mu.Unlock()
+
+	time.Sleep(wait)
+}
+
+// touch marks the bucket as in use at the given time.
+func (b *Bucket) touch(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.next.Before(now) {
+		b.next = now
+	}
+}
+
+// idleSince returns true if the bucket has been neither retrieved nor pacing
+// bytes since before the given time.
+func (b *Bucket) idleSince(t time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.next.Before(t)
+}
+
+// NewBuckets creates an empty set of keyed Buckets that discards each Bucket
+// once it has been idle for idleTimeout. The timeout should comfortably exceed
+// the longest pause between reads, because a reader that resumes after its
+// Bucket is discarded no longer shares a Bucket with new readers.
+func NewBuckets(idleTimeout time.Duration) *Buckets {
+	return &Buckets{
+		idleTimeout: idleTimeout,
+		buckets:     map[string]*Bucket{},
+	}
+}
+
+// Get returns the Bucket for key, creating it if necessary, and sets its rate
+// to bytesPerSecond.
+func (bs *Buckets) Get(key string, bytesPerSecond uint64) *Bucket {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	now := time.Now()
+	bs.prune(now)
+
+	b, ok := bs.buckets[key]
+	if !ok {
+		b = NewBucket(bytesPerSecond)
+		bs.buckets[key] = b
+	}
+	b.SetBytesPerSecond(bytesPerSecond)
+	b.touch(now)
+	return b
+}
+
+// prune drops Buckets that have been idle for longer than the idle timeout so
+// that keys that are no longer downloaded don't accumulate in memory forever.
+func (bs *Buckets) prune(now time.Time) {
+	cutoff := now.Add(-bs.idleTimeout)
+	for key, b := range bs.buckets {
+		if b.idleSince(cutoff) {
+			delete(bs.buckets, key)
+		}
+	}
+}
+
+// NewReader wraps r so that reads from it proceed no faster than every one of
+// the given buckets allows.
+func NewReader(r io.Reader, buckets ...*Bucket) io.Reader {
+	return &reader{
+		r:       r,
+		buckets: buckets,
+	}
+}
+
+// NewReadSeeker is like NewReader, but it preserves the ability to seek within
+// rs.
+func NewReadSeeker(rs io.ReadSeeker, buckets ...*Bucket) io.ReadSeeker {
+	return &readSeeker{
+		reader: reader{
+			r:       rs,
+			buckets: buckets,
+		},
+		s: rs,
+	}
+}
+
+func (tr *reader) Read(p []byte) (int, error) {
+	if len(p) > maxReadSize {
+		p = p[:maxReadSize]
+	}
+	n, err := tr.r.Read(p)
+	for _, b := range tr.buckets {
+		b.Wait(n)
+	}
+	return n, err
+}
+
+func (trs *readSeeker) Seek(offset int64, whence int) (int64, error) {
+	return trs.s.Seek(offset, whence)
+}
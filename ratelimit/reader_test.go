@@ -0,0 +1,83 @@
+package ratelimit_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mtlynch/picoshare/ratelimit"
+)
+
+func TestReaderPreservesContents(t *testing.T) {
+	input := strings.Repeat("A", 100*1024)
+
+	r := ratelimit.NewReader(strings.NewReader(input), ratelimit.NewBucket(1024*1024*1024))
+	contents, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("failed to read throttled reader: %v", err)
+	}
+
+	if got, want := string(contents), input; got != want {
+		t.Errorf("contents differ: got %d bytes, want %d bytes", len(got), len(want))
+	}
+}
+
+func TestReaderThrottlesThroughput(t *testing.T) {
+	input := bytes.Repeat([]byte("A"), 300)
+	bytesPerSecond := uint64(1000)
+
+	// Read in 100-byte pieces so that the bucket paces the later reads.
+	r := ratelimit.NewReader(bytes.NewReader(input), ratelimit.NewBucket(bytesPerSecond))
+	buf := make([]byte, 100)
+
+	start := time.Now()
+	total := 0
+	for {
+		n, err := r.Read(buf)
+		total += n
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("failed to read throttled reader: %v", err)
+		}
+	}
+	elapsed := time.Since(start)
+
+	if got, want := total, len(input); got != want {
+		t.Errorf("total=%d, want=%d", got, want)
+	}
+
+	// The first read passes immediately, so three 100-byte reads at 1000 B/s
+	// take at least 200ms.
+	if minimum := 200 * time.Millisecond; elapsed < minimum {
+		t.Errorf("elapsed=%v, want at least %v", elapsed, minimum)
+	}
+}
+
+func TestBucketsShareBucketPerKey(t *testing.T) {
+	buckets := ratelimit.NewBuckets(time.Hour)
+
+	if got, want := buckets.Get("A", 1000), buckets.Get("A", 2000); got != want {
+		t.Errorf("Get returned different buckets for the same key")
+	}
+	if got, notWant := buckets.Get("B", 1000), buckets.Get("A", 1000); got == notWant {
+		t.Errorf("Get returned the same bucket for different keys")
+	}
+}
+
+func TestBucketsDiscardIdleBuckets(t *testing.T) {
+	idleTimeout := 50 * time.Millisecond
+	buckets := ratelimit.NewBuckets(idleTimeout)
+
+	idle := buckets.Get("A", 1000)
+	time.Sleep(2 * idleTimeout)
+
+	// Retrieving any key prunes the idle buckets.
+	buckets.Get("B", 1000)
+
+	if got, notWant := buckets.Get("A", 1000), idle; got == notWant {
+		t.Errorf("Get returned a bucket that had been idle for longer than the idle timeout")
+	}
+}
@@ -0,0 +1,126 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+type (
+	// Window keeps a record of recent events per key so that callers can check
+	// how many events (and how many bytes) a key has accumulated over a recent
+	// period. Events older than the window's retention period are discarded.
+	Window struct {
+		retention time.Duration
+		mu        sync.Mutex
+		events    map[string][]*event
+	}
+
+	event struct {
+		t     time.Time
+		bytes uint64
+	}
+
+	// CountFunc returns the number of events and the total bytes recorded for a
+	// key at or after the given time.
+	CountFunc func(since time.Time) (int, uint64)
+
+	// Reservation is an event that TryRecord recorded before the event's final
+	// size was known.
+	Reservation struct {
+		w *Window
+		e *event
+	}
+)
+
+// NewWindow creates a Window that remembers events for the given retention
+// period.
+func NewWindow(retention time.Duration) *Window {
+	return &Window{
+		retention: retention,
+		events:    map[string][]*event{},
+	}
+}
+
+// Count returns the number of events and the total bytes recorded for key at
+// or after the given time.
+func (w *Window) Count(key string, since time.Time) (int, uint64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.count(key, since)
+}
+
+func (w *Window) count(key string, since time.Time) (int, uint64) {
+	count := 0
+	var total uint64
+	for _, e := range w.events[key] {
+		if e.t.Before(since) {
+			continue
+		}
+		count++
+		total += e.bytes
+	}
+
+	return count, total
+}
+
+// Record adds an event of the given size for key at time t.
+func (w *Window) Record(key string, t time.Time, bytes uint64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.events[key] = append(w.events[key], &event{t: t, bytes: bytes})
+	w.prune(t)
+}
+
+// TryRecord checks whether key may record another event at time t and, if so,
+// records one, all in a single step so that concurrent callers can't exceed
+// the limits that check enforces. check receives a CountFunc for key and
+// returns either the number of bytes to reserve for the new event or an error
+// to reject it. Reserving the most bytes the event could use prevents
+// concurrent events from overrunning a byte limit. Callers settle the
+// reservation once they know the event's actual size.
+func (w *Window) TryRecord(key string, t time.Time, check func(count CountFunc) (uint64, error)) (*Reservation, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	bytes, err := check(func(since time.Time) (int, uint64) {
+		return w.count(key, since)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	e := &event{t: t, bytes: bytes}
+	w.events[key] = append(w.events[key], e)
+	w.prune(t)
+
+	return &Reservation{w: w, e: e}, nil
+}
+
+// Settle replaces the bytes that the reservation reserved with the number of
+// bytes the event actually used.
+func (r *Reservation) Settle(bytes uint64) {
+	r.w.mu.Lock()
+	defer r.w.mu.Unlock()
+	r.e.bytes = bytes
+}
+
+// prune drops events that have fallen out of the retention period so that
+// idle keys don't accumulate in memory forever.
+func (w *Window) prune(now time.Time) {
+	cutoff := now.Add(-w.retention)
+	for key, events := range w.events {
+		kept := events[:0]
+		for _, e := range events {
+			if !e.t.Before(cutoff) {
+				kept = append(kept, e)
+			}
+		}
+		if len(kept) == 0 {
+			delete(w.events, key)
+			continue
+		}
+		w.events[key] = kept
+	}
+}
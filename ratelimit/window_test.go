@@ -0,0 +1,141 @@
+package ratelimit_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mtlynch/picoshare/ratelimit"
+)
+
+func TestWindow(t *testing.T) {
+	start := mustParseTime("2024-01-01T00:00:00Z")
+
+	w := ratelimit.NewWindow(24 * time.Hour)
+	w.Record("1.2.3.4", start, 100)
+	w.Record("1.2.3.4", start.Add(30*time.Minute), 200)
+	w.Record("1.2.3.4", start.Add(2*time.Hour), 400)
+	w.Record("5.6.7.8", start.Add(2*time.Hour), 800)
+
+	for _, tt := range []struct {
+		description   string
+		key           string
+		since         time.Time
+		countExpected int
+		bytesExpected uint64
+	}{
+		{
+			description:   "counts all events for key since start",
+			key:           "1.2.3.4",
+			since:         start,
+			countExpected: 3,
+			bytesExpected: 700,
+		},
+		{
+			description:   "excludes events before the cutoff",
+			key:           "1.2.3.4",
+			since:         start.Add(time.Hour),
+			countExpected: 1,
+			bytesExpected: 400,
+		},
+		{
+			description:   "keeps keys independent",
+			key:           "5.6.7.8",
+			since:         start,
+			countExpected: 1,
+			bytesExpected: 800,
+		},
+		{
+			description:   "returns zero for unknown key",
+			key:           "9.9.9.9",
+			since:         start,
+			countExpected: 0,
+			bytesExpected: 0,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			count, bytes := w.Count(tt.key, tt.since)
+			if got, want := count, tt.countExpected; got != want {
+				t.Errorf("count=%d, want=%d", got, want)
+			}
+			if got, want := bytes, tt.bytesExpected; got != want {
+				t.Errorf("bytes=%d, want=%d", got, want)
+			}
+		})
+	}
+}
+
+func TestWindowDiscardsEventsPastRetention(t *testing.T) {
+	start := mustParseTime("2024-01-01T00:00:00Z")
+
+	w := ratelimit.NewWindow(time.Hour)
+	w.Record("1.2.3.4", start, 100)
+	w.Record("5.6.7.8", start.Add(3*time.Hour), 200)
+
+	if count, _ := w.Count("1.2.3.4", start); count != 0 {
+		t.Errorf("count=%d, want=%d", count, 0)
+	}
+}
+
+func TestWindowTryRecordIsAtomic(t *testing.T) {
+	start := mustParseTime("2024-01-01T00:00:00Z")
+
+	w := ratelimit.NewWindow(24 * time.Hour)
+	allowOne := func(count ratelimit.CountFunc) (uint64, error) {
+		if events, _ := count(start); events >= 1 {
+			return 0, errors.New("dummy limit reached")
+		}
+		return 0, nil
+	}
+
+	var wg sync.WaitGroup
+	var accepted atomic.Int32
+	for range 50 {
+		wg.Go(func() {
+			if _, err := w.TryRecord("1.2.3.4", start, allowOne); err == nil {
+				accepted.Add(1)
+			}
+		})
+	}
+	wg.Wait()
+
+	if got, want := accepted.Load(), int32(1); got != want {
+		t.Errorf("accepted=%d, want=%d", got, want)
+	}
+}
+
+func TestWindowTryRecordReservesBytesUntilSettled(t *testing.T) {
+	start := mustParseTime("2024-01-01T00:00:00Z")
+
+	w := ratelimit.NewWindow(24 * time.Hour)
+	reservation, err := w.TryRecord("1.2.3.4", start, func(ratelimit.CountFunc) (uint64, error) {
+		return 500, nil
+	})
+	if err != nil {
+		t.Fatalf("failed to reserve event: %v", err)
+	}
+
+	if _, bytes := w.Count("1.2.3.4", start); bytes != 500 {
+		t.Errorf("bytes before settling=%d, want=%d", bytes, 500)
+	}
+
+	reservation.Settle(120)
+
+	count, bytes := w.Count("1.2.3.4", start)
+	if got, want := count, 1; got != want {
+		t.Errorf("count=%d, want=%d", got, want)
+	}
+	if got, want := bytes, uint64(120); got != want {
+		t.Errorf("bytes=%d, want=%d", got, want)
+	}
+}
+
+func mustParseTime(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
@@ -0,0 +1,107 @@
+// Package reminders notifies the PicoShare owner before entries expire.
+package reminders
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/mtlynch/picoshare/notify"
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+type (
+	EntryStore interface {
+		GetEntriesDueExpirationReminder(now, cutoff time.Time) ([]picoshare.UploadMetadata, error)
+		RecordExpirationReminder(id picoshare.EntryID, expiration picoshare.ExpirationTime) error
+		ReadSettings() (picoshare.Settings, error)
+	}
+
+	Reminder struct {
+		store    EntryStore
+		notifier notify.Notifier
+		lead     time.Duration
+		baseURL  string
+		mu       sync.Mutex
+	}
+)
+
+// NewReminder creates a Reminder that notifies the owner when an entry expires
+// within the lead time. If baseURL is non-empty, reminders link to the
+// expiring files page on the PicoShare server at that URL.
+func NewReminder(store EntryStore, notifier notify.Notifier, lead time.Duration, baseURL string) Reminder {
+	return Reminder{
+		store:    store,
+		notifier: notifier,
+		lead:     lead,
+		baseURL:  baseURL,
+	}
+}
+
+// Send notifies the owner about each entry that expires within the lead time,
+// unless the owner already got a reminder about the entry's current expiration
+// time. It returns the number of reminders it sent. If a notification fails,
+// Send continues with the other entries and tries the failed entry again on
+// its next run. If the owner turned off expiration reminders in the settings,
+// Send does nothing.
+func (r *Reminder) Send(now time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	settings, err := r.store.ReadSettings()
+	if err != nil {
+		return 0, err
+	}
+	if !settings.Notifications.ExpiringEntries {
+		return 0, nil
+	}
+
+	entries, err := r.store.GetEntriesDueExpirationReminder(now, now.Add(r.lead))
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	var errs []error
+	for _, entry := range entries {
+		if err := r.notifier.Notify(notify.Event{
+			Kind:     notify.EventEntryExpiring,
+			Entry:    entry,
+			URL:      r.expiringFilesURL(),
+			EntryURL: r.entryURL(entry.ID),
+		}); err != nil {
+			log.Printf("failed to send expiration reminder for entry %v: %v", entry.ID, err)
+			errs = append(errs, fmt.Errorf("entry %v: %w", entry.ID, err))
+			continue
+		}
+
+		if err := r.store.RecordExpirationReminder(entry.ID, entry.Expires); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+
+	return sent, errors.Join(errs...)
+}
+
+func (r *Reminder) expiringFilesURL() string {
+	if r.baseURL == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/files/expiring?days=%d", r.baseURL, leadDays(r.lead))
+}
+
+func (r *Reminder) entryURL(id picoshare.EntryID) string {
+	if r.baseURL == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/-%s", r.baseURL, id)
+}
+
+// leadDays rounds the lead time up to a whole number of days.
+func leadDays(lead time.Duration) int {
+	day := 24 * time.Hour
+	return int((lead + day - 1) / day)
+}
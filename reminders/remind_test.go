@@ -0,0 +1,138 @@
+package reminders_test
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mtlynch/picoshare/notify"
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/reminders"
+	"github.com/mtlynch/picoshare/store/test_sqlite"
+)
+
+type mockNotifier struct {
+	events []notify.Event
+	err    error
+}
+
+func (n *mockNotifier) Notify(e notify.Event) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.events = append(n.events, e)
+	return nil
+}
+
+func TestSend(t *testing.T) {
+	dataStore := test_sqlite.New()
+	for _, entry := range []picoshare.UploadMetadata{
+		{ID: "AAAAAAAAAA", Filename: "soon.txt", Expires: mustParseExpirationTime("2024-01-02T00:00:00Z")},
+		{ID: "BBBBBBBBBB", Filename: "later.txt", Expires: mustParseExpirationTime("2024-03-01T00:00:00Z")},
+		{ID: "CCCCCCCCCC", Filename: "forever.txt", Expires: picoshare.NeverExpire},
+	} {
+		entry.Uploaded = mustParseTime("2024-01-01T00:00:00Z")
+		if err := dataStore.InsertEntry(strings.NewReader("dummy data"), entry); err != nil {
+			t.Fatalf("failed to insert dummy entry: %v", err)
+		}
+	}
+
+	now := mustParseTime("2024-01-01T00:00:00Z")
+
+	failing := mockNotifier{err: errors.New("dummy notification failure")}
+	reminder := reminders.NewReminder(&dataStore, &failing, 3*24*time.Hour, "https://example.com")
+	if sent, err := reminder.Send(now); err == nil {
+		t.Errorf("Send succeeded with failing notifier, want error")
+	} else if sent != 0 {
+		t.Errorf("sent=%d, want=0", sent)
+	}
+
+	notifier := mockNotifier{}
+	reminder = reminders.NewReminder(&dataStore, &notifier, 3*24*time.Hour, "https://example.com")
+	sent, err := reminder.Send(now)
+	if err != nil {
+		t.Fatalf("failed to send reminders: %v", err)
+	}
+	if got, want := sent, 1; got != want {
+		t.Errorf("sent=%d, want=%d", got, want)
+	}
+
+	ids := []picoshare.EntryID{}
+	for _, e := range notifier.events {
+		ids = append(ids, e.Entry.ID)
+		if got, want := e.Kind, notify.EventEntryExpiring; got != want {
+			t.Errorf("kind=%v, want=%v", got, want)
+		}
+		if got, want := e.URL, "https://example.com/files/expiring?days=3"; got != want {
+			t.Errorf("url=%v, want=%v", got, want)
+		}
+		if got, want := e.EntryURL, "https://example.com/-"+e.Entry.ID.String(); got != want {
+			t.Errorf("entry url=%v, want=%v", got, want)
+		}
+	}
+	if got, want := ids, []picoshare.EntryID{"AAAAAAAAAA"}; !slices.Equal(got, want) {
+		t.Errorf("reminded about %v, want %v", got, want)
+	}
+
+	// The owner only gets one reminder per expiration time.
+	if sent, err := reminder.Send(now.Add(time.Hour)); err != nil {
+		t.Fatalf("failed to send reminders: %v", err)
+	} else if sent != 0 {
+		t.Errorf("sent=%d on second run, want=0", sent)
+	}
+}
+
+func TestSendRespectsSettings(t *testing.T) {
+	dataStore := test_sqlite.New()
+	if err := dataStore.InsertEntry(strings.NewReader("dummy data"), picoshare.UploadMetadata{
+		ID:       "AAAAAAAAAA",
+		Filename: "soon.txt",
+		Uploaded: mustParseTime("2024-01-01T00:00:00Z"),
+		Expires:  mustParseExpirationTime("2024-01-02T00:00:00Z"),
+	}); err != nil {
+		t.Fatalf("failed to insert dummy entry: %v", err)
+	}
+
+	settings, err := dataStore.ReadSettings()
+	if err != nil {
+		t.Fatalf("failed to read settings: %v", err)
+	}
+	settings.Notifications.ExpiringEntries = false
+	if err := dataStore.UpdateSettings(settings); err != nil {
+		t.Fatalf("failed to update settings: %v", err)
+	}
+
+	now := mustParseTime("2024-01-01T00:00:00Z")
+	notifier := mockNotifier{}
+	reminder := reminders.NewReminder(&dataStore, &notifier, 3*24*time.Hour, "")
+	if sent, err := reminder.Send(now); err != nil {
+		t.Fatalf("failed to send reminders: %v", err)
+	} else if sent != 0 {
+		t.Errorf("sent=%d with reminders turned off, want=0", sent)
+	}
+
+	// Turning reminders back on sends the reminder the owner didn't get.
+	settings.Notifications.ExpiringEntries = true
+	if err := dataStore.UpdateSettings(settings); err != nil {
+		t.Fatalf("failed to update settings: %v", err)
+	}
+	if sent, err := reminder.Send(now); err != nil {
+		t.Fatalf("failed to send reminders: %v", err)
+	} else if sent != 1 {
+		t.Errorf("sent=%d with reminders turned on, want=1", sent)
+	}
+}
+
+func mustParseTime(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func mustParseExpirationTime(s string) picoshare.ExpirationTime {
+	return picoshare.ExpirationTime(mustParseTime(s))
+}
@@ -0,0 +1,32 @@
+package reminders
+
+import (
+	"log"
+	"time"
+)
+
+type Scheduler struct {
+	reminder *Reminder
+	ticker   *time.Ticker
+}
+
+func NewScheduler(reminder *Reminder, interval time.Duration) Scheduler {
+	return Scheduler{
+		reminder: reminder,
+		ticker:   time.NewTicker(interval),
+	}
+}
+
+func (s *Scheduler) StartAsync() {
+	go func() {
+		for range s.ticker.C {
+			sent, err := s.reminder.Send(time.Now())
+			if sent > 0 {
+				log.Printf("sent %d expiration reminder(s)", sent)
+			}
+			if err != nil {
+				log.Printf("sending expiration reminders failed: %v", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,68 @@
+package scan
+
+import (
+	"log"
+	"time"
+
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+type (
+	RetryStore interface {
+		EntryStore
+		GetEntriesNeedingScan() ([]picoshare.EntryID, error)
+	}
+
+	// Scheduler periodically queues scans of entries whose scans are pending or
+	// failed, such as scans that were still queued when PicoShare stopped or
+	// that failed because the scanner was unreachable.
+	Scheduler struct {
+		worker *Worker
+		store  RetryStore
+		done   func(picoshare.EntryID, picoshare.ScanResult)
+		ticker *time.Ticker
+	}
+)
+
+// NewScheduler creates a Scheduler that queues retries on worker every
+// interval. done receives the result for each retried entry's current
+// contents.
+func NewScheduler(worker *Worker, store RetryStore, done func(picoshare.EntryID, picoshare.ScanResult), interval time.Duration) Scheduler {
+	return Scheduler{
+		worker: worker,
+		store:  store,
+		done:   done,
+		ticker: time.NewTicker(interval),
+	}
+}
+
+// StartAsync queues retries immediately and then on every tick.
+func (s *Scheduler) StartAsync() {
+	go func() {
+		s.retry()
+		for range s.ticker.C {
+			s.retry()
+		}
+	}()
+}
+
+func (s *Scheduler) retry() {
+	ids, err := s.store.GetEntriesNeedingScan()
+	if err != nil {
+		log.Printf("failed to retrieve entries needing a malware scan: %v", err)
+		return
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	log.Printf("retrying malware scans for %d entries", len(ids))
+	for _, id := range ids {
+		if !s.worker.Enqueue(s.store, id, func(result picoshare.ScanResult) {
+			s.done(id, result)
+		}) {
+			// The rest will wait for the next retry.
+			return
+		}
+	}
+}
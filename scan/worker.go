@@ -0,0 +1,129 @@
+package scan
+
+import (
+	"io"
+	"log"
+
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+type (
+	// Scanner checks files for malware.
+	Scanner interface {
+		Scan(r io.Reader) (picoshare.ScanResult, error)
+	}
+
+	EntryStore interface {
+		GetEntryVersions(id picoshare.EntryID) ([]picoshare.EntryVersion, error)
+		ReadEntryVersionFile(id picoshare.EntryID, version int) (io.ReadSeeker, error)
+		UpdateEntryVersionScanResult(id picoshare.EntryID, version int, result picoshare.ScanResult) error
+	}
+
+	job struct {
+		store EntryStore
+		id    picoshare.EntryID
+		done  func(picoshare.ScanResult)
+	}
+
+	// Worker scans entries for malware in the background, one at a time, so
+	// that a slow scanner never slows down uploads.
+	Worker struct {
+		scanner Scanner
+		jobs    chan job
+	}
+)
+
+// NewWorker creates a Worker that scans with the given scanner and holds at
+// most queueSize pending jobs.
+func NewWorker(scanner Scanner, queueSize int) *Worker {
+	return &Worker{
+		scanner: scanner,
+		jobs:    make(chan job, queueSize),
+	}
+}
+
+// StartAsync starts processing queued jobs in the background.
+func (w *Worker) StartAsync() {
+	go func() {
+		for j := range w.jobs {
+			result, err := Process(w.scanner, j.store, j.id)
+			if err != nil {
+				log.Printf("failed to scan entry %v for malware: %v", j.id, err)
+				continue
+			}
+			if j.done != nil {
+				j.done(result)
+			}
+		}
+	}()
+}
+
+// Enqueue schedules a scan of the given entry and calls done with the result
+// for the entry's current contents once the scan finishes. The caller should
+// mark the entry's scan as pending before enqueueing it. If the queue is full,
+// Enqueue drops the job and returns false rather than blocking the caller. The
+// entry stays pending until a Scheduler retries it.
+func (w *Worker) Enqueue(store EntryStore, id picoshare.EntryID, done func(picoshare.ScanResult)) bool {
+	select {
+	case w.jobs <- job{store: store, id: id, done: done}:
+		return true
+	default:
+		log.Printf("malware scan queue is full, deferring scan for entry %v", id)
+		return false
+	}
+}
+
+// Process scans every version of the entry's contents whose scan is pending or
+// failed and records the results. It returns the scan result of the entry's
+// current contents. A failed scan is recorded on the version so that the admin
+// can see it, and PicoShare won't serve that version until a later scan
+// succeeds.
+func Process(scanner Scanner, store EntryStore, id picoshare.EntryID) (picoshare.ScanResult, error) {
+	versions, err := store.GetEntryVersions(id)
+	if err != nil {
+		return picoshare.ScanResult{}, err
+	}
+
+	// GetEntryVersions lists the current version first.
+	var current picoshare.ScanResult
+	for i, v := range versions {
+		result := v.Scan
+		if needsScan(result) {
+			result = scanVersion(scanner, store, id, v.Number)
+		}
+		if i == 0 {
+			current = result
+		}
+	}
+
+	return current, nil
+}
+
+func needsScan(result picoshare.ScanResult) bool {
+	return result.IsPending() || result.Status == picoshare.ScanStatusError
+}
+
+func scanVersion(scanner Scanner, store EntryStore, id picoshare.EntryID, version int) picoshare.ScanResult {
+	result, err := func() (picoshare.ScanResult, error) {
+		entryFile, err := store.ReadEntryVersionFile(id, version)
+		if err != nil {
+			return picoshare.ScanResult{}, err
+		}
+		return scanner.Scan(entryFile)
+	}()
+	if err != nil {
+		log.Printf("failed to scan version %d of entry %v for malware: %v", version, id, err)
+		result = picoshare.ScanResult{
+			Status: picoshare.ScanStatusError,
+			Detail: err.Error(),
+		}
+	} else if result.IsInfected() {
+		log.Printf("quarantining version %d of entry %v: malware scan found %s", version, id, result.Detail)
+	}
+
+	if err := store.UpdateEntryVersionScanResult(id, version, result); err != nil {
+		log.Printf("failed to save malware scan result for version %d of entry %v: %v", version, id, err)
+	}
+
+	return result
+}
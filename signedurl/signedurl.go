@@ -0,0 +1,101 @@
+// Package signedurl signs and verifies links that grant temporary access to an
+// entry. A signed link carries its own expiration time, independent of the
+// entry's, and an HMAC signature over the entry ID and the link's parameters,
+// so recipients can't extend or repurpose the link.
+package signedurl
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+const (
+	expiresParam   = "exp"
+	signatureParam = "sig"
+	singleUseParam = "once"
+)
+
+var (
+	ErrInvalidSignature = errors.New("signed link is invalid")
+	ErrExpired          = errors.New("signed link has expired")
+)
+
+// Grant is the access that a valid signed link grants.
+type Grant struct {
+	Expires   time.Time
+	SingleUse bool
+	// Signature uniquely identifies the link.
+	Signature string
+}
+
+// Query returns the URL query parameters that grant access to the entry until
+// the expiration time. If singleUse is true, the link is only valid for one
+// download.
+func Query(key []byte, id picoshare.EntryID, expires time.Time, singleUse bool) url.Values {
+	q := url.Values{}
+	q.Set(expiresParam, strconv.FormatInt(expires.Unix(), 10))
+	if singleUse {
+		q.Set(singleUseParam, "1")
+	}
+	q.Set(signatureParam, sign(key, id, expires.Unix(), singleUse))
+	return q
+}
+
+// IsSigned returns true if the query contains any signed link parameters.
+func IsSigned(q url.Values) bool {
+	return q.Has(expiresParam) || q.Has(signatureParam)
+}
+
+// Verify checks that the query contains a valid, unexpired signature for the
+// entry.
+func Verify(key []byte, id picoshare.EntryID, q url.Values, now time.Time) (Grant, error) {
+	expiresUnix, err := strconv.ParseInt(q.Get(expiresParam), 10, 64)
+	if err != nil {
+		return Grant{}, ErrInvalidSignature
+	}
+
+	var singleUse bool
+	switch q.Get(singleUseParam) {
+	case "":
+		singleUse = false
+	case "1":
+		singleUse = true
+	default:
+		return Grant{}, ErrInvalidSignature
+	}
+
+	sig := q.Get(signatureParam)
+	if !hmac.Equal([]byte(sig), []byte(sign(key, id, expiresUnix, singleUse))) {
+		return Grant{}, ErrInvalidSignature
+	}
+
+	expires := time.Unix(expiresUnix, 0)
+	if now.After(expires) {
+		return Grant{}, ErrExpired
+	}
+
+	return Grant{
+		Expires:   expires,
+		SingleUse: singleUse,
+		Signature: sig,
+	}, nil
+}
+
+func sign(key []byte, id picoshare.EntryID, expiresUnix int64, singleUse bool) string {
+	payload := strings.Join([]string{
+		id.String(),
+		strconv.FormatInt(expiresUnix, 10),
+		strconv.FormatBool(singleUse),
+	}, "|")
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,142 @@
+package signedurl_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/mtlynch/picoshare/signedurl"
+)
+
+func TestVerify(t *testing.T) {
+	key := []byte("dummy-key")
+	expires := mustParseTime("2024-01-01T00:15:00Z")
+
+	for _, tt := range []struct {
+		description string
+		query       func() url.Values
+		now         time.Time
+		grant       signedurl.Grant
+		err         error
+	}{
+		{
+			description: "accepts valid link",
+			query: func() url.Values {
+				return signedurl.Query(key, "AAAAAAAAAA", expires, false)
+			},
+			now:   mustParseTime("2024-01-01T00:00:00Z"),
+			grant: signedurl.Grant{Expires: expires, SingleUse: false},
+		},
+		{
+			description: "accepts valid single-use link",
+			query: func() url.Values {
+				return signedurl.Query(key, "AAAAAAAAAA", expires, true)
+			},
+			now:   mustParseTime("2024-01-01T00:00:00Z"),
+			grant: signedurl.Grant{Expires: expires, SingleUse: true},
+		},
+		{
+			description: "rejects expired link",
+			query: func() url.Values {
+				return signedurl.Query(key, "AAAAAAAAAA", expires, false)
+			},
+			now: mustParseTime("2024-01-01T00:15:01Z"),
+			err: signedurl.ErrExpired,
+		},
+		{
+			description: "rejects link for a different entry",
+			query: func() url.Values {
+				return signedurl.Query(key, "BBBBBBBBBB", expires, false)
+			},
+			now: mustParseTime("2024-01-01T00:00:00Z"),
+			err: signedurl.ErrInvalidSignature,
+		},
+		{
+			description: "rejects link signed with a different key",
+			query: func() url.Values {
+				return signedurl.Query([]byte("other-key"), "AAAAAAAAAA", expires, false)
+			},
+			now: mustParseTime("2024-01-01T00:00:00Z"),
+			err: signedurl.ErrInvalidSignature,
+		},
+		{
+			description: "rejects link with extended expiration",
+			query: func() url.Values {
+				q := signedurl.Query(key, "AAAAAAAAAA", expires, false)
+				q.Set("exp", "1893456000")
+				return q
+			},
+			now: mustParseTime("2024-01-01T00:00:00Z"),
+			err: signedurl.ErrInvalidSignature,
+		},
+		{
+			description: "rejects single-use link with single-use flag removed",
+			query: func() url.Values {
+				q := signedurl.Query(key, "AAAAAAAAAA", expires, true)
+				q.Del("once")
+				return q
+			},
+			now: mustParseTime("2024-01-01T00:00:00Z"),
+			err: signedurl.ErrInvalidSignature,
+		},
+		{
+			description: "rejects link without signature",
+			query: func() url.Values {
+				q := signedurl.Query(key, "AAAAAAAAAA", expires, false)
+				q.Del("sig")
+				return q
+			},
+			now: mustParseTime("2024-01-01T00:00:00Z"),
+			err: signedurl.ErrInvalidSignature,
+		},
+		{
+			description: "rejects link with malformed expiration",
+			query: func() url.Values {
+				q := signedurl.Query(key, "AAAAAAAAAA", expires, false)
+				q.Set("exp", "tomorrow")
+				return q
+			},
+			now: mustParseTime("2024-01-01T00:00:00Z"),
+			err: signedurl.ErrInvalidSignature,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			q := tt.query()
+			if !signedurl.IsSigned(q) {
+				t.Errorf("IsSigned=false, want=true")
+			}
+
+			grant, err := signedurl.Verify(key, "AAAAAAAAAA", q, tt.now)
+			if got, want := err, tt.err; got != want {
+				t.Fatalf("err=%v, want=%v", got, want)
+			}
+			if err != nil {
+				return
+			}
+
+			if got, want := grant.Expires, tt.grant.Expires; !got.Equal(want) {
+				t.Errorf("expires=%v, want=%v", got, want)
+			}
+			if got, want := grant.SingleUse, tt.grant.SingleUse; got != want {
+				t.Errorf("singleUse=%v, want=%v", got, want)
+			}
+			if got, want := grant.Signature, q.Get("sig"); got != want {
+				t.Errorf("signature=%v, want=%v", got, want)
+			}
+		})
+	}
+}
+
+func TestIsSigned(t *testing.T) {
+	if signedurl.IsSigned(url.Values{"v": []string{"2"}}) {
+		t.Errorf("IsSigned=true for unsigned query, want=false")
+	}
+}
+
+func mustParseTime(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
@@ -9,6 +9,7 @@ type (
 
 	DatabaseChecker interface {
 		TotalSize() (uint64, error)
+		StoredSize() (uint64, error)
 	}
 
 	Checker struct {
@@ -20,7 +21,14 @@ type (
 		// TotalServingBytes represents the sum total of the bytes of file data that
 		// PicoShare has of file uploads in the database. This is just file bytes
 		// and does not include PicoShare-specific metadata about the files.
+		//
+		// This is the logical size of the uploads, so if two entries have
+		// identical contents, both count toward the total.
 		TotalServingBytes uint64
+		// TotalStoredBytes represents the physical bytes of file data that
+		// PicoShare stores in the database. Entries with identical contents share
+		// their data, so this can be smaller than TotalServingBytes.
+		TotalStoredBytes uint64
 		// DatabaseFileSize represents the total number of bytes on the filesystem
 		// dedicated to storing PicoShare's SQLite database files.
 		DatabaseFileSize uint64
@@ -56,8 +64,14 @@ func (c Checker) Check() (Usage, error) {
 		return Usage{}, err
 	}
 
+	dbStoredSize, err := c.dbChecker.StoredSize()
+	if err != nil {
+		return Usage{}, err
+	}
+
 	return Usage{
 		TotalServingBytes:    dbTotalSize,
+		TotalStoredBytes:     dbStoredSize,
 		DatabaseFileSize:     fsUsage.PicoShareDbFileSize,
 		FileSystemUsedBytes:  fsUsage.UsedBytes,
 		FileSystemTotalBytes: fsUsage.TotalBytes,
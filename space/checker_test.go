@@ -18,14 +18,19 @@ func (c mockFileSystemChecker) MeasureUsage() (checkers.PicoShareUsage, error) {
 }
 
 type mockDatabaseChecker struct {
-	totalSize uint64
-	err       error
+	totalSize  uint64
+	storedSize uint64
+	err        error
 }
 
 func (c mockDatabaseChecker) TotalSize() (uint64, error) {
 	return c.totalSize, c.err
 }
 
+func (c mockDatabaseChecker) StoredSize() (uint64, error) {
+	return c.storedSize, c.err
+}
+
 func TestCheck(t *testing.T) {
 	dummyFileSystemErr := errors.New("dummy filesystem checker error")
 	dummyDatabaseErr := errors.New("dummy database checker error")
@@ -34,6 +39,7 @@ func TestCheck(t *testing.T) {
 		fsUsage       checkers.PicoShareUsage
 		fsErr         error
 		dbUsage       uint64
+		dbStored      uint64
 		dbErr         error
 		usageExpected space.Usage
 		errExpected   error
@@ -47,11 +53,13 @@ func TestCheck(t *testing.T) {
 				},
 				PicoShareDbFileSize: 65,
 			},
-			fsErr:   nil,
-			dbUsage: 60,
-			dbErr:   nil,
+			fsErr:    nil,
+			dbUsage:  60,
+			dbStored: 45,
+			dbErr:    nil,
 			usageExpected: space.Usage{
 				TotalServingBytes:    60,
+				TotalStoredBytes:     45,
 				DatabaseFileSize:     65,
 				FileSystemUsedBytes:  70,
 				FileSystemTotalBytes: 100,
@@ -89,8 +97,9 @@ func TestCheck(t *testing.T) {
 				err:   tt.fsErr,
 			}
 			dbc := mockDatabaseChecker{
-				totalSize: tt.dbUsage,
-				err:       tt.dbErr,
+				totalSize:  tt.dbUsage,
+				storedSize: tt.dbStored,
+				err:        tt.dbErr,
 			}
 
 			usage, err := space.NewCheckerFromCheckers(fsc, dbc).Check()
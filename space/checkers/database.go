@@ -11,6 +11,7 @@ import (
 type (
 	DatabaseMetadataReader interface {
 		GetEntriesMetadata() ([]picoshare.UploadMetadata, error)
+		GetStoredDataSize() (uint64, error)
 	}
 
 	DatabaseChecker struct {
@@ -42,6 +43,13 @@ func (dbc DatabaseChecker) TotalSize() (uint64, error) {
 	return bigIntToUint64(dbTotal)
 }
 
+// StoredSize returns the number of bytes of file data that the database
+// actually stores. This can be smaller than TotalSize because entries with
+// identical contents share their data.
+func (dbc DatabaseChecker) StoredSize() (uint64, error) {
+	return dbc.reader.GetStoredDataSize()
+}
+
 func uint64ToBigInt(val uint64) (*big.Int, error) {
 	if val > math.MaxInt64 {
 		return big.NewInt(0), ErrSizeOverflow
@@ -18,6 +18,10 @@ func (r mockDatabaseReader) GetEntriesMetadata() ([]picoshare.UploadMetadata, er
 	return r.metadataEntries, r.err
 }
 
+func (r mockDatabaseReader) GetStoredDataSize() (uint64, error) {
+	return 0, r.err
+}
+
 func TestTotalSize(t *testing.T) {
 	dummyDatabaseReaderErr := errors.New("dummy database reader error")
 	for _, tt := range []struct {
@@ -0,0 +1,57 @@
+package store
+
+import (
+	"time"
+
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+// EntrySortField is the attribute by which to order entries in a query.
+type EntrySortField string
+
+const (
+	SortByFilename EntrySortField = "filename"
+	SortByNote     EntrySortField = "note"
+	SortBySize     EntrySortField = "size"
+	SortByUploaded EntrySortField = "uploaded"
+	SortByExpires  EntrySortField = "expires"
+)
+
+type (
+	// EntryQuery selects a page of entries that match all of its filters. Zero
+	// values mean no filter.
+	EntryQuery struct {
+		// Search matches words in the entry's filename or note. The last word
+		// matches as a prefix so that results can update as the user types.
+		Search string
+		// GuestLinkID matches entries uploaded through the given guest link.
+		GuestLinkID picoshare.GuestLinkID
+		// ContentTypePrefix matches entries whose content type starts with the
+		// given prefix, such as "image/".
+		ContentTypePrefix string
+		MinSize           *uint64
+		MaxSize           *uint64
+		// ExpiresFrom and ExpiresBefore match entries that expire in the interval
+		// [ExpiresFrom, ExpiresBefore).
+		ExpiresFrom   *time.Time
+		ExpiresBefore *time.Time
+		// Tag matches entries that have the given tag.
+		Tag picoshare.Tag
+		// Folder matches entries in the given folder or any of its subfolders.
+		Folder picoshare.Folder
+
+		SortBy     EntrySortField
+		Descending bool
+
+		Offset int
+		// Limit is the maximum number of entries to return. Zero means no limit.
+		Limit int
+	}
+
+	// EntryPage is a page of the results of an EntryQuery.
+	EntryPage struct {
+		Entries []picoshare.UploadMetadata
+		// Total is the number of entries that match the query across all pages.
+		Total int
+	}
+)
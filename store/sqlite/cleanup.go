@@ -7,13 +7,28 @@ import (
 	"time"
 )
 
-// Purge deletes expired entries and clears orphaned rows from the database.
+// Purge deletes expired entries, including entries that went unused for longer
+// than their idle lifetime, entries that have been in the trash for longer than
+// the retention period, old notification emails, and orphaned rows from the
+// database.
 func (s Store) Purge() error {
 	log.Printf("deleting expired entries and orphaned data from database")
 	if err := s.deleteExpiredEntries(); err != nil {
 		return err
 	}
 
+	if err := s.deleteTrashedEntries(); err != nil {
+		return err
+	}
+
+	if err := s.deleteExpiredSignedURLs(); err != nil {
+		return err
+	}
+
+	if err := s.deleteOldNotificationEmails(); err != nil {
+		return err
+	}
+
 	if err := s.deleteOrphanedRows(); err != nil {
 		return err
 	}
@@ -21,6 +36,33 @@ func (s Store) Purge() error {
 	return nil
 }
 
+// expiredEntryCondition matches entries that are past their expiration time or
+// that have gone longer than their idle lifetime without any activity. An
+// entry's last activity is its most recent download, new version, or the time
+// it became available, whichever is latest.
+const expiredEntryCondition = `(
+   	(
+   		entries.expiration_time IS NOT NULL AND
+   		entries.expiration_time < :current_time
+   	) OR (
+   		entries.idle_expiration_in_days IS NOT NULL AND
+   		MAX(
+   			COALESCE(
+   				(
+   					SELECT
+   						MAX(downloads.download_timestamp)
+   					FROM
+   						downloads
+   					WHERE
+   						downloads.entry_id = entries.id
+   				),
+   				entries.upload_time
+   			),
+   			COALESCE(entries.available_from, entries.upload_time)
+   		) < strftime('%Y-%m-%dT%H:%M:%SZ', :current_time, '-' || entries.idle_expiration_in_days || ' days')
+   	)
+   )`
+
 func (s Store) deleteExpiredEntries() error {
 	log.Printf("deleting expired entries from database")
 
@@ -47,24 +89,123 @@ func (s Store) deleteExpiredEntries() error {
    		FROM
    			entries
    		WHERE
-   			entries.expiration_time IS NOT NULL AND
-   			entries.expiration_time < :current_time
+   			`+expiredEntryCondition+`
    	);`, sql.Named("current_time", currentTime)); err != nil {
 		return err
 	}
 
 	if _, err = tx.Exec(`
    DELETE FROM
-   	entries_data
+   	thumbnails
+   WHERE
+   	entry_id IN (
+   		SELECT
+   			id
+   		FROM
+   			entries
+   		WHERE
+   			`+expiredEntryCondition+`
+   	);`, sql.Named("current_time", currentTime)); err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(`
+   DELETE FROM
+   	entry_tags
+   WHERE
+   	entry_id IN (
+   		SELECT
+   			id
+   		FROM
+   			entries
+   		WHERE
+   			`+expiredEntryCondition+`
+   	);`, sql.Named("current_time", currentTime)); err != nil {
+		return err
+	}
+
+	if err := deleteUnusedTags(tx); err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(`
+   DELETE FROM
+   	link_recipients
+   WHERE
+   	entry_id IN (
+   		SELECT
+   			id
+   		FROM
+   			entries
+   		WHERE
+   			`+expiredEntryCondition+`
+   	);`, sql.Named("current_time", currentTime)); err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(`
+   UPDATE blobs
+   SET
+   	ref_count = ref_count - (
+   		SELECT
+   			COUNT(*)
+   		FROM
+   			entry_versions
+   		INNER JOIN
+   			entries ON entry_versions.entry_id = entries.id
+   		WHERE
+   			entry_versions.blob_id = blobs.id AND
+   			`+expiredEntryCondition+`
+   	)
    WHERE
    	id IN (
+   		SELECT
+   			entry_versions.blob_id
+   		FROM
+   			entry_versions
+   		INNER JOIN
+   			entries ON entry_versions.entry_id = entries.id
+   		WHERE
+   			`+expiredEntryCondition+`
+   	);`, sql.Named("current_time", currentTime)); err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(`
+   DELETE FROM
+   	entry_versions
+   WHERE
+   	entry_id IN (
    		SELECT
    			id
    		FROM
    			entries
    		WHERE
-   			entries.expiration_time IS NOT NULL AND
-   			entries.expiration_time < :current_time
+   			`+expiredEntryCondition+`
+   	);`, sql.Named("current_time", currentTime)); err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(`
+   UPDATE blobs
+   SET
+   	ref_count = ref_count - (
+   		SELECT
+   			COUNT(*)
+   		FROM
+   			entries
+   		WHERE
+   			entries.blob_id = blobs.id AND
+   			`+expiredEntryCondition+`
+   	)
+   WHERE
+   	id IN (
+   		SELECT
+   			blob_id
+   		FROM
+   			entries
+   		WHERE
+   			`+expiredEntryCondition+`
    	);`, sql.Named("current_time", currentTime)); err != nil {
 		return err
 	}
@@ -73,33 +214,109 @@ func (s Store) deleteExpiredEntries() error {
    DELETE FROM
    	entries
    WHERE
-   	entries.expiration_time IS NOT NULL AND
-   	entries.expiration_time < :current_time;
+   	`+expiredEntryCondition+`;
    `, sql.Named("current_time", currentTime)); err != nil {
 		return err
 	}
 
+	if err := deleteUnreferencedBlobs(tx); err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
 
+// deleteUnreferencedBlobs deletes the data for any blob that no entry
+// references anymore.
+func deleteUnreferencedBlobs(tx *sql.Tx) error {
+	if _, err := tx.Exec(`
+   DELETE FROM
+   	entries_data
+   WHERE
+   	id IN (
+   		SELECT
+   			id
+   		FROM
+   			blobs
+   		WHERE
+   			ref_count = 0
+   	);`); err != nil {
+		log.Printf("delete from entries_data table failed, aborting transaction: %v", err)
+		return err
+	}
+
+	if _, err := tx.Exec(`
+   DELETE FROM
+   	blobs
+   WHERE
+   	ref_count = 0;`); err != nil {
+		log.Printf("delete from blobs table failed, aborting transaction: %v", err)
+		return err
+	}
+
+	return nil
+}
+
 func (s Store) deleteOrphanedRows() error {
 	log.Printf("purging orphaned rows from database")
 
-	// Delete rows from entries_data if they don't reference valid rows in
-	// entries. This can happen if the entry insertion fails partway through.
-	rows, err := s.ctx.Exec(`
+	tx, err := s.ctx.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Printf("failed to rollback purge orphaned rows: %v", err)
+		}
+	}()
+
+	// Recount the references to each blob in case a reference count drifted
+	// from the entries and versions that actually use the blob.
+	if _, err := tx.Exec(`
+   	UPDATE blobs
+   	SET
+   		ref_count = (
+   			SELECT
+   				COUNT(*)
+   			FROM
+   				entries
+   			WHERE
+   				entries.blob_id = blobs.id
+   		) + (
+   			SELECT
+   				COUNT(*)
+   			FROM
+   				entry_versions
+   			WHERE
+   				entry_versions.blob_id = blobs.id
+   		)`); err != nil {
+		return err
+	}
+
+	if err := deleteUnreferencedBlobs(tx); err != nil {
+		return err
+	}
+
+	if err := deleteOrphanedExpirationReminders(tx); err != nil {
+		return err
+	}
+
+	// Delete rows from entries_data if they don't belong to a blob. This can
+	// happen if the entry insertion fails partway through.
+	rows, err := tx.Exec(`
    	DELETE FROM
    		entries_data
    	WHERE
    	id IN (
    		SELECT
-   			DISTINCT entries_data.id AS entry_id
+   			DISTINCT entries_data.id AS blob_id
    		FROM
    			entries_data
    		LEFT JOIN
-   			entries ON entries_data.id = entries.id
+   			blobs ON entries_data.id = blobs.id
    		WHERE
-   			entries.id IS NULL
+   			blobs.id IS NULL
    		)`)
 	if err != nil {
 		return err
@@ -110,6 +327,10 @@ func (s Store) deleteOrphanedRows() error {
 		return err
 	}
 
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
 	log.Printf("purge completed successfully (%d rows affected)", ra)
 
 	return nil
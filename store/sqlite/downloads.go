@@ -16,13 +16,15 @@ func (s Store) InsertEntryDownload(id picoshare.EntryID, r picoshare.DownloadRec
 		entry_id,
 		download_timestamp,
 		client_ip,
-		user_agent
+		user_agent,
+		version
 	)
-	VALUES(:entry_id, :download_timestamp, :client_ip, :user_agent)`,
+	VALUES(:entry_id, :download_timestamp, :client_ip, :user_agent, NULLIF(:version, 0))`,
 		sql.Named("entry_id", id.String()),
 		sql.Named("download_timestamp", formatTime(r.Time)),
 		sql.Named("client_ip", r.ClientIP),
 		sql.Named("user_agent", r.UserAgent),
+		sql.Named("version", r.Version),
 	); err != nil {
 		log.Printf("insert into downloads table failed: %v", err)
 		return err
@@ -35,7 +37,8 @@ func (s Store) GetEntryDownloads(id picoshare.EntryID) ([]picoshare.DownloadReco
 	SELECT
 		download_timestamp,
 		client_ip,
-		user_agent
+		user_agent,
+		version
 	FROM
 		downloads
 	WHERE
@@ -53,8 +56,9 @@ func (s Store) GetEntryDownloads(id picoshare.EntryID) ([]picoshare.DownloadReco
 		var downloadTimeRaw string
 		var clientIP string
 		var userAgent string
+		var version *int
 
-		if err := rows.Scan(&downloadTimeRaw, &clientIP, &userAgent); err != nil {
+		if err := rows.Scan(&downloadTimeRaw, &clientIP, &userAgent, &version); err != nil {
 			return []picoshare.DownloadRecord{}, err
 		}
 
@@ -63,11 +67,15 @@ func (s Store) GetEntryDownloads(id picoshare.EntryID) ([]picoshare.DownloadReco
 			return []picoshare.DownloadRecord{}, err
 		}
 
-		downloads = append(downloads, picoshare.DownloadRecord{
+		record := picoshare.DownloadRecord{
 			Time:      dt,
 			ClientIP:  clientIP,
 			UserAgent: userAgent,
-		})
+		}
+		if version != nil {
+			record.Version = *version
+		}
+		downloads = append(downloads, record)
 	}
 
 	return downloads, nil
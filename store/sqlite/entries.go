@@ -2,40 +2,75 @@ package sqlite
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"io"
 	"log"
+	"time"
 
 	"github.com/mtlynch/picoshare/picoshare"
 	"github.com/mtlynch/picoshare/store"
 	"github.com/mtlynch/picoshare/store/sqlite/file"
 )
 
+// GetEntriesMetadata returns the metadata of every entry, including entries in
+// the trash, which still occupy storage.
 func (s Store) GetEntriesMetadata() ([]picoshare.UploadMetadata, error) {
-	rows, err := s.ctx.Query(`
-	SELECT
-		entries.id AS id,
-		entries.filename AS filename,
-		entries.note AS note,
-		entries.content_type AS content_type,
-		entries.upload_time AS upload_time,
-		entries.expiration_time AS expiration_time,
-		sizes.file_size AS file_size
+	return s.selectEntriesMetadata("", "")
+}
+
+// GetTrashedEntries returns the metadata of the entries in the trash, most
+// recently deleted first.
+func (s Store) GetTrashedEntries() ([]picoshare.UploadMetadata, error) {
+	return s.selectEntriesMetadata(`
+	WHERE
+		entries.deletion_time IS NOT NULL`, `
+	ORDER BY
+		entries.deletion_time DESC,
+		entries.id`)
+}
+
+// entriesMetadataFrom is the FROM clause for queries of entries' metadata.
+const entriesMetadataFrom = `
 	FROM
 		entries
 	INNER JOIN
 		(
 			SELECT
 				id,
-				SUM(LENGTH(chunk)) AS file_size
+				SUM(COALESCE(uncompressed_length, LENGTH(chunk))) AS file_size
 			FROM
 				entries_data
 			GROUP BY
 				id
-		) sizes ON entries.id = sizes.id`)
+		) sizes ON entries.blob_id = sizes.id
+	LEFT JOIN
+		thumbnails ON entries.id = thumbnails.entry_id`
+
+// selectEntriesMetadata reads the metadata of the entries that match the
+// where clause (if any), with a suffix for ordering and limits.
+func (s Store) selectEntriesMetadata(where, suffix string, args ...any) ([]picoshare.UploadMetadata, error) {
+	rows, err := s.ctx.Query(`
+	SELECT
+		entries.id AS id,
+		entries.filename AS filename,
+		entries.note AS note,
+		entries.content_type AS content_type,
+		entries.upload_time AS upload_time,
+		entries.expiration_time AS expiration_time,
+		sizes.file_size AS file_size,
+		thumbnails.entry_id IS NOT NULL AS has_thumbnail,
+		entries.is_snippet AS is_snippet,
+		entries.folder AS folder,
+		entries.deletion_time AS deletion_time,
+		entries.visibility AS visibility,
+		entries.available_from AS available_from,
+		entries.idle_expiration_in_days AS idle_expiration_in_days`+entriesMetadataFrom+where+suffix, args...)
 	if err != nil {
 		return []picoshare.UploadMetadata{}, err
 	}
+	defer rows.Close()
 
 	ee := []picoshare.UploadMetadata{}
 	for rows.Next() {
@@ -46,7 +81,14 @@ func (s Store) GetEntriesMetadata() ([]picoshare.UploadMetadata, error) {
 		var uploadTimeRaw string
 		var expirationTimeRaw string
 		var fileSizeRaw uint64
-		if err = rows.Scan(&id, &filename, &note, &contentType, &uploadTimeRaw, &expirationTimeRaw, &fileSizeRaw); err != nil {
+		var hasThumbnail bool
+		var isSnippet bool
+		var folder *string
+		var deletionTimeRaw *string
+		var visibility *string
+		var availableFromRaw *string
+		var idleExpirationInDays *uint16
+		if err = rows.Scan(&id, &filename, &note, &contentType, &uploadTimeRaw, &expirationTimeRaw, &fileSizeRaw, &hasThumbnail, &isSnippet, &folder, &deletionTimeRaw, &visibility, &availableFromRaw, &idleExpirationInDays); err != nil {
 			return []picoshare.UploadMetadata{}, err
 		}
 
@@ -65,6 +107,22 @@ func (s Store) GetEntriesMetadata() ([]picoshare.UploadMetadata, error) {
 			return []picoshare.UploadMetadata{}, err
 		}
 
+		var deleted time.Time
+		if deletionTimeRaw != nil {
+			deleted, err = parseDatetime(*deletionTimeRaw)
+			if err != nil {
+				return []picoshare.UploadMetadata{}, err
+			}
+		}
+
+		var availableFrom time.Time
+		if availableFromRaw != nil {
+			availableFrom, err = parseDatetime(*availableFromRaw)
+			if err != nil {
+				return []picoshare.UploadMetadata{}, err
+			}
+		}
+
 		ee = append(ee, picoshare.UploadMetadata{
 			ID:          picoshare.EntryID(id),
 			Filename:    picoshare.Filename(filename),
@@ -73,14 +131,52 @@ func (s Store) GetEntriesMetadata() ([]picoshare.UploadMetadata, error) {
 			Uploaded:    ut,
 			Expires:     picoshare.ExpirationTime(et),
 			Size:        fileSize,
+
+			HasThumbnail:  hasThumbnail,
+			IsSnippet:     isSnippet,
+			Folder:        picoshare.Folder(stringFromNullable(folder)),
+			Deleted:       deleted,
+			Visibility:    picoshare.Visibility(stringFromNullable(visibility)),
+			AvailableFrom: availableFrom,
+			IdleLifetime:  fileLifetimeFromNullableDays(idleExpirationInDays),
 		})
 	}
 
 	return ee, nil
 }
 
+// GetStoredDataSize returns the total bytes of file data in the database.
+// Entries with identical contents share their data, so this can be smaller than
+// the sum of the entries' sizes.
+func (s Store) GetStoredDataSize() (uint64, error) {
+	var size uint64
+	if err := s.ctx.QueryRow(`
+	SELECT
+		COALESCE(SUM(LENGTH(chunk)), 0)
+	FROM
+		entries_data`).Scan(&size); err != nil {
+		return 0, err
+	}
+
+	return size, nil
+}
+
 func (s Store) ReadEntryFile(id picoshare.EntryID) (io.ReadSeeker, error) {
-	r, err := file.NewReader(s.ctx, id)
+	var blobID picoshare.EntryID
+	err := s.ctx.QueryRow(`
+	SELECT
+		blob_id
+	FROM
+		entries
+	WHERE
+		id = :entry_id`, sql.Named("entry_id", id)).Scan(&blobID)
+	if err == sql.ErrNoRows {
+		return nil, store.EntryNotFoundError{ID: id}
+	} else if err != nil {
+		return nil, err
+	}
+
+	r, err := file.NewReader(s.ctx, blobID)
 	if err != nil {
 		return nil, err
 	}
@@ -96,6 +192,24 @@ func (s Store) GetEntryMetadata(id picoshare.EntryID) (picoshare.UploadMetadata,
 	var expirationTimeRaw string
 	var fileSizeRaw uint64
 	var guestLinkID *picoshare.GuestLinkID
+	var maxDownloadBytesPerSecond *uint64
+	var scanStatus *string
+	var scanDetail *string
+	var scanPending bool
+	var sha256 *string
+	var integrityCheckTimeRaw *string
+	var integrityMismatch bool
+	var storedSizeRaw uint64
+	var compressed bool
+	var hasThumbnail bool
+	var isSnippet bool
+	var snippetLanguage *string
+	var folder *string
+	var version int
+	var slug *string
+	var visibility *string
+	var availableFromRaw *string
+	var idleExpirationInDays *uint16
 	err := s.ctx.QueryRow(`
 	SELECT
 		entries.filename AS filename,
@@ -104,21 +218,45 @@ func (s Store) GetEntryMetadata(id picoshare.EntryID) (picoshare.UploadMetadata,
 		entries.upload_time AS upload_time,
 		entries.expiration_time AS expiration_time,
 		sizes.file_size AS file_size,
-		entries.guest_link_id AS guest_link_id
+		entries.guest_link_id AS guest_link_id,
+		entries.max_download_bytes_per_second AS max_download_bytes_per_second,
+		entries.scan_status AS scan_status,
+		entries.scan_detail AS scan_detail,
+		entries.scan_pending AS scan_pending,
+		entries.sha256 AS sha256,
+		entries.integrity_check_time AS integrity_check_time,
+		entries.integrity_mismatch AS integrity_mismatch,
+		sizes.stored_size AS stored_size,
+		blobs.compressed AS compressed,
+		thumbnails.entry_id IS NOT NULL AS has_thumbnail,
+		entries.is_snippet AS is_snippet,
+		entries.snippet_language AS snippet_language,
+		entries.folder AS folder,
+		entries.version AS version,
+		entries.slug AS slug,
+		entries.visibility AS visibility,
+		entries.available_from AS available_from,
+		entries.idle_expiration_in_days AS idle_expiration_in_days
 	FROM
 		entries
 	INNER JOIN
 		(
 			SELECT
 				id,
-				SUM(LENGTH(chunk)) AS file_size
+				SUM(COALESCE(uncompressed_length, LENGTH(chunk))) AS file_size,
+				SUM(LENGTH(chunk)) AS stored_size
 			FROM
 				entries_data
 			GROUP BY
 				id
-		) sizes ON entries.id = sizes.id
+		) sizes ON entries.blob_id = sizes.id
+	INNER JOIN
+		blobs ON entries.blob_id = blobs.id
+	LEFT JOIN
+		thumbnails ON entries.id = thumbnails.entry_id
 	WHERE
-		entries.id = :entry_id`, sql.Named("entry_id", id)).Scan(&filename, &note, &contentType, &uploadTimeRaw, &expirationTimeRaw, &fileSizeRaw, &guestLinkID)
+		entries.id = :entry_id AND
+		entries.deletion_time IS NULL`, sql.Named("entry_id", id)).Scan(&filename, &note, &contentType, &uploadTimeRaw, &expirationTimeRaw, &fileSizeRaw, &guestLinkID, &maxDownloadBytesPerSecond, &scanStatus, &scanDetail, &scanPending, &sha256, &integrityCheckTimeRaw, &integrityMismatch, &storedSizeRaw, &compressed, &hasThumbnail, &isSnippet, &snippetLanguage, &folder, &version, &slug, &visibility, &availableFromRaw, &idleExpirationInDays)
 	if err == sql.ErrNoRows {
 		return picoshare.UploadMetadata{}, store.EntryNotFoundError{ID: id}
 	} else if err != nil {
@@ -148,6 +286,34 @@ func (s Store) GetEntryMetadata(id picoshare.EntryID) (picoshare.UploadMetadata,
 		return picoshare.UploadMetadata{}, err
 	}
 
+	storedSize, err := picoshare.FileSizeFromUint64(storedSizeRaw)
+	if err != nil {
+		return picoshare.UploadMetadata{}, err
+	}
+
+	tags, err := s.getEntryTags(id)
+	if err != nil {
+		return picoshare.UploadMetadata{}, err
+	}
+
+	integrity := picoshare.IntegrityCheck{
+		Mismatch: integrityMismatch,
+	}
+	if integrityCheckTimeRaw != nil {
+		integrity.Checked, err = parseDatetime(*integrityCheckTimeRaw)
+		if err != nil {
+			return picoshare.UploadMetadata{}, err
+		}
+	}
+
+	var availableFrom time.Time
+	if availableFromRaw != nil {
+		availableFrom, err = parseDatetime(*availableFromRaw)
+		if err != nil {
+			return picoshare.UploadMetadata{}, err
+		}
+	}
+
 	return picoshare.UploadMetadata{
 		ID:          id,
 		Filename:    picoshare.Filename(filename),
@@ -157,27 +323,55 @@ func (s Store) GetEntryMetadata(id picoshare.EntryID) (picoshare.UploadMetadata,
 		Uploaded:    ut,
 		Expires:     picoshare.ExpirationTime(et),
 		Size:        fileSize,
+
+		MaxDownloadBytesPerSecond: maxDownloadBytesPerSecond,
+		Scan:                      scanResultFromColumns(scanStatus, scanDetail, scanPending),
+		SHA256:                    stringFromNullable(sha256),
+		Integrity:                 integrity,
+		Compressed:                compressed,
+		StoredSize:                storedSize,
+		HasThumbnail:              hasThumbnail,
+		IsSnippet:                 isSnippet,
+		SnippetLanguage:           picoshare.SnippetLanguage(stringFromNullable(snippetLanguage)),
+		Tags:                      tags,
+		Folder:                    picoshare.Folder(stringFromNullable(folder)),
+		Version:                   version,
+		Slug:                      picoshare.EntrySlug(stringFromNullable(slug)),
+		Visibility:                picoshare.Visibility(stringFromNullable(visibility)),
+		AvailableFrom:             availableFrom,
+		IdleLifetime:              fileLifetimeFromNullableDays(idleExpirationInDays),
 	}, nil
 }
 
 func (s Store) InsertEntry(reader io.Reader, metadata picoshare.UploadMetadata) error {
 	log.Printf("saving new entry %s", metadata.ID)
 
-	// Note: We deliberately don't use a transaction here, as it bloats memory, so
-	// we can end up in a state with orphaned entries data. We clean it up in
-	// Purge().
-	// See: https://github.com/mtlynch/picoshare/issues/284
-	w := file.NewWriter(s.ctx, metadata.ID, s.chunkSize)
-	if _, err := io.Copy(w, reader); err != nil {
+	sha256Hex, err := s.writeBlobData(metadata.ID, reader, metadata.Compressed)
+	if err != nil {
 		return err
 	}
 
-	// Close() flushes the buffer, and it can fail.
-	if err := w.Close(); err != nil {
+	tx, err := s.ctx.BeginTx(context.Background(), nil)
+	if err != nil {
 		return err
 	}
 
-	_, err := s.ctx.Exec(`
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Printf("failed to rollback insert entry: %v", err)
+		}
+	}()
+
+	if err := checkSlugAvailable(tx, metadata.Slug, metadata.ID); err != nil {
+		return err
+	}
+
+	blobID, err := claimBlob(tx, metadata.ID, sha256Hex, metadata.Compressed)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`
 	INSERT INTO
 		entries
 	(
@@ -187,9 +381,20 @@ func (s Store) InsertEntry(reader io.Reader, metadata picoshare.UploadMetadata)
 		note,
 		content_type,
 		upload_time,
-		expiration_time
+		expiration_time,
+		max_download_bytes_per_second,
+		sha256,
+		blob_id,
+		is_snippet,
+		snippet_language,
+		folder,
+		slug,
+		visibility,
+		available_from,
+		idle_expiration_in_days,
+		scan_pending
 	)
-	VALUES(:entry_id, NULLIF(:guest_link_id, ''), :filename, :note, :content_type, :upload_time, :expiration_time)`,
+	VALUES(:entry_id, NULLIF(:guest_link_id, ''), :filename, :note, :content_type, :upload_time, :expiration_time, :max_download_bytes_per_second, :sha256, :blob_id, :is_snippet, NULLIF(:snippet_language, ''), NULLIF(:folder, ''), NULLIF(:slug, ''), NULLIF(:visibility, ''), :available_from, NULLIF(:idle_expiration_in_days, 0), :scan_pending)`,
 		sql.Named("entry_id", metadata.ID),
 		sql.Named("guest_link_id", metadata.GuestLink.ID),
 		sql.Named("filename", metadata.Filename),
@@ -197,29 +402,240 @@ func (s Store) InsertEntry(reader io.Reader, metadata picoshare.UploadMetadata)
 		sql.Named("content_type", metadata.ContentType),
 		sql.Named("upload_time", formatTime(metadata.Uploaded)),
 		sql.Named("expiration_time", formatExpirationTime(metadata.Expires)),
-	)
-	if err != nil {
+		sql.Named("max_download_bytes_per_second", metadata.MaxDownloadBytesPerSecond),
+		sql.Named("sha256", sha256Hex),
+		sql.Named("blob_id", blobID),
+		sql.Named("is_snippet", metadata.IsSnippet),
+		sql.Named("snippet_language", metadata.SnippetLanguage),
+		sql.Named("folder", metadata.Folder),
+		sql.Named("slug", metadata.Slug),
+		sql.Named("visibility", metadata.Visibility),
+		sql.Named("available_from", formatOptionalTime(metadata.AvailableFrom)),
+		sql.Named("idle_expiration_in_days", metadata.IdleLifetime.Days()),
+		sql.Named("scan_pending", metadata.Scan.IsPending()),
+	); err != nil {
 		log.Printf("insert into entries table failed, aborting transaction: %v", err)
 		return err
 	}
 
-	return nil
+	if err := setEntryTags(tx, metadata.ID, metadata.Tags); err != nil {
+		log.Printf("saving tags failed, aborting transaction: %v", err)
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// writeBlobData writes the data from reader to entries_data under the given ID
+// and returns the hex-encoded SHA-256 hash of the data.
+func (s Store) writeBlobData(id picoshare.EntryID, reader io.Reader, compressed bool) (string, error) {
+	// Note: We deliberately don't use a transaction here, as it bloats memory, so
+	// we can end up in a state with orphaned entries data. We clean it up in
+	// Purge().
+	// See: https://github.com/mtlynch/picoshare/issues/284
+	var w io.WriteCloser
+	if compressed {
+		w = file.NewCompressingWriter(s.ctx, id, s.chunkSize)
+	} else {
+		w = file.NewWriter(s.ctx, id, s.chunkSize)
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(w, hash), reader); err != nil {
+		return "", err
+	}
+
+	// Close() flushes the buffer, and it can fail.
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// claimBlob finds the blob that should hold the data for a newly-uploaded
+// entry, whose chunks have already been written under the entry's own ID. If
+// an existing blob has the same hash, the new entry shares that blob and we
+// discard the newly-written chunks. Otherwise, the new chunks become a new
+// blob.
+//
+// Claiming the blob is a single upsert so that two uploads of identical
+// contents at the same time can't both try to create the same blob.
+func claimBlob(tx *sql.Tx, id picoshare.EntryID, sha256Hex string, compressed bool) (picoshare.EntryID, error) {
+	var blobID picoshare.EntryID
+	if err := tx.QueryRow(`
+	INSERT INTO
+		blobs
+	(
+		id,
+		sha256,
+		ref_count,
+		compressed
+	)
+	VALUES(:blob_id, :sha256, 1, :compressed)
+	ON CONFLICT(sha256) DO UPDATE SET
+		ref_count = ref_count + 1
+	RETURNING
+		id`,
+		sql.Named("blob_id", id),
+		sql.Named("sha256", sha256Hex),
+		sql.Named("compressed", compressed)).Scan(&blobID); err != nil {
+		log.Printf("upsert into blobs table failed, aborting transaction: %v", err)
+		return "", err
+	}
+
+	if blobID == id {
+		return id, nil
+	}
+
+	log.Printf("entry %s has identical contents to blob %s, sharing data", id, blobID)
+
+	if _, err := tx.Exec(`
+	DELETE FROM
+		entries_data
+	WHERE
+		id = :entry_id`, sql.Named("entry_id", id)); err != nil {
+		log.Printf("delete from entries_data table failed, aborting transaction: %v", err)
+		return "", err
+	}
+
+	return blobID, nil
+}
+
+// releaseBlob drops one reference to the given blob and deletes the blob's
+// data if no entries reference it anymore.
+func releaseBlob(tx *sql.Tx, blobID picoshare.EntryID) error {
+	if _, err := tx.Exec(`
+	UPDATE blobs
+	SET
+		ref_count = ref_count - 1
+	WHERE
+		id = :blob_id AND
+		ref_count > 0`, sql.Named("blob_id", blobID)); err != nil {
+		log.Printf("update blobs table failed, aborting transaction: %v", err)
+		return err
+	}
+
+	return deleteUnreferencedBlobs(tx)
 }
 
 func (s Store) UpdateEntryMetadata(id picoshare.EntryID, metadata picoshare.UploadMetadata) error {
 	log.Printf("updating metadata for entry %s", id)
 
-	res, err := s.ctx.Exec(`
+	tx, err := s.ctx.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Printf("failed to rollback update entry metadata: %v", err)
+		}
+	}()
+
+	if err := checkSlugAvailable(tx, metadata.Slug, id); err != nil {
+		return err
+	}
+
+	res, err := tx.Exec(`
 	UPDATE entries
 	SET
 		filename = :filename,
 		expiration_time = :expiration_time,
-		note = :note
+		note = :note,
+		max_download_bytes_per_second = :max_download_bytes_per_second,
+		folder = NULLIF(:folder, ''),
+		slug = NULLIF(:slug, ''),
+		visibility = NULLIF(:visibility, ''),
+		available_from = :available_from,
+		idle_expiration_in_days = NULLIF(:idle_expiration_in_days, 0)
 	WHERE
-		id = :entry_id`,
+		id = :entry_id AND
+		deletion_time IS NULL`,
 		sql.Named("filename", metadata.Filename),
 		sql.Named("expiration_time", formatExpirationTime(metadata.Expires)),
 		sql.Named("note", metadata.Note.Value),
+		sql.Named("max_download_bytes_per_second", metadata.MaxDownloadBytesPerSecond),
+		sql.Named("folder", metadata.Folder),
+		sql.Named("slug", metadata.Slug),
+		sql.Named("visibility", metadata.Visibility),
+		sql.Named("available_from", formatOptionalTime(metadata.AvailableFrom)),
+		sql.Named("idle_expiration_in_days", metadata.IdleLifetime.Days()),
+		sql.Named("entry_id", id))
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return store.EntryNotFoundError{ID: id}
+	}
+
+	if err := setEntryTags(tx, id, metadata.Tags); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetEntriesNeedingScan returns the IDs of entries with contents that are
+// waiting for a malware scan or whose last scan failed, including previous
+// versions of their contents.
+func (s Store) GetEntriesNeedingScan() ([]picoshare.EntryID, error) {
+	rows, err := s.ctx.Query(`
+	SELECT
+		id
+	FROM
+		entries
+	WHERE
+		deletion_time IS NULL AND
+		(
+			scan_pending = 1 OR
+			scan_status = 'error' OR
+			id IN (
+				SELECT
+					entry_id
+				FROM
+					entry_versions
+				WHERE
+					scan_pending = 1 OR
+					scan_status = 'error'
+			)
+		)
+	ORDER BY
+		upload_time ASC`)
+	if err != nil {
+		return []picoshare.EntryID{}, err
+	}
+	defer rows.Close()
+
+	ids := []picoshare.EntryID{}
+	for rows.Next() {
+		var id picoshare.EntryID
+		if err := rows.Scan(&id); err != nil {
+			return []picoshare.EntryID{}, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return []picoshare.EntryID{}, err
+	}
+
+	return ids, nil
+}
+
+func (s Store) UpdateEntryIntegrityCheck(id picoshare.EntryID, check picoshare.IntegrityCheck) error {
+	res, err := s.ctx.Exec(`
+	UPDATE entries
+	SET
+		integrity_check_time = :integrity_check_time,
+		integrity_mismatch = :integrity_mismatch
+	WHERE
+		id = :entry_id`,
+		sql.Named("integrity_check_time", formatTime(check.Checked)),
+		sql.Named("integrity_mismatch", check.Mismatch),
 		sql.Named("entry_id", id))
 	if err != nil {
 		return err
@@ -236,9 +652,70 @@ func (s Store) UpdateEntryMetadata(id picoshare.EntryID, metadata picoshare.Uplo
 	return nil
 }
 
+// BackfillEntrySHA256 records the SHA-256 hash of the data of an entry that
+// was uploaded before PicoShare hashed files. It leaves entries that already
+// have a hash unchanged. The entry's blob takes the hash too, so that new
+// uploads of the same data share it, unless another blob already claims that
+// hash.
+func (s Store) BackfillEntrySHA256(id picoshare.EntryID, sha256Hex string) error {
+	tx, err := s.ctx.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Printf("failed to rollback backfill entry hash: %v", err)
+		}
+	}()
+
+	res, err := tx.Exec(`
+	UPDATE entries
+	SET
+		sha256 = :sha256
+	WHERE
+		id = :entry_id AND
+		sha256 IS NULL`,
+		sql.Named("sha256", sha256Hex),
+		sql.Named("entry_id", id))
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return nil
+	}
+
+	if _, err := tx.Exec(`
+	UPDATE blobs
+	SET
+		sha256 = :sha256
+	WHERE
+		id = (SELECT blob_id FROM entries WHERE id = :entry_id) AND
+		sha256 IS NULL AND
+		NOT EXISTS (SELECT 1 FROM blobs WHERE sha256 = :sha256)`,
+		sql.Named("sha256", sha256Hex),
+		sql.Named("entry_id", id)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteEntry permanently deletes an entry and its data. To delete an entry in
+// a way that the user can undo, use TrashEntries.
 func (s Store) DeleteEntry(id picoshare.EntryID) error {
-	log.Printf("deleting entry %v", id)
+	return s.DeleteEntries([]picoshare.EntryID{id})
+}
 
+// DeleteEntries permanently deletes several entries in a single transaction,
+// so either all of the entries are deleted or none of them are. IDs that don't
+// match any entry are ignored.
+func (s Store) DeleteEntries(ids []picoshare.EntryID) error {
 	tx, err := s.ctx.BeginTx(context.Background(), nil)
 	if err != nil {
 		return err
@@ -250,6 +727,18 @@ func (s Store) DeleteEntry(id picoshare.EntryID) error {
 		}
 	}()
 
+	for _, id := range ids {
+		if err := deleteEntry(tx, id); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func deleteEntry(tx *sql.Tx, id picoshare.EntryID) error {
+	log.Printf("deleting entry %v", id)
+
 	if _, err := tx.Exec(`
 	DELETE FROM
 		downloads
@@ -261,10 +750,41 @@ func (s Store) DeleteEntry(id picoshare.EntryID) error {
 
 	if _, err := tx.Exec(`
 	DELETE FROM
-		entries_data
+		link_recipients
 	WHERE
-		id = :entry_id`, sql.Named("entry_id", id)); err != nil {
-		log.Printf("delete from entries_data table failed, aborting transaction: %v", err)
+		entry_id = :entry_id`, sql.Named("entry_id", id)); err != nil {
+		log.Printf("delete from link_recipients table failed, aborting transaction: %v", err)
+		return err
+	}
+
+	var blobID *picoshare.EntryID
+	if err := tx.QueryRow(`
+	SELECT
+		blob_id
+	FROM
+		entries
+	WHERE
+		id = :entry_id`, sql.Named("entry_id", id)).Scan(&blobID); err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`
+	DELETE FROM
+		thumbnails
+	WHERE
+		entry_id = :entry_id`, sql.Named("entry_id", id)); err != nil {
+		log.Printf("delete from thumbnails table failed, aborting transaction: %v", err)
+		return err
+	}
+
+	if err := setEntryTags(tx, id, nil); err != nil {
+		log.Printf("delete from entry_tags table failed, aborting transaction: %v", err)
+		return err
+	}
+
+	if err := deleteEntryVersions(tx, id); err != nil {
 		return err
 	}
 
@@ -277,5 +797,92 @@ func (s Store) DeleteEntry(id picoshare.EntryID) error {
 		return err
 	}
 
+	if blobID != nil {
+		if err := releaseBlob(tx, *blobID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UpdateEntriesExpiration sets the expiration time of several entries in a
+// single transaction. If any ID doesn't match an entry, none of the entries
+// change.
+func (s Store) UpdateEntriesExpiration(ids []picoshare.EntryID, expiration picoshare.ExpirationTime) error {
+	log.Printf("updating expiration for %d entries", len(ids))
+
+	tx, err := s.ctx.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Printf("failed to rollback update entries expiration: %v", err)
+		}
+	}()
+
+	for _, id := range ids {
+		res, err := tx.Exec(`
+		UPDATE entries
+		SET
+			expiration_time = :expiration_time
+		WHERE
+			id = :entry_id AND
+			deletion_time IS NULL`,
+			sql.Named("expiration_time", formatExpirationTime(expiration)),
+			sql.Named("entry_id", id))
+		if err != nil {
+			return err
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return store.EntryNotFoundError{ID: id}
+		}
+	}
+
 	return tx.Commit()
 }
+
+func stringFromNullable(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// fileLifetimeFromNullableDays converts a nullable column of days to a file
+// lifetime, where NULL is the zero lifetime.
+func fileLifetimeFromNullableDays(days *uint16) picoshare.FileLifetime {
+	if days == nil {
+		return picoshare.FileLifetime{}
+	}
+	return picoshare.NewFileLifetimeInDays(*days)
+}
+
+func scanResultFromColumns(status, detail *string, pending bool) picoshare.ScanResult {
+	var result picoshare.ScanResult
+	if pending {
+		result.Status = picoshare.ScanStatusPending
+	} else if status != nil {
+		result.Status = picoshare.ScanStatus(*status)
+	}
+	if detail != nil {
+		result.Detail = *detail
+	}
+	return result
+}
+
+// scanStatusColumn returns the value to store in the scan_status column. The
+// database tracks pending scans in the separate scan_pending column.
+func scanStatusColumn(result picoshare.ScanResult) picoshare.ScanStatus {
+	if result.IsPending() {
+		return picoshare.ScanStatusNotScanned
+	}
+	return result.Status
+}
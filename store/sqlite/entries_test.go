@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"io"
 	"log"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -116,6 +118,172 @@ func TestReadLastByteOfEntry(t *testing.T) {
 	}
 }
 
+func TestCompressedEntry(t *testing.T) {
+	chunkSize := uint64(64)
+	dataStore := test_sqlite.NewWithChunkSize(chunkSize)
+
+	input := strings.Repeat("timestamp,level,message\n", 20)
+	if err := dataStore.InsertEntry(bytes.NewBufferString(input), picoshare.UploadMetadata{
+		ID:         picoshare.EntryID("dummy-id"),
+		Filename:   "dummy-file.csv",
+		Uploaded:   mustParseTime("2025-05-25T00:00:00Z"),
+		Expires:    mustParseExpirationTime("2040-01-01T00:00:00Z"),
+		Size:       mustParseFileSize(len(input)),
+		Compressed: true,
+	}); err != nil {
+		t.Fatalf("failed to insert file into sqlite: %v", err)
+	}
+
+	meta, err := dataStore.GetEntryMetadata("dummy-id")
+	if err != nil {
+		t.Fatalf("failed to get entry metadata: %v", err)
+	}
+	if !meta.Compressed {
+		t.Errorf("compressed=%v, want=%v", meta.Compressed, true)
+	}
+	if got, want := meta.Size, mustParseFileSize(len(input)); !got.Equal(want) {
+		t.Errorf("size=%v, want=%v", got, want)
+	}
+	if meta.StoredSize.UInt64() >= meta.Size.UInt64() {
+		t.Errorf("stored size=%v, want less than %v", meta.StoredSize, meta.Size)
+	}
+
+	entryFile, err := dataStore.ReadEntryFile("dummy-id")
+	if err != nil {
+		t.Fatalf("failed to read entry: %v", err)
+	}
+
+	contents, err := io.ReadAll(entryFile)
+	if err != nil {
+		t.Fatalf("failed to read entry contents: %v", err)
+	}
+	if got, want := string(contents), input; got != want {
+		t.Fatalf("contents=%s, want=%s", got, want)
+	}
+
+	offset := int64(len(input) - 30)
+	if _, err := entryFile.Seek(offset, io.SeekStart); err != nil {
+		t.Fatalf("failed to seek file reader: %v", err)
+	}
+	contents, err = io.ReadAll(entryFile)
+	if err != nil {
+		t.Fatalf("failed to read entry contents: %v", err)
+	}
+	if got, want := string(contents), input[offset:]; got != want {
+		t.Fatalf("contents after seek=%s, want=%s", got, want)
+	}
+}
+
+func TestIdenticalEntriesShareData(t *testing.T) {
+	chunkSize := uint64(5)
+	dataStore := test_sqlite.NewWithChunkSize(chunkSize)
+
+	input := "hello, world!"
+	for _, id := range []picoshare.EntryID{"dummy-id-1", "dummy-id-2"} {
+		if err := dataStore.InsertEntry(bytes.NewBufferString(input), picoshare.UploadMetadata{
+			ID:       id,
+			Filename: picoshare.Filename(string(id) + ".txt"),
+			Uploaded: mustParseTime("2025-05-25T00:00:00Z"),
+			Expires:  mustParseExpirationTime("2040-01-01T00:00:00Z"),
+			Size:     mustParseFileSize(len(input)),
+		}); err != nil {
+			t.Fatalf("failed to insert file into sqlite: %v", err)
+		}
+	}
+
+	stored, err := dataStore.GetStoredDataSize()
+	if err != nil {
+		t.Fatalf("failed to get stored data size: %v", err)
+	}
+	if got, want := stored, uint64(len(input)); got != want {
+		t.Fatalf("stored size=%d, want=%d", got, want)
+	}
+
+	meta, err := dataStore.GetEntryMetadata("dummy-id-2")
+	if err != nil {
+		t.Fatalf("failed to get entry metadata: %v", err)
+	}
+	if got, want := meta.Filename, picoshare.Filename("dummy-id-2.txt"); got != want {
+		t.Fatalf("filename=%s, want=%s", got, want)
+	}
+	if got, want := meta.Size, mustParseFileSize(len(input)); !got.Equal(want) {
+		t.Fatalf("size=%v, want=%v", got, want)
+	}
+
+	if err := dataStore.DeleteEntry("dummy-id-1"); err != nil {
+		t.Fatalf("failed to delete entry: %v", err)
+	}
+
+	entryFile, err := dataStore.ReadEntryFile("dummy-id-2")
+	if err != nil {
+		t.Fatalf("failed to read entry: %v", err)
+	}
+	contents, err := io.ReadAll(entryFile)
+	if err != nil {
+		t.Fatalf("failed to read entry contents: %v", err)
+	}
+	if got, want := string(contents), input; got != want {
+		t.Fatalf("contents=%s, want=%s", got, want)
+	}
+
+	if err := dataStore.DeleteEntry("dummy-id-2"); err != nil {
+		t.Fatalf("failed to delete entry: %v", err)
+	}
+
+	stored, err = dataStore.GetStoredDataSize()
+	if err != nil {
+		t.Fatalf("failed to get stored data size: %v", err)
+	}
+	if got, want := stored, uint64(0); got != want {
+		t.Fatalf("stored size=%d, want=%d", got, want)
+	}
+}
+
+func TestPurgeKeepsDataSharedWithUnexpiredEntry(t *testing.T) {
+	chunkSize := uint64(5)
+	dataStore := test_sqlite.NewWithChunkSize(chunkSize)
+
+	input := "hello, world!"
+	for _, m := range []picoshare.UploadMetadata{
+		{
+			ID:       picoshare.EntryID("expired-id"),
+			Filename: "expired.txt",
+			Uploaded: mustParseTime("2023-01-01T00:00:00Z"),
+			Expires:  mustParseExpirationTime("2023-01-02T00:00:00Z"),
+		},
+		{
+			ID:       picoshare.EntryID("unexpired-id"),
+			Filename: "unexpired.txt",
+			Uploaded: mustParseTime("2025-05-25T00:00:00Z"),
+			Expires:  mustParseExpirationTime("2040-01-01T00:00:00Z"),
+		},
+	} {
+		if err := dataStore.InsertEntry(bytes.NewBufferString(input), m); err != nil {
+			t.Fatalf("failed to insert file into sqlite: %v", err)
+		}
+	}
+
+	if err := dataStore.Purge(); err != nil {
+		t.Fatalf("failed to purge database: %v", err)
+	}
+
+	if _, err := dataStore.GetEntryMetadata("expired-id"); err == nil {
+		t.Fatalf("expired entry still exists after purge")
+	}
+
+	entryFile, err := dataStore.ReadEntryFile("unexpired-id")
+	if err != nil {
+		t.Fatalf("failed to read entry: %v", err)
+	}
+	contents, err := io.ReadAll(entryFile)
+	if err != nil {
+		t.Fatalf("failed to read entry contents: %v", err)
+	}
+	if got, want := string(contents), input; got != want {
+		t.Fatalf("contents=%s, want=%s", got, want)
+	}
+}
+
 func mustParseTime(s string) time.Time {
 	t, err := time.Parse(time.RFC3339, s)
 	if err != nil {
@@ -140,3 +308,60 @@ func mustParseFileSize(val int) picoshare.FileSize {
 
 	return fileSize
 }
+
+func TestEntryPendingScan(t *testing.T) {
+	dataStore := test_sqlite.New()
+
+	for _, id := range []picoshare.EntryID{"pending-id", "unscanned-id"} {
+		metadata := picoshare.UploadMetadata{
+			ID:       id,
+			Filename: "dummy-file.txt",
+			Uploaded: mustParseTime("2025-05-25T00:00:00Z"),
+			Expires:  mustParseExpirationTime("2040-01-01T00:00:00Z"),
+			Size:     mustParseFileSize(len("dummy data")),
+		}
+		if id == "pending-id" {
+			metadata.Scan = picoshare.ScanResult{Status: picoshare.ScanStatusPending}
+		}
+		if err := dataStore.InsertEntry(strings.NewReader("dummy data"), metadata); err != nil {
+			t.Fatalf("failed to insert entry: %v", err)
+		}
+	}
+
+	pending, err := dataStore.GetEntriesNeedingScan()
+	if err != nil {
+		t.Fatalf("failed to get entries needing scan: %v", err)
+	}
+	if got, want := pending, []picoshare.EntryID{"pending-id"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("pending=%v, want=%v", got, want)
+	}
+
+	entry, err := dataStore.GetEntryMetadata("pending-id")
+	if err != nil {
+		t.Fatalf("failed to get entry metadata: %v", err)
+	}
+	if got, want := entry.Scan, (picoshare.ScanResult{Status: picoshare.ScanStatusPending}); got != want {
+		t.Fatalf("scan=%+v, want=%+v", got, want)
+	}
+
+	result := picoshare.ScanResult{Status: picoshare.ScanStatusInfected, Detail: "Eicar-Test-Signature"}
+	if err := dataStore.UpdateEntryVersionScanResult("pending-id", 1, result); err != nil {
+		t.Fatalf("failed to update scan result: %v", err)
+	}
+
+	entry, err = dataStore.GetEntryMetadata("pending-id")
+	if err != nil {
+		t.Fatalf("failed to get entry metadata: %v", err)
+	}
+	if got, want := entry.Scan, result; got != want {
+		t.Errorf("scan=%+v, want=%+v", got, want)
+	}
+
+	pending, err = dataStore.GetEntriesNeedingScan()
+	if err != nil {
+		t.Fatalf("failed to get entries needing scan: %v", err)
+	}
+	if got, want := len(pending), 0; got != want {
+		t.Errorf("len(pending)=%d, want=%d", got, want)
+	}
+}
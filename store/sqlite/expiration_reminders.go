@@ -0,0 +1,75 @@
+package sqlite
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+// GetEntriesDueExpirationReminder returns the entries outside the trash that
+// expire between now and the cutoff and that PicoShare hasn't sent a reminder
+// about for their current expiration time, soonest expiration first.
+func (s Store) GetEntriesDueExpirationReminder(now, cutoff time.Time) ([]picoshare.UploadMetadata, error) {
+	return s.selectEntriesMetadata(`
+	WHERE
+		entries.deletion_time IS NULL AND
+		entries.expiration_time >= :now AND
+		entries.expiration_time < :cutoff AND
+		NOT EXISTS (
+			SELECT
+				1
+			FROM
+				expiration_reminders
+			WHERE
+				expiration_reminders.entry_id = entries.id AND
+				expiration_reminders.expiration_time = entries.expiration_time
+		)`, `
+	ORDER BY
+		entries.expiration_time ASC,
+		entries.id`,
+		sql.Named("now", formatTime(now)),
+		sql.Named("cutoff", formatTime(cutoff)))
+}
+
+// RecordExpirationReminder records that PicoShare sent a reminder that the
+// entry expires at the given time.
+func (s Store) RecordExpirationReminder(id picoshare.EntryID, expiration picoshare.ExpirationTime) error {
+	if _, err := s.ctx.Exec(`
+	INSERT INTO
+		expiration_reminders
+	(
+		entry_id,
+		expiration_time
+	)
+	VALUES(:entry_id, :expiration_time)
+	ON CONFLICT (entry_id) DO UPDATE SET
+		expiration_time = excluded.expiration_time`,
+		sql.Named("entry_id", id),
+		sql.Named("expiration_time", formatExpirationTime(expiration))); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// deleteOrphanedExpirationReminders deletes reminders for entries that no
+// longer exist.
+func deleteOrphanedExpirationReminders(tx *sql.Tx) error {
+	if _, err := tx.Exec(`
+	DELETE FROM
+		expiration_reminders
+	WHERE
+		entry_id NOT IN (
+			SELECT
+				id
+			FROM
+				entries
+		)`); err != nil {
+		log.Printf("delete from expiration_reminders table failed, aborting transaction: %v", err)
+		return err
+	}
+
+	return nil
+}
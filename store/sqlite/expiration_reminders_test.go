@@ -0,0 +1,81 @@
+package sqlite_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/store/test_sqlite"
+)
+
+func TestExpirationReminders(t *testing.T) {
+	dataStore := test_sqlite.New()
+
+	for _, entry := range []picoshare.UploadMetadata{
+		{
+			ID:      "AAAAAAAAAA",
+			Expires: mustParseExpirationTime("2024-01-03T00:00:00Z"),
+		},
+		{
+			ID:      "BBBBBBBBBB",
+			Expires: mustParseExpirationTime("2024-01-02T00:00:00Z"),
+		},
+		{
+			ID:      "CCCCCCCCCC",
+			Expires: mustParseExpirationTime("2024-02-01T00:00:00Z"),
+		},
+		{
+			ID:      "DDDDDDDDDD",
+			Expires: picoshare.NeverExpire,
+		},
+	} {
+		entry.Filename = "dummy.txt"
+		entry.Uploaded = mustParseTime("2024-01-01T00:00:00Z")
+		if err := dataStore.InsertEntry(strings.NewReader("dummy data"), entry); err != nil {
+			t.Fatalf("failed to insert dummy entry: %v", err)
+		}
+	}
+
+	now := mustParseTime("2024-01-01T00:00:00Z")
+	cutoff := mustParseTime("2024-01-08T00:00:00Z")
+
+	assertDue := func(want ...picoshare.EntryID) {
+		t.Helper()
+		entries, err := dataStore.GetEntriesDueExpirationReminder(now, cutoff)
+		if err != nil {
+			t.Fatalf("failed to get entries due reminders: %v", err)
+		}
+		got := []picoshare.EntryID{}
+		for _, entry := range entries {
+			got = append(got, entry.ID)
+		}
+		if want == nil {
+			want = []picoshare.EntryID{}
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("entries due reminders=%v, want=%v", got, want)
+		}
+	}
+
+	assertDue("BBBBBBBBBB", "AAAAAAAAAA")
+
+	if err := dataStore.RecordExpirationReminder("BBBBBBBBBB", mustParseExpirationTime("2024-01-02T00:00:00Z")); err != nil {
+		t.Fatalf("failed to record reminder: %v", err)
+	}
+	assertDue("AAAAAAAAAA")
+
+	// Extending the entry makes it due for another reminder.
+	if err := dataStore.UpdateEntriesExpiration([]picoshare.EntryID{"BBBBBBBBBB"}, mustParseExpirationTime("2024-01-05T00:00:00Z")); err != nil {
+		t.Fatalf("failed to update expiration: %v", err)
+	}
+	assertDue("AAAAAAAAAA", "BBBBBBBBBB")
+
+	if err := dataStore.RecordExpirationReminder("BBBBBBBBBB", mustParseExpirationTime("2024-01-05T00:00:00Z")); err != nil {
+		t.Fatalf("failed to record reminder: %v", err)
+	}
+	if err := dataStore.RecordExpirationReminder("AAAAAAAAAA", mustParseExpirationTime("2024-01-03T00:00:00Z")); err != nil {
+		t.Fatalf("failed to record reminder: %v", err)
+	}
+	assertDue()
+}
@@ -0,0 +1,40 @@
+package file
+
+import "github.com/klauspost/compress/zstd"
+
+// The zstd encoder and decoder are safe for concurrent use through EncodeAll
+// and DecodeAll, so all readers and writers share a single instance of each.
+var (
+	encoder = mustCreateEncoder()
+	decoder = mustCreateDecoder()
+)
+
+// compressChunk compresses a chunk of file data. If compression doesn't shrink
+// the chunk, it returns nil, and the caller should store the chunk as-is.
+func compressChunk(chunk []byte) []byte {
+	compressed := encoder.EncodeAll(chunk, make([]byte, 0, len(chunk)))
+	if len(compressed) >= len(chunk) {
+		return nil
+	}
+	return compressed
+}
+
+func decompressChunk(chunk []byte, uncompressedLength int64) ([]byte, error) {
+	return decoder.DecodeAll(chunk, make([]byte, 0, uncompressedLength))
+}
+
+func mustCreateEncoder() *zstd.Encoder {
+	e, err := zstd.NewWriter(nil)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+func mustCreateDecoder() *zstd.Decoder {
+	d, err := zstd.NewReader(nil)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
@@ -89,9 +89,11 @@ func (fr *fileReader) populateBuffer() error {
 
 	chunkIndex := fr.offset / int64(fr.chunkSize)
 	var chunk []byte
+	var uncompressedLength *int64
 	if err := fr.db.QueryRow(`
 			SELECT
-				chunk
+				chunk,
+				uncompressed_length
 			FROM
 				entries_data
 			WHERE
@@ -99,11 +101,20 @@ func (fr *fileReader) populateBuffer() error {
 				chunk_index=?
 			ORDER BY
 				chunk_index ASC
-			`, fr.entryID, chunkIndex).Scan(&chunk); err != nil {
+			`, fr.entryID, chunkIndex).Scan(&chunk, &uncompressedLength); err != nil {
 		log.Printf("reading chunk failed: %v", err)
 		return err
 	}
 
+	if uncompressedLength != nil {
+		var err error
+		chunk, err = decompressChunk(chunk, *uncompressedLength)
+		if err != nil {
+			log.Printf("decompressing chunk failed: %v", err)
+			return err
+		}
+	}
+
 	// Move the start index to the position in the chunk we want to read.
 	readStart := fr.offset % int64(fr.chunkSize)
 
@@ -119,7 +130,7 @@ func getFileLength(db *sql.DB, id picoshare.EntryID, chunkSize int64) (int64, er
 	if err := db.QueryRow(`
 	SELECT
 		chunk_index,
-		LENGTH(chunk) AS chunk_size
+		COALESCE(uncompressed_length, LENGTH(chunk)) AS chunk_size
 	FROM
 		entries_data
 	WHERE
@@ -141,7 +152,7 @@ func getChunkSize(db *sql.DB, id picoshare.EntryID) (int64, error) {
 	var chunkSize int64
 	if err := db.QueryRow(`
 	SELECT
-		LENGTH(chunk) AS chunk_size
+		COALESCE(uncompressed_length, LENGTH(chunk)) AS chunk_size
 	FROM
 		entries_data
 	WHERE
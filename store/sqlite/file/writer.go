@@ -8,10 +8,11 @@ import (
 )
 
 type writer struct {
-	ctx     wrapped.SqlDB
-	entryID picoshare.EntryID
-	buf     []byte
-	written int
+	ctx      wrapped.SqlDB
+	entryID  picoshare.EntryID
+	buf      []byte
+	written  int
+	compress bool
 }
 
 // Create a new writer for the entry ID using the given SqlTx and splitting the
@@ -24,6 +25,18 @@ func NewWriter(ctx wrapped.SqlDB, id picoshare.EntryID, chunkSize uint64) io.Wri
 	})
 }
 
+// NewCompressingWriter is like NewWriter, but it compresses each chunk with zstd
+// before saving it. Chunks that don't shrink under compression are saved
+// uncompressed.
+func NewCompressingWriter(ctx wrapped.SqlDB, id picoshare.EntryID, chunkSize uint64) io.WriteCloser {
+	return new(writer{
+		ctx:      ctx,
+		entryID:  id,
+		buf:      make([]byte, chunkSize),
+		compress: true,
+	})
+}
+
 // Write writes a buffer to the SQLite database.
 func (w *writer) Write(p []byte) (int, error) {
 	n := 0
@@ -65,15 +78,25 @@ func (w *writer) Close() error {
 
 func (w *writer) flush(n int) error {
 	idx := w.written / len(w.buf)
+	chunk := w.buf[0:n]
+	var uncompressedLength *int
+	if w.compress {
+		if compressed := compressChunk(chunk); compressed != nil {
+			chunk = compressed
+			uncompressedLength = &n
+		}
+	}
+
 	_, err := w.ctx.Exec(`
 	INSERT INTO
 		entries_data
 	(
 		id,
 		chunk_index,
-		chunk
+		chunk,
+		uncompressed_length
 	)
-	VALUES(?,?,?)`, w.entryID, idx, w.buf[0:n])
+	VALUES(?,?,?,?)`, w.entryID, idx, chunk, uncompressedLength)
 
 	return err
 }
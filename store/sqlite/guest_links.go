@@ -17,9 +17,15 @@ func (s Store) GetGuestLink(id picoshare.GuestLinkID) (picoshare.GuestLink, erro
 			guest_links.is_disabled As is_disabled,
 			guest_links.max_file_bytes AS max_file_bytes,
 			guest_links.max_file_uploads AS max_file_uploads,
+			guest_links.max_uploads_per_ip_per_hour AS max_uploads_per_ip_per_hour,
+			guest_links.max_bytes_per_ip_per_day AS max_bytes_per_ip_per_day,
+			guest_links.max_upload_bytes_per_second AS max_upload_bytes_per_second,
+			guest_links.require_challenge AS require_challenge,
 			guest_links.creation_time AS creation_time,
 			guest_links.url_expiration_time AS url_expiration_time,
 			guest_links.file_expiration_time AS file_expiration_time,
+			guest_links.auto_tag AS auto_tag,
+			guest_links.notify_on_upload AS notify_on_upload,
 			SUM(CASE WHEN entries.id IS NOT NULL THEN 1 ELSE 0 END) AS entry_count
 		FROM
 			guest_links
@@ -41,9 +47,15 @@ func (s Store) GetGuestLinks() ([]picoshare.GuestLink, error) {
 			guest_links.is_disabled As is_disabled,
 			guest_links.max_file_bytes AS max_file_bytes,
 			guest_links.max_file_uploads AS max_file_uploads,
+			guest_links.max_uploads_per_ip_per_hour AS max_uploads_per_ip_per_hour,
+			guest_links.max_bytes_per_ip_per_day AS max_bytes_per_ip_per_day,
+			guest_links.max_upload_bytes_per_second AS max_upload_bytes_per_second,
+			guest_links.require_challenge AS require_challenge,
 			guest_links.creation_time AS creation_time,
 			guest_links.url_expiration_time AS url_expiration_time,
 			guest_links.file_expiration_time AS file_expiration_time,
+			guest_links.auto_tag AS auto_tag,
+			guest_links.notify_on_upload AS notify_on_upload,
 			SUM(CASE WHEN entries.id IS NOT NULL THEN 1 ELSE 0 END) AS entry_count
 		FROM
 			guest_links
@@ -79,20 +91,32 @@ func (s *Store) InsertGuestLink(guestLink picoshare.GuestLink) error {
 			is_disabled,
 			max_file_bytes,
 			max_file_uploads,
+			max_uploads_per_ip_per_hour,
+			max_bytes_per_ip_per_day,
+			max_upload_bytes_per_second,
+			require_challenge,
 			creation_time,
 			url_expiration_time,
-			file_expiration_time
+			file_expiration_time,
+			auto_tag,
+			notify_on_upload
 		)
-		VALUES (:id, :label, :is_disabled,:max_file_bytes, :max_file_uploads, :creation_time, :url_expiration_time, :file_expiration_time)
+		VALUES (:id, :label, :is_disabled,:max_file_bytes, :max_file_uploads, :max_uploads_per_ip_per_hour, :max_bytes_per_ip_per_day, :max_upload_bytes_per_second, :require_challenge, :creation_time, :url_expiration_time, :file_expiration_time, NULLIF(:auto_tag, ''), :notify_on_upload)
 	`,
 		sql.Named("id", guestLink.ID),
 		sql.Named("label", guestLink.Label),
 		sql.Named("is_disabled", guestLink.IsDisabled),
 		sql.Named("max_file_bytes", guestLink.MaxFileBytes),
 		sql.Named("max_file_uploads", guestLink.MaxFileUploads),
+		sql.Named("max_uploads_per_ip_per_hour", guestLink.RateLimit.MaxUploadsPerIPPerHour),
+		sql.Named("max_bytes_per_ip_per_day", guestLink.RateLimit.MaxBytesPerIPPerDay),
+		sql.Named("max_upload_bytes_per_second", guestLink.RateLimit.MaxBytesPerSecond),
+		sql.Named("require_challenge", guestLink.RequireChallenge),
 		sql.Named("creation_time", formatTime(guestLink.Created)),
 		sql.Named("url_expiration_time", formatExpirationTime(guestLink.UrlExpires)),
-		sql.Named("file_expiration_time", formatFileLifetime(guestLink.MaxFileLifetime))); err != nil {
+		sql.Named("file_expiration_time", formatFileLifetime(guestLink.MaxFileLifetime)),
+		sql.Named("auto_tag", guestLink.AutoTag),
+		sql.Named("notify_on_upload", guestLink.NotifyOnUpload)); err != nil {
 		return err
 	}
 
@@ -180,12 +204,16 @@ func guestLinkFromRow(row rowScanner) (picoshare.GuestLink, error) {
 	var isDisabled bool
 	var maxFileBytes picoshare.GuestUploadMaxFileBytes
 	var maxFileUploads picoshare.GuestUploadCountLimit
+	var rateLimit picoshare.GuestUploadRateLimit
+	var requireChallenge bool
 	var creationTimeRaw string
 	var urlExpirationTimeRaw string
 	var fileLifetimeRaw *string
+	var autoTag *string
+	var notifyOnUpload bool
 	var filesUploaded int
 
-	err := row.Scan(&id, &label, &isDisabled, &maxFileBytes, &maxFileUploads, &creationTimeRaw, &urlExpirationTimeRaw, &fileLifetimeRaw, &filesUploaded)
+	err := row.Scan(&id, &label, &isDisabled, &maxFileBytes, &maxFileUploads, &rateLimit.MaxUploadsPerIPPerHour, &rateLimit.MaxBytesPerIPPerDay, &rateLimit.MaxBytesPerSecond, &requireChallenge, &creationTimeRaw, &urlExpirationTimeRaw, &fileLifetimeRaw, &autoTag, &notifyOnUpload, &filesUploaded)
 	if err == sql.ErrNoRows {
 		return picoshare.GuestLink{}, store.GuestLinkNotFoundError{ID: id}
 	} else if err != nil {
@@ -213,14 +241,18 @@ func guestLinkFromRow(row rowScanner) (picoshare.GuestLink, error) {
 	}
 
 	return picoshare.GuestLink{
-		ID:              id,
-		Label:           label,
-		IsDisabled:      isDisabled,
-		MaxFileBytes:    maxFileBytes,
-		MaxFileUploads:  maxFileUploads,
-		FilesUploaded:   filesUploaded,
-		Created:         ct,
-		UrlExpires:      picoshare.ExpirationTime(uet),
-		MaxFileLifetime: fileLifetime,
+		ID:               id,
+		Label:            label,
+		IsDisabled:       isDisabled,
+		MaxFileBytes:     maxFileBytes,
+		MaxFileUploads:   maxFileUploads,
+		RateLimit:        rateLimit,
+		RequireChallenge: requireChallenge,
+		FilesUploaded:    filesUploaded,
+		Created:          ct,
+		UrlExpires:       picoshare.ExpirationTime(uet),
+		MaxFileLifetime:  fileLifetime,
+		AutoTag:          picoshare.Tag(stringFromNullable(autoTag)),
+		NotifyOnUpload:   notifyOnUpload,
 	}, nil
 }
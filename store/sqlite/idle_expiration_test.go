@@ -0,0 +1,108 @@
+package sqlite_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/store"
+	"github.com/mtlynch/picoshare/store/test_sqlite"
+)
+
+func TestPurgeIdleEntries(t *testing.T) {
+	day := 24 * time.Hour
+	now := time.Now()
+	for _, tt := range []struct {
+		description   string
+		idleLifetime  picoshare.FileLifetime
+		uploaded      time.Time
+		availableFrom time.Time
+		downloads     []time.Time
+		purged        bool
+	}{
+		{
+			description:  "purges entry that nobody downloaded within idle lifetime",
+			idleLifetime: picoshare.NewFileLifetimeInDays(30),
+			uploaded:     now.Add(-60 * day),
+			purged:       true,
+		},
+		{
+			description:  "purges entry whose last download is older than idle lifetime",
+			idleLifetime: picoshare.NewFileLifetimeInDays(30),
+			uploaded:     now.Add(-60 * day),
+			downloads:    []time.Time{now.Add(-50 * day), now.Add(-40 * day)},
+			purged:       true,
+		},
+		{
+			description:  "keeps entry with recent download",
+			idleLifetime: picoshare.NewFileLifetimeInDays(30),
+			uploaded:     now.Add(-60 * day),
+			downloads:    []time.Time{now.Add(-50 * day), now.Add(-10 * day)},
+			purged:       false,
+		},
+		{
+			description:  "keeps recent upload that nobody has downloaded yet",
+			idleLifetime: picoshare.NewFileLifetimeInDays(30),
+			uploaded:     now.Add(-10 * day),
+			purged:       false,
+		},
+		{
+			description:   "measures idle time from when entry became available",
+			idleLifetime:  picoshare.NewFileLifetimeInDays(30),
+			uploaded:      now.Add(-60 * day),
+			availableFrom: now.Add(-5 * day),
+			purged:        false,
+		},
+		{
+			description: "keeps old entry without idle lifetime",
+			uploaded:    now.Add(-60 * day),
+			purged:      false,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			dataStore := test_sqlite.New()
+
+			contents := "dummy data"
+			if err := dataStore.InsertEntry(strings.NewReader(contents), picoshare.UploadMetadata{
+				ID:            "AAAAAAAAAA",
+				Filename:      "dummy.txt",
+				Uploaded:      tt.uploaded,
+				Expires:       picoshare.NeverExpire,
+				Size:          mustParseFileSize(len(contents)),
+				AvailableFrom: tt.availableFrom,
+				IdleLifetime:  tt.idleLifetime,
+			}); err != nil {
+				t.Fatalf("failed to insert entry: %v", err)
+			}
+			for _, d := range tt.downloads {
+				if err := dataStore.InsertEntryDownload("AAAAAAAAAA", picoshare.DownloadRecord{
+					Time:     d,
+					ClientIP: "127.0.0.1",
+				}); err != nil {
+					t.Fatalf("failed to record download: %v", err)
+				}
+			}
+
+			if err := dataStore.Purge(); err != nil {
+				t.Fatalf("purge failed: %v", err)
+			}
+
+			entry, err := dataStore.GetEntryMetadata("AAAAAAAAAA")
+			if tt.purged {
+				if err == nil {
+					t.Fatalf("idle entry still exists after purge")
+				} else if _, ok := err.(store.EntryNotFoundError); !ok {
+					t.Fatalf("failed to get entry: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to get entry: %v", err)
+			}
+			if got, want := entry.IdleLifetime, tt.idleLifetime; !got.Equal(want) {
+				t.Errorf("idle lifetime=%v, want=%v", got, want)
+			}
+		})
+	}
+}
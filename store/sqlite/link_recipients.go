@@ -0,0 +1,87 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+// InsertLinkRecipients records that the owner emailed the entry's link to the
+// recipients at the given time.
+func (s Store) InsertLinkRecipients(id picoshare.EntryID, recipients []string, sent time.Time) error {
+	log.Printf("recording %d recipient(s) of link to file ID %s", len(recipients), id.String())
+
+	tx, err := s.ctx.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Printf("failed to rollback insert link recipients: %v", err)
+		}
+	}()
+
+	for _, recipient := range recipients {
+		if _, err := tx.Exec(`
+		INSERT INTO
+			link_recipients
+		(
+			entry_id,
+			email,
+			sent_time
+		)
+		VALUES(:entry_id, :email, :sent_time)`,
+			sql.Named("entry_id", id.String()),
+			sql.Named("email", recipient),
+			sql.Named("sent_time", formatTime(sent))); err != nil {
+			log.Printf("insert into link_recipients table failed: %v", err)
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetLinkRecipients returns everyone to whom the owner emailed the entry's
+// link, most recent first.
+func (s Store) GetLinkRecipients(id picoshare.EntryID) ([]picoshare.LinkRecipient, error) {
+	rows, err := s.ctx.Query(`
+	SELECT
+		email,
+		sent_time
+	FROM
+		link_recipients
+	WHERE
+		entry_id = :entry_id
+	ORDER BY
+		sent_time DESC,
+		rowid ASC`, sql.Named("entry_id", id))
+	if err != nil {
+		return []picoshare.LinkRecipient{}, err
+	}
+	defer rows.Close()
+
+	recipients := []picoshare.LinkRecipient{}
+	for rows.Next() {
+		var email, sentTimeRaw string
+		if err := rows.Scan(&email, &sentTimeRaw); err != nil {
+			return []picoshare.LinkRecipient{}, err
+		}
+
+		sent, err := parseDatetime(sentTimeRaw)
+		if err != nil {
+			return []picoshare.LinkRecipient{}, err
+		}
+
+		recipients = append(recipients, picoshare.LinkRecipient{
+			Email: email,
+			Sent:  sent,
+		})
+	}
+
+	return recipients, rows.Err()
+}
@@ -0,0 +1,82 @@
+package sqlite_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/store/test_sqlite"
+)
+
+func TestLinkRecipients(t *testing.T) {
+	dataStore := test_sqlite.New()
+	if err := dataStore.InsertEntry(strings.NewReader("dummy data"), picoshare.UploadMetadata{
+		ID:       "AAAAAAAAAA",
+		Filename: "dummy.txt",
+		Uploaded: mustParseTime("2024-01-01T00:00:00Z"),
+		Expires:  picoshare.NeverExpire,
+	}); err != nil {
+		t.Fatalf("failed to insert dummy entry: %v", err)
+	}
+
+	if err := dataStore.InsertLinkRecipients("AAAAAAAAAA", []string{"alice@example.com", "bob@example.com"}, mustParseTime("2024-01-01T00:00:00Z")); err != nil {
+		t.Fatalf("failed to insert link recipients: %v", err)
+	}
+	if err := dataStore.InsertLinkRecipients("AAAAAAAAAA", []string{"carol@example.com"}, mustParseTime("2024-01-02T00:00:00Z")); err != nil {
+		t.Fatalf("failed to insert link recipients: %v", err)
+	}
+
+	recipients, err := dataStore.GetLinkRecipients("AAAAAAAAAA")
+	if err != nil {
+		t.Fatalf("failed to get link recipients: %v", err)
+	}
+	if got, want := recipients, []picoshare.LinkRecipient{
+		{Email: "carol@example.com", Sent: mustParseTime("2024-01-02T00:00:00Z")},
+		{Email: "alice@example.com", Sent: mustParseTime("2024-01-01T00:00:00Z")},
+		{Email: "bob@example.com", Sent: mustParseTime("2024-01-01T00:00:00Z")},
+	}; !reflect.DeepEqual(got, want) {
+		t.Errorf("recipients=%+v, want=%+v", got, want)
+	}
+
+	if err := dataStore.DeleteEntry("AAAAAAAAAA"); err != nil {
+		t.Fatalf("failed to delete entry: %v", err)
+	}
+	recipients, err = dataStore.GetLinkRecipients("AAAAAAAAAA")
+	if err != nil {
+		t.Fatalf("failed to get link recipients: %v", err)
+	}
+	if len(recipients) != 0 {
+		t.Errorf("recipients=%+v after deleting entry, want none", recipients)
+	}
+}
+
+func TestPurgeExpiredEntryWithLinkRecipients(t *testing.T) {
+	dataStore := test_sqlite.New()
+	if err := dataStore.InsertEntry(strings.NewReader("dummy data"), picoshare.UploadMetadata{
+		ID:       "AAAAAAAAAA",
+		Filename: "dummy.txt",
+		Uploaded: mustParseTime("2024-01-01T00:00:00Z"),
+		Expires:  mustParseExpirationTime("2024-01-02T00:00:00Z"),
+	}); err != nil {
+		t.Fatalf("failed to insert dummy entry: %v", err)
+	}
+	if err := dataStore.InsertLinkRecipients("AAAAAAAAAA", []string{"alice@example.com"}, mustParseTime("2024-01-01T00:00:00Z")); err != nil {
+		t.Fatalf("failed to insert link recipients: %v", err)
+	}
+
+	if err := dataStore.Purge(); err != nil {
+		t.Fatalf("failed to purge database: %v", err)
+	}
+
+	if _, err := dataStore.GetEntryMetadata("AAAAAAAAAA"); err == nil {
+		t.Errorf("expired entry still exists after purge")
+	}
+	recipients, err := dataStore.GetLinkRecipients("AAAAAAAAAA")
+	if err != nil {
+		t.Fatalf("failed to get link recipients: %v", err)
+	}
+	if len(recipients) != 0 {
+		t.Errorf("recipients=%+v after purging entry, want none", recipients)
+	}
+}
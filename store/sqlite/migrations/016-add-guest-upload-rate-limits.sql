@@ -0,0 +1,35 @@
+-- Per-IP rate limits for guest uploads. A NULL value means the guest link
+-- falls back to the default from the settings table.
+ALTER TABLE guest_links
+ADD COLUMN max_uploads_per_ip_per_hour INTEGER CHECK (
+    max_uploads_per_ip_per_hour IS NULL OR max_uploads_per_ip_per_hour > 0
+);
+
+ALTER TABLE guest_links
+ADD COLUMN max_bytes_per_ip_per_day INTEGER CHECK (
+    max_bytes_per_ip_per_day IS NULL OR max_bytes_per_ip_per_day > 0
+);
+
+ALTER TABLE guest_links
+ADD COLUMN max_upload_bytes_per_second INTEGER CHECK (
+    max_upload_bytes_per_second IS NULL OR max_upload_bytes_per_second > 0
+);
+
+-- Default rate limits for guest links. A NULL value means unlimited.
+ALTER TABLE settings
+ADD COLUMN guest_max_uploads_per_ip_per_hour INTEGER CHECK (
+    guest_max_uploads_per_ip_per_hour IS NULL
+    OR guest_max_uploads_per_ip_per_hour > 0
+);
+
+ALTER TABLE settings
+ADD COLUMN guest_max_bytes_per_ip_per_day INTEGER CHECK (
+    guest_max_bytes_per_ip_per_day IS NULL
+    OR guest_max_bytes_per_ip_per_day > 0
+);
+
+ALTER TABLE settings
+ADD COLUMN guest_max_upload_bytes_per_second INTEGER CHECK (
+    guest_max_upload_bytes_per_second IS NULL
+    OR guest_max_upload_bytes_per_second > 0
+);
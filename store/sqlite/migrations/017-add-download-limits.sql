@@ -0,0 +1,20 @@
+-- Limits the combined bandwidth of all downloads of an entry. A NULL value
+-- means no limit.
+ALTER TABLE entries
+ADD COLUMN max_download_bytes_per_second INTEGER CHECK (
+    max_download_bytes_per_second IS NULL
+    OR max_download_bytes_per_second > 0
+);
+
+-- Server-wide download limits. A NULL value means no limit.
+ALTER TABLE settings
+ADD COLUMN max_download_bytes_per_second INTEGER CHECK (
+    max_download_bytes_per_second IS NULL
+    OR max_download_bytes_per_second > 0
+);
+
+ALTER TABLE settings
+ADD COLUMN max_concurrent_downloads_per_ip INTEGER CHECK (
+    max_concurrent_downloads_per_ip IS NULL
+    OR max_concurrent_downloads_per_ip > 0
+);
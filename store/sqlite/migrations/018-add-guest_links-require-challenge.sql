@@ -0,0 +1,3 @@
+-- Whether guests must solve a proof-of-work challenge before uploading.
+ALTER TABLE guest_links
+ADD COLUMN require_challenge INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,9 @@
+-- Results of scanning entries for malware. A NULL status means the entry was
+-- never scanned.
+ALTER TABLE entries
+ADD COLUMN scan_status TEXT CHECK (
+    scan_status IS NULL OR scan_status IN ('clean', 'infected', 'error')
+);
+
+ALTER TABLE entries
+ADD COLUMN scan_detail TEXT;
@@ -0,0 +1,18 @@
+-- SHA-256 hash of each entry's data, computed at upload time. Entries uploaded
+-- before we hashed files have a NULL hash.
+ALTER TABLE entries
+ADD COLUMN sha256 TEXT CHECK (
+    sha256 IS NULL OR length(sha256) = 64
+);
+
+-- Results of the most recent integrity check of the entry's data against its
+-- hash.
+ALTER TABLE entries
+ADD COLUMN integrity_check_time TEXT CHECK (
+    integrity_check_time IS NULL OR datetime(integrity_check_time) IS NOT NULL
+);
+
+ALTER TABLE entries
+ADD COLUMN integrity_mismatch INTEGER NOT NULL CHECK (
+    integrity_mismatch IN (0, 1)
+) DEFAULT 0;
@@ -0,0 +1,35 @@
+-- A blob is a unique piece of file data, stored in entries_data under the
+-- blob's ID. Entries with identical contents share a single blob, and
+-- ref_count tracks how many entries reference it.
+CREATE TABLE blobs (
+    id TEXT PRIMARY KEY,
+    sha256 TEXT UNIQUE CHECK (
+        sha256 IS NULL OR length(sha256) = 64
+    ),
+    ref_count INTEGER NOT NULL CHECK (ref_count >= 0)
+) STRICT;
+
+ALTER TABLE entries
+ADD COLUMN blob_id TEXT REFERENCES blobs (id);
+
+-- Existing entries store their data under their own ID, so each one becomes
+-- its own blob. If several entries already have the same hash, only one of
+-- them claims it so that new uploads have a single blob to match against.
+INSERT INTO blobs (id, sha256, ref_count)
+SELECT
+    entries.id,
+    CASE
+        WHEN entries.id = (
+            SELECT MIN(duplicates.id)
+            FROM entries AS duplicates
+            WHERE duplicates.sha256 = entries.sha256
+        ) THEN entries.sha256
+    END,
+    1
+FROM
+    entries;
+
+UPDATE entries
+SET blob_id = id;
+
+CREATE INDEX idx_entries_blob_id ON entries (blob_id);
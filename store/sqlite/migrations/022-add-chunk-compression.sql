@@ -0,0 +1,19 @@
+-- Chunks may be compressed with zstd. uncompressed_length is the chunk's size
+-- before compression, or NULL if the chunk is stored uncompressed.
+ALTER TABLE entries_data
+ADD COLUMN uncompressed_length INTEGER CHECK (
+    uncompressed_length IS NULL OR uncompressed_length > 0
+);
+
+-- Whether we asked to compress the blob's chunks. Individual chunks that don't
+-- shrink under compression are still stored uncompressed.
+ALTER TABLE blobs
+ADD COLUMN compressed INTEGER NOT NULL CHECK (
+    compressed IN (0, 1)
+) DEFAULT 0;
+
+-- Recreate the file size index so that it covers uncompressed lengths as well.
+DROP INDEX idx_entries_data_length;
+
+CREATE INDEX idx_entries_data_length
+ON entries_data (id, LENGTH(chunk), uncompressed_length);
@@ -0,0 +1,7 @@
+-- Thumbnail previews of image entries, generated in the background after
+-- upload.
+CREATE TABLE thumbnails (
+    entry_id TEXT PRIMARY KEY,
+    image BLOB NOT NULL,
+    FOREIGN KEY (entry_id) REFERENCES entries (id)
+) STRICT;
@@ -0,0 +1,10 @@
+-- Text snippets are entries created from pasted text rather than an uploaded
+-- file. snippet_language is the language for syntax highlighting, or NULL if
+-- PicoShare should detect the language from the snippet's contents.
+ALTER TABLE entries
+ADD COLUMN is_snippet INTEGER NOT NULL CHECK (
+    is_snippet IN (0, 1)
+) DEFAULT 0;
+
+ALTER TABLE entries
+ADD COLUMN snippet_language TEXT;
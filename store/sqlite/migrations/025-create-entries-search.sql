@@ -0,0 +1,41 @@
+-- Full-text index of entries' filenames and notes for searching the file
+-- index. FTS5 requires building PicoShare with the sqlite_fts5 tag. The index
+-- stores each entry's ID rather than matching rows to entries by rowid, because
+-- entries has a TEXT primary key, so VACUUM can renumber its rowids.
+CREATE VIRTUAL TABLE entries_search USING fts5 (
+    entry_id UNINDEXED,
+    filename,
+    note,
+    tokenize = unicode61
+);
+
+INSERT INTO entries_search (entry_id, filename, note)
+SELECT
+    id,
+    filename,
+    COALESCE(note, '')
+FROM
+    entries;
+
+CREATE TRIGGER entries_search_insert AFTER INSERT ON entries
+BEGIN
+INSERT INTO entries_search (entry_id, filename, note)
+VALUES (new.id, new.filename, COALESCE(new.note, ''));
+END;
+
+CREATE TRIGGER entries_search_update AFTER UPDATE OF filename, note ON entries
+BEGIN
+UPDATE entries_search
+SET
+    filename = new.filename,
+    note = COALESCE(new.note, '')
+WHERE
+    entry_id = old.id;
+END;
+
+CREATE TRIGGER entries_search_delete AFTER DELETE ON entries
+BEGIN
+DELETE FROM entries_search
+WHERE
+    entry_id = old.id;
+END;
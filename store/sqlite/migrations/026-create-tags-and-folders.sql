@@ -0,0 +1,27 @@
+-- Tags are user-defined labels for entries. Each entry can have many tags, and
+-- each tag can apply to many entries.
+CREATE TABLE tags (
+    id INTEGER PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE
+) STRICT;
+
+CREATE TABLE entry_tags (
+    entry_id TEXT NOT NULL,
+    tag_id INTEGER NOT NULL,
+    PRIMARY KEY (entry_id, tag_id),
+    FOREIGN KEY (entry_id) REFERENCES entries (id),
+    FOREIGN KEY (tag_id) REFERENCES tags (id)
+) STRICT;
+
+CREATE INDEX idx_entry_tags_tag_id ON entry_tags (tag_id);
+
+-- The folder that contains the entry, or NULL if the entry isn't in a folder.
+ALTER TABLE entries
+ADD COLUMN folder TEXT;
+
+CREATE INDEX idx_entries_folder ON entries (folder);
+
+-- A tag that PicoShare applies to every file that guests upload through the
+-- guest link, or NULL for no tag.
+ALTER TABLE guest_links
+ADD COLUMN auto_tag TEXT;
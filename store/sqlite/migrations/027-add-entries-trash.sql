@@ -0,0 +1,11 @@
+-- The time the user moved the entry to the trash, or NULL if the entry isn't
+-- in the trash. PicoShare stops serving entries in the trash and deletes them
+-- permanently once they've been in the trash longer than the retention period.
+ALTER TABLE entries
+ADD COLUMN deletion_time TEXT;
+
+CREATE INDEX idx_entries_deletion_time ON entries (deletion_time);
+
+-- The number of days entries stay in the trash, or NULL for the default.
+ALTER TABLE settings
+ADD COLUMN trash_retention_in_days INTEGER;
@@ -0,0 +1,29 @@
+-- The number of the entry's current version. Uploading new contents into an
+-- existing entry moves its previous contents to entry_versions and increments
+-- the version.
+ALTER TABLE entries
+ADD COLUMN version INTEGER NOT NULL DEFAULT 1 CHECK (version >= 1);
+
+-- Previous versions of entries' contents. Like entries.blob_id, each row holds
+-- a reference to its blob, which counts toward the blob's ref_count.
+CREATE TABLE entry_versions (
+    entry_id TEXT NOT NULL REFERENCES entries (id),
+    version INTEGER NOT NULL CHECK (version >= 1),
+    filename TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    upload_time TEXT NOT NULL CHECK (
+        datetime(upload_time) IS NOT NULL
+    ),
+    sha256 TEXT,
+    scan_status TEXT,
+    scan_detail TEXT,
+    blob_id TEXT NOT NULL REFERENCES blobs (id),
+    PRIMARY KEY (entry_id, version)
+) STRICT;
+
+CREATE INDEX idx_entry_versions_blob_id ON entry_versions (blob_id);
+
+-- The version of the entry that the client downloaded, or NULL for downloads
+-- that predate versioning.
+ALTER TABLE downloads
+ADD COLUMN version INTEGER;
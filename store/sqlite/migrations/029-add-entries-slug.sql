@@ -0,0 +1,6 @@
+-- A custom, human-readable alias for the entry's ID, or NULL if the entry has
+-- no alias.
+ALTER TABLE entries
+ADD COLUMN slug TEXT;
+
+CREATE UNIQUE INDEX idx_entries_slug ON entries (slug);
@@ -0,0 +1,23 @@
+-- The key that signs temporary links to entries. It lives in the database so
+-- that signed links stay valid across server restarts.
+CREATE TABLE url_signing_key (
+    id INTEGER PRIMARY KEY,
+    key BLOB NOT NULL
+) STRICT;
+
+INSERT INTO url_signing_key (
+    id,
+    key
+) VALUES (
+    1,
+    randomblob(32)
+);
+
+-- Single-use signed links that clients have already used. Purge() deletes rows
+-- after the links expire, as expired links are invalid anyway.
+CREATE TABLE used_signed_urls (
+    signature TEXT PRIMARY KEY,
+    expiration_time TEXT NOT NULL CHECK (
+        datetime(expiration_time) IS NOT NULL
+    )
+) STRICT;
@@ -0,0 +1,9 @@
+-- Who can download the entry, or NULL if anyone with the link can download it.
+ALTER TABLE entries
+ADD COLUMN visibility TEXT CHECK (visibility IN ('private', 'signed'));
+
+-- The visibility of new entries, or NULL for public.
+ALTER TABLE settings
+ADD COLUMN default_visibility TEXT CHECK (
+    default_visibility IN ('private', 'signed')
+);
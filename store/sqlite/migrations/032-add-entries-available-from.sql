@@ -0,0 +1,4 @@
+-- The time when clients can start downloading the entry, or NULL if the entry
+-- is available as soon as it's uploaded.
+ALTER TABLE entries
+ADD COLUMN available_from TEXT;
@@ -0,0 +1,9 @@
+-- The expiration time that PicoShare last sent a reminder about for each
+-- entry. PicoShare sends one reminder per expiration time, so extending an
+-- entry lets PicoShare remind the owner again before the new expiration.
+CREATE TABLE expiration_reminders (
+    entry_id TEXT PRIMARY KEY,
+    expiration_time TEXT NOT NULL CHECK (
+        datetime(expiration_time) IS NOT NULL
+    )
+) STRICT;
@@ -0,0 +1,14 @@
+-- How many days the entry can go without a download before PicoShare deletes
+-- it, or NULL if the entry doesn't expire from inactivity.
+ALTER TABLE entries
+ADD COLUMN idle_expiration_in_days INTEGER CHECK (
+    idle_expiration_in_days IS NULL
+    OR idle_expiration_in_days > 0
+);
+
+-- The idle expiration of new entries, or NULL for none.
+ALTER TABLE settings
+ADD COLUMN default_idle_expiration_in_days INTEGER CHECK (
+    default_idle_expiration_in_days IS NULL
+    OR default_idle_expiration_in_days > 0
+);
@@ -0,0 +1,40 @@
+-- Which events PicoShare notifies the owner about. Existing servers already
+-- sent expiration reminders, so every event starts out enabled.
+ALTER TABLE settings
+ADD COLUMN notify_guest_uploads INTEGER NOT NULL DEFAULT 1 CHECK (
+    notify_guest_uploads IN (0, 1)
+);
+
+ALTER TABLE settings
+ADD COLUMN notify_sensitive_downloads INTEGER NOT NULL DEFAULT 1 CHECK (
+    notify_sensitive_downloads IN (0, 1)
+);
+
+ALTER TABLE settings
+ADD COLUMN notify_expiring_entries INTEGER NOT NULL DEFAULT 1 CHECK (
+    notify_expiring_entries IN (0, 1)
+);
+
+-- The send log of notification emails. PicoShare keeps each email until
+-- Purge() removes old rows, so it can retry emails that failed to send.
+CREATE TABLE notification_emails (
+    id INTEGER PRIMARY KEY,
+    event TEXT NOT NULL,
+    -- Comma-separated list of recipient addresses.
+    recipients TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    body TEXT NOT NULL,
+    created_time TEXT NOT NULL CHECK (
+        datetime(created_time) IS NOT NULL
+    ),
+    attempts INTEGER NOT NULL DEFAULT 0 CHECK (attempts >= 0),
+    last_attempt_time TEXT CHECK (
+        last_attempt_time IS NULL
+        OR datetime(last_attempt_time) IS NOT NULL
+    ),
+    sent_time TEXT CHECK (
+        sent_time IS NULL
+        OR datetime(sent_time) IS NOT NULL
+    ),
+    last_error TEXT
+) STRICT;
@@ -0,0 +1,11 @@
+-- People to whom the owner emailed an entry's link from PicoShare. Each email
+-- adds one row per recipient.
+CREATE TABLE link_recipients (
+    entry_id TEXT NOT NULL REFERENCES entries (id),
+    email TEXT NOT NULL,
+    sent_time TEXT NOT NULL CHECK (
+        datetime(sent_time) IS NOT NULL
+    )
+) STRICT;
+
+CREATE INDEX idx_link_recipients_entry_id ON link_recipients (entry_id);
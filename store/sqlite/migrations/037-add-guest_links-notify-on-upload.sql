@@ -0,0 +1,6 @@
+-- Whether PicoShare notifies the owner when a guest uploads through the link,
+-- even if guest upload notifications are off in the settings.
+ALTER TABLE guest_links
+ADD COLUMN notify_on_upload INTEGER NOT NULL DEFAULT 0 CHECK (
+    notify_on_upload IN (0, 1)
+);
@@ -0,0 +1,14 @@
+-- Whether the entry's contents are waiting for their malware scan to finish.
+-- PicoShare scans uploads in the background and refuses to serve contents
+-- until their scan records a result.
+ALTER TABLE entries
+ADD COLUMN scan_pending INTEGER NOT NULL CHECK (
+    scan_pending IN (0, 1)
+) DEFAULT 0;
+
+-- Contents can be replaced before their scan finishes, so previous versions
+-- track pending scans too.
+ALTER TABLE entry_versions
+ADD COLUMN scan_pending INTEGER NOT NULL CHECK (
+    scan_pending IN (0, 1)
+) DEFAULT 0;
@@ -0,0 +1,172 @@
+package sqlite
+
+import (
+	"database/sql"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+// notificationEmailRetention is how long PicoShare keeps emails in the send
+// log.
+const notificationEmailRetention = 30 * 24 * time.Hour
+
+const notificationEmailRecipientSeparator = ","
+
+// InsertNotificationEmail adds an email to the send log and returns its ID.
+func (s Store) InsertNotificationEmail(m picoshare.NotificationEmail) (picoshare.NotificationEmailID, error) {
+	res, err := s.ctx.Exec(`
+	INSERT INTO
+		notification_emails
+	(
+		event,
+		recipients,
+		subject,
+		body,
+		created_time
+	)
+	VALUES(:event, :recipients, :subject, :body, :created_time)`,
+		sql.Named("event", m.Event),
+		sql.Named("recipients", strings.Join(m.Recipients, notificationEmailRecipientSeparator)),
+		sql.Named("subject", m.Subject),
+		sql.Named("body", m.Body),
+		sql.Named("created_time", formatTime(m.Created)))
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return picoshare.NotificationEmailID(id), nil
+}
+
+// RecordNotificationEmailAttempt records an attempt to send an email from the
+// send log. A nil sendErr means that the attempt succeeded.
+func (s Store) RecordNotificationEmailAttempt(id picoshare.NotificationEmailID, attempted time.Time, sendErr error) error {
+	var sentTime, lastError *string
+	if sendErr == nil {
+		sentTime = new(formatTime(attempted))
+	} else {
+		lastError = new(sendErr.Error())
+	}
+
+	if _, err := s.ctx.Exec(`
+	UPDATE
+		notification_emails
+	SET
+		attempts = attempts + 1,
+		last_attempt_time = :last_attempt_time,
+		sent_time = :sent_time,
+		last_error = :last_error
+	WHERE
+		id = :id`,
+		sql.Named("last_attempt_time", formatTime(attempted)),
+		sql.Named("sent_time", sentTime),
+		sql.Named("last_error", lastError),
+		sql.Named("id", id)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetNotificationEmailsToRetry returns the emails in the send log that
+// PicoShare hasn't sent and has tried to send fewer than maxAttempts times,
+// oldest first.
+func (s Store) GetNotificationEmailsToRetry(maxAttempts int) ([]picoshare.NotificationEmail, error) {
+	return s.selectNotificationEmails(`
+	WHERE
+		sent_time IS NULL AND
+		attempts < :max_attempts
+	ORDER BY
+		id ASC`, sql.Named("max_attempts", maxAttempts))
+}
+
+// GetNotificationEmails returns the most recent emails in the send log, newest
+// first.
+func (s Store) GetNotificationEmails(limit int) ([]picoshare.NotificationEmail, error) {
+	return s.selectNotificationEmails(`
+	ORDER BY
+		id DESC
+	LIMIT :limit`, sql.Named("limit", limit))
+}
+
+func (s Store) selectNotificationEmails(suffix string, args ...any) ([]picoshare.NotificationEmail, error) {
+	rows, err := s.ctx.Query(`
+	SELECT
+		id,
+		event,
+		recipients,
+		subject,
+		body,
+		created_time,
+		attempts,
+		last_attempt_time,
+		sent_time,
+		last_error
+	FROM
+		notification_emails`+suffix, args...)
+	if err != nil {
+		return []picoshare.NotificationEmail{}, err
+	}
+	defer rows.Close()
+
+	emails := []picoshare.NotificationEmail{}
+	for rows.Next() {
+		var m picoshare.NotificationEmail
+		var recipients string
+		var createdTimeRaw string
+		var lastAttemptTimeRaw *string
+		var sentTimeRaw *string
+		var lastError *string
+		if err := rows.Scan(&m.ID, &m.Event, &recipients, &m.Subject, &m.Body, &createdTimeRaw, &m.Attempts, &lastAttemptTimeRaw, &sentTimeRaw, &lastError); err != nil {
+			return []picoshare.NotificationEmail{}, err
+		}
+
+		m.Recipients = strings.Split(recipients, notificationEmailRecipientSeparator)
+
+		m.Created, err = parseDatetime(createdTimeRaw)
+		if err != nil {
+			return []picoshare.NotificationEmail{}, err
+		}
+
+		if lastAttemptTimeRaw != nil {
+			m.LastAttempt, err = parseDatetime(*lastAttemptTimeRaw)
+			if err != nil {
+				return []picoshare.NotificationEmail{}, err
+			}
+		}
+
+		if sentTimeRaw != nil {
+			m.Sent, err = parseDatetime(*sentTimeRaw)
+			if err != nil {
+				return []picoshare.NotificationEmail{}, err
+			}
+		}
+
+		m.LastError = stringFromNullable(lastError)
+
+		emails = append(emails, m)
+	}
+
+	return emails, rows.Err()
+}
+
+func (s Store) deleteOldNotificationEmails() error {
+	log.Printf("deleting old notification emails from database")
+
+	if _, err := s.ctx.Exec(`
+	DELETE FROM
+		notification_emails
+	WHERE
+		created_time < :cutoff`, sql.Named("cutoff", formatTime(time.Now().Add(-notificationEmailRetention)))); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package sqlite_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/store/test_sqlite"
+)
+
+func TestNotificationEmails(t *testing.T) {
+	dataStore := test_sqlite.New()
+
+	var ids []picoshare.NotificationEmailID
+	for _, subject := range []string{"first", "second", "third"} {
+		id, err := dataStore.InsertNotificationEmail(picoshare.NotificationEmail{
+			Event:      "guest.upload",
+			Recipients: []string{"alice@example.com", "bob@example.com"},
+			Subject:    subject,
+			Body:       "Hello\nworld",
+			Created:    mustParseTime("2024-01-01T00:00:00Z"),
+		})
+		if err != nil {
+			t.Fatalf("failed to insert email: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	attempted := mustParseTime("2024-01-01T00:01:00Z")
+	if err := dataStore.RecordNotificationEmailAttempt(ids[0], attempted, nil); err != nil {
+		t.Fatalf("failed to record attempt: %v", err)
+	}
+	for range 2 {
+		if err := dataStore.RecordNotificationEmailAttempt(ids[1], attempted, errors.New("connection refused")); err != nil {
+			t.Fatalf("failed to record attempt: %v", err)
+		}
+	}
+
+	retries, err := dataStore.GetNotificationEmailsToRetry(2)
+	if err != nil {
+		t.Fatalf("failed to get emails to retry: %v", err)
+	}
+	if got, want := subjects(retries), []string{"third"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("emails to retry=%v, want=%v", got, want)
+	}
+
+	retries, err = dataStore.GetNotificationEmailsToRetry(3)
+	if err != nil {
+		t.Fatalf("failed to get emails to retry: %v", err)
+	}
+	if got, want := subjects(retries), []string{"second", "third"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("emails to retry=%v, want=%v", got, want)
+	}
+
+	emails, err := dataStore.GetNotificationEmails(2)
+	if err != nil {
+		t.Fatalf("failed to get emails: %v", err)
+	}
+	if got, want := emails, []picoshare.NotificationEmail{
+		{
+			ID:         ids[2],
+			Event:      "guest.upload",
+			Recipients: []string{"alice@example.com", "bob@example.com"},
+			Subject:    "third",
+			Body:       "Hello\nworld",
+			Created:    mustParseTime("2024-01-01T00:00:00Z"),
+		},
+		{
+			ID:          ids[1],
+			Event:       "guest.upload",
+			Recipients:  []string{"alice@example.com", "bob@example.com"},
+			Subject:     "second",
+			Body:        "Hello\nworld",
+			Created:     mustParseTime("2024-01-01T00:00:00Z"),
+			Attempts:    2,
+			LastAttempt: attempted,
+			LastError:   "connection refused",
+		},
+	}; !reflect.DeepEqual(got, want) {
+		t.Errorf("emails=%+v, want=%+v", got, want)
+	}
+
+	emails, err = dataStore.GetNotificationEmails(3)
+	if err != nil {
+		t.Fatalf("failed to get emails: %v", err)
+	}
+	if got, want := emails[2].Sent, attempted; !got.Equal(want) {
+		t.Errorf("sent=%v, want=%v", got, want)
+	}
+}
+
+func subjects(emails []picoshare.NotificationEmail) []string {
+	ss := []string{}
+	for _, m := range emails {
+		ss = append(ss, m.Subject)
+	}
+	return ss
+}
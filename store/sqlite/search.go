@@ -0,0 +1,137 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/store"
+)
+
+// entrySortColumns maps each sort field to the expression that orders by it.
+var entrySortColumns = map[store.EntrySortField]string{
+	store.SortByFilename: "entries.filename COLLATE NOCASE",
+	store.SortByNote:     "COALESCE(entries.note, '') COLLATE NOCASE",
+	store.SortBySize:     "sizes.file_size",
+	store.SortByUploaded: "entries.upload_time",
+	store.SortByExpires:  "entries.expiration_time",
+}
+
+// QueryEntries returns the page of entries that match the query, along with
+// the total number of matching entries.
+func (s Store) QueryEntries(q store.EntryQuery) (store.EntryPage, error) {
+	// Entries in the trash only appear on the trash page.
+	conditions := []string{"entries.deletion_time IS NULL"}
+	args := []any{}
+
+	if match := searchMatchExpression(q.Search); match != "" {
+		conditions = append(conditions, `entries.id IN (
+			SELECT entry_id FROM entries_search WHERE entries_search MATCH :search
+		)`)
+		args = append(args, sql.Named("search", match))
+	} else if q.Search != "" {
+		// The search has no words to match, so nothing can match it.
+		return store.EntryPage{Entries: []picoshare.UploadMetadata{}}, nil
+	}
+	if q.GuestLinkID != "" {
+		conditions = append(conditions, "entries.guest_link_id = :guest_link_id")
+		args = append(args, sql.Named("guest_link_id", q.GuestLinkID))
+	}
+	if q.ContentTypePrefix != "" {
+		conditions = append(conditions, "SUBSTR(entries.content_type, 1, LENGTH(:content_type_prefix)) = :content_type_prefix")
+		args = append(args, sql.Named("content_type_prefix", q.ContentTypePrefix))
+	}
+	if q.MinSize != nil {
+		conditions = append(conditions, "sizes.file_size >= :min_size")
+		args = append(args, sql.Named("min_size", *q.MinSize))
+	}
+	if q.MaxSize != nil {
+		conditions = append(conditions, "sizes.file_size <= :max_size")
+		args = append(args, sql.Named("max_size", *q.MaxSize))
+	}
+	if q.ExpiresFrom != nil {
+		conditions = append(conditions, "entries.expiration_time >= :expires_from")
+		args = append(args, sql.Named("expires_from", formatTime(*q.ExpiresFrom)))
+	}
+	if q.ExpiresBefore != nil {
+		conditions = append(conditions, "entries.expiration_time < :expires_before")
+		args = append(args, sql.Named("expires_before", formatTime(*q.ExpiresBefore)))
+	}
+	if q.Tag != "" {
+		conditions = append(conditions, `entries.id IN (
+			SELECT entry_tags.entry_id FROM entry_tags
+			INNER JOIN tags ON entry_tags.tag_id = tags.id
+			WHERE tags.name = :tag
+		)`)
+		args = append(args, sql.Named("tag", q.Tag))
+	}
+	if q.Folder != "" {
+		conditions = append(conditions, "(entries.folder = :folder OR SUBSTR(entries.folder, 1, LENGTH(:folder) + 1) = :folder || '/')")
+		args = append(args, sql.Named("folder", q.Folder))
+	}
+
+	where := "\n\tWHERE\n\t\t" + strings.Join(conditions, " AND\n\t\t")
+
+	var total int
+	if err := s.ctx.QueryRow("SELECT COUNT(*)"+entriesMetadataFrom+where, args...).Scan(&total); err != nil {
+		return store.EntryPage{}, err
+	}
+
+	sortColumn, ok := entrySortColumns[q.SortBy]
+	if !ok {
+		sortColumn = entrySortColumns[store.SortByUploaded]
+	}
+	direction := "ASC"
+	if q.Descending {
+		direction = "DESC"
+	}
+	// Break ties by ID so that pages are stable.
+	suffix := fmt.Sprintf("\n\tORDER BY\n\t\t%s %s,\n\t\tentries.id %s", sortColumn, direction, direction)
+	if q.Limit > 0 {
+		suffix += "\n\tLIMIT :limit OFFSET :offset"
+		args = append(args, sql.Named("limit", q.Limit), sql.Named("offset", q.Offset))
+	}
+
+	entries, err := s.selectEntriesMetadata(where, suffix, args...)
+	if err != nil {
+		return store.EntryPage{}, err
+	}
+
+	ids := make([]picoshare.EntryID, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.ID
+	}
+	tagsByEntry, err := s.getTagsForEntries(ids)
+	if err != nil {
+		return store.EntryPage{}, err
+	}
+	for i := range entries {
+		entries[i].Tags = tagsByEntry[entries[i].ID]
+	}
+
+	return store.EntryPage{
+		Entries: entries,
+		Total:   total,
+	}, nil
+}
+
+// searchMatchExpression converts a user's search into a full-text query that
+// matches entries containing every word in the search. The last word matches
+// as a prefix. Punctuation separates words, just as it does in the index, so
+// users can't inject full-text query syntax.
+func searchMatchExpression(search string) string {
+	words := strings.FieldsFunc(search, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if len(words) == 0 {
+		return ""
+	}
+	// Lowercase words so that AND, OR, and NOT don't act as operators.
+	for i, word := range words {
+		words[i] = strings.ToLower(word)
+	}
+	words[len(words)-1] += "*"
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,200 @@
+package sqlite_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/store"
+	"github.com/mtlynch/picoshare/store/test_sqlite"
+)
+
+func TestQueryEntries(t *testing.T) {
+	dataStore := test_sqlite.New()
+
+	if err := dataStore.InsertGuestLink(picoshare.GuestLink{
+		ID:              "guestlink1234567",
+		Created:         mustParseTime("2024-01-01T00:00:00Z"),
+		UrlExpires:      picoshare.NeverExpire,
+		MaxFileLifetime: picoshare.FileLifetimeInfinite,
+	}); err != nil {
+		t.Fatalf("failed to insert guest link: %v", err)
+	}
+
+	for _, e := range []struct {
+		id          picoshare.EntryID
+		filename    picoshare.Filename
+		note        string
+		contentType picoshare.ContentType
+		contents    string
+		uploaded    string
+		expires     picoshare.ExpirationTime
+		guestLinkID picoshare.GuestLinkID
+	}{
+		{"AAAAAAAAAA", "quarterly_report.pdf", "Numbers for Q3", "application/pdf", "12345", "2024-01-01T00:00:00Z", mustParseExpirationTime("2024-02-01T00:00:00Z"), ""},
+		{"BBBBBBBBBB", "beach.jpg", "", "image/jpeg", "1234567890", "2024-01-02T00:00:00Z", mustParseExpirationTime("2024-01-05T00:00:00Z"), "guestlink1234567"},
+		{"CCCCCCCCCC", "Report draft.docx", "", "application/vnd.openxmlformats-officedocument.wordprocessingml.document", "1", "2024-01-03T00:00:00Z", picoshare.NeverExpire, ""},
+		{"DDDDDDDDDD", "mountains.png", "Vacation report photos", "image/png", "123", "2024-01-04T00:00:00Z", picoshare.NeverExpire, "guestlink1234567"},
+	} {
+		var note picoshare.FileNote
+		if e.note != "" {
+			note = picoshare.FileNote{Value: &e.note}
+		}
+		if err := dataStore.InsertEntry(strings.NewReader(e.contents), picoshare.UploadMetadata{
+			ID:          e.id,
+			Filename:    e.filename,
+			Note:        note,
+			ContentType: e.contentType,
+			Uploaded:    mustParseTime(e.uploaded),
+			Expires:     e.expires,
+			Size:        mustParseFileSize(len(e.contents)),
+			GuestLink:   picoshare.GuestLink{ID: e.guestLinkID},
+		}); err != nil {
+			t.Fatalf("failed to insert entry: %v", err)
+		}
+	}
+
+	for _, tt := range []struct {
+		description string
+		query       store.EntryQuery
+		ids         []picoshare.EntryID
+		total       int
+	}{
+		{
+			description: "returns all entries by upload time",
+			query:       store.EntryQuery{SortBy: store.SortByUploaded},
+			ids:         []picoshare.EntryID{"AAAAAAAAAA", "BBBBBBBBBB", "CCCCCCCCCC", "DDDDDDDDDD"},
+			total:       4,
+		},
+		{
+			description: "searches filenames and notes",
+			query:       store.EntryQuery{Search: "report", SortBy: store.SortByUploaded},
+			ids:         []picoshare.EntryID{"AAAAAAAAAA", "CCCCCCCCCC", "DDDDDDDDDD"},
+			total:       3,
+		},
+		{
+			description: "matches the last search word as a prefix",
+			query:       store.EntryQuery{Search: "vacation rep"},
+			ids:         []picoshare.EntryID{"DDDDDDDDDD"},
+			total:       1,
+		},
+		{
+			description: "treats search operators as words",
+			query:       store.EntryQuery{Search: `report OR "beach"`},
+			ids:         []picoshare.EntryID{},
+			total:       0,
+		},
+		{
+			description: "returns nothing for a search without words",
+			query:       store.EntryQuery{Search: "!!!"},
+			ids:         []picoshare.EntryID{},
+			total:       0,
+		},
+		{
+			description: "filters by guest link",
+			query:       store.EntryQuery{GuestLinkID: "guestlink1234567", SortBy: store.SortByUploaded},
+			ids:         []picoshare.EntryID{"BBBBBBBBBB", "DDDDDDDDDD"},
+			total:       2,
+		},
+		{
+			description: "filters by content type",
+			query:       store.EntryQuery{ContentTypePrefix: "image/", SortBy: store.SortByFilename},
+			ids:         []picoshare.EntryID{"BBBBBBBBBB", "DDDDDDDDDD"},
+			total:       2,
+		},
+		{
+			description: "filters by size range",
+			query:       store.EntryQuery{MinSize: new(uint64(2)), MaxSize: new(uint64(5)), SortBy: store.SortBySize},
+			ids:         []picoshare.EntryID{"DDDDDDDDDD", "AAAAAAAAAA"},
+			total:       2,
+		},
+		{
+			description: "filters by expiration window",
+			query: store.EntryQuery{
+				ExpiresFrom:   new(mustParseTime("2024-01-01T00:00:00Z")),
+				ExpiresBefore: new(mustParseTime("2024-01-10T00:00:00Z")),
+			},
+			ids:   []picoshare.EntryID{"BBBBBBBBBB"},
+			total: 1,
+		},
+		{
+			description: "sorts by filename without regard to case",
+			query:       store.EntryQuery{SortBy: store.SortByFilename, Descending: true},
+			ids:         []picoshare.EntryID{"CCCCCCCCCC", "AAAAAAAAAA", "DDDDDDDDDD", "BBBBBBBBBB"},
+			total:       4,
+		},
+		{
+			description: "paginates results",
+			query:       store.EntryQuery{SortBy: store.SortByUploaded, Descending: true, Offset: 1, Limit: 2},
+			ids:         []picoshare.EntryID{"CCCCCCCCCC", "BBBBBBBBBB"},
+			total:       4,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			page, err := dataStore.QueryEntries(tt.query)
+			if err != nil {
+				t.Fatalf("failed to query entries: %v", err)
+			}
+
+			ids := []picoshare.EntryID{}
+			for _, entry := range page.Entries {
+				ids = append(ids, entry.ID)
+			}
+			if diff := deep.Equal(ids, tt.ids); diff != nil {
+				t.Error(diff)
+			}
+			if got, want := page.Total, tt.total; got != want {
+				t.Errorf("total=%d, want=%d", got, want)
+			}
+		})
+	}
+}
+
+func TestQueryEntriesAfterRename(t *testing.T) {
+	dataStore := test_sqlite.New()
+
+	contents := "hello"
+	if err := dataStore.InsertEntry(strings.NewReader(contents), picoshare.UploadMetadata{
+		ID:       "AAAAAAAAAA",
+		Filename: "old-name.txt",
+		Uploaded: mustParseTime("2024-01-01T00:00:00Z"),
+		Expires:  picoshare.NeverExpire,
+		Size:     mustParseFileSize(len(contents)),
+	}); err != nil {
+		t.Fatalf("failed to insert entry: %v", err)
+	}
+
+	if err := dataStore.UpdateEntryMetadata("AAAAAAAAAA", picoshare.UploadMetadata{
+		Filename: "new-name.txt",
+		Expires:  picoshare.NeverExpire,
+	}); err != nil {
+		t.Fatalf("failed to update entry: %v", err)
+	}
+
+	for _, search := range []string{"old", "new"} {
+		page, err := dataStore.QueryEntries(store.EntryQuery{Search: search})
+		if err != nil {
+			t.Fatalf("failed to query entries: %v", err)
+		}
+		want := 0
+		if search == "new" {
+			want = 1
+		}
+		if got := page.Total; got != want {
+			t.Errorf("search %q: total=%d, want=%d", search, got, want)
+		}
+	}
+
+	if err := dataStore.DeleteEntry("AAAAAAAAAA"); err != nil {
+		t.Fatalf("failed to delete entry: %v", err)
+	}
+	page, err := dataStore.QueryEntries(store.EntryQuery{Search: "new"})
+	if err != nil {
+		t.Fatalf("failed to query entries: %v", err)
+	}
+	if got, want := page.Total, 0; got != want {
+		t.Errorf("total after delete=%d, want=%d", got, want)
+	}
+}
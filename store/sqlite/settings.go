@@ -12,21 +12,61 @@ const settingsRowID = 1
 
 func (s Store) ReadSettings() (picoshare.Settings, error) {
 	var expirationInDays uint16
+	var guestRateLimit picoshare.GuestUploadRateLimit
+	var downloadLimits picoshare.DownloadLimits
+	var trashRetentionInDays *uint16
+	var defaultVisibility *string
+	var defaultIdleExpirationInDays *uint16
+	var notifications picoshare.NotificationSettings
 	if err := s.ctx.QueryRow(`
    SELECT
-   	default_expiration_in_days
+   	default_expiration_in_days,
+   	guest_max_uploads_per_ip_per_hour,
+   	guest_max_bytes_per_ip_per_day,
+   	guest_max_upload_bytes_per_second,
+   	max_download_bytes_per_second,
+   	max_concurrent_downloads_per_ip,
+   	trash_retention_in_days,
+   	default_visibility,
+   	default_idle_expiration_in_days,
+   	notify_guest_uploads,
+   	notify_sensitive_downloads,
+   	notify_expiring_entries
    FROM
    	settings
    WHERE
-   	id = :row_id`, sql.Named("row_id", settingsRowID)).Scan(&expirationInDays); err != nil {
+   	id = :row_id`, sql.Named("row_id", settingsRowID)).Scan(
+		&expirationInDays,
+		&guestRateLimit.MaxUploadsPerIPPerHour,
+		&guestRateLimit.MaxBytesPerIPPerDay,
+		&guestRateLimit.MaxBytesPerSecond,
+		&downloadLimits.MaxBytesPerSecond,
+		&downloadLimits.MaxConcurrentPerIP,
+		&trashRetentionInDays,
+		&defaultVisibility,
+		&defaultIdleExpirationInDays,
+		&notifications.GuestUploads,
+		&notifications.SensitiveDownloads,
+		&notifications.ExpiringEntries); err != nil {
 		if err == sql.ErrNoRows {
 			return picoshare.Settings{}, nil
 		}
 		return picoshare.Settings{}, err
 	}
 
+	var trashRetention picoshare.FileLifetime
+	if trashRetentionInDays != nil {
+		trashRetention = picoshare.NewFileLifetimeInDays(*trashRetentionInDays)
+	}
+
 	return picoshare.Settings{
-		DefaultFileLifetime: picoshare.NewFileLifetimeInDays(expirationInDays),
+		DefaultFileLifetime:         picoshare.NewFileLifetimeInDays(expirationInDays),
+		DefaultGuestUploadRateLimit: guestRateLimit,
+		DownloadLimits:              downloadLimits,
+		TrashRetention:              trashRetention,
+		DefaultVisibility:           picoshare.Visibility(stringFromNullable(defaultVisibility)),
+		DefaultIdleLifetime:         fileLifetimeFromNullableDays(defaultIdleExpirationInDays),
+		Notifications:               notifications,
 	}, nil
 }
 
@@ -37,9 +77,33 @@ func (s Store) UpdateSettings(settings picoshare.Settings) error {
    UPDATE
    	settings
    SET
-   	default_expiration_in_days = :expiration
+   	default_expiration_in_days = :expiration,
+   	guest_max_uploads_per_ip_per_hour = :guest_max_uploads_per_ip_per_hour,
+   	guest_max_bytes_per_ip_per_day = :guest_max_bytes_per_ip_per_day,
+   	guest_max_upload_bytes_per_second = :guest_max_upload_bytes_per_second,
+   	max_download_bytes_per_second = :max_download_bytes_per_second,
+   	max_concurrent_downloads_per_ip = :max_concurrent_downloads_per_ip,
+   	trash_retention_in_days = NULLIF(:trash_retention_in_days, 0),
+   	default_visibility = NULLIF(:default_visibility, ''),
+   	default_idle_expiration_in_days = NULLIF(:default_idle_expiration_in_days, 0),
+   	notify_guest_uploads = :notify_guest_uploads,
+   	notify_sensitive_downloads = :notify_sensitive_downloads,
+   	notify_expiring_entries = :notify_expiring_entries
    WHERE
-   	id = :row_id`, sql.Named("expiration", expirationInDays), sql.Named("row_id", settingsRowID)); err != nil {
+   	id = :row_id`,
+		sql.Named("expiration", expirationInDays),
+		sql.Named("guest_max_uploads_per_ip_per_hour", settings.DefaultGuestUploadRateLimit.MaxUploadsPerIPPerHour),
+		sql.Named("guest_max_bytes_per_ip_per_day", settings.DefaultGuestUploadRateLimit.MaxBytesPerIPPerDay),
+		sql.Named("guest_max_upload_bytes_per_second", settings.DefaultGuestUploadRateLimit.MaxBytesPerSecond),
+		sql.Named("max_download_bytes_per_second", settings.DownloadLimits.MaxBytesPerSecond),
+		sql.Named("max_concurrent_downloads_per_ip", settings.DownloadLimits.MaxConcurrentPerIP),
+		sql.Named("trash_retention_in_days", settings.TrashRetention.Days()),
+		sql.Named("default_visibility", settings.DefaultVisibility),
+		sql.Named("default_idle_expiration_in_days", settings.DefaultIdleLifetime.Days()),
+		sql.Named("notify_guest_uploads", settings.Notifications.GuestUploads),
+		sql.Named("notify_sensitive_downloads", settings.Notifications.SensitiveDownloads),
+		sql.Named("notify_expiring_entries", settings.Notifications.ExpiringEntries),
+		sql.Named("row_id", settingsRowID)); err != nil {
 		return err
 	}
 
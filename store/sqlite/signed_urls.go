@@ -0,0 +1,72 @@
+package sqlite
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/mtlynch/picoshare/store"
+)
+
+// We only store one signing key at a time, so we used a fixed row ID.
+const urlSigningKeyRowID = 1
+
+// ReadURLSigningKey returns the key that signs temporary links to entries.
+func (s Store) ReadURLSigningKey() ([]byte, error) {
+	var key []byte
+	if err := s.ctx.QueryRow(`
+	SELECT
+		key
+	FROM
+		url_signing_key
+	WHERE
+		id = :row_id`, sql.Named("row_id", urlSigningKeyRowID)).Scan(&key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// ClaimSignedURL records that a client used the single-use signed link with the
+// given signature. If a client already used the link, ClaimSignedURL returns
+// SignedURLUsedError.
+func (s Store) ClaimSignedURL(signature string, expires time.Time) error {
+	res, err := s.ctx.Exec(`
+	INSERT INTO
+		used_signed_urls
+	(
+		signature,
+		expiration_time
+	)
+	VALUES(:signature, :expiration_time)
+	ON CONFLICT (signature) DO NOTHING`,
+		sql.Named("signature", signature),
+		sql.Named("expiration_time", formatTime(expires)))
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return store.SignedURLUsedError{Signature: signature}
+	}
+
+	return nil
+}
+
+func (s Store) deleteExpiredSignedURLs() error {
+	log.Printf("deleting expired single-use links from database")
+
+	if _, err := s.ctx.Exec(`
+	DELETE FROM
+		used_signed_urls
+	WHERE
+		expiration_time < :current_time`, sql.Named("current_time", formatTime(time.Now()))); err != nil {
+		return err
+	}
+
+	return nil
+}
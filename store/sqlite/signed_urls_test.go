@@ -0,0 +1,60 @@
+package sqlite_test
+
+import (
+	"testing"
+
+	"github.com/mtlynch/picoshare/store"
+	"github.com/mtlynch/picoshare/store/test_sqlite"
+)
+
+func TestReadURLSigningKey(t *testing.T) {
+	dataStore := test_sqlite.New()
+
+	key, err := dataStore.ReadURLSigningKey()
+	if err != nil {
+		t.Fatalf("failed to read signing key: %v", err)
+	}
+	if got, want := len(key), 32; got != want {
+		t.Errorf("len(key)=%d, want=%d", got, want)
+	}
+
+	keyAgain, err := dataStore.ReadURLSigningKey()
+	if err != nil {
+		t.Fatalf("failed to read signing key: %v", err)
+	}
+	if got, want := string(keyAgain), string(key); got != want {
+		t.Errorf("signing key changed between reads")
+	}
+}
+
+func TestClaimSignedURL(t *testing.T) {
+	dataStore := test_sqlite.New()
+
+	if err := dataStore.ClaimSignedURL("dummy-signature", mustParseTime("2999-01-01T00:00:00Z")); err != nil {
+		t.Fatalf("failed to claim signed link: %v", err)
+	}
+
+	if err := dataStore.ClaimSignedURL("dummy-signature", mustParseTime("2999-01-01T00:00:00Z")); err == nil {
+		t.Errorf("claiming signed link twice succeeded, want error")
+	} else if _, ok := err.(store.SignedURLUsedError); !ok {
+		t.Errorf("unexpected error claiming signed link twice: %v", err)
+	}
+
+	if err := dataStore.ClaimSignedURL("other-signature", mustParseTime("2999-01-01T00:00:00Z")); err != nil {
+		t.Errorf("failed to claim different signed link: %v", err)
+	}
+
+	// Purge forgets expired links, but it keeps links that are still valid.
+	if err := dataStore.ClaimSignedURL("expired-signature", mustParseTime("2000-01-01T00:00:00Z")); err != nil {
+		t.Fatalf("failed to claim expired signed link: %v", err)
+	}
+	if err := dataStore.Purge(); err != nil {
+		t.Fatalf("failed to purge database: %v", err)
+	}
+	if err := dataStore.ClaimSignedURL("expired-signature", mustParseTime("2000-01-01T00:00:00Z")); err != nil {
+		t.Errorf("failed to claim purged signed link: %v", err)
+	}
+	if err := dataStore.ClaimSignedURL("dummy-signature", mustParseTime("2999-01-01T00:00:00Z")); err == nil {
+		t.Errorf("claiming unexpired signed link after purge succeeded, want error")
+	}
+}
@@ -0,0 +1,59 @@
+package sqlite
+
+import (
+	"database/sql"
+
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/store"
+)
+
+// GetEntryIDBySlug returns the ID of the entry with the given slug.
+func (s Store) GetEntryIDBySlug(slug picoshare.EntrySlug) (picoshare.EntryID, error) {
+	var id picoshare.EntryID
+	err := s.ctx.QueryRow(`
+	SELECT
+		id
+	FROM
+		entries
+	WHERE
+		slug = :slug AND
+		deletion_time IS NULL`, sql.Named("slug", slug)).Scan(&id)
+	if err == sql.ErrNoRows {
+		return picoshare.EntryID(""), store.EntrySlugNotFoundError{Slug: slug}
+	} else if err != nil {
+		return picoshare.EntryID(""), err
+	}
+
+	return id, nil
+}
+
+// checkSlugAvailable returns an error if an entry other than the one with the
+// given ID has the slug. Entries in the trash keep their slugs so that they can
+// be restored.
+func checkSlugAvailable(tx *sql.Tx, slug picoshare.EntrySlug, id picoshare.EntryID) error {
+	if slug == "" {
+		return nil
+	}
+
+	var taken bool
+	if err := tx.QueryRow(`
+	SELECT
+		EXISTS (
+			SELECT
+				1
+			FROM
+				entries
+			WHERE
+				slug = :slug AND
+				id != :entry_id
+		)`,
+		sql.Named("slug", slug),
+		sql.Named("entry_id", id)).Scan(&taken); err != nil {
+		return err
+	}
+	if taken {
+		return store.EntrySlugTakenError{Slug: slug}
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package sqlite_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/store"
+	"github.com/mtlynch/picoshare/store/test_sqlite"
+)
+
+func TestEntrySlugs(t *testing.T) {
+	dataStore := test_sqlite.New()
+
+	for _, e := range []struct {
+		id   picoshare.EntryID
+		slug picoshare.EntrySlug
+	}{
+		{"AAAAAAAAAA", "q3-report"},
+		{"BBBBBBBBBB", ""},
+		{"CCCCCCCCCC", ""},
+	} {
+		contents := "dummy data"
+		if err := dataStore.InsertEntry(strings.NewReader(contents), picoshare.UploadMetadata{
+			ID:       e.id,
+			Filename: picoshare.Filename(e.id.String() + ".txt"),
+			Uploaded: mustParseTime("2024-01-01T00:00:00Z"),
+			Expires:  picoshare.NeverExpire,
+			Size:     mustParseFileSize(len(contents)),
+			Slug:     e.slug,
+		}); err != nil {
+			t.Fatalf("failed to insert entry: %v", err)
+		}
+	}
+
+	id, err := dataStore.GetEntryIDBySlug("q3-report")
+	if err != nil {
+		t.Fatalf("failed to get entry by slug: %v", err)
+	}
+	if got, want := id, picoshare.EntryID("AAAAAAAAAA"); got != want {
+		t.Errorf("id=%v, want=%v", got, want)
+	}
+
+	entry, err := dataStore.GetEntryMetadata("AAAAAAAAAA")
+	if err != nil {
+		t.Fatalf("failed to get entry: %v", err)
+	}
+	if got, want := entry.Slug, picoshare.EntrySlug("q3-report"); got != want {
+		t.Errorf("slug=%v, want=%v", got, want)
+	}
+
+	if _, err := dataStore.GetEntryIDBySlug("q4-report"); err == nil {
+		t.Errorf("getting entry by nonexistent slug succeeded, want error")
+	} else if _, ok := err.(store.EntrySlugNotFoundError); !ok {
+		t.Errorf("unexpected error getting entry by nonexistent slug: %v", err)
+	}
+
+	// Entries without slugs don't conflict with each other.
+	if err := dataStore.UpdateEntryMetadata("CCCCCCCCCC", picoshare.UploadMetadata{
+		Filename: "CCCCCCCCCC.txt",
+		Expires:  picoshare.NeverExpire,
+	}); err != nil {
+		t.Errorf("failed to update entry without slug: %v", err)
+	}
+
+	if err := dataStore.UpdateEntryMetadata("BBBBBBBBBB", picoshare.UploadMetadata{
+		Filename: "BBBBBBBBBB.txt",
+		Expires:  picoshare.NeverExpire,
+		Slug:     "q3-report",
+	}); err == nil {
+		t.Errorf("reusing another entry's slug succeeded, want error")
+	} else if _, ok := err.(store.EntrySlugTakenError); !ok {
+		t.Errorf("unexpected error reusing another entry's slug: %v", err)
+	}
+
+	contents := "dummy data"
+	if err := dataStore.InsertEntry(strings.NewReader(contents), picoshare.UploadMetadata{
+		ID:       "DDDDDDDDDD",
+		Filename: "DDDDDDDDDD.txt",
+		Uploaded: mustParseTime("2024-01-01T00:00:00Z"),
+		Expires:  picoshare.NeverExpire,
+		Size:     mustParseFileSize(len(contents)),
+		Slug:     "q3-report",
+	}); err == nil {
+		t.Errorf("inserting entry with another entry's slug succeeded, want error")
+	} else if _, ok := err.(store.EntrySlugTakenError); !ok {
+		t.Errorf("unexpected error inserting entry with another entry's slug: %v", err)
+	}
+
+	// An entry can keep its own slug.
+	if err := dataStore.UpdateEntryMetadata("AAAAAAAAAA", picoshare.UploadMetadata{
+		Filename: "renamed.txt",
+		Expires:  picoshare.NeverExpire,
+		Slug:     "q3-report",
+	}); err != nil {
+		t.Errorf("failed to update entry with its own slug: %v", err)
+	}
+
+	// Removing the slug frees it for other entries.
+	if err := dataStore.UpdateEntryMetadata("AAAAAAAAAA", picoshare.UploadMetadata{
+		Filename: "renamed.txt",
+		Expires:  picoshare.NeverExpire,
+	}); err != nil {
+		t.Fatalf("failed to remove slug: %v", err)
+	}
+	if err := dataStore.UpdateEntryMetadata("BBBBBBBBBB", picoshare.UploadMetadata{
+		Filename: "BBBBBBBBBB.txt",
+		Expires:  picoshare.NeverExpire,
+		Slug:     "q3-report",
+	}); err != nil {
+		t.Errorf("failed to reuse freed slug: %v", err)
+	}
+	id, err = dataStore.GetEntryIDBySlug("q3-report")
+	if err != nil {
+		t.Fatalf("failed to get entry by slug: %v", err)
+	}
+	if got, want := id, picoshare.EntryID("BBBBBBBBBB"); got != want {
+		t.Errorf("id=%v, want=%v", got, want)
+	}
+}
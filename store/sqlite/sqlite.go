@@ -75,6 +75,15 @@ func formatTime(t time.Time) string {
 	return t.UTC().Format(timeFormat)
 }
 
+// formatOptionalTime formats the time for a nullable column, where the zero
+// time is NULL.
+func formatOptionalTime(t time.Time) *string {
+	if t.IsZero() {
+		return nil
+	}
+	return new(formatTime(t))
+}
+
 func formatFileLifetime(lt picoshare.FileLifetime) string {
 	return lt.String()
 }
@@ -0,0 +1,269 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/store"
+)
+
+// GetTags returns every tag that applies to at least one entry outside the
+// trash, in alphabetical order.
+func (s Store) GetTags() ([]picoshare.Tag, error) {
+	rows, err := s.ctx.Query(`
+	SELECT DISTINCT
+		tags.name AS name
+	FROM
+		tags
+	INNER JOIN
+		entry_tags ON tags.id = entry_tags.tag_id
+	INNER JOIN
+		entries ON entry_tags.entry_id = entries.id
+	WHERE
+		entries.deletion_time IS NULL
+	ORDER BY
+		tags.name`)
+	if err != nil {
+		return []picoshare.Tag{}, err
+	}
+	defer rows.Close()
+
+	tags := []picoshare.Tag{}
+	for rows.Next() {
+		var tag picoshare.Tag
+		if err := rows.Scan(&tag); err != nil {
+			return []picoshare.Tag{}, err
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
+
+// GetFolders returns every folder that contains at least one entry outside the
+// trash, along with the folders that contain those folders, in alphabetical
+// order.
+func (s Store) GetFolders() ([]picoshare.Folder, error) {
+	rows, err := s.ctx.Query(`
+	SELECT DISTINCT
+		folder
+	FROM
+		entries
+	WHERE
+		folder IS NOT NULL AND
+		deletion_time IS NULL`)
+	if err != nil {
+		return []picoshare.Folder{}, err
+	}
+	defer rows.Close()
+
+	seen := map[picoshare.Folder]bool{}
+	for rows.Next() {
+		var folder string
+		if err := rows.Scan(&folder); err != nil {
+			return []picoshare.Folder{}, err
+		}
+		for {
+			seen[picoshare.Folder(folder)] = true
+			i := strings.LastIndex(folder, "/")
+			if i < 0 {
+				break
+			}
+			folder = folder[:i]
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return []picoshare.Folder{}, err
+	}
+
+	folders := make([]picoshare.Folder, 0, len(seen))
+	for folder := range seen {
+		folders = append(folders, folder)
+	}
+	slices.Sort(folders)
+
+	return folders, nil
+}
+
+func (s Store) getEntryTags(id picoshare.EntryID) ([]picoshare.Tag, error) {
+	tagsByEntry, err := s.getTagsForEntries([]picoshare.EntryID{id})
+	if err != nil {
+		return []picoshare.Tag{}, err
+	}
+	if tags, ok := tagsByEntry[id]; ok {
+		return tags, nil
+	}
+	return []picoshare.Tag{}, nil
+}
+
+// getTagsForEntries returns the tags of each of the given entries, in
+// alphabetical order. Entries without tags are absent from the result.
+func (s Store) getTagsForEntries(ids []picoshare.EntryID) (map[picoshare.EntryID][]picoshare.Tag, error) {
+	tagsByEntry := map[picoshare.EntryID][]picoshare.Tag{}
+	if len(ids) == 0 {
+		return tagsByEntry, nil
+	}
+
+	placeholders := make([]string, len(ids))
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	rows, err := s.ctx.Query(`
+	SELECT
+		entry_tags.entry_id AS entry_id,
+		tags.name AS name
+	FROM
+		entry_tags
+	INNER JOIN
+		tags ON entry_tags.tag_id = tags.id
+	WHERE
+		entry_tags.entry_id IN (`+strings.Join(placeholders, ", ")+`)
+	ORDER BY
+		tags.name`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id picoshare.EntryID
+		var tag picoshare.Tag
+		if err := rows.Scan(&id, &tag); err != nil {
+			return nil, err
+		}
+		tagsByEntry[id] = append(tagsByEntry[id], tag)
+	}
+
+	return tagsByEntry, rows.Err()
+}
+
+// AddEntriesTags adds tags to several entries in a single transaction, keeping
+// the tags that the entries already have. If any ID doesn't match an entry or
+// any entry would end up with more than maxTagsPerEntry tags, none of the
+// entries change.
+func (s Store) AddEntriesTags(ids []picoshare.EntryID, tags []picoshare.Tag, maxTagsPerEntry int) error {
+	log.Printf("adding %d tags to %d entries", len(tags), len(ids))
+
+	tx, err := s.ctx.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Printf("failed to rollback add entries tags: %v", err)
+		}
+	}()
+
+	for _, tag := range tags {
+		if _, err := tx.Exec(`
+		INSERT INTO tags (name)
+		VALUES (:name)
+		ON CONFLICT (name) DO NOTHING`, sql.Named("name", tag)); err != nil {
+			return err
+		}
+	}
+
+	for _, id := range ids {
+		var exists bool
+		if err := tx.QueryRow(`
+		SELECT
+			COUNT(*) > 0
+		FROM
+			entries
+		WHERE
+			id = :entry_id AND
+			deletion_time IS NULL`, sql.Named("entry_id", id)).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return store.EntryNotFoundError{ID: id}
+		}
+
+		for _, tag := range tags {
+			if _, err := tx.Exec(`
+			INSERT INTO entry_tags (entry_id, tag_id)
+			SELECT
+				:entry_id,
+				id
+			FROM
+				tags
+			WHERE
+				name = :name
+			ON CONFLICT DO NOTHING`, sql.Named("entry_id", id), sql.Named("name", tag)); err != nil {
+				return err
+			}
+		}
+
+		var tagCount int
+		if err := tx.QueryRow(`
+		SELECT
+			COUNT(*)
+		FROM
+			entry_tags
+		WHERE
+			entry_id = :entry_id`, sql.Named("entry_id", id)).Scan(&tagCount); err != nil {
+			return err
+		}
+		if tagCount > maxTagsPerEntry {
+			return store.EntryTooManyTagsError{ID: id, Max: maxTagsPerEntry}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// setEntryTags replaces the tags on an entry.
+func setEntryTags(tx *sql.Tx, id picoshare.EntryID, tags []picoshare.Tag) error {
+	if _, err := tx.Exec(`
+	DELETE FROM
+		entry_tags
+	WHERE
+		entry_id = :entry_id`, sql.Named("entry_id", id)); err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		if _, err := tx.Exec(`
+		INSERT INTO tags (name)
+		VALUES (:name)
+		ON CONFLICT (name) DO NOTHING`, sql.Named("name", tag)); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`
+		INSERT INTO entry_tags (entry_id, tag_id)
+		SELECT
+			:entry_id,
+			id
+		FROM
+			tags
+		WHERE
+			name = :name`, sql.Named("entry_id", id), sql.Named("name", tag)); err != nil {
+			return err
+		}
+	}
+
+	return deleteUnusedTags(tx)
+}
+
+// deleteUnusedTags deletes tags that no longer apply to any entry.
+func deleteUnusedTags(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+	DELETE FROM
+		tags
+	WHERE
+		id NOT IN (
+			SELECT
+				tag_id
+			FROM
+				entry_tags
+		)`)
+	return err
+}
@@ -0,0 +1,180 @@
+package sqlite_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/store"
+	"github.com/mtlynch/picoshare/store/test_sqlite"
+)
+
+func TestTagsAndFolders(t *testing.T) {
+	dataStore := test_sqlite.New()
+
+	for _, e := range []struct {
+		id     picoshare.EntryID
+		tags   []picoshare.Tag
+		folder picoshare.Folder
+	}{
+		{"AAAAAAAAAA", []picoshare.Tag{"invoices", "acme"}, "clients/acme"},
+		{"BBBBBBBBBB", []picoshare.Tag{"invoices"}, "clients/globex"},
+		{"CCCCCCCCCC", nil, "clientsarchive"},
+		{"DDDDDDDDDD", nil, ""},
+	} {
+		contents := "dummy data " + e.id.String()
+		if err := dataStore.InsertEntry(strings.NewReader(contents), picoshare.UploadMetadata{
+			ID:       e.id,
+			Filename: picoshare.Filename(e.id.String() + ".txt"),
+			Uploaded: mustParseTime("2024-01-01T00:00:00Z"),
+			Expires:  picoshare.NeverExpire,
+			Size:     mustParseFileSize(len(contents)),
+			Tags:     e.tags,
+			Folder:   e.folder,
+		}); err != nil {
+			t.Fatalf("failed to insert entry: %v", err)
+		}
+	}
+
+	entry, err := dataStore.GetEntryMetadata("AAAAAAAAAA")
+	if err != nil {
+		t.Fatalf("failed to get entry: %v", err)
+	}
+	if diff := deep.Equal(entry.Tags, []picoshare.Tag{"acme", "invoices"}); diff != nil {
+		t.Errorf("unexpected tags: %v", diff)
+	}
+	if got, want := entry.Folder, picoshare.Folder("clients/acme"); got != want {
+		t.Errorf("folder=%v, want=%v", got, want)
+	}
+
+	tags, err := dataStore.GetTags()
+	if err != nil {
+		t.Fatalf("failed to get tags: %v", err)
+	}
+	if diff := deep.Equal(tags, []picoshare.Tag{"acme", "invoices"}); diff != nil {
+		t.Errorf("unexpected tags: %v", diff)
+	}
+
+	folders, err := dataStore.GetFolders()
+	if err != nil {
+		t.Fatalf("failed to get folders: %v", err)
+	}
+	if diff := deep.Equal(folders, []picoshare.Folder{"clients", "clients/acme", "clients/globex", "clientsarchive"}); diff != nil {
+		t.Errorf("unexpected folders: %v", diff)
+	}
+
+	for _, tt := range []struct {
+		description string
+		query       store.EntryQuery
+		ids         []picoshare.EntryID
+	}{
+		{
+			description: "filters by tag",
+			query:       store.EntryQuery{Tag: "invoices"},
+			ids:         []picoshare.EntryID{"AAAAAAAAAA", "BBBBBBBBBB"},
+		},
+		{
+			description: "filters by folder and its subfolders",
+			query:       store.EntryQuery{Folder: "clients"},
+			ids:         []picoshare.EntryID{"AAAAAAAAAA", "BBBBBBBBBB"},
+		},
+		{
+			description: "filters by exact folder",
+			query:       store.EntryQuery{Folder: "clients/globex"},
+			ids:         []picoshare.EntryID{"BBBBBBBBBB"},
+		},
+		{
+			description: "combines tag and folder filters",
+			query:       store.EntryQuery{Tag: "acme", Folder: "clients/globex"},
+			ids:         []picoshare.EntryID{},
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			page, err := dataStore.QueryEntries(tt.query)
+			if err != nil {
+				t.Fatalf("failed to query entries: %v", err)
+			}
+			ids := []picoshare.EntryID{}
+			for _, entry := range page.Entries {
+				ids = append(ids, entry.ID)
+			}
+			if diff := deep.Equal(ids, tt.ids); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+
+	page, err := dataStore.QueryEntries(store.EntryQuery{Tag: "acme"})
+	if err != nil {
+		t.Fatalf("failed to query entries: %v", err)
+	}
+	if diff := deep.Equal(page.Entries[0].Tags, []picoshare.Tag{"acme", "invoices"}); diff != nil {
+		t.Errorf("unexpected tags in query results: %v", diff)
+	}
+
+	// Replacing an entry's tags deletes tags that no entry uses anymore.
+	if err := dataStore.UpdateEntryMetadata("AAAAAAAAAA", picoshare.UploadMetadata{
+		Filename: "AAAAAAAAAA.txt",
+		Expires:  picoshare.NeverExpire,
+		Tags:     []picoshare.Tag{"paid"},
+	}); err != nil {
+		t.Fatalf("failed to update entry: %v", err)
+	}
+	tags, err = dataStore.GetTags()
+	if err != nil {
+		t.Fatalf("failed to get tags: %v", err)
+	}
+	if diff := deep.Equal(tags, []picoshare.Tag{"invoices", "paid"}); diff != nil {
+		t.Errorf("unexpected tags after update: %v", diff)
+	}
+
+	if err := dataStore.DeleteEntry("BBBBBBBBBB"); err != nil {
+		t.Fatalf("failed to delete entry: %v", err)
+	}
+	tags, err = dataStore.GetTags()
+	if err != nil {
+		t.Fatalf("failed to get tags: %v", err)
+	}
+	if diff := deep.Equal(tags, []picoshare.Tag{"paid"}); diff != nil {
+		t.Errorf("unexpected tags after delete: %v", diff)
+	}
+}
+
+func TestGuestLinkAutoTag(t *testing.T) {
+	dataStore := test_sqlite.New()
+
+	for _, gl := range []picoshare.GuestLink{
+		{
+			ID:              "guestlink1234567",
+			Created:         mustParseTime("2024-01-01T00:00:00Z"),
+			UrlExpires:      picoshare.NeverExpire,
+			MaxFileLifetime: picoshare.FileLifetimeInfinite,
+			AutoTag:         "acme",
+		},
+		{
+			ID:              "guestlink7654321",
+			Created:         mustParseTime("2024-01-01T00:00:00Z"),
+			UrlExpires:      picoshare.NeverExpire,
+			MaxFileLifetime: picoshare.FileLifetimeInfinite,
+		},
+	} {
+		if err := dataStore.InsertGuestLink(gl); err != nil {
+			t.Fatalf("failed to insert guest link: %v", err)
+		}
+	}
+
+	for id, want := range map[picoshare.GuestLinkID]picoshare.Tag{
+		"guestlink1234567": "acme",
+		"guestlink7654321": "",
+	} {
+		gl, err := dataStore.GetGuestLink(id)
+		if err != nil {
+			t.Fatalf("failed to get guest link: %v", err)
+		}
+		if got := gl.AutoTag; got != want {
+			t.Errorf("%s: autoTag=%q, want=%q", id, got, want)
+		}
+	}
+}
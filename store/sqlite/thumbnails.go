@@ -0,0 +1,49 @@
+package sqlite
+
+import (
+	"database/sql"
+	"log"
+
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/store"
+)
+
+// InsertEntryThumbnail saves a JPEG thumbnail for the given entry, replacing
+// any existing thumbnail.
+func (s Store) InsertEntryThumbnail(id picoshare.EntryID, jpeg []byte) error {
+	log.Printf("saving thumbnail for entry %s (%d bytes)", id, len(jpeg))
+
+	if _, err := s.ctx.Exec(`
+	INSERT OR REPLACE INTO
+		thumbnails
+	(
+		entry_id,
+		image
+	)
+	VALUES(:entry_id, :image)`,
+		sql.Named("entry_id", id),
+		sql.Named("image", jpeg)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetEntryThumbnail returns the JPEG thumbnail for the given entry.
+func (s Store) GetEntryThumbnail(id picoshare.EntryID) ([]byte, error) {
+	var jpeg []byte
+	err := s.ctx.QueryRow(`
+	SELECT
+		image
+	FROM
+		thumbnails
+	WHERE
+		entry_id = :entry_id`, sql.Named("entry_id", id)).Scan(&jpeg)
+	if err == sql.ErrNoRows {
+		return nil, store.ThumbnailNotFoundError{ID: id}
+	} else if err != nil {
+		return nil, err
+	}
+
+	return jpeg, nil
+}
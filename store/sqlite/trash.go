@@ -0,0 +1,125 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/store"
+)
+
+// TrashEntries moves several entries to the trash in a single transaction.
+// PicoShare stops serving entries in the trash, but the user can restore them
+// until Purge deletes them permanently. If any ID doesn't match an entry
+// outside the trash, TrashEntries returns store.EntryNotFoundError and trashes
+// nothing.
+func (s Store) TrashEntries(ids []picoshare.EntryID) error {
+	tx, err := s.ctx.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Printf("failed to rollback trash entries: %v", err)
+		}
+	}()
+
+	deletionTime := formatTime(time.Now())
+	for _, id := range ids {
+		log.Printf("moving entry %v to trash", id)
+		res, err := tx.Exec(`
+		UPDATE entries
+		SET
+			deletion_time = :deletion_time
+		WHERE
+			id = :entry_id AND
+			deletion_time IS NULL`,
+			sql.Named("deletion_time", deletionTime),
+			sql.Named("entry_id", id))
+		if err != nil {
+			return err
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return store.EntryNotFoundError{ID: id}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// RestoreEntry moves an entry out of the trash.
+func (s Store) RestoreEntry(id picoshare.EntryID) error {
+	log.Printf("restoring entry %v from trash", id)
+
+	res, err := s.ctx.Exec(`
+	UPDATE entries
+	SET
+		deletion_time = NULL
+	WHERE
+		id = :entry_id AND
+		deletion_time IS NOT NULL`, sql.Named("entry_id", id))
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return store.EntryNotFoundError{ID: id}
+	}
+
+	return nil
+}
+
+// deleteTrashedEntries permanently deletes entries that have been in the trash
+// for longer than the retention period in the settings.
+func (s Store) deleteTrashedEntries() error {
+	settings, err := s.ReadSettings()
+	if err != nil {
+		return err
+	}
+	retentionDays := settings.EffectiveTrashRetention().Days()
+	cutoff := time.Now().Add(-time.Duration(retentionDays) * 24 * time.Hour)
+
+	log.Printf("deleting entries that have been in the trash for more than %d days", retentionDays)
+
+	rows, err := s.ctx.Query(`
+	SELECT
+		id
+	FROM
+		entries
+	WHERE
+		deletion_time IS NOT NULL AND
+		deletion_time < :cutoff`, sql.Named("cutoff", formatTime(cutoff)))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	ids := []picoshare.EntryID{}
+	for rows.Next() {
+		var id picoshare.EntryID
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return s.DeleteEntries(ids)
+}
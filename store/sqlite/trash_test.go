@@ -0,0 +1,89 @@
+package sqlite_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/store"
+	"github.com/mtlynch/picoshare/store/test_sqlite"
+)
+
+func TestTrashAndRestoreEntry(t *testing.T) {
+	dataStore := test_sqlite.New()
+
+	for _, id := range []picoshare.EntryID{"AAAAAAAAAA", "BBBBBBBBBB"} {
+		contents := "dummy data " + id.String()
+		if err := dataStore.InsertEntry(strings.NewReader(contents), picoshare.UploadMetadata{
+			ID:       id,
+			Filename: picoshare.Filename(id.String() + ".txt"),
+			Uploaded: mustParseTime("2024-01-01T00:00:00Z"),
+			Expires:  picoshare.NeverExpire,
+			Size:     mustParseFileSize(len(contents)),
+		}); err != nil {
+			t.Fatalf("failed to insert entry: %v", err)
+		}
+	}
+
+	if err := dataStore.TrashEntries([]picoshare.EntryID{"AAAAAAAAAA"}); err != nil {
+		t.Fatalf("failed to trash entry: %v", err)
+	}
+
+	if _, err := dataStore.GetEntryMetadata("AAAAAAAAAA"); err == nil {
+		t.Errorf("trashed entry is still available")
+	} else if _, ok := err.(store.EntryNotFoundError); !ok {
+		t.Fatalf("failed to get entry: %v", err)
+	}
+
+	page, err := dataStore.QueryEntries(store.EntryQuery{})
+	if err != nil {
+		t.Fatalf("failed to query entries: %v", err)
+	}
+	if got, want := page.Total, 1; got != want {
+		t.Errorf("total=%d, want=%d", got, want)
+	}
+
+	trashed, err := dataStore.GetTrashedEntries()
+	if err != nil {
+		t.Fatalf("failed to get trashed entries: %v", err)
+	}
+	if got, want := len(trashed), 1; got != want {
+		t.Fatalf("len(trashed)=%d, want=%d", got, want)
+	}
+	if got, want := trashed[0].ID, picoshare.EntryID("AAAAAAAAAA"); got != want {
+		t.Errorf("trashed ID=%v, want=%v", got, want)
+	}
+	if trashed[0].Deleted.IsZero() {
+		t.Errorf("trashed entry has no deletion time")
+	}
+
+	// Trashing an entry that's already in the trash fails and trashes none of
+	// the other entries in the same request.
+	if err := dataStore.TrashEntries([]picoshare.EntryID{"BBBBBBBBBB", "AAAAAAAAAA"}); err == nil {
+		t.Errorf("trashing an entry that's already in the trash succeeded, want error")
+	} else if _, ok := err.(store.EntryNotFoundError); !ok {
+		t.Errorf("unexpected error trashing an entry that's already in the trash: %v", err)
+	}
+	if _, err := dataStore.GetEntryMetadata("BBBBBBBBBB"); err != nil {
+		t.Errorf("failed to get entry after rejected trash request: %v", err)
+	}
+
+	// Purge keeps entries that have been in the trash for less than the
+	// retention period.
+	if err := dataStore.Purge(); err != nil {
+		t.Fatalf("failed to purge database: %v", err)
+	}
+
+	if err := dataStore.RestoreEntry("AAAAAAAAAA"); err != nil {
+		t.Fatalf("failed to restore entry: %v", err)
+	}
+	if _, err := dataStore.GetEntryMetadata("AAAAAAAAAA"); err != nil {
+		t.Errorf("failed to get restored entry: %v", err)
+	}
+
+	if err := dataStore.RestoreEntry("BBBBBBBBBB"); err == nil {
+		t.Errorf("restoring an entry outside the trash succeeded, want error")
+	} else if _, ok := err.(store.EntryNotFoundError); !ok {
+		t.Errorf("unexpected error restoring entry outside the trash: %v", err)
+	}
+}
@@ -0,0 +1,371 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log"
+
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/random"
+	"github.com/mtlynch/picoshare/store"
+	"github.com/mtlynch/picoshare/store/sqlite/file"
+)
+
+// versionBlobIDSuffixLength is the length of the random suffix that
+// distinguishes the data of a new version from the entry's existing data.
+const versionBlobIDSuffixLength = 8
+
+var versionBlobIDCharacters = []rune("abcdefghijklmnopqrstuvwxyz0123456789")
+
+// InsertEntryVersion replaces the contents of an existing entry with a new
+// version, using the ID, filename, content type, upload time, and compression
+// from the metadata. The entry's previous contents remain available by their
+// version number.
+func (s Store) InsertEntryVersion(reader io.Reader, metadata picoshare.UploadMetadata) error {
+	log.Printf("saving new version of entry %s", metadata.ID)
+
+	// The entry's existing data may already occupy a blob with the entry's own
+	// ID, so write the new data under an ID of its own.
+	stagingID := picoshare.EntryID(metadata.ID.String() + "-" + random.String(versionBlobIDSuffixLength, versionBlobIDCharacters))
+	sha256Hex, err := s.writeBlobData(stagingID, reader, metadata.Compressed)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.ctx.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Printf("failed to rollback insert entry version: %v", err)
+		}
+	}()
+
+	// Archive the current version before it's replaced. If the entry doesn't
+	// exist, the new data is orphaned, and Purge() cleans it up.
+	res, err := tx.Exec(`
+	INSERT INTO
+		entry_versions
+	(
+		entry_id,
+		version,
+		filename,
+		content_type,
+		upload_time,
+		sha256,
+		scan_status,
+		scan_detail,
+		scan_pending,
+		blob_id
+	)
+	SELECT
+		id,
+		version,
+		filename,
+		content_type,
+		upload_time,
+		sha256,
+		scan_status,
+		scan_detail,
+		scan_pending,
+		blob_id
+	FROM
+		entries
+	WHERE
+		id = :entry_id AND
+		deletion_time IS NULL`, sql.Named("entry_id", metadata.ID))
+	if err != nil {
+		log.Printf("insert into entry_versions table failed, aborting transaction: %v", err)
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return store.EntryNotFoundError{ID: metadata.ID}
+	}
+
+	blobID, err := claimBlob(tx, stagingID, sha256Hex, metadata.Compressed)
+	if err != nil {
+		return err
+	}
+
+	// The new contents haven't been scanned, checked, or thumbnailed yet.
+	if _, err := tx.Exec(`
+	UPDATE entries
+	SET
+		version = version + 1,
+		filename = :filename,
+		content_type = :content_type,
+		upload_time = :upload_time,
+		sha256 = :sha256,
+		blob_id = :blob_id,
+		scan_status = NULL,
+		scan_detail = NULL,
+		scan_pending = :scan_pending,
+		integrity_check_time = NULL,
+		integrity_mismatch = 0
+	WHERE
+		id = :entry_id`,
+		sql.Named("filename", metadata.Filename),
+		sql.Named("content_type", metadata.ContentType),
+		sql.Named("upload_time", formatTime(metadata.Uploaded)),
+		sql.Named("sha256", sha256Hex),
+		sql.Named("blob_id", blobID),
+		sql.Named("scan_pending", metadata.Scan.IsPending()),
+		sql.Named("entry_id", metadata.ID)); err != nil {
+		log.Printf("update entries table failed, aborting transaction: %v", err)
+		return err
+	}
+
+	if _, err := tx.Exec(`
+	DELETE FROM
+		thumbnails
+	WHERE
+		entry_id = :entry_id`, sql.Named("entry_id", metadata.ID)); err != nil {
+		log.Printf("delete from thumbnails table failed, aborting transaction: %v", err)
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetEntryVersions returns every version of an entry's contents, newest first.
+func (s Store) GetEntryVersions(id picoshare.EntryID) ([]picoshare.EntryVersion, error) {
+	rows, err := s.ctx.Query(`
+	SELECT
+		versions.version AS version,
+		versions.filename AS filename,
+		versions.content_type AS content_type,
+		versions.upload_time AS upload_time,
+		versions.sha256 AS sha256,
+		versions.scan_status AS scan_status,
+		versions.scan_detail AS scan_detail,
+		versions.scan_pending AS scan_pending,
+		(
+			SELECT
+				COALESCE(SUM(COALESCE(uncompressed_length, LENGTH(chunk))), 0)
+			FROM
+				entries_data
+			WHERE
+				entries_data.id = versions.blob_id
+		) AS file_size
+	FROM
+		(
+			SELECT
+				version,
+				filename,
+				content_type,
+				upload_time,
+				sha256,
+				scan_status,
+				scan_detail,
+				scan_pending,
+				blob_id
+			FROM
+				entries
+			WHERE
+				id = :entry_id AND
+				deletion_time IS NULL
+			UNION ALL
+			SELECT
+				entry_versions.version,
+				entry_versions.filename,
+				entry_versions.content_type,
+				entry_versions.upload_time,
+				entry_versions.sha256,
+				entry_versions.scan_status,
+				entry_versions.scan_detail,
+				entry_versions.scan_pending,
+				entry_versions.blob_id
+			FROM
+				entry_versions
+			INNER JOIN
+				entries ON entry_versions.entry_id = entries.id
+			WHERE
+				entry_versions.entry_id = :entry_id AND
+				entries.deletion_time IS NULL
+		) versions
+	ORDER BY
+		versions.version DESC`, sql.Named("entry_id", id))
+	if err != nil {
+		return []picoshare.EntryVersion{}, err
+	}
+	defer rows.Close()
+
+	versions := []picoshare.EntryVersion{}
+	for rows.Next() {
+		var version int
+		var filename string
+		var contentType string
+		var uploadTimeRaw string
+		var sha256 *string
+		var scanStatus *string
+		var scanDetail *string
+		var scanPending bool
+		var fileSizeRaw uint64
+		if err := rows.Scan(&version, &filename, &contentType, &uploadTimeRaw, &sha256, &scanStatus, &scanDetail, &scanPending, &fileSizeRaw); err != nil {
+			return []picoshare.EntryVersion{}, err
+		}
+
+		ut, err := parseDatetime(uploadTimeRaw)
+		if err != nil {
+			return []picoshare.EntryVersion{}, err
+		}
+
+		fileSize, err := picoshare.FileSizeFromUint64(fileSizeRaw)
+		if err != nil {
+			return []picoshare.EntryVersion{}, err
+		}
+
+		versions = append(versions, picoshare.EntryVersion{
+			Number:      version,
+			Filename:    picoshare.Filename(filename),
+			ContentType: picoshare.ContentType(contentType),
+			Uploaded:    ut,
+			Size:        fileSize,
+			SHA256:      stringFromNullable(sha256),
+			Scan:        scanResultFromColumns(scanStatus, scanDetail, scanPending),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return []picoshare.EntryVersion{}, err
+	}
+
+	if len(versions) == 0 {
+		return []picoshare.EntryVersion{}, store.EntryNotFoundError{ID: id}
+	}
+
+	return versions, nil
+}
+
+// ReadEntryVersionFile reads the data of a particular version of an entry's
+// contents, which can be either the current version or a previous one.
+func (s Store) ReadEntryVersionFile(id picoshare.EntryID, version int) (io.ReadSeeker, error) {
+	var blobID picoshare.EntryID
+	err := s.ctx.QueryRow(`
+	SELECT
+		blob_id
+	FROM
+		entries
+	WHERE
+		id = :entry_id AND
+		version = :version
+	UNION ALL
+	SELECT
+		blob_id
+	FROM
+		entry_versions
+	WHERE
+		entry_id = :entry_id AND
+		version = :version`,
+		sql.Named("entry_id", id),
+		sql.Named("version", version)).Scan(&blobID)
+	if err == sql.ErrNoRows {
+		return nil, store.EntryVersionNotFoundError{ID: id, Version: version}
+	} else if err != nil {
+		return nil, err
+	}
+
+	r, err := file.NewReader(s.ctx, blobID)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// UpdateEntryVersionScanResult records the result of scanning a particular
+// version of an entry's contents for malware, which can be either the current
+// version or a previous one.
+func (s Store) UpdateEntryVersionScanResult(id picoshare.EntryID, version int, result picoshare.ScanResult) error {
+	log.Printf("saving scan result for version %d of entry %s: %s", version, id, result.Status)
+
+	for _, query := range []string{`
+	UPDATE entries
+	SET
+		scan_status = NULLIF(:scan_status, ''),
+		scan_detail = NULLIF(:scan_detail, ''),
+		scan_pending = :scan_pending
+	WHERE
+		id = :entry_id AND
+		version = :version`, `
+	UPDATE entry_versions
+	SET
+		scan_status = NULLIF(:scan_status, ''),
+		scan_detail = NULLIF(:scan_detail, ''),
+		scan_pending = :scan_pending
+	WHERE
+		entry_id = :entry_id AND
+		version = :version`} {
+		res, err := s.ctx.Exec(query,
+			sql.Named("scan_status", scanStatusColumn(result)),
+			sql.Named("scan_detail", result.Detail),
+			sql.Named("scan_pending", result.IsPending()),
+			sql.Named("entry_id", id),
+			sql.Named("version", version))
+		if err != nil {
+			return err
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rows > 0 {
+			return nil
+		}
+	}
+
+	return store.EntryVersionNotFoundError{ID: id, Version: version}
+}
+
+// deleteEntryVersions deletes the previous versions of an entry's contents and
+// releases their data.
+func deleteEntryVersions(tx *sql.Tx, id picoshare.EntryID) error {
+	rows, err := tx.Query(`
+	SELECT
+		blob_id
+	FROM
+		entry_versions
+	WHERE
+		entry_id = :entry_id`, sql.Named("entry_id", id))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	blobIDs := []picoshare.EntryID{}
+	for rows.Next() {
+		var blobID picoshare.EntryID
+		if err := rows.Scan(&blobID); err != nil {
+			return err
+		}
+		blobIDs = append(blobIDs, blobID)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`
+	DELETE FROM
+		entry_versions
+	WHERE
+		entry_id = :entry_id`, sql.Named("entry_id", id)); err != nil {
+		log.Printf("delete from entry_versions table failed, aborting transaction: %v", err)
+		return err
+	}
+
+	for _, blobID := range blobIDs {
+		if err := releaseBlob(tx, blobID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package sqlite_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/store"
+	"github.com/mtlynch/picoshare/store/test_sqlite"
+)
+
+func TestEntryVersions(t *testing.T) {
+	dataStore := test_sqlite.New()
+
+	for _, v := range []struct {
+		filename picoshare.Filename
+		contents string
+		uploaded string
+	}{
+		{"build-1.txt", "first build", "2024-01-01T00:00:00Z"},
+		{"build-2.txt", "second build", "2024-01-02T00:00:00Z"},
+		{"build-3.txt", "third build!", "2024-01-03T00:00:00Z"},
+	} {
+		metadata := picoshare.UploadMetadata{
+			ID:          "AAAAAAAAAA",
+			Filename:    v.filename,
+			ContentType: "text/plain",
+			Uploaded:    mustParseTime(v.uploaded),
+			Expires:     picoshare.NeverExpire,
+			Size:        mustParseFileSize(len(v.contents)),
+		}
+		var err error
+		if v.filename == "build-1.txt" {
+			err = dataStore.InsertEntry(strings.NewReader(v.contents), metadata)
+		} else {
+			err = dataStore.InsertEntryVersion(strings.NewReader(v.contents), metadata)
+		}
+		if err != nil {
+			t.Fatalf("failed to save %s: %v", v.filename, err)
+		}
+	}
+
+	entry, err := dataStore.GetEntryMetadata("AAAAAAAAAA")
+	if err != nil {
+		t.Fatalf("failed to get entry: %v", err)
+	}
+	if got, want := entry.Version, 3; got != want {
+		t.Errorf("version=%d, want=%d", got, want)
+	}
+	if got, want := entry.Filename, picoshare.Filename("build-3.txt"); got != want {
+		t.Errorf("filename=%s, want=%s", got, want)
+	}
+
+	versions, err := dataStore.GetEntryVersions("AAAAAAAAAA")
+	if err != nil {
+		t.Fatalf("failed to get versions: %v", err)
+	}
+	numbers := []int{}
+	for _, v := range versions {
+		numbers = append(numbers, v.Number)
+	}
+	if diff := deep.Equal(numbers, []int{3, 2, 1}); diff != nil {
+		t.Errorf("unexpected versions: %v", diff)
+	}
+	if got, want := versions[1].Size, mustParseFileSize(len("second build")); got != want {
+		t.Errorf("size of version 2=%v, want=%v", got, want)
+	}
+
+	r, err := dataStore.ReadEntryVersionFile("AAAAAAAAAA", 1)
+	if err != nil {
+		t.Fatalf("failed to read version 1: %v", err)
+	}
+	contents, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("failed to read version 1 contents: %v", err)
+	}
+	if got, want := string(contents), "first build"; got != want {
+		t.Errorf("contents=%s, want=%s", got, want)
+	}
+
+	if _, err := dataStore.ReadEntryVersionFile("AAAAAAAAAA", 4); err == nil {
+		t.Errorf("reading nonexistent version succeeded, want error")
+	} else if _, ok := err.(store.EntryVersionNotFoundError); !ok {
+		t.Errorf("unexpected error reading nonexistent version: %v", err)
+	}
+
+	if err := dataStore.InsertEntryVersion(strings.NewReader("orphan"), picoshare.UploadMetadata{
+		ID:       "BBBBBBBBBB",
+		Filename: "orphan.txt",
+		Uploaded: mustParseTime("2024-01-03T00:00:00Z"),
+	}); err == nil {
+		t.Errorf("saving version of nonexistent entry succeeded, want error")
+	} else if _, ok := err.(store.EntryNotFoundError); !ok {
+		t.Errorf("unexpected error saving version of nonexistent entry: %v", err)
+	}
+
+	// Deleting the entry deletes the data of every version.
+	if err := dataStore.DeleteEntry("AAAAAAAAAA"); err != nil {
+		t.Fatalf("failed to delete entry: %v", err)
+	}
+	if err := dataStore.Purge(); err != nil {
+		t.Fatalf("failed to purge database: %v", err)
+	}
+	size, err := dataStore.GetStoredDataSize()
+	if err != nil {
+		t.Fatalf("failed to get stored data size: %v", err)
+	}
+	if got, want := size, uint64(0); got != want {
+		t.Errorf("stored data size=%d, want=%d", got, want)
+	}
+}
@@ -23,3 +23,64 @@ type GuestLinkNotFoundError struct {
 func (f GuestLinkNotFoundError) Error() string {
 	return fmt.Sprintf("Could not find guest link with ID %v", f.ID)
 }
+
+// ThumbnailNotFoundError occurs when no thumbnail exists for the given entry
+// ID.
+type ThumbnailNotFoundError struct {
+	ID picoshare.EntryID
+}
+
+func (f ThumbnailNotFoundError) Error() string {
+	return fmt.Sprintf("Could not find thumbnail for entry with ID %v", f.ID)
+}
+
+// EntryVersionNotFoundError occurs when an entry has no version with the given
+// number.
+type EntryVersionNotFoundError struct {
+	ID      picoshare.EntryID
+	Version int
+}
+
+func (f EntryVersionNotFoundError) Error() string {
+	return fmt.Sprintf("Could not find version %d of entry with ID %v", f.Version, f.ID)
+}
+
+// EntrySlugNotFoundError occurs when no entry has the given slug.
+type EntrySlugNotFoundError struct {
+	Slug picoshare.EntrySlug
+}
+
+func (f EntrySlugNotFoundError) Error() string {
+	return fmt.Sprintf("Could not find entry with slug %v", f.Slug)
+}
+
+// EntrySlugTakenError occurs when a different entry already has the given
+// slug.
+type EntrySlugTakenError struct {
+	Slug picoshare.EntrySlug
+}
+
+func (f EntrySlugTakenError) Error() string {
+	return fmt.Sprintf("Slug %v is already in use by another file", f.Slug)
+}
+
+// EntryTooManyTagsError occurs when adding tags would give an entry more than
+// the maximum number of tags.
+type EntryTooManyTagsError struct {
+	ID  picoshare.EntryID
+	Max int
+}
+
+func (f EntryTooManyTagsError) Error() string {
+	return fmt.Sprintf("Entry with ID %v would have more than %d tags", f.ID, f.Max)
+}
+
+// SignedURLUsedError occurs when a client tries to use a single-use signed link
+// that a client already used.
+type SignedURLUsedError struct {
+	Signature string
+}
+
+func (f SignedURLUsedError) Error() string {
+	return fmt.Sprintf("Signed link %v has already been used", f.Signature)
+}
@@ -0,0 +1,91 @@
+package thumbnail
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/gif" // Register GIF decoder.
+	"image/jpeg"
+	_ "image/png" // Register PNG decoder.
+	"io"
+	"strings"
+
+	_ "golang.org/x/image/bmp" // Register BMP decoder.
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/tiff" // Register TIFF decoder.
+	_ "golang.org/x/image/webp" // Register WebP decoder.
+
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+const (
+	// MaxDimension is the maximum width or height of a thumbnail in pixels.
+	MaxDimension = 256
+
+	// maxSourcePixels limits the size of images we decode, as a decoded image
+	// occupies far more memory than its compressed file.
+	maxSourcePixels = 50_000_000
+
+	jpegQuality = 80
+)
+
+var ErrImageTooLarge = errors.New("image is too large to generate a thumbnail")
+
+// Supports returns true if PicoShare can generate thumbnails for files of the
+// given content type.
+func Supports(ct picoshare.ContentType) bool {
+	mediaType, _, _ := strings.Cut(strings.ToLower(ct.String()), ";")
+	switch strings.TrimSpace(mediaType) {
+	case "image/bmp", "image/gif", "image/jpeg", "image/png", "image/tiff", "image/webp":
+		return true
+	}
+	return false
+}
+
+// Generate decodes an image and returns a JPEG thumbnail of it that fits
+// within MaxDimension pixels on each side. Transparent areas are filled with
+// white.
+func Generate(r io.ReadSeeker) ([]byte, error) {
+	cfg, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return nil, err
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > maxSourcePixels {
+		return nil, ErrImageTooLarge
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	src, _, err := image.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	dst := image.NewRGBA(scaledBounds(src.Bounds()))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.ApproxBiLinear.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// scaledBounds returns the bounds of a thumbnail for an image with the given
+// bounds, preserving the aspect ratio. Images that already fit keep their
+// original size.
+func scaledBounds(b image.Rectangle) image.Rectangle {
+	w, h := b.Dx(), b.Dy()
+	if w <= MaxDimension && h <= MaxDimension {
+		return image.Rect(0, 0, w, h)
+	}
+
+	if w >= h {
+		return image.Rect(0, 0, MaxDimension, max(1, h*MaxDimension/w))
+	}
+	return image.Rect(0, 0, max(1, w*MaxDimension/h), MaxDimension)
+}
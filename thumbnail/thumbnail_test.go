@@ -0,0 +1,107 @@
+package thumbnail_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/mtlynch/picoshare/picoshare"
+	"github.com/mtlynch/picoshare/thumbnail"
+)
+
+func TestSupports(t *testing.T) {
+	for _, tt := range []struct {
+		contentType picoshare.ContentType
+		want        bool
+	}{
+		{"image/png", true},
+		{"image/jpeg", true},
+		{"IMAGE/GIF", true},
+		{"image/webp", true},
+		{"image/png; charset=binary", true},
+		{"image/svg+xml", false},
+		{"video/mp4", false},
+		{"text/plain", false},
+	} {
+		t.Run(tt.contentType.String(), func(t *testing.T) {
+			if got, want := thumbnail.Supports(tt.contentType), tt.want; got != want {
+				t.Errorf("Supports=%v, want=%v", got, want)
+			}
+		})
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	for _, tt := range []struct {
+		description  string
+		width        int
+		height       int
+		widthWanted  int
+		heightWanted int
+	}{
+		{
+			description:  "scales down a wide image",
+			width:        1000,
+			height:       500,
+			widthWanted:  256,
+			heightWanted: 128,
+		},
+		{
+			description:  "scales down a tall image",
+			width:        300,
+			height:       600,
+			widthWanted:  128,
+			heightWanted: 256,
+		},
+		{
+			description:  "keeps the size of a small image",
+			width:        40,
+			height:       30,
+			widthWanted:  40,
+			heightWanted: 30,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			thumb, err := thumbnail.Generate(bytes.NewReader(mustEncodePNG(tt.width, tt.height)))
+			if err != nil {
+				t.Fatalf("failed to generate thumbnail: %v", err)
+			}
+
+			img, err := jpeg.Decode(bytes.NewReader(thumb))
+			if err != nil {
+				t.Fatalf("thumbnail is not a valid JPEG: %v", err)
+			}
+
+			if got, want := img.Bounds().Dx(), tt.widthWanted; got != want {
+				t.Errorf("width=%d, want=%d", got, want)
+			}
+			if got, want := img.Bounds().Dy(), tt.heightWanted; got != want {
+				t.Errorf("height=%d, want=%d", got, want)
+			}
+		})
+	}
+}
+
+func TestGenerateRejectsNonImage(t *testing.T) {
+	if _, err := thumbnail.Generate(bytes.NewReader([]byte("not an image"))); err == nil {
+		t.Fatalf("expected error when generating thumbnail from non-image data")
+	}
+}
+
+func mustEncodePNG(width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := range width {
+		for y := range height {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
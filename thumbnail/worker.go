@@ -0,0 +1,71 @@
+package thumbnail
+
+import (
+	"io"
+	"log"
+
+	"github.com/mtlynch/picoshare/picoshare"
+)
+
+type (
+	EntryStore interface {
+		ReadEntryFile(picoshare.EntryID) (io.ReadSeeker, error)
+		InsertEntryThumbnail(id picoshare.EntryID, jpeg []byte) error
+	}
+
+	job struct {
+		store EntryStore
+		id    picoshare.EntryID
+	}
+
+	// Worker generates thumbnails in the background, one at a time, so that
+	// thumbnail generation never slows down uploads.
+	Worker struct {
+		jobs chan job
+	}
+)
+
+// NewWorker creates a Worker that holds at most queueSize pending jobs.
+func NewWorker(queueSize int) *Worker {
+	return &Worker{
+		jobs: make(chan job, queueSize),
+	}
+}
+
+// StartAsync starts processing queued jobs in the background.
+func (w *Worker) StartAsync() {
+	go func() {
+		for j := range w.jobs {
+			if err := Process(j.store, j.id); err != nil {
+				log.Printf("failed to generate thumbnail for entry %v: %v", j.id, err)
+			}
+		}
+	}()
+}
+
+// Enqueue schedules a thumbnail for the given entry. If the queue is full, it
+// drops the job and returns false rather than blocking the caller.
+func (w *Worker) Enqueue(store EntryStore, id picoshare.EntryID) bool {
+	select {
+	case w.jobs <- job{store: store, id: id}:
+		return true
+	default:
+		log.Printf("thumbnail queue is full, skipping thumbnail for entry %v", id)
+		return false
+	}
+}
+
+// Process generates and saves a thumbnail for the given entry.
+func Process(store EntryStore, id picoshare.EntryID) error {
+	entryFile, err := store.ReadEntryFile(id)
+	if err != nil {
+		return err
+	}
+
+	thumb, err := Generate(entryFile)
+	if err != nil {
+		return err
+	}
+
+	return store.InsertEntryThumbnail(id, thumb)
+}